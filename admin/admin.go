@@ -0,0 +1,577 @@
+// Package admin implements the maintenance commands of "openspmregistry admin",
+// working directly on the configured repository without a running server.
+package admin
+
+import (
+	"OpenSPMRegistry/authenticator"
+	"OpenSPMRegistry/config"
+	"OpenSPMRegistry/fsck"
+	"OpenSPMRegistry/migrate"
+	"OpenSPMRegistry/mimetypes"
+	"OpenSPMRegistry/models"
+	"OpenSPMRegistry/repo"
+	"OpenSPMRegistry/signing"
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Env is what the commands work on
+type Env struct {
+	Config config.ServerConfig
+	// OpenRepo opens the configured repository, only called by commands using it
+	OpenRepo func() (repo.Repo, error)
+	// OpenTargetRepo opens the repository configured in another config file (the target of a migration)
+	OpenTargetRepo func(configFile string) (repo.Repo, error)
+	Stdin          io.Reader
+	Stdout         io.Writer
+	Stderr         io.Writer
+}
+
+// command is a subcommand of admin
+type command struct {
+	name  string
+	args  string
+	usage string
+	// run executes the command on the repository, or without one if noRepo is set
+	run    func(ctx context.Context, env Env, r repo.Repo, flags *flag.FlagSet, args []string) error
+	noRepo bool
+	// setup declares the flags of the command
+	setup func(flags *flag.FlagSet)
+}
+
+// releaseDetails is what "show" reports about a release
+type releaseDetails struct {
+	Release   string         `json:"release"`
+	Deleted   *time.Time     `json:"deletedAt,omitempty"`
+	Published *time.Time     `json:"publishedAt,omitempty"`
+	Publisher string         `json:"publisher,omitempty"`
+	Checksum  string         `json:"checksum,omitempty"`
+	Signed    bool           `json:"signed"`
+	Manifests []string       `json:"manifests,omitempty"`
+	Metadata  map[string]any `json:"metadata,omitempty"`
+}
+
+var errUsage = errors.New("usage")
+
+var commands = []command{
+	{name: "scopes", usage: "list the scopes", run: listScopes},
+	{name: "packages", args: "[scope]", usage: "list the packages (scope.name), of one scope if given", run: listPackages},
+	{name: "releases", args: "<scope> <package>", usage: "list the releases of a package, highest first", run: listReleases},
+	{name: "show", args: "<scope> <package> <version>", usage: "show the details of a release", run: showRelease,
+		setup: func(flags *flag.FlagSet) { flags.Bool("json", false, "print as JSON") }},
+	{name: "delete", args: "<scope> <package> <version>", usage: "delete a release, leaving a tombstone like the API", run: deleteRelease},
+	{name: "reextract", args: "-all | <scope> <package> <version>", usage: "extract the manifests of releases from their source archives again", run: reextract,
+		setup: func(flags *flag.FlagSet) { flags.Bool("all", false, "re-extract every release") }},
+	{name: "hash-password", args: "[password]", usage: "hash a password for auth.users (read from stdin if not given)", run: hashPassword, noRepo: true,
+		setup: func(flags *flag.FlagSet) {
+			flags.String("algorithm", authenticator.HashBcrypt, "hash algorithm: bcrypt or argon2id")
+		}},
+	{name: "collection", args: "[scope]", usage: "print the package collection, of one scope if given (signed if configured)", run: printCollection},
+	{name: "migrate", args: "-target <config file> [scope]", usage: "copy every release, of one scope if given, to the repository configured in the target config file (resumable)", run: migrateReleases,
+		setup: func(flags *flag.FlagSet) {
+			flags.String("target", "", "config file of the target repository (repo section)")
+			flags.Bool("json", false, "print the report as JSON")
+		}},
+	{name: "fsck", args: "[-repair] [scope]", usage: "check the integrity of the repository, of one scope if given", run: checkRepository,
+		setup: func(flags *flag.FlagSet) {
+			flags.Bool("repair", false, "re-extract mismatching manifests and rebuild the package index")
+			flags.Bool("json", false, "print the report as JSON")
+		}},
+}
+
+// Run executes the admin command given by args and returns the exit code
+// (0 on success, 1 if the command failed, 2 on invalid usage)
+func Run(ctx context.Context, args []string, env Env) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "-help" {
+		printUsage(env.Stderr)
+		if len(args) == 0 {
+			return 2
+		}
+		return 0
+	}
+
+	index := slices.IndexFunc(commands, func(c command) bool { return c.name == args[0] })
+	if index < 0 {
+		_, _ = fmt.Fprintf(env.Stderr, "unknown command %q\n\n", args[0])
+		printUsage(env.Stderr)
+		return 2
+	}
+	cmd := commands[index]
+
+	flags := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	flags.SetOutput(env.Stderr)
+	flags.Usage = func() {
+		_, _ = fmt.Fprintf(env.Stderr, "usage: admin %s %s\n%s\n", cmd.name, cmd.args, cmd.usage)
+		flags.PrintDefaults()
+	}
+	if cmd.setup != nil {
+		cmd.setup(flags)
+	}
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	var r repo.Repo
+	if !cmd.noRepo {
+		var err error
+		if r, err = env.OpenRepo(); err != nil {
+			_, _ = fmt.Fprintf(env.Stderr, "opening repository: %v\n", err)
+			return 1
+		}
+	}
+
+	if err := cmd.run(ctx, env, r, flags, flags.Args()); err != nil {
+		if errors.Is(err, errUsage) {
+			flags.Usage()
+			return 2
+		}
+		_, _ = fmt.Fprintf(env.Stderr, "%s: %v\n", cmd.name, err)
+		return 1
+	}
+	return 0
+}
+
+func printUsage(w io.Writer) {
+	_, _ = fmt.Fprintln(w, "usage: openspmregistry [-config file] admin <command> [arguments]")
+	_, _ = fmt.Fprintln(w, "\ncommands:")
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, cmd := range commands {
+		_, _ = fmt.Fprintf(tw, "  %s %s\t%s\n", cmd.name, cmd.args, cmd.usage)
+	}
+	_ = tw.Flush()
+}
+
+func listScopes(ctx context.Context, env Env, r repo.Repo, _ *flag.FlagSet, args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+	scopes, err := r.ListScopes(ctx)
+	if err != nil {
+		return err
+	}
+	slices.Sort(scopes)
+	for _, scope := range scopes {
+		_, _ = fmt.Fprintln(env.Stdout, scope)
+	}
+	return nil
+}
+
+func listPackages(ctx context.Context, env Env, r repo.Repo, _ *flag.FlagSet, args []string) error {
+	var elements []models.ListElement
+	var err error
+	switch len(args) {
+	case 0:
+		elements, err = r.ListAll(ctx)
+	case 1:
+		elements, err = r.ListInScope(ctx, args[0])
+	default:
+		return errUsage
+	}
+	if err != nil {
+		return err
+	}
+
+	var packages []string
+	for _, element := range elements {
+		packages = append(packages, element.Scope+"."+element.PackageName)
+	}
+	slices.Sort(packages)
+	for _, pkg := range slices.Compact(packages) {
+		_, _ = fmt.Fprintln(env.Stdout, pkg)
+	}
+	return nil
+}
+
+func listReleases(ctx context.Context, env Env, r repo.Repo, _ *flag.FlagSet, args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+	scope, name := args[0], args[1]
+	elements, err := r.List(ctx, scope, name)
+	if err != nil {
+		return err
+	}
+	if len(elements) == 0 {
+		return fmt.Errorf("package %s.%s not found", scope, name)
+	}
+
+	versions := make([]string, 0, len(elements))
+	for _, element := range elements {
+		versions = append(versions, element.Version)
+	}
+	tw := tabwriter.NewWriter(env.Stdout, 0, 4, 2, ' ', 0)
+	for _, version := range models.SortVersions(versions) {
+		status := "published"
+		if repo.IsReleaseDeleted(ctx, r, scope, name, version) {
+			status = "deleted"
+		} else if published, err := r.PublishDate(ctx, sourceArchive(scope, name, version)); err == nil {
+			status = "published " + published.UTC().Format(time.RFC3339)
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\n", version, status)
+	}
+	return tw.Flush()
+}
+
+func showRelease(ctx context.Context, env Env, r repo.Repo, flags *flag.FlagSet, args []string) error {
+	if len(args) != 3 {
+		return errUsage
+	}
+	scope, name, version := args[0], args[1], args[2]
+	archive := sourceArchive(scope, name, version)
+	details := releaseDetails{Release: fmt.Sprintf("%s.%s@%s", scope, name, version)}
+
+	if repo.IsReleaseDeleted(ctx, r, scope, name, version) {
+		tombstone, err := readTombstone(ctx, r, scope, name, version)
+		if err != nil {
+			return err
+		}
+		details.Deleted = &tombstone.DeletedAt
+		details.Checksum = tombstone.Checksum
+	} else {
+		if !r.Exists(ctx, archive) {
+			return fmt.Errorf("release %s not found", details.Release)
+		}
+		if published, err := r.PublishDate(ctx, archive); err == nil {
+			details.Published = &published
+		}
+		if publication, err := repo.ReadPublication(ctx, r, scope, name, version); err == nil {
+			details.Publisher = publication.Publisher
+		}
+		checksum, err := r.Checksum(ctx, archive)
+		if err != nil {
+			return err
+		}
+		details.Checksum = checksum
+		details.Signed = r.Exists(ctx, models.NewUploadElement(scope, name, version, mimetypes.ApplicationZip, models.SourceArchiveSignature))
+		details.Manifests = manifests(ctx, r, scope, name, version)
+		if metadata, err := r.LoadMetadata(ctx, scope, name, version); err == nil {
+			details.Metadata = metadata
+		}
+	}
+
+	if boolFlag(flags, "json") {
+		encoder := json.NewEncoder(env.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(details)
+	}
+
+	tw := tabwriter.NewWriter(env.Stdout, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "Release:\t%s\n", details.Release)
+	if details.Deleted != nil {
+		_, _ = fmt.Fprintf(tw, "Deleted:\t%s\n", details.Deleted.UTC().Format(time.RFC3339))
+	}
+	if details.Published != nil {
+		_, _ = fmt.Fprintf(tw, "Published:\t%s\n", details.Published.UTC().Format(time.RFC3339))
+	}
+	if details.Publisher != "" {
+		_, _ = fmt.Fprintf(tw, "Publisher:\t%s\n", details.Publisher)
+	}
+	if details.Checksum != "" {
+		_, _ = fmt.Fprintf(tw, "Checksum:\t%s\n", details.Checksum)
+	}
+	if details.Deleted == nil {
+		_, _ = fmt.Fprintf(tw, "Signed:\t%t\n", details.Signed)
+		_, _ = fmt.Fprintf(tw, "Manifests:\t%s\n", strings.Join(details.Manifests, ", "))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if details.Metadata != nil {
+		data, err := json.MarshalIndent(details.Metadata, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(env.Stdout, "Metadata:\n%s\n", data)
+	}
+	return nil
+}
+
+func deleteRelease(ctx context.Context, env Env, r repo.Repo, _ *flag.FlagSet, args []string) error {
+	if len(args) != 3 {
+		return errUsage
+	}
+	scope, name, version := args[0], args[1], args[2]
+	archive := sourceArchive(scope, name, version)
+	deleted := repo.IsReleaseDeleted(ctx, r, scope, name, version)
+	if !r.Exists(ctx, archive) {
+		if deleted {
+			return fmt.Errorf("release %s.%s@%s was already deleted", scope, name, version)
+		}
+		return fmt.Errorf("release %s.%s@%s not found", scope, name, version)
+	}
+
+	// write the tombstone first, so even a partially removed release can never be republished
+	if !deleted {
+		checksum, err := r.Checksum(ctx, archive)
+		if err != nil {
+			_, _ = fmt.Fprintf(env.Stderr, "checksum of the release not available: %v\n", err)
+		}
+		if err := repo.WriteTombstone(ctx, r, archive, checksum, time.Now()); err != nil {
+			return fmt.Errorf("storing tombstone: %w", err)
+		}
+	}
+	if indexer, ok := r.(repo.Indexer); ok {
+		if err := indexer.UnindexRelease(ctx, scope, name, version); err != nil {
+			_, _ = fmt.Fprintf(env.Stderr, "unindexing release: %v\n", err)
+		}
+	}
+	if err := repo.RemoveRelease(ctx, r, scope, name, version); err != nil {
+		return fmt.Errorf("release was only partially removed: %w", err)
+	}
+	_, _ = fmt.Fprintf(env.Stdout, "deleted %s.%s@%s\n", scope, name, version)
+	return nil
+}
+
+func reextract(ctx context.Context, env Env, r repo.Repo, flags *flag.FlagSet, args []string) error {
+	all := boolFlag(flags, "all")
+	var releases []models.ListElement
+	switch {
+	case all && len(args) == 0:
+		elements, err := r.ListAll(ctx)
+		if err != nil {
+			return err
+		}
+		releases = elements
+	case !all && len(args) == 3:
+		releases = []models.ListElement{*models.NewListElement(args[0], args[1], args[2])}
+	default:
+		return errUsage
+	}
+
+	var failed int
+	for _, release := range releases {
+		id := fmt.Sprintf("%s.%s@%s", release.Scope, release.PackageName, release.Version)
+		archive := sourceArchive(release.Scope, release.PackageName, release.Version)
+		if !r.Exists(ctx, archive) {
+			// deleted releases keep their version directory for the tombstone
+			if !all {
+				return fmt.Errorf("source archive of %s not found", id)
+			}
+			continue
+		}
+		if err := r.ExtractManifestFiles(ctx, archive); err != nil {
+			failed++
+			_, _ = fmt.Fprintf(env.Stderr, "%s: %v\n", id, err)
+			continue
+		}
+		_, _ = fmt.Fprintf(env.Stdout, "extracted %s\n", id)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d releases failed", failed, len(releases))
+	}
+	return nil
+}
+
+func hashPassword(_ context.Context, env Env, _ repo.Repo, flags *flag.FlagSet, args []string) error {
+	var password string
+	switch len(args) {
+	case 0:
+		line, err := bufio.NewReader(env.Stdin).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		password = strings.TrimRight(line, "\r\n")
+	case 1:
+		password = args[0]
+	default:
+		return errUsage
+	}
+	if password == "" {
+		return errors.New("empty password")
+	}
+
+	hash, err := authenticator.HashPassword(password, flags.Lookup("algorithm").Value.String())
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintln(env.Stdout, hash)
+	return nil
+}
+
+func printCollection(ctx context.Context, env Env, r repo.Repo, _ *flag.FlagSet, args []string) error {
+	var scope string
+	var packages []models.ListElement
+	var err error
+	switch len(args) {
+	case 0:
+		packages, err = r.ListAll(ctx)
+	case 1:
+		scope = args[0]
+		packages, err = r.ListInScope(ctx, scope)
+	default:
+		return errUsage
+	}
+	if err != nil {
+		return err
+	}
+
+	collection, err := repo.GenerateCollection(ctx, r, scope, packages, env.Config.Hostname)
+	if err != nil {
+		return err
+	}
+	var data []byte
+	if env.Config.PackageCollections.Signing.Enabled {
+		signer, err := signing.NewCollectionSigner(env.Config.PackageCollections.Signing)
+		if err != nil {
+			return err
+		}
+		data, err = signer.Sign(collection)
+		if err != nil {
+			return err
+		}
+	} else if data, err = json.MarshalIndent(collection, "", "  "); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintln(env.Stdout, string(data))
+	return nil
+}
+
+func migrateReleases(ctx context.Context, env Env, r repo.Repo, flags *flag.FlagSet, args []string) error {
+	targetConfig := flags.Lookup("target").Value.String()
+	if targetConfig == "" || len(args) > 1 {
+		return errUsage
+	}
+	target, err := env.OpenTargetRepo(targetConfig)
+	if err != nil {
+		return fmt.Errorf("opening target repository: %w", err)
+	}
+
+	asJson := boolFlag(flags, "json")
+	opts := migrate.Options{}
+	if len(args) == 1 {
+		opts.Scope = args[0]
+	}
+	if !asJson {
+		opts.Progress = func(result migrate.Result) {
+			line := fmt.Sprintf("%s %s (%d copied, %d present)", result.Status, result.Release, result.Copied, result.Skipped)
+			if result.Error != "" {
+				line += ": " + result.Error
+			}
+			_, _ = fmt.Fprintln(env.Stdout, line)
+		}
+	}
+	report, err := migrate.Run(ctx, r, target, opts)
+	if err != nil {
+		return err
+	}
+
+	if asJson {
+		encoder := json.NewEncoder(env.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		_, _ = fmt.Fprintf(env.Stdout, "%d releases migrated, %d unchanged, %d failed\n", report.Migrated, report.Unchanged, report.Failed)
+	}
+	if report.Failed > 0 {
+		return fmt.Errorf("%d releases failed, run again to retry them", report.Failed)
+	}
+	return nil
+}
+
+func checkRepository(ctx context.Context, env Env, r repo.Repo, flags *flag.FlagSet, args []string) error {
+	if len(args) > 1 {
+		return errUsage
+	}
+	opts := fsck.Options{Repair: boolFlag(flags, "repair")}
+	if len(args) == 1 {
+		opts.Scope = args[0]
+	}
+	report, err := fsck.Check(ctx, r, opts)
+	if err != nil {
+		return err
+	}
+
+	if boolFlag(flags, "json") {
+		encoder := json.NewEncoder(env.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		tw := tabwriter.NewWriter(env.Stdout, 0, 4, 2, ' ', 0)
+		for _, issue := range report.Issues {
+			location := issue.Scope
+			if issue.Package != "" {
+				location += "." + issue.Package
+			}
+			if issue.Version != "" {
+				location += "@" + issue.Version
+			}
+			if issue.File != "" {
+				location += " " + issue.File
+			}
+			detail := issue.Detail
+			if issue.Repaired {
+				detail += " (repaired)"
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\n", issue.Kind, location, detail)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(env.Stdout, "%d releases checked, %d issues found, %d repaired\n",
+			report.Releases, len(report.Issues), len(report.Issues)-report.Unrepaired())
+	}
+
+	if unrepaired := report.Unrepaired(); unrepaired > 0 {
+		return fmt.Errorf("%d issues left", unrepaired)
+	}
+	return nil
+}
+
+// boolFlag returns the value of a boolean flag declared by the setup of a command
+func boolFlag(flags *flag.FlagSet, name string) bool {
+	value, _ := flags.Lookup(name).Value.(flag.Getter).Get().(bool)
+	return value
+}
+
+func sourceArchive(scope string, name string, version string) *models.UploadElement {
+	return models.NewUploadElement(scope, name, version, mimetypes.ApplicationZip, models.SourceArchive)
+}
+
+// manifests lists the manifest file names of a release with their tools version
+func manifests(ctx context.Context, r repo.Repo, scope string, name string, version string) []string {
+	manifest := models.NewUploadElement(scope, name, version, mimetypes.TextXSwift, models.Manifest)
+	if !r.Exists(ctx, manifest) {
+		return nil
+	}
+	elements := []models.UploadElement{*manifest}
+	if alternatives, err := r.GetAlternativeManifests(ctx, manifest); err == nil {
+		elements = append(elements, alternatives...)
+	}
+
+	var names []string
+	for _, element := range elements {
+		description := element.FileName()
+		if toolsVersion, err := r.GetSwiftToolVersion(ctx, &element); err == nil && toolsVersion != "" {
+			description += " (tools " + toolsVersion + ")"
+		}
+		names = append(names, description)
+	}
+	return names
+}
+
+func readTombstone(ctx context.Context, r repo.Repo, scope string, name string, version string) (*models.ReleaseTombstone, error) {
+	reader, err := r.GetReader(ctx, models.NewUploadElement(scope, name, version, mimetypes.ApplicationJson, models.Tombstone))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = reader.Close() }()
+
+	var tombstone models.ReleaseTombstone
+	if err := json.NewDecoder(reader).Decode(&tombstone); err != nil {
+		return nil, fmt.Errorf("reading tombstone: %w", err)
+	}
+	return &tombstone, nil
+}
@@ -0,0 +1,216 @@
+package admin
+
+import (
+	"OpenSPMRegistry/config"
+	"OpenSPMRegistry/models"
+	"OpenSPMRegistry/repo"
+	"OpenSPMRegistry/repo/files"
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestRepo creates a files repo holding acme.sdk 1.0.0 and 1.1.0 and other.tool 2.0.0
+func newTestRepo(t *testing.T) (repo.Repo, string) {
+	t.Helper()
+	dir := t.TempDir()
+	r := files.NewFileRepo(dir)
+	for _, release := range []*models.ListElement{
+		models.NewListElement("acme", "sdk", "1.0.0"),
+		models.NewListElement("acme", "sdk", "1.1.0"),
+		models.NewListElement("other", "tool", "2.0.0"),
+	} {
+		var archive bytes.Buffer
+		zw := zip.NewWriter(&archive)
+		f, _ := zw.Create(release.Scope + "." + release.PackageName + "/Package.swift")
+		_, _ = f.Write([]byte("// swift-tools-version:5.9\n"))
+		_ = zw.Close()
+
+		element := sourceArchive(release.Scope, release.PackageName, release.Version)
+		writer, err := r.GetWriter(context.Background(), element)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = writer.Write(archive.Bytes())
+		if err := writer.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.ExtractManifestFiles(context.Background(), element); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return r, dir
+}
+
+// runAdmin runs the command on the repository and returns the exit code, stdout and stderr
+func runAdmin(r repo.Repo, stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := Run(context.Background(), args, Env{
+		Config:   config.ServerConfig{},
+		OpenRepo: func() (repo.Repo, error) { return r, nil },
+		Stdin:    strings.NewReader(stdin),
+		Stdout:   &stdout,
+		Stderr:   &stderr,
+	})
+	return code, stdout.String(), stderr.String()
+}
+
+func Test_Run_ListsScopesPackagesAndReleases(t *testing.T) {
+	r, _ := newTestRepo(t)
+
+	if code, out, _ := runAdmin(r, "", "scopes"); code != 0 || out != "acme\nother\n" {
+		t.Errorf("unexpected scopes (%d): %q", code, out)
+	}
+	if code, out, _ := runAdmin(r, "", "packages"); code != 0 || out != "acme.sdk\nother.tool\n" {
+		t.Errorf("unexpected packages (%d): %q", code, out)
+	}
+	code, out, _ := runAdmin(r, "", "releases", "acme", "sdk")
+	if lines := strings.Split(strings.TrimSpace(out), "\n"); code != 0 || len(lines) != 2 || !strings.HasPrefix(lines[0], "1.1.0") || !strings.Contains(lines[1], "published") {
+		t.Errorf("expected releases highest first (%d): %q", code, out)
+	}
+}
+
+func Test_Run_ShowRelease_ReportsDetails(t *testing.T) {
+	r, _ := newTestRepo(t)
+	if err := repo.WritePublication(context.Background(), r, sourceArchive("acme", "sdk", "1.0.0"), models.ReleasePublication{PublishedAt: time.Now(), Publisher: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+
+	code, out, stderr := runAdmin(r, "", "show", "-json", "acme", "sdk", "1.0.0")
+
+	var details releaseDetails
+	if err := json.Unmarshal([]byte(out), &details); code != 0 || err != nil {
+		t.Fatalf("expected JSON details (%d): %s %s", code, out, stderr)
+	}
+	if details.Release != "acme.sdk@1.0.0" || len(details.Checksum) != 64 || details.Published == nil || details.Publisher != "alice" || details.Signed ||
+		len(details.Manifests) != 1 || details.Manifests[0] != "Package.swift (tools 5.9)" {
+		t.Errorf("unexpected details %+v", details)
+	}
+}
+
+func Test_Run_DeleteRelease_LeavesTombstone(t *testing.T) {
+	r, _ := newTestRepo(t)
+
+	if code, _, stderr := runAdmin(r, "", "delete", "acme", "sdk", "1.0.0"); code != 0 {
+		t.Fatalf("expected release to be deleted: %s", stderr)
+	}
+
+	if r.Exists(context.Background(), sourceArchive("acme", "sdk", "1.0.0")) || !repo.IsReleaseDeleted(context.Background(), r, "acme", "sdk", "1.0.0") {
+		t.Errorf("expected archive to be removed and tombstone to be written")
+	}
+	if code, out, _ := runAdmin(r, "", "show", "acme", "sdk", "1.0.0"); code != 0 || !strings.Contains(out, "Deleted:") {
+		t.Errorf("expected deleted release to be shown (%d): %s", code, out)
+	}
+	if code, _, stderr := runAdmin(r, "", "delete", "acme", "sdk", "1.0.0"); code != 1 || !strings.Contains(stderr, "already deleted") {
+		t.Errorf("expected second delete to fail (%d): %s", code, stderr)
+	}
+}
+
+func Test_Run_Reextract_RestoresManifests(t *testing.T) {
+	r, dir := newTestRepo(t)
+	manifest := filepath.Join(dir, "other", "tool", "2.0.0", "Package.swift")
+	if err := os.Remove(manifest); err != nil {
+		t.Fatal(err)
+	}
+
+	code, out, stderr := runAdmin(r, "", "reextract", "-all")
+
+	if code != 0 || strings.Count(out, "extracted") != 3 {
+		t.Errorf("expected every release to be re-extracted (%d): %s %s", code, out, stderr)
+	}
+	if _, err := os.Stat(manifest); err != nil {
+		t.Errorf("expected manifest to be extracted again: %v", err)
+	}
+	if code, _, _ := runAdmin(r, "", "reextract"); code != 2 {
+		t.Errorf("expected usage error without release or -all, got %d", code)
+	}
+}
+
+func Test_Run_Fsck_RepairsManifests(t *testing.T) {
+	r, dir := newTestRepo(t)
+	if err := os.WriteFile(filepath.Join(dir, "acme", "sdk", "1.0.0", "Package.swift"), []byte("// changed\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if code, out, _ := runAdmin(r, "", "fsck", "acme"); code != 1 || !strings.Contains(out, "manifest-mismatch") || !strings.Contains(out, "2 releases checked, 1 issues found, 0 repaired") {
+		t.Errorf("expected manifest mismatch to be reported (%d): %s", code, out)
+	}
+	if code, out, _ := runAdmin(r, "", "fsck", "-repair"); code != 0 || !strings.Contains(out, "(repaired)") {
+		t.Errorf("expected manifest mismatch to be repaired (%d): %s", code, out)
+	}
+	if code, out, _ := runAdmin(r, "", "fsck"); code != 0 || !strings.Contains(out, "3 releases checked, 0 issues found") {
+		t.Errorf("expected no issues after repair (%d): %s", code, out)
+	}
+}
+
+func Test_Run_Migrate_CopiesToTarget(t *testing.T) {
+	r, _ := newTestRepo(t)
+	target := files.NewFileRepo(t.TempDir())
+	var stdout, stderr bytes.Buffer
+	env := Env{
+		OpenRepo: func() (repo.Repo, error) { return r, nil },
+		OpenTargetRepo: func(configFile string) (repo.Repo, error) {
+			if configFile != "target.yml" {
+				return nil, errors.New("unexpected config file " + configFile)
+			}
+			return target, nil
+		},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+
+	if code := Run(context.Background(), []string{"migrate", "-target", "target.yml", "acme"}, env); code != 0 ||
+		!strings.Contains(stdout.String(), "migrated acme.sdk@1.1.0") || !strings.Contains(stdout.String(), "2 releases migrated, 0 unchanged, 0 failed") {
+		t.Errorf("expected releases of acme to be migrated (%d): %s %s", code, stdout.String(), stderr.String())
+	}
+	if !target.Exists(context.Background(), sourceArchive("acme", "sdk", "1.0.0")) || target.Exists(context.Background(), sourceArchive("other", "tool", "2.0.0")) {
+		t.Error("expected only the releases of acme in the target")
+	}
+	if code := Run(context.Background(), []string{"migrate", "acme"}, env); code != 2 {
+		t.Errorf("expected usage error without target, got %d", code)
+	}
+}
+
+func Test_Run_HashPassword_ReadsStdin(t *testing.T) {
+	code, out, _ := runAdmin(nil, "s3cret\n", "hash-password", "-algorithm", "argon2id")
+
+	if code != 0 || !strings.HasPrefix(out, "$argon2id$") {
+		t.Errorf("expected argon2id hash (%d): %s", code, out)
+	}
+}
+
+func Test_Run_Collection_PrintsScopeCollection(t *testing.T) {
+	r, _ := newTestRepo(t)
+
+	code, out, stderr := runAdmin(r, "", "collection", "acme")
+
+	var collection models.PackageCollection
+	// the test releases have no Package.json, so the collection holds no packages
+	if err := json.Unmarshal([]byte(out), &collection); code != 0 || err != nil || collection.Name != "acme Packages" {
+		t.Errorf("expected collection of acme (%d): %s %s", code, out, stderr)
+	}
+}
+
+func Test_Run_InvalidUsage(t *testing.T) {
+	opened := false
+	var stderr bytes.Buffer
+	env := Env{
+		OpenRepo: func() (repo.Repo, error) { opened = true; return nil, errors.New("unreachable") },
+		Stdout:   &bytes.Buffer{},
+		Stderr:   &stderr,
+	}
+
+	if code := Run(context.Background(), []string{"unknown"}, env); code != 2 || opened {
+		t.Errorf("expected usage error for unknown command, got %d", code)
+	}
+	if code := Run(context.Background(), []string{"scopes"}, env); code != 1 || !strings.Contains(stderr.String(), "unreachable") {
+		t.Errorf("expected repository error, got %d: %s", code, stderr.String())
+	}
+}
@@ -0,0 +1,181 @@
+package audit
+
+import (
+	"OpenSPMRegistry/authorizer"
+	"OpenSPMRegistry/config"
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Entry is one record of the audit log
+type Entry struct {
+	Time    time.Time `json:"time"`
+	Action  string    `json:"action"`
+	Outcome string    `json:"outcome"`
+	Status  int       `json:"status,omitempty"` // HTTP status code of the response
+	// Principal is the user the request was made by, or the user name tried if authentication failed
+	Principal string `json:"principal,omitempty"`
+	// Token is the id of the personal access token the principal authenticated with
+	Token    string `json:"token,omitempty"`
+	IP       string `json:"ip,omitempty"`
+	Method   string `json:"method,omitempty"`
+	Path     string `json:"path,omitempty"`
+	Scope    string `json:"scope,omitempty"`
+	Package  string `json:"package,omitempty"`
+	Version  string `json:"version,omitempty"`
+	Checksum string `json:"checksum,omitempty"` // SHA-256 of the source archive published or deleted
+	// Subject identifies what the action applied to if not a release, e.g. an issued token or a submission
+	Subject string `json:"subject,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// Filter selects entries of the audit log, empty fields match every entry
+type Filter struct {
+	Action    string
+	Outcome   string
+	Principal string
+	Scope     string
+	Package   string
+	Since     time.Time
+	Until     time.Time
+}
+
+// auditRecorder captures the status code written by an audited handler
+type auditRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+const (
+	ActionPublish      = "publish"
+	ActionDelete       = "delete"
+	ActionLogin        = "login"
+	ActionAuthenticate = "authenticate"
+	ActionAuthorize    = "authorize"
+	ActionTokenCreate  = "token.create"
+	ActionTokenRevoke  = "token.revoke"
+
+	OutcomeSuccess = "success"
+	// OutcomeAccepted is recorded for asynchronous publications, followed by an entry with the final outcome
+	OutcomeAccepted = "accepted"
+	OutcomeDenied   = "denied"
+	OutcomeFailure  = "failure"
+
+	entryContextKey config.ContextKey = "auditEntry"
+)
+
+// Matches checks whether the entry is selected by the filter
+func (f Filter) Matches(entry Entry) bool {
+	switch {
+	case f.Action != "" && entry.Action != f.Action,
+		f.Outcome != "" && entry.Outcome != f.Outcome,
+		f.Principal != "" && entry.Principal != f.Principal,
+		f.Scope != "" && !strings.EqualFold(entry.Scope, f.Scope),
+		f.Package != "" && !strings.EqualFold(entry.Package, f.Package),
+		!f.Since.IsZero() && entry.Time.Before(f.Since),
+		!f.Until.IsZero() && entry.Time.After(f.Until):
+		return false
+	}
+	return true
+}
+
+// NewEntry creates an entry of the action for the request,
+// filled in with the principal, source IP and release identifier of the request
+func (l *Log) NewEntry(r *http.Request, action string) Entry {
+	entry := Entry{
+		Action:  action,
+		IP:      l.ClientIP(r),
+		Method:  r.Method,
+		Path:    r.URL.Path,
+		Scope:   r.PathValue("scope"),
+		Package: r.PathValue("package"),
+		Version: r.PathValue("version"),
+		Subject: r.PathValue("id"), // token or submission id
+	}
+	if principal := authorizer.PrincipalFromContext(r.Context()); principal != nil {
+		entry.Principal = principal.Name
+		entry.Token = principal.TokenId
+	}
+	return entry
+}
+
+// ClientIP returns the source IP of the request,
+// taken from X-Forwarded-For if the log is configured to trust it
+func (l *Log) ClientIP(r *http.Request) string {
+	if l != nil && l.trustForwardedFor {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			client, _, _ := strings.Cut(forwarded, ",")
+			return strings.TrimSpace(client)
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// Handler records an entry of the action for every request served by next, with the outcome derived
+// from the response status. next can add details to the entry through FromContext.
+// Returns next unchanged if the log is nil (auditing disabled).
+func (l *Log) Handler(action string, next http.HandlerFunc) http.HandlerFunc {
+	if l == nil {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		entry := l.NewEntry(r, action)
+		recorder := &auditRecorder{ResponseWriter: w}
+
+		next(recorder, r.WithContext(context.WithValue(r.Context(), entryContextKey, &entry)))
+
+		entry.Status = recorder.status
+		if entry.Status == 0 {
+			entry.Status = http.StatusOK
+		}
+		entry.Outcome = OutcomeForStatus(entry.Status)
+		l.Record(entry)
+	}
+}
+
+// FromContext returns the entry recorded for the request by Handler, or nil if the request is not audited.
+// The entry must not be modified after the handler returned.
+func FromContext(ctx context.Context) *Entry {
+	entry, _ := ctx.Value(entryContextKey).(*Entry)
+	return entry
+}
+
+// OutcomeForStatus maps the status code of a response to the outcome of the audited action
+func OutcomeForStatus(status int) string {
+	switch {
+	case status == http.StatusAccepted:
+		return OutcomeAccepted
+	case status < 400:
+		return OutcomeSuccess
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return OutcomeDenied
+	default:
+		return OutcomeFailure
+	}
+}
+
+func (a *auditRecorder) WriteHeader(status int) {
+	if a.status == 0 {
+		a.status = status
+	}
+	a.ResponseWriter.WriteHeader(status)
+}
+
+func (a *auditRecorder) Write(b []byte) (int, error) {
+	if a.status == 0 {
+		a.status = http.StatusOK
+	}
+	return a.ResponseWriter.Write(b)
+}
+
+// Unwrap gives http.ResponseController access to the underlying writer (e.g. for flushing)
+func (a *auditRecorder) Unwrap() http.ResponseWriter {
+	return a.ResponseWriter
+}
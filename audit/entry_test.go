@@ -0,0 +1,74 @@
+package audit
+
+import (
+	"OpenSPMRegistry/authorizer"
+	"OpenSPMRegistry/config"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_Handler_RecordsOutcomeAndDetails(t *testing.T) {
+	l := openTestLog(t, config.AuditConfig{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("PUT /{scope}/{package}/{version}", l.Handler(ActionPublish, func(w http.ResponseWriter, r *http.Request) {
+		FromContext(r.Context()).Checksum = "abc123"
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	req := httptest.NewRequest("PUT", "/acme/sdk/1.0.0", nil)
+	req.RemoteAddr = "192.0.2.7:51234"
+	req = req.WithContext(authorizer.WithPrincipal(req.Context(), &authorizer.Principal{Name: "alice", TokenId: "tok1"}))
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries, _ := l.Query(Filter{}, 0)
+	if len(entries) != 1 {
+		t.Fatalf("expected one entry, got %d", len(entries))
+	}
+	entry := entries[0]
+	if entry.Action != ActionPublish || entry.Outcome != OutcomeSuccess || entry.Status != http.StatusCreated ||
+		entry.Principal != "alice" || entry.Token != "tok1" || entry.IP != "192.0.2.7" ||
+		entry.Scope != "acme" || entry.Package != "sdk" || entry.Version != "1.0.0" || entry.Checksum != "abc123" || entry.Time.IsZero() {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+}
+
+func Test_Handler_NilLog_ReturnsHandler(t *testing.T) {
+	var l *Log
+	called := false
+	l.Handler(ActionDelete, func(w http.ResponseWriter, r *http.Request) { called = true })(httptest.NewRecorder(), httptest.NewRequest("DELETE", "/", nil))
+	l.Record(Entry{Action: ActionDelete})
+
+	if !called {
+		t.Errorf("expected handler to be called")
+	}
+}
+
+func Test_ClientIP_ForwardedForOnlyIfTrusted(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "10.0.0.1:443"
+	req.Header.Set("X-Forwarded-For", "203.0.113.9, 10.0.0.1")
+
+	if ip := openTestLog(t, config.AuditConfig{}).ClientIP(req); ip != "10.0.0.1" {
+		t.Errorf("expected the remote address, got %s", ip)
+	}
+	if ip := openTestLog(t, config.AuditConfig{TrustForwardedFor: true}).ClientIP(req); ip != "203.0.113.9" {
+		t.Errorf("expected the forwarded client, got %s", ip)
+	}
+}
+
+func Test_OutcomeForStatus(t *testing.T) {
+	for status, outcome := range map[int]string{
+		http.StatusCreated:             OutcomeSuccess,
+		http.StatusFound:               OutcomeSuccess,
+		http.StatusAccepted:            OutcomeAccepted,
+		http.StatusUnauthorized:        OutcomeDenied,
+		http.StatusForbidden:           OutcomeDenied,
+		http.StatusConflict:            OutcomeFailure,
+		http.StatusInternalServerError: OutcomeFailure,
+	} {
+		if got := OutcomeForStatus(status); got != outcome {
+			t.Errorf("expected %s for %d, got %s", outcome, status, got)
+		}
+	}
+}
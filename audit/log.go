@@ -0,0 +1,227 @@
+package audit
+
+import (
+	"OpenSPMRegistry/config"
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Log is an append-only audit log of JSON lines (one Entry per line).
+// If a maximum size is configured, the file is rotated to <path>.1 (the newest backup), <path>.2, ...
+// before it would exceed it. A nil Log records nothing.
+type Log struct {
+	path              string
+	maxSize           int64
+	maxBackups        int
+	trustForwardedFor bool
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+	// rotation is held by queries while they read the files, which rotating renames.
+	// Separate from mu so recording is not blocked by a query unless the log rotates.
+	rotation sync.RWMutex
+}
+
+const (
+	defaultPath       = "audit.log"
+	defaultMaxBackups = 5
+	// maxEntrySize bounds a line read back from the log
+	maxEntrySize = 1 << 20
+)
+
+// Open opens (or creates) the audit log configured
+func Open(cfg config.AuditConfig) (*Log, error) {
+	path := cfg.Path
+	if path == "" {
+		path = defaultPath
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return nil, err
+		}
+	}
+	maxBackups := cfg.MaxBackups
+	if maxBackups <= 0 {
+		maxBackups = defaultMaxBackups
+	}
+
+	l := &Log{
+		path:              path,
+		maxSize:           cfg.MaxSize,
+		maxBackups:        maxBackups,
+		trustForwardedFor: cfg.TrustForwardedFor,
+	}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Record appends the entry to the log, setting its time if missing.
+// Errors are logged, as a failing audit log must not fail the audited request.
+func (l *Log) Record(entry Entry) {
+	if l == nil {
+		return
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		slog.Error("Error encoding audit log entry:", "error", err)
+		return
+	}
+	data = append(data, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		slog.Error("Audit log closed, dropping entry", "action", entry.Action, "outcome", entry.Outcome)
+		return
+	}
+	if l.maxSize > 0 && l.size > 0 && l.size+int64(len(data)) > l.maxSize {
+		if err := l.rotate(); err != nil {
+			slog.Error("Error rotating audit log:", "error", err)
+			if l.file == nil {
+				return
+			}
+		}
+	}
+	n, err := l.file.Write(data)
+	l.size += int64(n)
+	if err != nil {
+		slog.Error("Error writing audit log entry:", "action", entry.Action, "error", err)
+	}
+}
+
+// Query returns up to limit entries matching the filter, newest first (all if limit <= 0).
+// Rotated files still present are searched as well.
+func (l *Log) Query(filter Filter, limit int) ([]Entry, error) {
+	// entries recorded meanwhile may be read partially, their lines are skipped
+	l.rotation.RLock()
+	defer l.rotation.RUnlock()
+
+	var entries []Entry
+	for i := l.maxBackups; i >= 0; i-- {
+		path := l.path
+		if i > 0 {
+			path = l.backupPath(i)
+		}
+		err := readEntries(path, func(entry Entry) {
+			if !filter.Matches(entry) {
+				return
+			}
+			entries = append(entries, entry)
+			// only the newest entries are returned, drop older ones in batches
+			if limit > 0 && len(entries)-limit >= limit {
+				entries = slices.Delete(entries, 0, len(entries)-limit)
+			}
+		})
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	slices.Reverse(entries)
+	return entries, nil
+}
+
+// Close closes the log file, entries recorded afterwards are dropped
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+func (l *Log) open() error {
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	l.file = file
+	l.size = info.Size()
+	return nil
+}
+
+// rotate shifts the backups by one, dropping the oldest, moves the log to the first backup and starts a new file
+func (l *Log) rotate() error {
+	l.rotation.Lock()
+	defer l.rotation.Unlock()
+	if err := l.file.Close(); err != nil {
+		slog.Warn("Error closing audit log before rotation", "error", err)
+	}
+	l.file = nil
+
+	err := l.shiftBackups()
+	if err == nil {
+		err = os.Rename(l.path, l.backupPath(1))
+	}
+	// keep logging even if the rotation failed, the file just grows further
+	if openErr := l.open(); openErr != nil {
+		return errors.Join(err, openErr)
+	}
+	return err
+}
+
+// shiftBackups renames every backup to the next number, removing the oldest one
+func (l *Log) shiftBackups() error {
+	if err := os.Remove(l.backupPath(l.maxBackups)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	for i := l.maxBackups - 1; i >= 1; i-- {
+		if err := os.Rename(l.backupPath(i), l.backupPath(i+1)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *Log) backupPath(i int) string {
+	return fmt.Sprintf("%s.%d", l.path, i)
+}
+
+// readEntries calls fn for every entry of the file in order, skipping lines that are no valid entry
+func readEntries(path string, fn func(Entry)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxEntrySize)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		fn(entry)
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,74 @@
+package audit
+
+import (
+	"OpenSPMRegistry/config"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openTestLog(t *testing.T, cfg config.AuditConfig) *Log {
+	t.Helper()
+	if cfg.Path == "" {
+		cfg.Path = filepath.Join(t.TempDir(), "audit.log")
+	}
+	l, err := Open(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = l.Close() })
+	return l
+}
+
+func Test_Query_FiltersNewestFirst(t *testing.T) {
+	l := openTestLog(t, config.AuditConfig{})
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, entry := range []Entry{
+		{Action: ActionPublish, Outcome: OutcomeSuccess, Principal: "alice", Scope: "acme", Version: "1.0.0"},
+		{Action: ActionDelete, Outcome: OutcomeSuccess, Principal: "bob", Scope: "acme", Version: "1.0.0"},
+		{Action: ActionPublish, Outcome: OutcomeDenied, Principal: "bob", Scope: "other", Version: "1.0.0"},
+		{Action: ActionPublish, Outcome: OutcomeSuccess, Principal: "alice", Scope: "acme", Version: "1.1.0"},
+	} {
+		entry.Time = start.Add(time.Duration(i) * time.Hour)
+		l.Record(entry)
+	}
+
+	entries, err := l.Query(Filter{Action: ActionPublish, Scope: "ACME"}, 0)
+	if err != nil || len(entries) != 2 || entries[0].Version != "1.1.0" || entries[1].Version != "1.0.0" {
+		t.Errorf("expected the publications of acme newest first, got %+v (%v)", entries, err)
+	}
+	if entries, _ := l.Query(Filter{Principal: "bob", Since: start.Add(2 * time.Hour)}, 0); len(entries) != 1 || entries[0].Outcome != OutcomeDenied {
+		t.Errorf("expected the denied publication of bob, got %+v", entries)
+	}
+	if entries, _ := l.Query(Filter{}, 3); len(entries) != 3 || entries[2].Action != ActionDelete {
+		t.Errorf("expected the 3 newest entries, got %+v", entries)
+	}
+	if entries, _ := l.Query(Filter{}, math.MaxInt); len(entries) != 4 {
+		t.Errorf("expected all entries, got %+v", entries)
+	}
+}
+
+func Test_Record_RotatesBySize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	l := openTestLog(t, config.AuditConfig{Path: path, MaxSize: 200, MaxBackups: 2})
+
+	for i := range 8 {
+		l.Record(Entry{Action: ActionPublish, Outcome: OutcomeSuccess, Version: time.Duration(i).String()})
+	}
+
+	for _, file := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(file)
+		if err != nil || info.Size() > 200 {
+			t.Errorf("expected %s of at most 200 bytes, got %v (%v)", file, info, err)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); err == nil {
+		t.Errorf("expected only 2 backups to be kept")
+	}
+	entries, _ := l.Query(Filter{}, 0)
+	if len(entries) == 0 || len(entries) >= 8 || entries[0].Version != "7ns" {
+		t.Errorf("expected the newest entries across the rotated files, got %+v", entries)
+	}
+}
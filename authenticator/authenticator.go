@@ -1,6 +1,7 @@
 package authenticator
 
 import (
+	"OpenSPMRegistry/authorizer"
 	"OpenSPMRegistry/config"
 	"OpenSPMRegistry/controller"
 	"context"
@@ -13,6 +14,35 @@ type Authenticator interface {
 	Authenticate(w http.ResponseWriter, r *http.Request) (string, error)
 }
 
+// PrincipalAuthenticator is implemented by authenticators that know who a request is made by
+type PrincipalAuthenticator interface {
+	Authenticator
+
+	// AuthenticatePrincipal authenticates the request like Authenticate
+	// and returns the principal (user name and groups) used for authorization
+	AuthenticatePrincipal(w http.ResponseWriter, r *http.Request) (*authorizer.Principal, error)
+}
+
+// TypeName returns the type of the authenticator handling the request (basic, oidc, token, noop or other),
+// e.g. to label authentication failures in metrics
+func TypeName(auth Authenticator, r *http.Request) string {
+	switch a := auth.(type) {
+	case *TokenAuthenticator:
+		if _, ok := accessToken(r); ok {
+			return "token"
+		}
+		return TypeName(a.primary, r)
+	case *BasicAuthenticator:
+		return "basic"
+	case *NoOpAuthenticator:
+		return "noop"
+	case OidcAuthenticator:
+		return "oidc"
+	default:
+		return "other"
+	}
+}
+
 // writeTokenOutput writes the token to the response
 // to be used by the client to authenticate via --token flag
 func writeTokenOutput(w http.ResponseWriter, token string, templateParser controller.TemplateParser) {
@@ -54,7 +84,7 @@ func CreateAuthenticator(config config.ServerConfig) Authenticator {
 			return NewOIDCAuthenticatorPassword(context.Background(), config)
 		}
 	case "basic":
-		return NewBasicAuthenticator(config.Auth.Users)
+		return NewBasicAuthenticatorWithHtpasswd(config.Auth.Users, config.Auth.Htpasswd)
 	default:
 		return &NoOpAuthenticator{}
 	}
@@ -126,6 +126,31 @@ func Test_CreateAuthenticator_UnknownType_ReturnsNoOpAuthenticator(t *testing.T)
 	}
 }
 
+func Test_TypeName_ReturnsAuthenticatorType(t *testing.T) {
+	basic := NewBasicAuthenticator(nil)
+	tokenAuth := NewTokenAuthenticator(nil, basic)
+	bearer := httptest.NewRequest("GET", "/", nil)
+	bearer.Header.Set("Authorization", "Bearer spmr_abc")
+	plain := httptest.NewRequest("GET", "/", nil)
+
+	tests := []struct {
+		auth     Authenticator
+		r        *http.Request
+		expected string
+	}{
+		{basic, plain, "basic"},
+		{&NoOpAuthenticator{}, plain, "noop"},
+		{&OidcAuthenticatorImpl{}, plain, "oidc"},
+		{tokenAuth, bearer, "token"},
+		{tokenAuth, plain, "basic"},
+	}
+	for _, test := range tests {
+		if name := TypeName(test.auth, test.r); name != test.expected {
+			t.Errorf("expected %s for %T, got %s", test.expected, test.auth, name)
+		}
+	}
+}
+
 func (m MockTemplateParser) ParseFiles(filenames ...string) (*template.Template, error) {
 	return &m.template, nil
 }
@@ -1,46 +1,103 @@
 package authenticator
 
 import (
+	"OpenSPMRegistry/authorizer"
 	"OpenSPMRegistry/config"
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
 	"errors"
 	"log/slog"
 	"net/http"
 )
 
 type BasicAuthenticator struct {
-	users []config.User
+	users    map[string]config.User
+	htpasswd *htpasswdFile
 }
 
+// dummyHash is verified for unknown users, so they take as long as known ones
+const dummyHash = "$2a$10$uAHqOLLDgus.cw9eCeCY6.dgGp/W6cFaJ2T46SdR8gsIEqvBeVBAS"
+
+// NewBasicAuthenticator creates a basic authenticator for the configured users
 func NewBasicAuthenticator(users []config.User) *BasicAuthenticator {
-	return &BasicAuthenticator{users: users}
+	return NewBasicAuthenticatorWithHtpasswd(users, "")
 }
 
+// NewBasicAuthenticatorWithHtpasswd creates a basic authenticator for the configured users
+// and the users of the htpasswd file at htpasswdPath (if not empty), which is re-read on change.
+// Users defined in both take the password from the htpasswd file if the configured one is empty,
+// so the configuration can assign groups to htpasswd users.
+func NewBasicAuthenticatorWithHtpasswd(users []config.User, htpasswdPath string) *BasicAuthenticator {
+	a := &BasicAuthenticator{users: make(map[string]config.User, len(users))}
+	for _, user := range users {
+		if err := checkPasswordHash(user.Password); err != nil {
+			slog.Error("Ignoring user with invalid password hash", "username", user.Username, "error", err)
+			continue
+		}
+		a.users[user.Username] = user
+	}
+	if htpasswdPath != "" {
+		a.htpasswd = newHtpasswdFile(htpasswdPath)
+	}
+	return a
+}
+
+// Authenticate checks the credentials of the request
+// returns the username as token
 func (a *BasicAuthenticator) Authenticate(w http.ResponseWriter, r *http.Request) (string, error) {
+	user, err := a.authenticateUser(r)
+	if err != nil {
+		return "", err
+	}
+	return user.Username, nil
+}
+
+func (a *BasicAuthenticator) AuthenticatePrincipal(w http.ResponseWriter, r *http.Request) (*authorizer.Principal, error) {
+	user, err := a.authenticateUser(r)
+	if err != nil {
+		return nil, err
+	}
+	return &authorizer.Principal{Name: user.Username, Groups: user.Groups}, nil
+}
+
+// authenticateUser returns the user matching the credentials of the request
+func (a *BasicAuthenticator) authenticateUser(r *http.Request) (*config.User, error) {
 	authorizationHeader := r.Header.Get("Authorization")
 	if authorizationHeader == "" {
-		return "", errors.New("authorization header not found")
+		return nil, errors.New("authorization header not found")
 	}
 
 	username, password, ok := r.BasicAuth()
 	if !ok {
-		return "", errors.New("missing credentials")
+		return nil, errors.New("missing credentials")
 	}
 
 	if slog.Default().Enabled(context.TODO(), slog.LevelDebug) {
 		slog.Debug("Basic authentication")
 	}
-	for _, user := range a.users {
-		if hashedPwd := hashPassword(password); user.Password == hashedPwd && user.Username == username {
-			return hashedPwd, nil
-		}
+	user, found := a.lookup(username)
+	if !found {
+		verifyPassword(dummyHash, password)
+		return nil, errors.New("invalid username or password")
 	}
-	return "", errors.New("invalid username or password")
+	if !verifyPassword(user.Password, password) {
+		return nil, errors.New("invalid username or password")
+	}
+	return &user, nil
 }
 
-func hashPassword(password string) string {
-	hash := sha256.Sum256([]byte(password))
-	return hex.EncodeToString(hash[:])
+// lookup returns the user with the given name, taking the password from the htpasswd file
+// if it is not configured
+func (a *BasicAuthenticator) lookup(username string) (config.User, bool) {
+	user, found := a.users[username]
+	if found && user.Password != "" {
+		return user, true
+	}
+	if a.htpasswd != nil {
+		if hash, ok := a.htpasswd.lookup(username); ok {
+			user.Username = username
+			user.Password = hash
+			return user, true
+		}
+	}
+	return user, false
 }
@@ -4,6 +4,9 @@ import (
 	"OpenSPMRegistry/config"
 	"log/slog"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
 	"testing"
 )
 
@@ -71,8 +74,8 @@ func Test_Authenticate_ValidCredentials_ReturnsNil(t *testing.T) {
 	if err != nil {
 		t.Errorf("expected nil error, got %v", err)
 	}
-	if token != hashPassword("pass") {
-		t.Errorf("expected hashed password, got %s", token)
+	if token != "user" {
+		t.Errorf("expected username instead of password hash, got %s", token)
 	}
 }
 
@@ -96,3 +99,97 @@ func Test_HashPassword_DifferentPasswords(t *testing.T) {
 		t.Errorf("expected different hashed passwords, got same")
 	}
 }
+
+func Test_AuthenticatePrincipal_ValidCredentials_ReturnsUserAndGroups(t *testing.T) {
+	users := []config.User{{Username: "user", Password: hashPassword("pass"), Groups: []string{"ios-team"}}}
+	auth := NewBasicAuthenticator(users)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.SetBasicAuth("user", "pass")
+	w := httptest.NewRecorder()
+
+	principal, err := auth.AuthenticatePrincipal(w, req)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if principal.Name != "user" || !slices.Equal(principal.Groups, []string{"ios-team"}) {
+		t.Errorf("unexpected principal %+v", principal)
+	}
+}
+
+func Test_AuthenticatePrincipal_InvalidCredentials_ReturnsError(t *testing.T) {
+	users := []config.User{{Username: "user", Password: hashPassword("pass")}}
+	auth := NewBasicAuthenticator(users)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.SetBasicAuth("user", "wrongpass")
+	w := httptest.NewRecorder()
+
+	principal, err := auth.AuthenticatePrincipal(w, req)
+	if err == nil {
+		t.Errorf("expected error, got nil")
+	}
+	if principal != nil {
+		t.Errorf("expected nil principal, got %+v", principal)
+	}
+}
+
+func Test_Authenticate_BcryptUser_ReturnsUsername(t *testing.T) {
+	users := []config.User{{Username: "user", Password: testBcryptHash}}
+	auth := NewBasicAuthenticator(users)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.SetBasicAuth("user", "pass")
+	w := httptest.NewRecorder()
+
+	if token, err := auth.Authenticate(w, req); err != nil || token != "user" {
+		t.Errorf("expected user to authenticate, got %q, %v", token, err)
+	}
+}
+
+func Test_Authenticate_HtpasswdUser_GroupsFromConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "htpasswd")
+	if err := os.WriteFile(path, []byte("# users\nfile-user:"+testApr1Hash+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	users := []config.User{{Username: "file-user", Groups: []string{"ios-team"}}}
+	auth := NewBasicAuthenticatorWithHtpasswd(users, path)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.SetBasicAuth("file-user", "password")
+	w := httptest.NewRecorder()
+
+	principal, err := auth.AuthenticatePrincipal(w, req)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if principal.Name != "file-user" || !slices.Equal(principal.Groups, []string{"ios-team"}) {
+		t.Errorf("unexpected principal %+v", principal)
+	}
+}
+
+func Test_Authenticate_UserWithoutPassword_ReturnsError(t *testing.T) {
+	users := []config.User{{Username: "user", Groups: []string{"ios-team"}}}
+	auth := NewBasicAuthenticator(users)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.SetBasicAuth("user", "")
+	w := httptest.NewRecorder()
+
+	if _, err := auth.Authenticate(w, req); err == nil {
+		t.Errorf("expected user without password to be rejected")
+	}
+}
+
+func Test_Authenticate_InvalidArgon2idHash_UserIgnored(t *testing.T) {
+	users := []config.User{{Username: "user", Password: "$argon2id$v=19$m=8192,t=1,p=0$c29tZQ$a2V5"}}
+	auth := NewBasicAuthenticator(users)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.SetBasicAuth("user", "pass")
+	w := httptest.NewRecorder()
+
+	if _, err := auth.Authenticate(w, req); err == nil {
+		t.Errorf("expected error for a user with an invalid password hash")
+	}
+}
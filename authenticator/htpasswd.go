@@ -0,0 +1,92 @@
+package authenticator
+
+import (
+	"bufio"
+	"bytes"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// htpasswdFile provides the users of an Apache htpasswd file (username:hash per line)
+// and re-reads the file when it changes
+type htpasswdFile struct {
+	mu        sync.Mutex
+	path      string
+	modTime   time.Time
+	size      int64
+	checkedAt time.Time
+	users     map[string]string
+}
+
+// htpasswdCheckInterval limits how often the htpasswd file is checked for changes
+const htpasswdCheckInterval = 2 * time.Second
+
+// newHtpasswdFile loads the htpasswd file at path
+func newHtpasswdFile(path string) *htpasswdFile {
+	f := &htpasswdFile{path: path, users: map[string]string{}}
+	f.reloadIfChanged(time.Now())
+	return f
+}
+
+// lookup returns the password hash of the user
+func (f *htpasswdFile) lookup(username string) (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if now := time.Now(); now.Sub(f.checkedAt) >= htpasswdCheckInterval {
+		f.reloadIfChanged(now)
+	}
+	hash, ok := f.users[username]
+	return hash, ok
+}
+
+// reloadIfChanged re-reads the file if its modification time or size changed.
+// On errors the users loaded before are kept. Callers must hold the lock (or own f exclusively).
+func (f *htpasswdFile) reloadIfChanged(now time.Time) {
+	f.checkedAt = now
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		slog.Error("Error reading htpasswd file", "path", f.path, "error", err)
+		return
+	}
+	if info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return
+	}
+
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		slog.Error("Error reading htpasswd file", "path", f.path, "error", err)
+		return
+	}
+	f.users = parseHtpasswd(data)
+	f.modTime = info.ModTime()
+	f.size = info.Size()
+	slog.Info("Loaded htpasswd file", "path", f.path, "users", len(f.users))
+}
+
+// parseHtpasswd parses lines of username:hash, ignoring empty lines and comments
+func parseHtpasswd(data []byte) map[string]string {
+	users := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		username, hash, ok := strings.Cut(line, ":")
+		if !ok || username == "" || hash == "" {
+			slog.Warn("Ignoring invalid htpasswd line")
+			continue
+		}
+		if err := checkPasswordHash(hash); err != nil {
+			slog.Warn("Ignoring htpasswd user with invalid password hash", "username", username, "error", err)
+			continue
+		}
+		users[username] = hash
+	}
+	return users
+}
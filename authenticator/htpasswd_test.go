@@ -0,0 +1,59 @@
+package authenticator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_ParseHtpasswd_IgnoresCommentsAndInvalidLines(t *testing.T) {
+	users := parseHtpasswd([]byte("# comment\n\nalice:$apr1$x$y\ninvalid\n:nouser\nbob:" + testBcryptHash +
+		"\nmallory:$argon2id$v=19$m=8192,t=0,p=1$c29tZQ$a2V5\n"))
+
+	if len(users) != 2 || users["alice"] != "$apr1$x$y" || users["bob"] != testBcryptHash {
+		t.Errorf("unexpected users %v", users)
+	}
+}
+
+func Test_HtpasswdFile_ReloadsOnChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "htpasswd")
+	if err := os.WriteFile(path, []byte("alice:"+testApr1Hash+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	f := newHtpasswdFile(path)
+
+	if _, ok := f.lookup("alice"); !ok {
+		t.Fatalf("expected alice to be loaded")
+	}
+
+	if err := os.WriteFile(path, []byte("bob:"+testBcryptHash+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// pretend the check interval elapsed
+	f.checkedAt = time.Time{}
+
+	if _, ok := f.lookup("alice"); ok {
+		t.Errorf("expected alice to be removed after reload")
+	}
+	if hash, ok := f.lookup("bob"); !ok || hash != testBcryptHash {
+		t.Errorf("expected bob to be loaded after reload, got %q", hash)
+	}
+}
+
+func Test_HtpasswdFile_MissingFile_KeepsPreviousUsers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "htpasswd")
+	if err := os.WriteFile(path, []byte("alice:"+testApr1Hash+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	f := newHtpasswdFile(path)
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	f.checkedAt = time.Time{}
+
+	if _, ok := f.lookup("alice"); !ok {
+		t.Errorf("expected alice to be kept when the file can not be read")
+	}
+}
@@ -1,6 +1,7 @@
 package authenticator
 
 import (
+	"OpenSPMRegistry/authorizer"
 	"OpenSPMRegistry/config"
 	"OpenSPMRegistry/controller"
 	"OpenSPMRegistry/utils"
@@ -36,6 +37,8 @@ type OidcAuthenticatorImpl struct {
 	config    oauth2.Config
 	template  controller.TemplateParser
 	grantType string
+	// claims the groups of a principal are read from (authorization)
+	groupClaims []string
 }
 
 // NewOIDCAuthenticatorWithConfig creates a new OIDC authenticator
@@ -73,12 +76,13 @@ func NewOIDCAuthenticatorWithConfig(
 	}
 
 	return &OidcAuthenticatorImpl{
-		ctx:       ctx,
-		config:    oauthConfig,
-		grantType: config.Auth.GrantType,
-		verifier:  verifier,
-		provider:  provider,
-		template:  template,
+		ctx:         ctx,
+		config:      oauthConfig,
+		grantType:   config.Auth.GrantType,
+		verifier:    verifier,
+		provider:    provider,
+		template:    template,
+		groupClaims: config.Auth.Authorization.GroupClaims,
 	}
 }
 
@@ -88,26 +92,72 @@ func NewOIDCAuthenticator(ctx context.Context, config config.ServerConfig) *Oidc
 	return NewOIDCAuthenticatorWithConfig(ctx, config, nil, controller.NewDefaultTemplateParser())
 }
 
+// CheckHealth reports whether the discovery document of the issuer was loaded on startup
+func (a *OidcAuthenticatorImpl) CheckHealth(_ context.Context) error {
+	if a == nil || a.provider == nil {
+		return errors.New("OIDC issuer discovery document not loaded")
+	}
+	return nil
+}
+
 func (a *OidcAuthenticatorImpl) Authenticate(w http.ResponseWriter, r *http.Request) (string, error) {
+	token, _, err := a.verifyRequest(r)
+	return token, err
+}
+
+func (a *OidcAuthenticatorImpl) AuthenticatePrincipal(w http.ResponseWriter, r *http.Request) (*authorizer.Principal, error) {
+	_, idToken, err := a.verifyRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	return a.principalFromIDToken(idToken)
+}
+
+// verifyRequest verifies the bearer token of the request
+// returns the raw token and the verified ID token
+func (a *OidcAuthenticatorImpl) verifyRequest(r *http.Request) (string, *oidc.IDToken, error) {
 	authorizationHeader := r.Header.Get("Authorization")
 
 	if authorizationHeader == "" {
-		return "", errors.New("authorization header not found")
+		return "", nil, errors.New("authorization header not found")
 	}
 
 	token, err := getBearerToken(authorizationHeader)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
-	_, err = a.verifier.Verify(a.ctx, token)
-	if err == nil {
-		if slog.Default().Enabled(context.TODO(), slog.LevelDebug) {
-			slog.Debug("Token still valid")
+	idToken, err := a.verifier.Verify(a.ctx, token)
+	if err != nil {
+		return "", nil, err
+	}
+	if slog.Default().Enabled(context.TODO(), slog.LevelDebug) {
+		slog.Debug("Token still valid")
+	}
+	return token, idToken, nil
+}
+
+// principalFromIDToken creates the principal of a verified ID token
+// the name is taken from the preferred_username, email or sub claim (first one present)
+// the groups are taken from the configured group claims
+func (a *OidcAuthenticatorImpl) principalFromIDToken(idToken *oidc.IDToken) (*authorizer.Principal, error) {
+	var claims map[string]any
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, err
+	}
+
+	name := idToken.Subject
+	for _, claim := range []string{"preferred_username", "email"} {
+		if value, ok := claims[claim].(string); ok && value != "" {
+			name = value
+			break
 		}
-		return token, nil
 	}
-	return "", err
+
+	return &authorizer.Principal{
+		Name:   name,
+		Groups: authorizer.GroupsFromClaims(claims, a.groupClaims),
+	}, nil
 }
 
 func (a *OidcAuthenticatorImpl) Login(_ http.ResponseWriter, _ *http.Request) {}
@@ -8,6 +8,7 @@ import (
 	"html/template"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -311,7 +312,72 @@ func Test_OIDC_CheckAuthHeaderPresent_WithoutAuthHeader_ReturnsFalse(t *testing.
 	}
 }
 
+func Test_OIDC_AuthenticatePrincipal_ValidBearerToken_ReturnsNameAndGroups(t *testing.T) {
+	ctx := context.Background()
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/.well-known/openid-configuration" {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{
+					"issuer": "http://` + r.Host + `",
+					"jwks_uri": "http://` + r.Host + `/keys"
+				}`))
+		}
+	}))
+	defer mockServer.Close()
+
+	c := config.ServerConfig{Auth: config.AuthConfig{
+		Issuer:        mockServer.URL,
+		ClientId:      "client-id",
+		Authorization: config.AuthorizationConfig{GroupClaims: []string{"groups", "realm_access.roles"}},
+	}}
+	auth := NewOIDCAuthenticatorWithConfig(ctx, c, &oidc.Config{
+		ClientID:                   "client-id",
+		InsecureSkipSignatureCheck: true,
+	}, nil)
+
+	iss := "http://" + mockServer.Listener.Addr().String()
+	jwtToken, err := createJWTWithClaims(iss, "test-user", "client-id", time.Now().Add(time.Hour), map[string]any{
+		"preferred_username": "alice",
+		"groups":             []string{"ios-team"},
+		"realm_access":       map[string]any{"roles": []string{"publisher"}},
+	})
+	if err != nil {
+		t.Fatalf("error creating JWT: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+jwtToken)
+	w := httptest.NewRecorder()
+
+	principal, err := auth.AuthenticatePrincipal(w, req)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if principal.Name != "alice" {
+		t.Errorf("expected name 'alice', got '%s'", principal.Name)
+	}
+	if !slices.Equal(principal.Groups, []string{"ios-team", "publisher"}) {
+		t.Errorf("expected groups [ios-team publisher], got %v", principal.Groups)
+	}
+}
+
+func Test_OIDC_CheckHealth_ProviderNotLoaded_ReturnsError(t *testing.T) {
+	var notLoaded *OidcAuthenticatorImpl
+	if err := notLoaded.CheckHealth(context.Background()); err == nil {
+		t.Errorf("expected error for authenticator without provider")
+	}
+	code := &OidcAuthenticatorCodeImpl{}
+	if err := code.CheckHealth(context.Background()); err == nil {
+		t.Errorf("expected error for code authenticator without provider")
+	}
+}
+
 func createJWT(iss string, sub string, aud string, exp time.Time) (string, error) {
+	return createJWTWithClaims(iss, sub, aud, exp, nil)
+}
+
+func createJWTWithClaims(iss string, sub string, aud string, exp time.Time, extraClaims map[string]any) (string, error) {
 	// Add claims to the JWT
 	claims := jwt.Claims{
 		Issuer:   iss,
@@ -327,7 +393,11 @@ func createJWT(iss string, sub string, aud string, exp time.Time) (string, error
 		return "", err
 	}
 
-	token, err := jwt.Signed(signer).Claims(claims).Serialize()
+	builder := jwt.Signed(signer).Claims(claims)
+	if extraClaims != nil {
+		builder = builder.Claims(extraClaims)
+	}
+	token, err := builder.Serialize()
 	if err != nil {
 		return "", err
 	}
@@ -1,6 +1,7 @@
 package authenticator
 
 import (
+	"OpenSPMRegistry/authorizer"
 	"OpenSPMRegistry/config"
 	"context"
 	"crypto/ed25519"
@@ -80,6 +81,23 @@ func (a *OidcAuthenticatorPasswordImpl) Authenticate(w http.ResponseWriter, r *h
 	return idToken, nil
 }
 
+func (a *OidcAuthenticatorPasswordImpl) AuthenticatePrincipal(w http.ResponseWriter, r *http.Request) (*authorizer.Principal, error) {
+	if _, _, ok := r.BasicAuth(); !ok {
+		return a.OidcAuthenticatorImpl.AuthenticatePrincipal(w, r)
+	}
+
+	// login with username and password, the principal is the owner of the new ID token
+	token, err := a.Authenticate(w, r)
+	if err != nil {
+		return nil, err
+	}
+	idToken, err := a.verifier.Verify(a.ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	return a.principalFromIDToken(idToken)
+}
+
 func (a *OidcAuthenticatorPasswordImpl) Login(w http.ResponseWriter, r *http.Request) {
 	if a.CheckAuthHeaderPresent(w, r) {
 		return
@@ -0,0 +1,216 @@
+package authenticator
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	apr1Prefix     = "$apr1$"
+	argon2idPrefix = "$argon2id$"
+	// itoa64 is the alphabet of the crypt(3) base64 encoding used by $apr1$
+	itoa64 = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+	// HashBcrypt and HashArgon2id are the algorithms HashPassword creates hashes with
+	HashBcrypt   = "bcrypt"
+	HashArgon2id = "argon2id"
+
+	// argon2id parameters of new hashes (RFC 9106 second recommended option)
+	argon2idMemory  = 64 * 1024
+	argon2idTime    = 3
+	argon2idThreads = 4
+	argon2idSaltLen = 16
+	argon2idKeyLen  = 32
+	// bounds of stored argon2id parameters, a hash beyond them would make every login allocate or compute excessively
+	argon2idMaxMemory = 1024 * 1024 // KiB
+	argon2idMaxTime   = 16
+)
+
+// HashPassword creates a salted hash of the password with the algorithm (bcrypt if empty)
+// to be used as password of a configured user
+func HashPassword(password string, algorithm string) (string, error) {
+	switch algorithm {
+	case "", HashBcrypt:
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		return string(hash), err
+	case HashArgon2id:
+		salt := make([]byte, argon2idSaltLen)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+		key := argon2.IDKey([]byte(password), salt, argon2idTime, argon2idMemory, argon2idThreads, argon2idKeyLen)
+		return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version, argon2idMemory, argon2idTime, argon2idThreads,
+			base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+	default:
+		return "", fmt.Errorf("unsupported hash algorithm %q, expected %s or %s", algorithm, HashBcrypt, HashArgon2id)
+	}
+}
+
+// verifyPassword checks the password against a stored hash, the format is detected from the prefix:
+//   - $2a$, $2b$, $2y$: bcrypt (htpasswd -B)
+//   - $argon2id$: argon2id in PHC string format
+//   - $apr1$: Apache MD5 (htpasswd -m)
+//   - 64 hex characters: unsalted SHA-256 (legacy, kept for backwards compatibility)
+func verifyPassword(hash string, password string) bool {
+	switch {
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+	case strings.HasPrefix(hash, argon2idPrefix):
+		return verifyArgon2id(hash, password)
+	case strings.HasPrefix(hash, apr1Prefix):
+		salt, _, _ := strings.Cut(hash[len(apr1Prefix):], "$")
+		return subtle.ConstantTimeCompare([]byte(apr1Hash(password, salt)), []byte(hash)) == 1
+	case isLegacyHash(hash):
+		return subtle.ConstantTimeCompare([]byte(hashPassword(password)), []byte(strings.ToLower(hash))) == 1
+	default:
+		return false
+	}
+}
+
+// hashPassword creates the legacy unsalted SHA-256 hash of a password
+func hashPassword(password string) string {
+	hash := sha256.Sum256([]byte(password))
+	return hex.EncodeToString(hash[:])
+}
+
+func isLegacyHash(hash string) bool {
+	if len(hash) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(hash)
+	return err == nil
+}
+
+// checkPasswordHash rejects stored hashes verifyPassword must not use, i.e. argon2id hashes with invalid
+// or excessive parameters. Other formats are checked on verification.
+func checkPasswordHash(hash string) error {
+	if !strings.HasPrefix(hash, argon2idPrefix) {
+		return nil
+	}
+	_, err := parseArgon2id(hash)
+	return err
+}
+
+// argon2idHash is a parsed argon2id hash
+type argon2idHash struct {
+	memory, time uint32
+	threads      uint8
+	salt, key    []byte
+}
+
+// parseArgon2id parses a hash of the form $argon2id$v=19$m=65536,t=3,p=4$<salt>$<key>
+// (salt and key base64 encoded without padding) and checks its parameters are within bounds
+func parseArgon2id(hash string) (*argon2idHash, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return nil, errors.New("invalid argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, errors.New("unsupported argon2id version")
+	}
+	var parsed argon2idHash
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &parsed.memory, &parsed.time, &parsed.threads); err != nil {
+		return nil, fmt.Errorf("invalid argon2id parameters: %w", err)
+	}
+	if parsed.time < 1 || parsed.time > argon2idMaxTime || parsed.threads < 1 || parsed.memory > argon2idMaxMemory {
+		return nil, fmt.Errorf("argon2id parameters out of bounds (t=1..%d, p=1..255, m up to %d KiB)", argon2idMaxTime, argon2idMaxMemory)
+	}
+
+	var err error
+	if parsed.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, errors.New("invalid argon2id salt")
+	}
+	if parsed.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(parsed.key) == 0 {
+		return nil, errors.New("invalid argon2id key")
+	}
+	return &parsed, nil
+}
+
+// verifyArgon2id checks the password against an argon2id hash, false if the hash is invalid
+func verifyArgon2id(hash string, password string) bool {
+	parsed, err := parseArgon2id(hash)
+	if err != nil {
+		return false
+	}
+	computed := argon2.IDKey([]byte(password), parsed.salt, parsed.time, parsed.memory, parsed.threads, uint32(len(parsed.key)))
+	return subtle.ConstantTimeCompare(computed, parsed.key) == 1
+}
+
+// apr1Hash computes the Apache variant of the MD5 based crypt(3) hash
+// https://httpd.apache.org/docs/2.4/misc/password_encryptions.html
+func apr1Hash(password string, salt string) string {
+	if len(salt) > 8 {
+		salt = salt[:8]
+	}
+	pw := []byte(password)
+
+	alternate := md5.New()
+	alternate.Write(pw)
+	alternate.Write([]byte(salt))
+	alternate.Write(pw)
+	alternateSum := alternate.Sum(nil)
+
+	ctx := md5.New()
+	ctx.Write(pw)
+	ctx.Write([]byte(apr1Prefix))
+	ctx.Write([]byte(salt))
+	for i := len(pw); i > 0; i -= md5.Size {
+		ctx.Write(alternateSum[:min(i, md5.Size)])
+	}
+	for i := len(pw); i > 0; i >>= 1 {
+		if i&1 != 0 {
+			ctx.Write([]byte{0})
+		} else {
+			ctx.Write(pw[:1])
+		}
+	}
+	final := ctx.Sum(nil)
+
+	// 1000 rounds to slow down brute force attacks
+	for i := 0; i < 1000; i++ {
+		round := md5.New()
+		if i&1 != 0 {
+			round.Write(pw)
+		} else {
+			round.Write(final)
+		}
+		if i%3 != 0 {
+			round.Write([]byte(salt))
+		}
+		if i%7 != 0 {
+			round.Write(pw)
+		}
+		if i&1 != 0 {
+			round.Write(final)
+		} else {
+			round.Write(pw)
+		}
+		final = round.Sum(nil)
+	}
+
+	var encoded strings.Builder
+	encode := func(value uint, n int) {
+		for ; n > 0; n-- {
+			encoded.WriteByte(itoa64[value&0x3f])
+			value >>= 6
+		}
+	}
+	for _, group := range [][3]int{{0, 6, 12}, {1, 7, 13}, {2, 8, 14}, {3, 9, 15}, {4, 10, 5}} {
+		encode(uint(final[group[0]])<<16|uint(final[group[1]])<<8|uint(final[group[2]]), 4)
+	}
+	encode(uint(final[11]), 2)
+
+	return apr1Prefix + salt + "$" + encoded.String()
+}
@@ -0,0 +1,70 @@
+package authenticator
+
+import (
+	"testing"
+)
+
+const (
+	testBcryptHash   = "$2a$04$wmXKMQdc443Die8swso4h.k2uHTxKMhH6w75/fNfPogI.fcroJx.G"
+	testArgon2idHash = "$argon2id$v=19$m=8192,t=1,p=1$c29tZXNhbHRzb21lc2FsdA$nuzCwEh9ckiiwTVNiarL9JwrSBU0dKlI9S2lP7h72/I"
+	// created with: openssl passwd -apr1 -salt saltsalt password
+	testApr1Hash = "$apr1$saltsalt$yAAkm4libquA.ZWLHbSBq/"
+)
+
+func Test_VerifyPassword_SupportedFormats(t *testing.T) {
+	tests := []struct {
+		name     string
+		hash     string
+		password string
+		expected bool
+	}{
+		{"bcrypt", testBcryptHash, "pass", true},
+		{"bcrypt wrong password", testBcryptHash, "wrong", false},
+		{"bcrypt $2y$", "$2y$" + testBcryptHash[4:], "pass", true},
+		{"argon2id", testArgon2idHash, "pass", true},
+		{"argon2id wrong password", testArgon2idHash, "wrong", false},
+		{"argon2id invalid parameters", "$argon2id$v=19$m=x$c29tZQ$a2V5", "pass", false},
+		{"argon2id zero rounds", "$argon2id$v=19$m=8192,t=0,p=1$c29tZQ$a2V5", "pass", false},
+		{"argon2id zero threads", "$argon2id$v=19$m=8192,t=1,p=0$c29tZQ$a2V5", "pass", false},
+		{"argon2id excessive memory", "$argon2id$v=19$m=4294967295,t=1,p=1$c29tZQ$a2V5", "pass", false},
+		{"apr1", testApr1Hash, "password", true},
+		{"apr1 wrong password", testApr1Hash, "wrong", false},
+		{"legacy sha256", hashPassword("pass"), "pass", true},
+		{"legacy sha256 upper case", "D74FF0EE8DA3B9806B18C877DBF29BBDE50B5BD8E4DAD7A3A725000FEB82E8F1", "pass", true},
+		{"legacy sha256 wrong password", hashPassword("pass"), "wrong", false},
+		{"plain text is not accepted", "pass", "pass", false},
+		{"empty hash", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := verifyPassword(tt.hash, tt.password); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func Test_Apr1Hash_MatchesOpenSSL(t *testing.T) {
+	// created with: openssl passwd -apr1 -salt ab "p@ss wörd"
+	expected := "$apr1$ab$HDnxNS1M3PPbjU1tC/KIj1"
+
+	if got := apr1Hash("p@ss wörd", "ab"); got != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+}
+
+func Test_HashPassword_VerifiesWithSupportedAlgorithms(t *testing.T) {
+	for _, algorithm := range []string{"", HashBcrypt, HashArgon2id} {
+		hash, err := HashPassword("s3cret", algorithm)
+		if err != nil {
+			t.Fatalf("%s: %v", algorithm, err)
+		}
+		if !verifyPassword(hash, "s3cret") || verifyPassword(hash, "wrong") {
+			t.Errorf("%s: expected hash %s to verify the password only", algorithm, hash)
+		}
+	}
+	if _, err := HashPassword("s3cret", "md5"); err == nil {
+		t.Errorf("expected error for unsupported algorithm")
+	}
+}
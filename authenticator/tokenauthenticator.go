@@ -0,0 +1,107 @@
+package authenticator
+
+import (
+	"OpenSPMRegistry/authorizer"
+	"OpenSPMRegistry/tokens"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// WrappingAuthenticator is implemented by authenticators that delegate to another (primary) authenticator
+type WrappingAuthenticator interface {
+	Authenticator
+
+	// Unwrap returns the primary authenticator
+	Unwrap() Authenticator
+}
+
+// TokenAuthenticator accepts personal access tokens issued by the registry as Bearer tokens
+// and passes every other request on to the primary authenticator
+type TokenAuthenticator struct {
+	store   *tokens.Store
+	primary Authenticator
+}
+
+// NewTokenAuthenticator creates a token authenticator in front of the primary authenticator
+func NewTokenAuthenticator(store *tokens.Store, primary Authenticator) *TokenAuthenticator {
+	return &TokenAuthenticator{store: store, primary: primary}
+}
+
+func (a *TokenAuthenticator) Authenticate(w http.ResponseWriter, r *http.Request) (string, error) {
+	secret, ok := accessToken(r)
+	if !ok {
+		return a.primary.Authenticate(w, r)
+	}
+	if _, err := a.verify(secret); err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+func (a *TokenAuthenticator) AuthenticatePrincipal(w http.ResponseWriter, r *http.Request) (*authorizer.Principal, error) {
+	secret, ok := accessToken(r)
+	if !ok {
+		if principalAuth, ok := a.primary.(PrincipalAuthenticator); ok {
+			return principalAuth.AuthenticatePrincipal(w, r)
+		}
+		_, err := a.primary.Authenticate(w, r)
+		return nil, err
+	}
+
+	token, err := a.verify(secret)
+	if err != nil {
+		return nil, err
+	}
+	permissions := make([]authorizer.Permission, len(token.Permissions))
+	for i, permission := range token.Permissions {
+		permissions[i] = authorizer.Permission(permission)
+	}
+	return &authorizer.Principal{
+		Name:        token.Owner,
+		Groups:      token.Groups,
+		TokenId:     token.Id,
+		Scopes:      token.Scopes,
+		Permissions: permissions,
+	}, nil
+}
+
+func (a *TokenAuthenticator) Unwrap() Authenticator {
+	return a.primary
+}
+
+// Primary returns the authenticator at the end of a chain of wrapping authenticators
+func Primary(auth Authenticator) Authenticator {
+	for {
+		wrapping, ok := auth.(WrappingAuthenticator)
+		if !ok {
+			return auth
+		}
+		auth = wrapping.Unwrap()
+	}
+}
+
+func (a *TokenAuthenticator) verify(secret string) (*tokens.Token, error) {
+	token, err := a.store.Verify(secret)
+	if err != nil {
+		if errors.Is(err, tokens.ErrTokenExpired) {
+			return nil, errors.New("personal access token expired")
+		}
+		return nil, errors.New("invalid personal access token")
+	}
+	if slog.Default().Enabled(context.TODO(), slog.LevelDebug) {
+		slog.Debug("Personal access token authentication", "token", token.Id)
+	}
+	return token, nil
+}
+
+// accessToken returns the Bearer token of the request if it is a personal access token
+func accessToken(r *http.Request) (string, bool) {
+	token, err := getBearerToken(r.Header.Get("Authorization"))
+	if err != nil || !tokens.IsToken(strings.TrimSpace(token)) {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
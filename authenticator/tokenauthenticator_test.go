@@ -0,0 +1,109 @@
+package authenticator
+
+import (
+	"OpenSPMRegistry/authorizer"
+	"OpenSPMRegistry/config"
+	"OpenSPMRegistry/tokens"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func newTestTokenAuthenticator(t *testing.T) (*TokenAuthenticator, *tokens.Store) {
+	t.Helper()
+	store, err := tokens.NewStore(config.TokensConfig{Path: filepath.Join(t.TempDir(), "tokens.json")})
+	if err != nil {
+		t.Fatalf("failed to create token store: %v", err)
+	}
+	users := []config.User{{Username: "user", Password: hashPassword("pass"), Groups: []string{"basic"}}}
+	return NewTokenAuthenticator(store, NewBasicAuthenticator(users)), store
+}
+
+func Test_TokenAuthenticator_ValidToken_ReturnsRestrictedPrincipal(t *testing.T) {
+	auth, store := newTestTokenAuthenticator(t)
+	token, secret, err := store.Create("alice", []string{"ios-team"}, "ci", []string{"acme"}, []string{"publish"}, time.Time{})
+	if err != nil {
+		t.Fatalf("failed to create token: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+secret)
+	w := httptest.NewRecorder()
+
+	principal, err := auth.AuthenticatePrincipal(w, req)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if principal.Name != "alice" || principal.TokenId != token.Id {
+		t.Errorf("unexpected principal %+v", principal)
+	}
+	if !slices.Equal(principal.Groups, []string{"ios-team"}) || !slices.Equal(principal.Scopes, []string{"acme"}) {
+		t.Errorf("unexpected groups or scopes %+v", principal)
+	}
+	if !slices.Equal(principal.Permissions, []authorizer.Permission{authorizer.Publish}) {
+		t.Errorf("unexpected permissions %v", principal.Permissions)
+	}
+
+	authenticated, err := auth.Authenticate(w, req)
+	if err != nil || authenticated != secret {
+		t.Errorf("expected token to authenticate, got %q, %v", authenticated, err)
+	}
+}
+
+func Test_TokenAuthenticator_InvalidToken_ReturnsError(t *testing.T) {
+	auth, _ := newTestTokenAuthenticator(t)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+tokens.Prefix+"unknown")
+	w := httptest.NewRecorder()
+
+	if _, err := auth.AuthenticatePrincipal(w, req); err == nil || err.Error() != "invalid personal access token" {
+		t.Errorf("expected 'invalid personal access token' error, got %v", err)
+	}
+	if _, err := auth.Authenticate(w, req); err == nil {
+		t.Errorf("expected error, got nil")
+	}
+}
+
+func Test_TokenAuthenticator_OtherCredentials_UsesPrimary(t *testing.T) {
+	auth, _ := newTestTokenAuthenticator(t)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.SetBasicAuth("user", "pass")
+	w := httptest.NewRecorder()
+
+	principal, err := auth.AuthenticatePrincipal(w, req)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if principal.Name != "user" || principal.TokenId != "" {
+		t.Errorf("expected principal of primary authenticator, got %+v", principal)
+	}
+
+	req.SetBasicAuth("user", "wrong")
+	if _, err := auth.Authenticate(w, req); err == nil {
+		t.Errorf("expected error of primary authenticator")
+	}
+}
+
+func Test_TokenAuthenticator_Unwrap_ReturnsPrimary(t *testing.T) {
+	primary := &NoOpAuthenticator{}
+	auth := NewTokenAuthenticator(nil, primary)
+
+	if auth.Unwrap() != primary {
+		t.Errorf("expected primary authenticator")
+	}
+}
+
+func Test_Primary_UnwrapsTokenAuthenticator(t *testing.T) {
+	basic := NewBasicAuthenticator(nil)
+
+	if primary := Primary(NewTokenAuthenticator(nil, basic)); primary != basic {
+		t.Errorf("expected basic authenticator, got %T", primary)
+	}
+	if primary := Primary(basic); primary != basic {
+		t.Errorf("expected basic authenticator, got %T", primary)
+	}
+}
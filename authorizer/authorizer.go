@@ -0,0 +1,160 @@
+package authorizer
+
+import (
+	"OpenSPMRegistry/config"
+	"log/slog"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Permission is an action a principal may perform on a scope
+type Permission string
+
+// Authorizer decides which permissions a principal holds per scope,
+// based on the rules of the authorization configuration
+type Authorizer struct {
+	rules []rule
+}
+
+type rule struct {
+	scopes      []string
+	users       []string
+	groups      []string
+	permissions []Permission
+}
+
+const (
+	// Read allows listing and downloading releases
+	Read Permission = "read"
+	// Publish allows publishing new releases (implies read)
+	Publish Permission = "publish"
+	// Delete allows deleting releases (implies read)
+	Delete Permission = "delete"
+	// Admin allows everything, including administrative endpoints
+	Admin Permission = "admin"
+
+	// AnyScope checks a permission for all scopes at once, e.g. for registry wide administration.
+	// Only rules matching every scope ("*") grant it.
+	AnyScope = "*"
+
+	anyUser = "*"
+)
+
+// NewAuthorizer creates an authorizer from the given configuration.
+// Returns nil if authorization is disabled, which allows everything.
+// Unknown permissions and invalid scope patterns are ignored (and never grant anything).
+func NewAuthorizer(cfg config.AuthorizationConfig) *Authorizer {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	a := &Authorizer{}
+	for i, r := range cfg.Rules {
+		var scopes []string
+		for _, scope := range r.Scopes {
+			pattern := strings.ToLower(scope)
+			if _, err := path.Match(pattern, ""); err != nil {
+				slog.Warn("Ignoring invalid scope pattern of authorization rule", "rule", i, "scope", scope, "error", err)
+				continue
+			}
+			scopes = append(scopes, pattern)
+		}
+
+		var permissions []Permission
+		for _, p := range r.Permissions {
+			permission := Permission(strings.ToLower(p))
+			switch permission {
+			case Read, Publish, Delete, Admin:
+				permissions = append(permissions, permission)
+			default:
+				slog.Warn("Ignoring unknown permission of authorization rule", "rule", i, "permission", p)
+			}
+		}
+
+		a.rules = append(a.rules, rule{
+			scopes:      scopes,
+			users:       r.Users,
+			groups:      r.Groups,
+			permissions: permissions,
+		})
+	}
+	return a
+}
+
+// Allowed reports whether the principal holds the permission on the scope.
+// A nil authorizer allows everything the principal is not restricted from,
+// otherwise a nil (anonymous) principal is allowed nothing.
+func (a *Authorizer) Allowed(principal *Principal, scope string, permission Permission) bool {
+	scope = strings.ToLower(scope)
+	if principal != nil && !principal.permits(scope, permission) {
+		return false
+	}
+	if a == nil {
+		return true
+	}
+	if principal == nil {
+		return false
+	}
+
+	for _, r := range a.rules {
+		if r.matchesPrincipal(principal) && r.matchesScope(scope) && r.grants(permission) {
+			return true
+		}
+	}
+	return false
+}
+
+// permits checks the restrictions of the principal, the scope must be lower case
+func (p *Principal) permits(scope string, permission Permission) bool {
+	if len(p.Scopes) > 0 && !MatchesAnyScope(p.Scopes, scope) {
+		return false
+	}
+	if len(p.Permissions) > 0 && !grantsAny(p.Permissions, permission) {
+		return false
+	}
+	return true
+}
+
+func (r rule) matchesPrincipal(principal *Principal) bool {
+	if slices.Contains(r.users, anyUser) || slices.Contains(r.users, principal.Name) {
+		return true
+	}
+	for _, group := range principal.Groups {
+		if slices.Contains(r.groups, group) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r rule) matchesScope(scope string) bool {
+	return MatchesAnyScope(r.scopes, scope)
+}
+
+func (r rule) grants(permission Permission) bool {
+	return grantsAny(r.permissions, permission)
+}
+
+// MatchesAnyScope checks the scope against the patterns, case-insensitively
+func MatchesAnyScope(patterns []string, scope string) bool {
+	for _, pattern := range patterns {
+		if match, _ := path.Match(strings.ToLower(pattern), scope); match {
+			return true
+		}
+	}
+	return false
+}
+
+// grantsAny checks whether one of the permissions grants (or implies) the permission
+func grantsAny(permissions []Permission, permission Permission) bool {
+	for _, p := range permissions {
+		if p == permission || p == Admin {
+			return true
+		}
+		if permission == Read && (p == Publish || p == Delete) {
+			return true
+		}
+	}
+	return false
+}
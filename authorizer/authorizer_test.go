@@ -0,0 +1,108 @@
+package authorizer
+
+import (
+	"OpenSPMRegistry/config"
+	"testing"
+)
+
+func newTestAuthorizer() *Authorizer {
+	return NewAuthorizer(config.AuthorizationConfig{
+		Enabled: true,
+		Rules: []config.AuthorizationRule{
+			{Scopes: []string{"*"}, Users: []string{"*"}, Permissions: []string{"read"}},
+			{Scopes: []string{"acme"}, Users: []string{"alice"}, Permissions: []string{"publish"}},
+			{Scopes: []string{"ios-*"}, Groups: []string{"ios-team"}, Permissions: []string{"publish", "delete"}},
+			{Scopes: []string{"*"}, Groups: []string{"registry-admins"}, Permissions: []string{"admin"}},
+			{Scopes: []string{"private"}, Users: []string{"bob"}, Permissions: []string{"unknown"}},
+		},
+	})
+}
+
+func Test_NewAuthorizer_Disabled_ReturnsNil(t *testing.T) {
+	if a := NewAuthorizer(config.AuthorizationConfig{Enabled: false}); a != nil {
+		t.Errorf("expected nil authorizer, got %v", a)
+	}
+}
+
+func Test_Allowed_NilAuthorizer_AllowsEverything(t *testing.T) {
+	var a *Authorizer
+	if !a.Allowed(nil, "acme", Admin) {
+		t.Errorf("expected nil authorizer to allow everything")
+	}
+}
+
+func Test_Allowed_AnonymousPrincipal_Denied(t *testing.T) {
+	if newTestAuthorizer().Allowed(nil, "acme", Read) {
+		t.Errorf("expected anonymous principal to be denied")
+	}
+}
+
+func Test_Allowed_Rules(t *testing.T) {
+	a := newTestAuthorizer()
+	alice := &Principal{Name: "alice"}
+	carol := &Principal{Name: "carol", Groups: []string{"ios-team"}}
+	admin := &Principal{Name: "dave", Groups: []string{"registry-admins"}}
+	bob := &Principal{Name: "bob"}
+
+	tests := []struct {
+		name       string
+		principal  *Principal
+		scope      string
+		permission Permission
+		expected   bool
+	}{
+		{"any user reads any scope", bob, "acme", Read, true},
+		{"user publishes to granted scope", alice, "acme", Publish, true},
+		{"scope matching is case insensitive", alice, "ACME", Publish, true},
+		{"user cannot publish to other scope", alice, "other", Publish, false},
+		{"publish does not imply delete", alice, "acme", Delete, false},
+		{"group matches scope pattern", carol, "ios-kit", Delete, true},
+		{"group does not match other scope", carol, "acme", Publish, false},
+		{"unknown permission grants nothing", bob, "private", Publish, false},
+		{"admin implies publish", admin, "acme", Publish, true},
+		{"admin on any scope", admin, AnyScope, Admin, true},
+		{"scope rule does not grant any scope", carol, AnyScope, Publish, false},
+		{"read on all scopes", bob, AnyScope, Read, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := a.Allowed(tt.principal, tt.scope, tt.permission); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func Test_Allowed_RestrictedPrincipal(t *testing.T) {
+	a := newTestAuthorizer()
+	admin := &Principal{Name: "dave", Groups: []string{"registry-admins"}, Scopes: []string{"acme"}, Permissions: []Permission{Publish}}
+
+	if !a.Allowed(admin, "acme", Publish) {
+		t.Errorf("expected publish on restricted scope to be allowed")
+	}
+	if !a.Allowed(admin, "acme", Read) {
+		t.Errorf("expected publish restriction to allow read")
+	}
+	if a.Allowed(admin, "other", Publish) {
+		t.Errorf("expected scope restriction to deny other scope")
+	}
+	if a.Allowed(admin, "acme", Delete) {
+		t.Errorf("expected permission restriction to deny delete")
+	}
+	if a.Allowed(admin, AnyScope, Admin) {
+		t.Errorf("expected restricted principal not to be admin")
+	}
+}
+
+func Test_Allowed_NilAuthorizer_AppliesPrincipalRestrictions(t *testing.T) {
+	var a *Authorizer
+	principal := &Principal{Name: "alice", Scopes: []string{"ACME"}}
+
+	if !a.Allowed(principal, "acme", Delete) {
+		t.Errorf("expected restricted scope to be allowed")
+	}
+	if a.Allowed(principal, "other", Read) {
+		t.Errorf("expected other scope to be denied")
+	}
+}
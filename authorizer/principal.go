@@ -0,0 +1,70 @@
+package authorizer
+
+import (
+	"OpenSPMRegistry/config"
+	"context"
+	"strings"
+)
+
+// Principal is the authenticated identity a request is made by
+type Principal struct {
+	Name   string
+	Groups []string
+	// TokenId is the id of the personal access token the request was authenticated with, if any
+	TokenId string
+	// Scopes and Permissions restrict the principal further (e.g. a token limited to one scope),
+	// independent of the authorization rules. Empty means no restriction.
+	Scopes      []string
+	Permissions []Permission
+}
+
+const (
+	principalContextKey config.ContextKey = "principal"
+	defaultGroupClaim                     = "groups"
+)
+
+// WithPrincipal returns a copy of ctx carrying the principal
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey, principal)
+}
+
+// PrincipalFromContext returns the principal stored by WithPrincipal
+// or nil if the request is anonymous
+func PrincipalFromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(principalContextKey).(*Principal)
+	return principal
+}
+
+// GroupsFromClaims collects the group and role names found in the given claims of an ID token.
+// Claims may hold a single string or a list of strings; nested claims are addressed with dots
+// (e.g. realm_access.roles). If no claim names are given, the "groups" claim is used.
+func GroupsFromClaims(claims map[string]any, claimNames []string) []string {
+	if len(claimNames) == 0 {
+		claimNames = []string{defaultGroupClaim}
+	}
+
+	var groups []string
+	for _, name := range claimNames {
+		var value any = claims
+		for _, key := range strings.Split(name, ".") {
+			object, ok := value.(map[string]any)
+			if !ok {
+				value = nil
+				break
+			}
+			value = object[key]
+		}
+
+		switch v := value.(type) {
+		case string:
+			groups = append(groups, v)
+		case []any:
+			for _, item := range v {
+				if group, ok := item.(string); ok {
+					groups = append(groups, group)
+				}
+			}
+		}
+	}
+	return groups
+}
@@ -0,0 +1,46 @@
+package authorizer
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func Test_PrincipalFromContext_WithPrincipal_ReturnsPrincipal(t *testing.T) {
+	principal := &Principal{Name: "alice"}
+	ctx := WithPrincipal(context.Background(), principal)
+
+	if got := PrincipalFromContext(ctx); got != principal {
+		t.Errorf("expected %v, got %v", principal, got)
+	}
+}
+
+func Test_PrincipalFromContext_Anonymous_ReturnsNil(t *testing.T) {
+	if got := PrincipalFromContext(context.Background()); got != nil {
+		t.Errorf("expected nil, got %v", got)
+	}
+}
+
+func Test_GroupsFromClaims_DefaultClaim(t *testing.T) {
+	claims := map[string]any{"groups": []any{"a", "b", 1}}
+
+	groups := GroupsFromClaims(claims, nil)
+
+	if !slices.Equal(groups, []string{"a", "b"}) {
+		t.Errorf("expected [a b], got %v", groups)
+	}
+}
+
+func Test_GroupsFromClaims_NestedAndStringClaims(t *testing.T) {
+	claims := map[string]any{
+		"realm_access": map[string]any{"roles": []any{"publisher"}},
+		"role":         "admin",
+		"groups":       []any{"ignored"},
+	}
+
+	groups := GroupsFromClaims(claims, []string{"realm_access.roles", "role", "missing.claim"})
+
+	if !slices.Equal(groups, []string{"publisher", "admin"}) {
+		t.Errorf("expected [publisher admin], got %v", groups)
+	}
+}
@@ -17,6 +17,11 @@ type ServerConfig struct {
 	Auth               AuthConfig               `yaml:"auth"`
 	TlsEnabled         bool                     `yaml:"tlsEnabled"`
 	PackageCollections PackageCollectionsConfig `yaml:"packageCollections"`
+	Metrics            MetricsConfig            `yaml:"metrics"`
+	Search             SearchConfig             `yaml:"search"`
+	Upstream           UpstreamConfig           `yaml:"upstream"`
+	Webhooks           WebhooksConfig           `yaml:"webhooks"`
+	Audit              AuditConfig              `yaml:"audit"`
 }
 
 type Certs struct {
@@ -26,12 +31,117 @@ type Certs struct {
 
 type PublishConfig struct {
 	MaxSize int64 `yaml:"maxSize"`
+	// Async configures asynchronous publication for clients sending "Prefer: respond-async" (spec 4.6.3.2).
+	Async AsyncPublishConfig `yaml:"async"`
+	// Archive bounds the contents of published source archives.
+	Archive ArchiveConfig `yaml:"archive"`
+	// Signatures configures verification of source archive signatures.
+	Signatures SignatureConfig `yaml:"signatures"`
+	// Metadata configures the handling of release metadata.
+	Metadata MetadataConfig `yaml:"metadata"`
+}
+
+// MetadataConfig configures server-side enrichment of release metadata, which is validated on publication regardless.
+// Enrichment fills in missing fields (originalPublicationTime) after the release was stored;
+// releases with a metadata signature are left untouched as the signature covers the uploaded metadata.
+type MetadataConfig struct {
+	Enrich bool `yaml:"enrich"`
+}
+
+// SignatureConfig configures verification of source archive signatures (cms-1.0.0) on publication.
+// Signed releases are rejected with 422 unless the signature matches the archive and chains up to a trusted root.
+type SignatureConfig struct {
+	Enabled      bool     `yaml:"enabled"`
+	TrustedRoots []string `yaml:"trustedRoots"` // PEM files with the trusted root certificates
+	// RequireSigned lists scope patterns (as in auth.authorization) whose releases must be signed
+	RequireSigned []string `yaml:"requireSigned"`
+}
+
+// ArchiveConfig limits source archives checked on publication, larger archives are rejected with 422 (zip bomb protection)
+type ArchiveConfig struct {
+	MaxUncompressedSize int64 `yaml:"maxUncompressedSize"` // Total uncompressed size of all entries in bytes (default: 512 MiB)
+	MaxEntries          int   `yaml:"maxEntries"`          // Number of files and directories (default: 10000)
+}
+
+type AsyncPublishConfig struct {
+	Enabled   bool   `yaml:"enabled"`
+	Workers   int    `yaml:"workers"`   // Number of background publish workers (default: 2)
+	QueueSize int    `yaml:"queueSize"` // Submissions waiting for a worker before 503 is returned (default: 32)
+	Retention int    `yaml:"retention"` // Seconds the status of a finished submission is kept (default: 3600)
+	TempDir   string `yaml:"tempDir"`   // Directory uploads and source archives being validated are spooled to (default: OS temp dir)
+}
+
+// MetricsConfig configures the Prometheus metrics endpoint (GET /metrics, served without authentication)
+type MetricsConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// SearchConfig configures the package search endpoint (GET /search).
+// The search index is built from the repository on startup and updated on publish and delete.
+type SearchConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// UpstreamConfig configures a Swift package registry this registry acts as a pull-through proxy for.
+// Releases missing locally are fetched from it, checksum verified, stored and served locally from then on.
+type UpstreamConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	URL     string `yaml:"url"` // Base URL of the upstream registry (e.g. https://registry.example.com)
+	// Username and Password authenticate with basic auth, Token as bearer token (takes precedence)
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	Token    string `yaml:"token"`
+	Timeout  int    `yaml:"timeout"` // HTTP client timeout in seconds (default: 30)
+}
+
+// WebhooksConfig configures HTTP webhooks notified of registry events (release.published, release.deleted,
+// publish.failed, collection.changed). Deliveries are retried with exponential backoff and recorded
+// in a persistent delivery log, so pending deliveries survive restarts.
+type WebhooksConfig struct {
+	Enabled        bool            `yaml:"enabled"`
+	DeliveryLog    string          `yaml:"deliveryLog"`    // Database file of the delivery log (default: webhooks.db)
+	MaxAttempts    int             `yaml:"maxAttempts"`    // Attempts per delivery before it is given up (default: 8)
+	InitialBackoff int             `yaml:"initialBackoff"` // Seconds before the first retry, doubled for every further one (default: 5)
+	Timeout        int             `yaml:"timeout"`        // HTTP timeout of a delivery in seconds (default: 10)
+	Retention      int             `yaml:"retention"`      // Days finished deliveries are kept in the log (default: 30)
+	Hooks          []WebhookConfig `yaml:"hooks"`
+}
+
+// WebhookConfig is an endpoint events are POSTed to as JSON
+type WebhookConfig struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+	// Secret signs the payload with HMAC-SHA256 (X-Registry-Signature-256 header), unsigned if empty
+	Secret string   `yaml:"secret"`
+	Events []string `yaml:"events"` // Event types delivered (default: all)
+	Scopes []string `yaml:"scopes"` // Scope patterns (as in auth.authorization) events are delivered for (default: all)
+}
+
+// AuditConfig configures the append-only audit log (JSON lines) of publications, deletions, logins,
+// token issuance and authentication or authorization failures
+type AuditConfig struct {
+	Enabled    bool   `yaml:"enabled"`
+	Path       string `yaml:"path"`       // File the entries are appended to (default: audit.log)
+	MaxSize    int64  `yaml:"maxSize"`    // Bytes after which the file is rotated to <path>.1, <path>.2, ... (default: 0, never rotated)
+	MaxBackups int    `yaml:"maxBackups"` // Rotated files kept, older ones are removed (default: 5)
+	// TrustForwardedFor takes the source IP from the X-Forwarded-For header, only enable behind a trusted proxy
+	TrustForwardedFor bool `yaml:"trustForwardedFor"`
 }
 
 type Repo struct {
 	Path  string      `yaml:"path"`
 	Type  string      `yaml:"type"`
 	Maven MavenConfig `yaml:"maven"`
+	S3    S3Config    `yaml:"s3"`
+	Index IndexConfig `yaml:"index"`
+}
+
+// IndexConfig configures the persistent metadata index (bbolt database) listings, lookups
+// and package collections are served from instead of walking the storage backend.
+// It is built from the backend on first start and kept up to date on publish and delete.
+type IndexConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Path    string `yaml:"path"` // Database file (default: index.db)
 }
 
 type MavenConfig struct {
@@ -43,6 +153,27 @@ type MavenConfig struct {
 	Timeout       int    `yaml:"timeout"`
 }
 
+// S3Config configures the S3-compatible object storage backend (repo.type: s3).
+// Works against AWS S3 and any S3 API endpoint (e.g. MinIO) using AWS Signature Version 4.
+type S3Config struct {
+	// Endpoint is the base URL of the S3 API (e.g. https://s3.eu-central-1.amazonaws.com or http://localhost:9000)
+	Endpoint string `yaml:"endpoint"`
+	Region   string `yaml:"region"`
+	Bucket   string `yaml:"bucket"`
+	// Prefix is an optional key prefix all registry objects are stored under (e.g. "spm/")
+	Prefix          string `yaml:"prefix"`
+	AccessKeyId     string `yaml:"accessKeyId"`
+	SecretAccessKey string `yaml:"secretAccessKey"`
+	SessionToken    string `yaml:"sessionToken"`
+	// PathStyle addresses the bucket as {endpoint}/{bucket}/{key} instead of {bucket}.{host}/{key}.
+	// Required for most self-hosted S3 implementations (e.g. MinIO).
+	PathStyle bool `yaml:"pathStyle"`
+	// PartSize is the multipart upload part size in MiB (default: 8, minimum: 5)
+	PartSize int64 `yaml:"partSize"`
+	// Timeout is the HTTP client timeout in seconds (default: 30)
+	Timeout int `yaml:"timeout"`
+}
+
 type AuthConfig struct {
 	Name         string `yaml:"name"`
 	Type         string `yaml:"type"`
@@ -52,11 +183,45 @@ type AuthConfig struct {
 	Issuer       string `yaml:"issuer"`
 	GrantType    string `yaml:"grant_type"`
 	Users        []User `yaml:"users"`
+	// Htpasswd is the path of an Apache htpasswd file with additional basic auth users, re-read on change
+	Htpasswd string `yaml:"htpasswd"`
+	// Authorization restricts what authenticated users may do per scope
+	Authorization AuthorizationConfig `yaml:"authorization"`
+	// Tokens enables personal access tokens issued by the registry (e.g. for CI)
+	Tokens TokensConfig `yaml:"tokens"`
 }
 
 type User struct {
-	Username string `yaml:"username"`
-	Password string `yaml:"password"`
+	Username string   `yaml:"username"`
+	Password string   `yaml:"password"`
+	Groups   []string `yaml:"groups"` // Groups the user belongs to, referenced by authorization rules
+}
+
+// AuthorizationConfig maps users and groups to per-scope permissions (read, publish, delete, admin).
+// When disabled, every authenticated user may do everything.
+// When enabled, everything not granted by a rule is denied.
+type AuthorizationConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// GroupClaims are the OIDC ID token claims group memberships and roles are read from (default: groups).
+	// Nested claims are addressed with dots (e.g. realm_access.roles).
+	GroupClaims []string            `yaml:"groupClaims"`
+	Rules       []AuthorizationRule `yaml:"rules"`
+}
+
+// AuthorizationRule grants permissions on the matching scopes to the listed users and groups.
+type AuthorizationRule struct {
+	Scopes      []string `yaml:"scopes"`      // Scope patterns, e.g. "acme", "acme-*" or "*" for all scopes
+	Users       []string `yaml:"users"`       // Usernames, "*" matches every authenticated user
+	Groups      []string `yaml:"groups"`      // Basic auth groups or OIDC group/role claim values
+	Permissions []string `yaml:"permissions"` // read, publish, delete or admin
+}
+
+// TokensConfig configures personal access tokens, accepted as Bearer tokens
+// next to the credentials of the configured authenticator.
+type TokensConfig struct {
+	Enabled     bool   `yaml:"enabled"`
+	Path        string `yaml:"path"`        // File the tokens (hashes only) are stored in (default: tokens.json)
+	MaxLifetime int    `yaml:"maxLifetime"` // Maximum (and default) lifetime of a token in days (default: 365)
 }
 
 type PackageCollectionsConfig struct {
@@ -69,6 +234,18 @@ type PackageCollectionsConfig struct {
 	// that cannot send headers (e.g. swift package-collection add). Off by default to avoid credential
 	// leakage via logs, referrers, and proxies. When true, decoded value must start with "Basic " or "Bearer ".
 	AllowAuthQueryParam bool `yaml:"allowAuthQueryParam"`
+	// Signing signs collections so clients can verify them (no untrusted collection warning)
+	Signing CollectionSigningConfig `yaml:"signing"`
+}
+
+// CollectionSigningConfig configures signing of package collections (SE-0291) with an X.509 certificate.
+// Supported keys are RSA (RS256) and EC P-256 (ES256).
+type CollectionSigningConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// CertificateChain is a PEM file with the signing certificate first, followed by its intermediates and root
+	CertificateChain string `yaml:"certificateChain"`
+	// PrivateKey is a PEM file with the private key of the signing certificate (PKCS#1, SEC 1 or PKCS#8)
+	PrivateKey string `yaml:"privateKey"`
 }
 
 const (
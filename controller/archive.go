@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"OpenSPMRegistry/models"
+	"OpenSPMRegistry/repo/files"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+)
+
+// spooledArchive is a source archive spooled to a temporary file, removed on Close
+type spooledArchive struct {
+	*os.File
+}
+
+const (
+	defaultMaxUncompressedSize = 512 << 20
+	defaultMaxArchiveEntries   = 10000
+)
+
+func (a *spooledArchive) Close() error {
+	err := a.File.Close()
+	if removeErr := os.Remove(a.Name()); removeErr != nil {
+		slog.Warn("Failed to remove spooled source archive", "file", a.Name(), "error", removeErr)
+	}
+	return err
+}
+
+// validateSourceArchive checks the source archive before it is stored (see files.ValidateSourceArchive).
+// Archives not already in a file are spooled to a temporary one. Returns the archive to store from its start,
+// or a 422 publish error listing every failure. content is closed on error.
+func validateSourceArchive(c *Controller, element *models.UploadElement, content io.ReadCloser) (io.ReadCloser, *publishError) {
+	archive, ok := content.(*os.File)
+	if !ok {
+		file, err := os.CreateTemp(c.config.Publish.Async.TempDir, "source-archive-*.zip")
+		if err != nil {
+			_ = content.Close()
+			slog.Error("Error creating temporary file for source archive:", "error", err)
+			return nil, newPublishError(publishFailureStorage, "upload failed, error storing file", http.StatusInternalServerError)
+		}
+		spooled := &spooledArchive{File: file}
+		_, err = io.Copy(file, content)
+		if closeErr := content.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			_ = spooled.Close()
+			slog.Error("Error spooling source archive:", "error", err)
+			return nil, newPublishError(publishFailureStorage, "upload failed, error storing file", http.StatusInternalServerError)
+		}
+		archive, content = file, spooled
+	}
+
+	info, err := archive.Stat()
+	if err != nil {
+		_ = content.Close()
+		slog.Error("Error reading source archive:", "error", err)
+		return nil, newPublishError(publishFailureStorage, "upload failed, error storing file", http.StatusInternalServerError)
+	}
+	if failures := files.ValidateSourceArchive(element, archive, info.Size(), archiveLimits(c)); len(failures) > 0 {
+		_ = content.Close()
+		msg := fmt.Sprintf("upload failed, source archive of %s.%s@%s is invalid", element.Scope, element.Name, element.Version)
+		slog.Error("Error", "msg", msg, "failures", failures)
+		pubErr := newPublishError(publishFailureInvalidArchive, msg, http.StatusUnprocessableEntity)
+		pubErr.failures = failures
+		return nil, pubErr
+	}
+
+	if _, err := archive.Seek(0, io.SeekStart); err != nil {
+		_ = content.Close()
+		slog.Error("Error reading source archive:", "error", err)
+		return nil, newPublishError(publishFailureStorage, "upload failed, error storing file", http.StatusInternalServerError)
+	}
+	return content, nil
+}
+
+func archiveLimits(c *Controller) files.ArchiveLimits {
+	limits := files.ArchiveLimits{
+		MaxUncompressedSize: c.config.Publish.Archive.MaxUncompressedSize,
+		MaxEntries:          c.config.Publish.Archive.MaxEntries,
+	}
+	if limits.MaxUncompressedSize <= 0 {
+		limits.MaxUncompressedSize = defaultMaxUncompressedSize
+	}
+	if limits.MaxEntries <= 0 {
+		limits.MaxEntries = defaultMaxArchiveEntries
+	}
+	return limits
+}
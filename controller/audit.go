@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"OpenSPMRegistry/audit"
+	"OpenSPMRegistry/authorizer"
+	"OpenSPMRegistry/mimetypes"
+	"OpenSPMRegistry/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// defaultAuditLimit is the number of audit log entries listed if no limit is requested
+	defaultAuditLimit = 100
+	maxAuditLimit     = 10000
+)
+
+// SetAuditLog records publications, deletions, token issuance and authorization failures in the audit log
+func (c *Controller) SetAuditLog(log *audit.Log) {
+	c.auditLog = log
+}
+
+// AuditLogAction queries the audit log, newest entries first (admin permission required).
+// Query parameters: action, outcome, principal, scope, package, since and until (RFC 3339) and limit (default 100, at most 10000).
+func (c *Controller) AuditLogAction(w http.ResponseWriter, r *http.Request) {
+	printCallInfo("AuditLog", r)
+
+	if c.auditLog == nil {
+		writeErrorWithStatusCode("audit log is not enabled", w, http.StatusNotFound)
+		return
+	}
+	if !c.authorize(w, r, authorizer.AnyScope, authorizer.Admin) {
+		return
+	}
+
+	query := r.URL.Query()
+	filter := audit.Filter{
+		Action:    query.Get("action"),
+		Outcome:   query.Get("outcome"),
+		Principal: query.Get("principal"),
+		Scope:     query.Get("scope"),
+		Package:   query.Get("package"),
+	}
+	for name, bound := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if value := query.Get(name); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				writeErrorWithStatusCode("invalid "+name+" "+value+", expected RFC 3339 time", w, http.StatusBadRequest)
+				return
+			}
+			*bound = parsed
+		}
+	}
+	limit := defaultAuditLimit
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > maxAuditLimit {
+			writeErrorWithStatusCode(fmt.Sprintf("limit must be between 1 and %d", maxAuditLimit), w, http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	entries, err := c.auditLog.Query(filter, limit)
+	if err != nil {
+		slog.Error("Error reading audit log:", "error", err)
+		writeError("error reading audit log", w)
+		return
+	}
+	if entries == nil {
+		entries = []audit.Entry{}
+	}
+	w.Header().Set("Content-Type", mimetypes.ApplicationJson)
+	if err := json.NewEncoder(w).Encode(entries); err != nil {
+		slog.Error("Error encoding JSON:", "error", err)
+	}
+}
+
+// auditChecksum adds the checksum of the published source archive to the audit entry of the request, if audited
+func auditChecksum(ctx context.Context, checksum string) {
+	if entry := audit.FromContext(ctx); entry != nil {
+		entry.Checksum = checksum
+	}
+}
+
+// recordSubmission records the final outcome of an asynchronous publication, following its "accepted" entry
+func (c *Controller) recordSubmission(sub *submission, err *publishError) {
+	if c.auditLog == nil || sub.audit == nil {
+		return
+	}
+	entry := *sub.audit
+	if err != nil {
+		entry.Outcome = audit.OutcomeFailure
+		entry.Status = err.httpStatusCode
+		entry.Reason = err.errorMessage
+	} else {
+		entry.Outcome = audit.OutcomeSuccess
+		entry.Status = http.StatusCreated
+		sourceArchive := models.NewUploadElement(sub.scope, sub.packageName, sub.version, mimetypes.ApplicationZip, models.SourceArchive)
+		if checksum, err := c.repo.Checksum(sub.context(), sourceArchive); err == nil {
+			entry.Checksum = checksum
+		}
+	}
+	c.auditLog.Record(entry)
+}
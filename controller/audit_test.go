@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"OpenSPMRegistry/audit"
+	"OpenSPMRegistry/authorizer"
+	"OpenSPMRegistry/config"
+	"OpenSPMRegistry/models"
+	"OpenSPMRegistry/repo/files"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+// newAuditedController creates a controller over a files repo recording into a temporary audit log
+func newAuditedController(t *testing.T, cfg config.ServerConfig) (*Controller, *audit.Log) {
+	t.Helper()
+	auditLog, err := audit.Open(config.AuditConfig{Path: filepath.Join(t.TempDir(), "audit.log")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = auditLog.Close() })
+	c := NewController(cfg, files.NewFileRepo(t.TempDir()))
+	t.Cleanup(c.Close)
+	c.SetAuditLog(auditLog)
+	return c, auditLog
+}
+
+func Test_PublishAction_Audited_RecordsPrincipalAndChecksum(t *testing.T) {
+	c, auditLog := newAuditedController(t, config.ServerConfig{})
+	req := withPrincipal(createMultipartRequest(t, map[string][]byte{string(models.SourceArchive): testSourceArchive}), "alice")
+
+	auditLog.Handler(audit.ActionPublish, c.PublishAction)(httptest.NewRecorder(), req)
+
+	entries, _ := auditLog.Query(audit.Filter{}, 0)
+	if len(entries) != 1 {
+		t.Fatalf("expected one audit entry, got %+v", entries)
+	}
+	entry := entries[0]
+	if entry.Action != audit.ActionPublish || entry.Outcome != audit.OutcomeSuccess || entry.Principal != "alice" ||
+		entry.Scope != "scope" || entry.Package != "package" || entry.Version != "1.0.0" || len(entry.Checksum) != 64 {
+		t.Errorf("unexpected audit entry %+v", entry)
+	}
+}
+
+func Test_PublishAction_Audited_RecordsFailureReason(t *testing.T) {
+	c, auditLog := newAuditedController(t, config.ServerConfig{})
+	req := createMultipartRequest(t, map[string][]byte{string(models.SourceArchive): []byte("not a zip")})
+
+	auditLog.Handler(audit.ActionPublish, c.PublishAction)(httptest.NewRecorder(), req)
+
+	entries, _ := auditLog.Query(audit.Filter{}, 0)
+	if len(entries) != 1 || entries[0].Outcome != audit.OutcomeFailure || entries[0].Status != http.StatusUnprocessableEntity || entries[0].Reason == "" {
+		t.Errorf("expected failed publication with reason, got %+v", entries)
+	}
+}
+
+func Test_PublishAction_AuditedAsync_RecordsAcceptedAndOutcome(t *testing.T) {
+	c, auditLog := newAuditedController(t, config.ServerConfig{
+		Publish: config.PublishConfig{Async: config.AsyncPublishConfig{Enabled: true, TempDir: t.TempDir()}},
+	})
+	req := withPrincipal(createMultipartRequest(t, map[string][]byte{string(models.SourceArchive): testSourceArchive}), "alice")
+	req.Header.Set("Prefer", "respond-async")
+
+	auditLog.Handler(audit.ActionPublish, c.PublishAction)(httptest.NewRecorder(), req)
+	c.Close()
+
+	entries, _ := auditLog.Query(audit.Filter{Principal: "alice"}, 0)
+	if len(entries) != 2 || entries[1].Outcome != audit.OutcomeAccepted || entries[0].Outcome != audit.OutcomeSuccess ||
+		entries[0].Subject == "" || entries[0].Subject != entries[1].Subject || len(entries[0].Checksum) != 64 {
+		t.Errorf("expected accepted and published entries of the submission, got %+v", entries)
+	}
+}
+
+func Test_authorize_NotAudited_RecordsAuthorizationFailure(t *testing.T) {
+	c, auditLog := newAuditedController(t, config.ServerConfig{})
+	c.authorizer = authorizer.NewAuthorizer(config.AuthorizationConfig{Enabled: true})
+	req := withPrincipal(httptest.NewRequest("GET", "/acme/sdk", nil), "mallory")
+	req.SetPathValue("scope", "acme")
+	req.SetPathValue("package", "sdk")
+
+	if c.authorize(httptest.NewRecorder(), req, "acme", authorizer.Read) {
+		t.Fatal("expected request to be denied")
+	}
+
+	entries, _ := auditLog.Query(audit.Filter{Action: audit.ActionAuthorize}, 0)
+	if len(entries) != 1 || entries[0].Principal != "mallory" || entries[0].Scope != "acme" || entries[0].Outcome != audit.OutcomeDenied || entries[0].Reason == "" {
+		t.Errorf("expected denied authorization entry, got %+v", entries)
+	}
+}
+
+func Test_AuditLogAction_FiltersEntries(t *testing.T) {
+	c, auditLog := newAuditedController(t, config.ServerConfig{})
+	auditLog.Record(audit.Entry{Action: audit.ActionPublish, Outcome: audit.OutcomeSuccess, Principal: "alice"})
+	auditLog.Record(audit.Entry{Action: audit.ActionDelete, Outcome: audit.OutcomeSuccess, Principal: "alice"})
+
+	w := httptest.NewRecorder()
+	c.AuditLogAction(w, httptest.NewRequest("GET", "/audit?action=delete", nil))
+
+	var entries []audit.Entry
+	if err := json.NewDecoder(w.Body).Decode(&entries); err != nil || len(entries) != 1 || entries[0].Action != audit.ActionDelete {
+		t.Errorf("expected the delete entry, got %+v (%v)", entries, err)
+	}
+
+	w = httptest.NewRecorder()
+	c.AuditLogAction(w, httptest.NewRequest("GET", "/audit?since=yesterday", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status code %d, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	c.AuditLogAction(w, httptest.NewRequest("GET", "/audit?limit=4611686018427387905", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status code %d for a limit above the maximum, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func Test_AuditLogAction_Disabled_ReturnsNotFound(t *testing.T) {
+	c := NewController(config.ServerConfig{}, nil)
+	w := httptest.NewRecorder()
+
+	c.AuditLogAction(w, httptest.NewRequest("GET", "/audit", nil))
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status code %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
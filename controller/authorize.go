@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"OpenSPMRegistry/audit"
+	"OpenSPMRegistry/authorizer"
+	"OpenSPMRegistry/models"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// authorize checks that the principal of the request holds the permission on the scope.
+// If not, a 403 problem is written and false returned.
+func (c *Controller) authorize(w http.ResponseWriter, r *http.Request, scope string, permission authorizer.Permission) bool {
+	principal := authorizer.PrincipalFromContext(r.Context())
+	if c.authorizer.Allowed(principal, scope, permission) {
+		return true
+	}
+
+	name := "anonymous"
+	if principal != nil {
+		name = principal.Name
+	}
+	slog.Warn("Request denied", "principal", name, "scope", scope, "permission", permission)
+	msg := fmt.Sprintf("%s permission required on scope %s", permission, scope)
+	c.recordDenied(r, scope, msg)
+	writeErrorWithStatusCode(msg, w, http.StatusForbidden)
+	return false
+}
+
+// recordDenied adds the reason to the audit entry of the request,
+// or records a separate authorization failure if the request is not audited itself
+func (c *Controller) recordDenied(r *http.Request, scope string, reason string) {
+	if entry := audit.FromContext(r.Context()); entry != nil {
+		entry.Reason = reason
+		return
+	}
+	if c.auditLog == nil {
+		return
+	}
+	entry := c.auditLog.NewEntry(r, audit.ActionAuthorize)
+	entry.Scope = scope
+	entry.Outcome = audit.OutcomeDenied
+	entry.Status = http.StatusForbidden
+	entry.Reason = reason
+	c.auditLog.Record(entry)
+}
+
+// filterReadable returns the elements in scopes the principal of the request may read
+func (c *Controller) filterReadable(r *http.Request, elements []models.ListElement) []models.ListElement {
+	principal := authorizer.PrincipalFromContext(r.Context())
+	readable := make([]models.ListElement, 0, len(elements))
+	for _, element := range elements {
+		if c.authorizer.Allowed(principal, element.Scope, authorizer.Read) {
+			readable = append(readable, element)
+		}
+	}
+	return readable
+}
+
+// filterReadableIdentifiers returns the package identifiers (scope.name) the principal of the request may read
+// or nil if none of them is readable
+func (c *Controller) filterReadableIdentifiers(r *http.Request, identifiers []string) []string {
+	if len(identifiers) == 0 {
+		return identifiers
+	}
+	principal := authorizer.PrincipalFromContext(r.Context())
+	var readable []string
+	for _, identifier := range identifiers {
+		scope, _, _ := strings.Cut(identifier, ".")
+		if c.authorizer.Allowed(principal, scope, authorizer.Read) {
+			readable = append(readable, identifier)
+		}
+	}
+	return readable
+}
@@ -0,0 +1,229 @@
+package controller
+
+import (
+	"OpenSPMRegistry/authorizer"
+	"OpenSPMRegistry/config"
+	"OpenSPMRegistry/models"
+	"OpenSPMRegistry/responses"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+// newAuthorizingController returns a controller granting alice read on every scope and publish/delete on "acme"
+func newAuthorizingController(r *deleteTestRepo) *Controller {
+	c := newDeleteController(r)
+	c.authorizer = authorizer.NewAuthorizer(config.AuthorizationConfig{
+		Enabled: true,
+		Rules: []config.AuthorizationRule{
+			{Scopes: []string{"*"}, Users: []string{"alice"}, Permissions: []string{"read"}},
+			{Scopes: []string{"acme"}, Users: []string{"alice"}, Permissions: []string{"publish", "delete"}},
+		},
+	})
+	return c
+}
+
+func withPrincipal(req *http.Request, name string) *http.Request {
+	return req.WithContext(authorizer.WithPrincipal(req.Context(), &authorizer.Principal{Name: name}))
+}
+
+func assertForbiddenProblem(t *testing.T, w *httptest.ResponseRecorder) {
+	t.Helper()
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected status code %d, got %d", http.StatusForbidden, w.Code)
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != "application/problem+json" {
+		t.Errorf("expected problem+json, got %s", contentType)
+	}
+	var problem responses.Error
+	if err := json.NewDecoder(w.Body).Decode(&problem); err != nil || problem.Detail == "" {
+		t.Errorf("expected problem detail, got %v (%v)", problem, err)
+	}
+}
+
+func Test_NewController_AuthDisabled_NoAuthorizer(t *testing.T) {
+	c := NewController(config.ServerConfig{Auth: config.AuthConfig{
+		Enabled:       false,
+		Authorization: config.AuthorizationConfig{Enabled: true},
+	}}, nil)
+
+	if c.authorizer != nil {
+		t.Errorf("expected no authorizer when authentication is disabled")
+	}
+}
+
+func Test_PublishAction_NotAllowedScope_ReturnsForbidden(t *testing.T) {
+	mockRepo := newDeleteTestRepo()
+	c := newAuthorizingController(mockRepo)
+	req := withPrincipal(createMultipartRequest(t, map[string][]byte{
+		string(models.SourceArchive): []byte("archive"),
+	}), "alice")
+	w := httptest.NewRecorder()
+
+	c.PublishAction(w, req)
+
+	assertForbiddenProblem(t, w)
+	if len(mockRepo.storedFiles) != 0 {
+		t.Errorf("expected nothing to be stored, got %v", mockRepo.storedFiles)
+	}
+}
+
+func Test_PublishAction_Anonymous_ReturnsForbidden(t *testing.T) {
+	c := newAuthorizingController(newDeleteTestRepo())
+	req := createMultipartRequest(t, map[string][]byte{
+		string(models.SourceArchive): []byte("archive"),
+	})
+	w := httptest.NewRecorder()
+
+	c.PublishAction(w, req)
+
+	assertForbiddenProblem(t, w)
+}
+
+func Test_DeleteAction_NotAllowedScope_ReturnsForbidden(t *testing.T) {
+	mockRepo := newDeleteTestRepo("scope.package-1.0.0.zip")
+	c := newAuthorizingController(mockRepo)
+	w := httptest.NewRecorder()
+
+	c.DeleteAction(w, withPrincipal(newDeleteRequest(http.MethodDelete), "alice"))
+
+	assertForbiddenProblem(t, w)
+	if _, ok := mockRepo.storedFiles["scope.package-1.0.0.zip"]; !ok {
+		t.Errorf("expected release to be kept")
+	}
+}
+
+func Test_DeleteAction_AllowedScope_DeletesRelease(t *testing.T) {
+	mockRepo := newDeleteTestRepo("acme.package-1.0.0.zip")
+	c := newAuthorizingController(mockRepo)
+	req := withPrincipal(newDeleteRequest(http.MethodDelete), "alice")
+	req.SetPathValue("scope", "acme")
+	w := httptest.NewRecorder()
+
+	c.DeleteAction(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("expected status code %d, got %d: %s", http.StatusNoContent, w.Code, w.Body.String())
+	}
+}
+
+func Test_ListAction_UnknownUser_ReturnsForbidden(t *testing.T) {
+	mockRepo := newDeleteTestRepo()
+	mockRepo.versions = []string{"1.0.0"}
+	c := newAuthorizingController(mockRepo)
+	req := httptest.NewRequest(http.MethodGet, "/scope/package", nil)
+	req.Header.Set("Accept", "application/vnd.swift.registry.v1+json")
+	req.SetPathValue("scope", "scope")
+	req.SetPathValue("package", "package")
+	w := httptest.NewRecorder()
+
+	c.ListAction(w, withPrincipal(req, "mallory"))
+
+	assertForbiddenProblem(t, w)
+}
+
+func Test_InfoAction_UnknownUser_ReturnsForbidden(t *testing.T) {
+	c := newAuthorizingController(newDeleteTestRepo("scope.package-1.0.0.zip"))
+	w := httptest.NewRecorder()
+
+	c.InfoAction(w, withPrincipal(newDeleteRequest(http.MethodGet), "mallory"))
+
+	assertForbiddenProblem(t, w)
+}
+
+func Test_LookupAction_FiltersUnreadableScopes(t *testing.T) {
+	c := &Controller{
+		repo: &MockLookupRepo{identifiers: []string{"acme.kit", "other.kit"}},
+		authorizer: authorizer.NewAuthorizer(config.AuthorizationConfig{
+			Enabled: true,
+			Rules:   []config.AuthorizationRule{{Scopes: []string{"acme"}, Users: []string{"alice"}, Permissions: []string{"read"}}},
+		}),
+	}
+	newRequest := func(name string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/identifiers?url=https://example.com/kit", nil)
+		req.Header.Set("Accept", "application/vnd.swift.registry.v1+json")
+		return withPrincipal(req, name)
+	}
+
+	w := httptest.NewRecorder()
+	c.LookupAction(w, newRequest("alice"))
+
+	var response struct {
+		Identifiers []string `json:"identifiers"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !slices.Equal(response.Identifiers, []string{"acme.kit"}) {
+		t.Errorf("expected [acme.kit], got %v", response.Identifiers)
+	}
+
+	w = httptest.NewRecorder()
+	c.LookupAction(w, newRequest("mallory"))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status code %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func Test_GlobalCollectionAction_FiltersUnreadableScopes(t *testing.T) {
+	c := &Controller{
+		config: config.ServerConfig{
+			Hostname:           "example.com",
+			PackageCollections: config.PackageCollectionsConfig{Enabled: true},
+		},
+		repo: newCollectionTestRepo([]models.ListElement{
+			{Scope: "acme", PackageName: "pkg", Version: "1.0.0"},
+			{Scope: "other", PackageName: "pkg", Version: "1.0.0"},
+		}),
+		authorizer: authorizer.NewAuthorizer(config.AuthorizationConfig{
+			Enabled: true,
+			Rules:   []config.AuthorizationRule{{Scopes: []string{"acme"}, Users: []string{"alice"}, Permissions: []string{"read"}}},
+		}),
+	}
+	req := withPrincipal(httptest.NewRequest(http.MethodGet, "/collection", nil), "alice")
+	w := httptest.NewRecorder()
+
+	c.GlobalCollectionAction(w, req)
+
+	var coll models.PackageCollection
+	if err := json.NewDecoder(w.Body).Decode(&coll); err != nil {
+		t.Fatalf("failed to decode collection: %v", err)
+	}
+	if len(coll.Packages) != 1 || coll.Packages[0].URL != "acme.pkg" {
+		t.Errorf("expected only acme.pkg, got %+v", coll.Packages)
+	}
+}
+
+func Test_ScopeCollectionAction_NotReadable_ReturnsForbidden(t *testing.T) {
+	newController := func(publicRead bool) *Controller {
+		return &Controller{
+			config: config.ServerConfig{
+				Hostname:           "example.com",
+				PackageCollections: config.PackageCollectionsConfig{Enabled: true, PublicRead: publicRead},
+			},
+			repo: newCollectionTestRepo([]models.ListElement{{Scope: "other", PackageName: "pkg", Version: "1.0.0"}}),
+			authorizer: authorizer.NewAuthorizer(config.AuthorizationConfig{
+				Enabled: true,
+				Rules:   []config.AuthorizationRule{{Scopes: []string{"acme"}, Users: []string{"alice"}, Permissions: []string{"read"}}},
+			}),
+		}
+	}
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/collection/other", nil)
+		req.SetPathValue("scope", "other")
+		return req
+	}
+
+	w := httptest.NewRecorder()
+	newController(false).ScopeCollectionAction(w, withPrincipal(newRequest(), "alice"))
+	assertForbiddenProblem(t, w)
+
+	// public collections are not restricted
+	w = httptest.NewRecorder()
+	newController(true).ScopeCollectionAction(w, newRequest())
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+}
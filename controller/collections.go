@@ -1,8 +1,11 @@
 package controller
 
 import (
+	"OpenSPMRegistry/authorizer"
 	"OpenSPMRegistry/mimetypes"
+	"OpenSPMRegistry/models"
 	"OpenSPMRegistry/repo"
+	"OpenSPMRegistry/signing"
 	"encoding/json"
 	"log/slog"
 	"net/http"
@@ -27,6 +30,10 @@ func (c *Controller) GlobalCollectionAction(w http.ResponseWriter, r *http.Reque
 		writeErrorWithStatusCode("Error generating collection", w, http.StatusInternalServerError)
 		return
 	}
+	// public collections are served without authentication, so there is no principal to restrict
+	if !c.config.PackageCollections.PublicRead {
+		packages = c.filterReadable(r, packages)
+	}
 
 	// Generate collection
 	collection, err := repo.GenerateCollection(ctx, c.repo, "", packages, c.config.Hostname)
@@ -37,9 +44,9 @@ func (c *Controller) GlobalCollectionAction(w http.ResponseWriter, r *http.Reque
 	}
 
 	// Set headers
-	data, err := json.Marshal(collection)
+	data, err := c.encodeCollection(collection)
 	if err != nil {
-		slog.Error("Error marshaling collection JSON", "error", err)
+		slog.Error("Error encoding collection JSON", "error", err)
 		writeErrorWithStatusCode("Error generating collection", w, http.StatusInternalServerError)
 		return
 	}
@@ -72,6 +79,9 @@ func (c *Controller) ScopeCollectionAction(w http.ResponseWriter, r *http.Reques
 		writeErrorWithStatusCode("Scope is required", w, http.StatusBadRequest)
 		return
 	}
+	if !c.config.PackageCollections.PublicRead && !c.authorize(w, r, scope, authorizer.Read) {
+		return
+	}
 
 	// Get packages in scope
 	packages, err := c.repo.ListInScope(ctx, scope)
@@ -95,9 +105,9 @@ func (c *Controller) ScopeCollectionAction(w http.ResponseWriter, r *http.Reques
 	}
 
 	// Set headers
-	data, err := json.Marshal(collection)
+	data, err := c.encodeCollection(collection)
 	if err != nil {
-		slog.Error("Error marshaling collection JSON", "error", err)
+		slog.Error("Error encoding collection JSON", "error", err)
 		writeErrorWithStatusCode("Error generating collection", w, http.StatusInternalServerError)
 		return
 	}
@@ -112,3 +122,16 @@ func (c *Controller) ScopeCollectionAction(w http.ResponseWriter, r *http.Reques
 		slog.Error("Error writing collection JSON", "error", err)
 	}
 }
+
+// SetCollectionSigner enables signing of package collections
+func (c *Controller) SetCollectionSigner(signer *signing.CollectionSigner) {
+	c.collectionSigner = signer
+}
+
+// encodeCollection returns the JSON of the collection, signed if a signer is configured
+func (c *Controller) encodeCollection(collection *models.PackageCollection) ([]byte, error) {
+	if c.collectionSigner != nil {
+		return c.collectionSigner.Sign(collection)
+	}
+	return json.Marshal(collection)
+}
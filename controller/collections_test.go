@@ -2,16 +2,26 @@ package controller
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
+	"encoding/pem"
 	"errors"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"OpenSPMRegistry/config"
 	"OpenSPMRegistry/models"
 	"OpenSPMRegistry/responses"
+	"OpenSPMRegistry/signing"
 )
 
 type collectionTestRepo struct {
@@ -89,6 +99,69 @@ func Test_GlobalCollectionAction_ReturnsCollectionJSON(t *testing.T) {
 	}
 }
 
+func Test_GlobalCollectionAction_SignerConfigured_ReturnsSignedCollection(t *testing.T) {
+	c := &Controller{
+		config: config.ServerConfig{PackageCollections: config.PackageCollectionsConfig{Enabled: true}},
+		repo: newCollectionTestRepo([]models.ListElement{
+			{Scope: "scope", PackageName: "pkg", Version: "1.0.0"},
+		}),
+	}
+	c.SetCollectionSigner(newTestCollectionSigner(t))
+	w := httptest.NewRecorder()
+
+	c.GlobalCollectionAction(w, httptest.NewRequest("GET", "/collection", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var signed models.SignedPackageCollection
+	if err := json.NewDecoder(w.Body).Decode(&signed); err != nil {
+		t.Fatalf("failed to decode collection: %v", err)
+	}
+	if signed.Signature.Signature == "" || signed.Signature.Certificate.Subject.CommonName != "Registry" {
+		t.Errorf("expected signature block, got %+v", signed.Signature)
+	}
+	if len(signed.Packages) != 1 {
+		t.Errorf("expected exactly one package, got %d", len(signed.Packages))
+	}
+}
+
+// newTestCollectionSigner creates a signer with a self-signed EC certificate
+func newTestCollectionSigner(t *testing.T) *signing.CollectionSigner {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "Registry"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	cfg := config.CollectionSigningConfig{
+		Enabled:          true,
+		CertificateChain: filepath.Join(dir, "chain.pem"),
+		PrivateKey:       filepath.Join(dir, "key.pem"),
+	}
+	_ = os.WriteFile(cfg.CertificateChain, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}), 0600)
+	_ = os.WriteFile(cfg.PrivateKey, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	signer, err := signing.NewCollectionSigner(cfg)
+	if err != nil {
+		t.Fatalf("failed to create signer: %v", err)
+	}
+	return signer
+}
+
 func Test_ScopeCollectionAction_MissingScope_ReturnsBadRequest(t *testing.T) {
 	c := &Controller{
 		config: config.ServerConfig{
@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"OpenSPMRegistry/audit"
+	"OpenSPMRegistry/authorizer"
+	"OpenSPMRegistry/mimetypes"
+	"OpenSPMRegistry/models"
+	"OpenSPMRegistry/repo"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+)
+
+// DeleteAction removes a package release (archive, signatures, metadata, manifests and Package.json)
+// and leaves a tombstone so the version cannot be published again.
+// Listing the package reports the release as gone (410) afterwards.
+func (c *Controller) DeleteAction(w http.ResponseWriter, r *http.Request) {
+	printCallInfo("Delete", r)
+
+	if err := checkHeadersEnforce(r, "json"); err != nil {
+		err.writeResponse(w)
+		return // error already logged
+	}
+
+	scope := r.PathValue("scope")
+	packageName := r.PathValue("package")
+	version := r.PathValue("version")
+
+	if !c.authorize(w, r, scope, authorizer.Delete) {
+		return
+	}
+
+	ctx := requestContext(r)
+	sourceArchive := models.NewUploadElement(scope, packageName, version, mimetypes.ApplicationZip, models.SourceArchive)
+	deleted := isReleaseDeleted(ctx, c, scope, packageName, version)
+
+	if !c.repo.Exists(ctx, sourceArchive) {
+		if deleted {
+			writeErrorWithStatusCode(fmt.Sprintf("release %s.%s@%s was already deleted", scope, packageName, version), w, http.StatusGone)
+			return
+		}
+		writeErrorWithStatusCode(fmt.Sprintf("source archive %s does not exist", sourceArchive.FileName()), w, http.StatusNotFound)
+		return
+	}
+
+	checksum, err := c.repo.Checksum(ctx, sourceArchive)
+	if err != nil {
+		slog.Warn("Checksum of deleted release not available", "error", err)
+	}
+	if entry := audit.FromContext(ctx); entry != nil {
+		entry.Checksum = checksum
+	}
+
+	// write the tombstone first, so even a partially removed release can never be republished
+	if !deleted {
+		if err := repo.WriteTombstone(ctx, c.repo, sourceArchive, checksum, c.timeProvider.Now()); err != nil {
+			slog.Error("Error writing tombstone:", "error", err)
+			writeError("delete failed, error storing tombstone", w)
+			return
+		}
+	}
+
+	c.unindexRelease(ctx, scope, packageName, version)
+	if err := repo.RemoveRelease(ctx, c.repo, scope, packageName, version); err != nil {
+		slog.Error("Error removing release:", "error", err)
+		writeError(fmt.Sprintf("delete failed, release %s.%s@%s was only partially removed", scope, packageName, version), w)
+		return
+	}
+
+	slog.Info("Release deleted", "scope", scope, "package", packageName, "version", version)
+	c.releaseDeleted(scope, packageName, version)
+	w.Header().Set("Content-Version", "1")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// isReleaseDeleted checks whether a tombstone exists for the release
+func isReleaseDeleted(ctx context.Context, c *Controller, scope, packageName, version string) bool {
+	return repo.IsReleaseDeleted(ctx, c.repo, scope, packageName, version)
+}
+
+// availableReleases drops the deleted releases of elements, e.g. for links which must not point at them
+func availableReleases(ctx context.Context, c *Controller, elements []models.ListElement) []models.ListElement {
+	return slices.DeleteFunc(slices.Clone(elements), func(element models.ListElement) bool {
+		return isReleaseDeleted(ctx, c, element.Scope, element.PackageName, element.Version)
+	})
+}
@@ -0,0 +1,297 @@
+package controller
+
+import (
+	"OpenSPMRegistry/config"
+	"OpenSPMRegistry/mimetypes"
+	"OpenSPMRegistry/models"
+	"OpenSPMRegistry/repo"
+	"OpenSPMRegistry/repo/files"
+	"OpenSPMRegistry/search"
+	"OpenSPMRegistry/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// deleteTestRepo keeps files in memory like mockPublishRepo and supports removal
+type deleteTestRepo struct {
+	mockPublishRepo
+	removed   []string
+	removeErr error
+	versions  []string
+}
+
+func newDeleteTestRepo(files ...string) *deleteTestRepo {
+	r := &deleteTestRepo{}
+	r.storedFiles = make(map[string][]byte)
+	for _, file := range files {
+		r.storedFiles[file] = []byte(file)
+	}
+	return r
+}
+
+func newDeleteRequest(method string) *http.Request {
+	req := httptest.NewRequest(method, "/scope/package/1.0.0", nil)
+	req.Header.Set("Accept", "application/vnd.swift.registry.v1+json")
+	req.SetPathValue("scope", "scope")
+	req.SetPathValue("package", "package")
+	req.SetPathValue("version", "1.0.0")
+	return req
+}
+
+func newDeleteController(r *deleteTestRepo) *Controller {
+	return &Controller{
+		repo:         r,
+		config:       config.ServerConfig{Hostname: "localhost", Port: 8080},
+		timeProvider: utils.NewMockTimeProvider(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)),
+	}
+}
+
+func Test_DeleteAction_ExistingRelease_RemovesAllFilesAndWritesTombstone(t *testing.T) {
+	mockRepo := newDeleteTestRepo(
+		"scope.package-1.0.0.zip",
+		"scope.package-1.0.0.sig",
+		"metadata.json",
+		"metadata.sig",
+		"Package.swift",
+		"Package@swift-5.8.swift",
+		"Package.json",
+	)
+	c := newDeleteController(mockRepo)
+	w := httptest.NewRecorder()
+
+	c.DeleteAction(w, newDeleteRequest(http.MethodDelete))
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected status code %d, got %d: %s", http.StatusNoContent, w.Code, w.Body.String())
+	}
+	if len(mockRepo.storedFiles) != 1 {
+		t.Errorf("expected only the tombstone to remain, got %v", mockRepo.storedFiles)
+	}
+	var tombstone models.ReleaseTombstone
+	if err := json.Unmarshal(mockRepo.storedFiles["tombstone.json"], &tombstone); err != nil {
+		t.Fatalf("expected tombstone json, got %v", err)
+	}
+	if !tombstone.DeletedAt.Equal(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected deletion time %v", tombstone.DeletedAt)
+	}
+	if !slices.Contains(mockRepo.removed, "Package@swift-5.8.swift") {
+		t.Errorf("expected alternative manifest to be removed, got %v", mockRepo.removed)
+	}
+}
+
+func Test_DeleteAction_ExistingRelease_RemovesFromSearchIndex(t *testing.T) {
+	c := newDeleteController(newDeleteTestRepo("scope.package-1.0.0.zip"))
+	c.searchIndex = search.NewIndex()
+	c.searchIndex.Add(&search.Document{Scope: "scope", Name: "package", Version: "1.0.0"})
+	w := httptest.NewRecorder()
+
+	c.DeleteAction(w, newDeleteRequest(http.MethodDelete))
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected status code %d, got %d: %s", http.StatusNoContent, w.Code, w.Body.String())
+	}
+	if c.searchIndex.Len() != 0 {
+		t.Errorf("expected release to be removed from the search index")
+	}
+}
+
+func Test_DeleteAction_MissingRelease_ReturnsNotFound(t *testing.T) {
+	c := newDeleteController(newDeleteTestRepo())
+	w := httptest.NewRecorder()
+
+	c.DeleteAction(w, newDeleteRequest(http.MethodDelete))
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status code %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func Test_DeleteAction_AlreadyDeleted_ReturnsGone(t *testing.T) {
+	c := newDeleteController(newDeleteTestRepo("tombstone.json"))
+	w := httptest.NewRecorder()
+
+	c.DeleteAction(w, newDeleteRequest(http.MethodDelete))
+
+	if w.Code != http.StatusGone {
+		t.Errorf("expected status code %d, got %d", http.StatusGone, w.Code)
+	}
+}
+
+func Test_DeleteAction_RemoveFails_ReturnsErrorAndKeepsTombstone(t *testing.T) {
+	mockRepo := newDeleteTestRepo("scope.package-1.0.0.zip")
+	mockRepo.removeErr = errors.New("boom")
+	c := newDeleteController(mockRepo)
+	w := httptest.NewRecorder()
+
+	c.DeleteAction(w, newDeleteRequest(http.MethodDelete))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected status code %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+	if _, ok := mockRepo.storedFiles["tombstone.json"]; !ok {
+		t.Errorf("expected tombstone to be written before removal")
+	}
+
+	// retrying completes the removal
+	mockRepo.removeErr = nil
+	w = httptest.NewRecorder()
+	c.DeleteAction(w, newDeleteRequest(http.MethodDelete))
+	if w.Code != http.StatusNoContent {
+		t.Errorf("expected status code %d, got %d", http.StatusNoContent, w.Code)
+	}
+}
+
+func Test_DeleteAction_InvalidAcceptHeader_ReturnsError(t *testing.T) {
+	c := newDeleteController(newDeleteTestRepo())
+	req := newDeleteRequest(http.MethodDelete)
+	req.Header.Set("Accept", "application/vnd.swift.registry.v1+zip")
+	w := httptest.NewRecorder()
+
+	c.DeleteAction(w, req)
+
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("expected status code %d, got %d", http.StatusUnsupportedMediaType, w.Code)
+	}
+}
+
+func Test_PublishAction_DeletedRelease_ReturnsConflict(t *testing.T) {
+	mockRepo := newDeleteTestRepo("tombstone.json")
+	c := newDeleteController(mockRepo)
+	req := createMultipartRequest(t, map[string][]byte{
+		string(models.SourceArchive): []byte("other archive"),
+	})
+	w := httptest.NewRecorder()
+
+	c.PublishAction(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected status code %d, got %d", http.StatusConflict, w.Code)
+	}
+	if _, ok := mockRepo.storedFiles["scope.package-1.0.0.zip"]; ok {
+		t.Errorf("expected archive of deleted release to not be stored")
+	}
+}
+
+func Test_ListAction_DeletedRelease_ReportsProblem(t *testing.T) {
+	mockRepo := newDeleteTestRepo("tombstone.json")
+	mockRepo.versions = []string{"1.0.0"}
+	c := newDeleteController(mockRepo)
+	req := httptest.NewRequest(http.MethodGet, "/scope/package", nil)
+	req.Header.Set("Accept", "application/vnd.swift.registry.v1+json")
+	req.SetPathValue("scope", "scope")
+	req.SetPathValue("package", "package")
+	w := httptest.NewRecorder()
+
+	c.ListAction(w, req)
+
+	var response models.ListRelease
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	release := response.Releases["1.0.0"]
+	if release.Problem == nil || release.Problem.Status != http.StatusGone {
+		t.Errorf("expected problem with status 410, got %+v", release)
+	}
+}
+
+func Test_InfoAction_DeletedRelease_ReturnsGone(t *testing.T) {
+	c := newDeleteController(newDeleteTestRepo("tombstone.json"))
+	w := httptest.NewRecorder()
+
+	c.InfoAction(w, newDeleteRequest(http.MethodGet))
+
+	if w.Code != http.StatusGone {
+		t.Errorf("expected status code %d, got %d", http.StatusGone, w.Code)
+	}
+}
+
+func Test_DownloadSourceArchiveAction_DeletedRelease_ReturnsGone(t *testing.T) {
+	c := newDeleteController(newDeleteTestRepo("tombstone.json"))
+	req := newDeleteRequest(http.MethodGet)
+	req.Header.Set("Accept", "application/vnd.swift.registry.v1+zip")
+	req.SetPathValue("version", "1.0.0.zip")
+	w := httptest.NewRecorder()
+
+	c.DownloadSourceArchiveAction(w, req)
+
+	if w.Code != http.StatusGone {
+		t.Errorf("expected status code %d, got %d", http.StatusGone, w.Code)
+	}
+}
+
+func (r *deleteTestRepo) Remove(ctx context.Context, element *models.UploadElement) error {
+	if r.removeErr != nil {
+		return r.removeErr
+	}
+	r.removed = append(r.removed, element.FileName())
+	delete(r.storedFiles, element.FileName())
+	return nil
+}
+
+func (r *deleteTestRepo) GetAlternativeManifests(ctx context.Context, element *models.UploadElement) ([]models.UploadElement, error) {
+	var manifests []models.UploadElement
+	for filename := range r.storedFiles {
+		if strings.HasPrefix(filename, "Package@") {
+			manifest := models.NewUploadElement(element.Scope, element.Name, element.Version, mimetypes.TextXSwift, models.Manifest)
+			manifest.SetFilenameOverwrite(strings.TrimSuffix(filename, ".swift"))
+			manifests = append(manifests, *manifest)
+		}
+	}
+	return manifests, nil
+}
+
+func (r *deleteTestRepo) List(ctx context.Context, scope, packageName string) ([]models.ListElement, error) {
+	var elements []models.ListElement
+	for _, version := range r.versions {
+		elements = append(elements, *models.NewListElement(scope, packageName, version))
+	}
+	return elements, nil
+}
+
+func Test_ListAction_DeletedLatestRelease_LinksAvailableReleasesOnly(t *testing.T) {
+	r := files.NewFileRepo(t.TempDir())
+	ctx := context.Background()
+	for _, version := range []string{"1.0.0", "1.1.0", "2.0.0"} {
+		writer, err := r.GetWriter(ctx, models.NewUploadElement("scope", "package", version, mimetypes.ApplicationZip, models.SourceArchive))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = writer.Write(testSourceArchive)
+		_ = writer.Close()
+	}
+	deleted := models.NewUploadElement("scope", "package", "2.0.0", mimetypes.ApplicationZip, models.SourceArchive)
+	if err := repo.WriteTombstone(ctx, r, deleted, "", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.RemoveRelease(ctx, r, "scope", "package", "2.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	c := NewController(config.ServerConfig{Hostname: "localhost", Port: 8080}, r)
+	t.Cleanup(c.Close)
+
+	for _, path := range []string{"/scope/package", "/scope/package/1.1.0"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept", "application/vnd.swift.registry.v1+json")
+		req.SetPathValue("scope", "scope")
+		req.SetPathValue("package", "package")
+		w := httptest.NewRecorder()
+		if strings.HasSuffix(path, "1.1.0") {
+			req.SetPathValue("version", "1.1.0")
+			c.InfoAction(w, req)
+		} else {
+			c.ListAction(w, req)
+		}
+
+		link := w.Header().Get("Link")
+		if !strings.Contains(link, "/1.1.0>; rel=\"latest-version\"") || strings.Contains(link, "2.0.0") {
+			t.Errorf("%s: expected links to skip the deleted release, got %q", path, link)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"OpenSPMRegistry/authorizer"
 	"OpenSPMRegistry/mimetypes"
 	"OpenSPMRegistry/models"
 	"fmt"
@@ -23,10 +24,22 @@ func (c *Controller) DownloadSourceArchiveAction(w http.ResponseWriter, r *http.
 	versionRaw := r.PathValue("version")
 	version := strings.TrimSuffix(versionRaw, filepath.Ext(versionRaw))
 
+	if !c.authorize(w, r, scope, authorizer.Read) {
+		return
+	}
+
 	ctx := requestContext(r)
 	element := models.NewUploadElement(scope, packageName, version, mimetypes.ApplicationZip, models.SourceArchive)
 
+	if !c.mirrorMissingRelease(w, ctx, scope, packageName, version) {
+		return
+	}
+
 	if !c.repo.Exists(ctx, element) {
+		if isReleaseDeleted(ctx, c, scope, packageName, version) {
+			writeErrorWithStatusCode(fmt.Sprintf("release %s.%s@%s was removed from the registry", scope, packageName, version), w, http.StatusGone)
+			return
+		}
 		writeErrorWithStatusCode(fmt.Sprintf("source archive %s does not exist", element.FileName()), w, http.StatusNotFound)
 		return
 	}
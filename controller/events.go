@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"OpenSPMRegistry/audit"
+	"OpenSPMRegistry/authorizer"
+	"OpenSPMRegistry/events"
+	"OpenSPMRegistry/mimetypes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+)
+
+// defaultDeliveriesLimit is the number of webhook deliveries listed if no limit is requested
+const defaultDeliveriesLimit = 100
+
+// SetEventDispatcher enables webhook notifications of registry events
+func (c *Controller) SetEventDispatcher(dispatcher *events.Dispatcher) {
+	c.eventDispatcher = dispatcher
+}
+
+// WebhookDeliveriesAction lists the webhook delivery log, newest first (admin permission required).
+// Query parameters: status (pending, delivered, failed) and limit (default 100).
+func (c *Controller) WebhookDeliveriesAction(w http.ResponseWriter, r *http.Request) {
+	printCallInfo("WebhookDeliveries", r)
+
+	if c.eventDispatcher == nil {
+		writeErrorWithStatusCode("webhooks are not enabled", w, http.StatusNotFound)
+		return
+	}
+	if !c.authorize(w, r, authorizer.AnyScope, authorizer.Admin) {
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	if status != "" && !slices.Contains([]string{events.DeliveryPending, events.DeliveryDelivered, events.DeliveryFailed}, status) {
+		writeErrorWithStatusCode("invalid status "+status, w, http.StatusBadRequest)
+		return
+	}
+	limit := defaultDeliveriesLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			writeErrorWithStatusCode("invalid limit "+value, w, http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	deliveries, err := c.eventDispatcher.Deliveries(status, limit)
+	if err != nil {
+		slog.Error("Error reading webhook deliveries:", "error", err)
+		writeError("error reading webhook deliveries", w)
+		return
+	}
+	if deliveries == nil {
+		deliveries = []*events.Delivery{}
+	}
+	w.Header().Set("Content-Type", mimetypes.ApplicationJson)
+	if err := json.NewEncoder(w).Encode(deliveries); err != nil {
+		slog.Error("Error encoding JSON:", "error", err)
+	}
+}
+
+// emit notifies the webhooks of the event, if enabled
+func (c *Controller) emit(event events.Event) {
+	if c.eventDispatcher != nil {
+		c.eventDispatcher.Emit(event)
+	}
+}
+
+// releasePublished notifies a new release, which also changes the package collection of its scope
+func (c *Controller) releasePublished(scope string, packageName string, version string) {
+	c.emit(events.NewReleaseEvent(events.ReleasePublished, scope, packageName, version))
+	c.emit(events.NewCollectionChangedEvent(scope))
+}
+
+// releaseDeleted notifies a deleted release, which also changes the package collection of its scope
+func (c *Controller) releaseDeleted(scope string, packageName string, version string) {
+	c.emit(events.NewReleaseEvent(events.ReleaseDeleted, scope, packageName, version))
+	c.emit(events.NewCollectionChangedEvent(scope))
+}
+
+// publishFailed counts a failed publication of a release, notifies it and adds the reason to the audit entry of the request
+func (c *Controller) publishFailed(ctx context.Context, scope string, packageName string, version string, err *publishError) {
+	recordPublishFailure(err.reason)
+	if entry := audit.FromContext(ctx); entry != nil {
+		entry.Reason = err.errorMessage
+	}
+	c.emit(events.NewPublishFailedEvent(scope, packageName, version, err.reason, err.errorMessage))
+}
@@ -0,0 +1,120 @@
+package controller
+
+import (
+	"OpenSPMRegistry/config"
+	"OpenSPMRegistry/events"
+	"OpenSPMRegistry/models"
+	"OpenSPMRegistry/repo/files"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// newEventsController creates a controller over a files repo notifying a webhook that accepts everything
+func newEventsController(t *testing.T) *Controller {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(server.Close)
+	dispatcher, err := events.NewDispatcher(config.WebhooksConfig{
+		DeliveryLog: filepath.Join(t.TempDir(), "webhooks.db"),
+		Hooks:       []config.WebhookConfig{{Name: "ci", URL: server.URL}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = dispatcher.Close() })
+	c := NewController(config.ServerConfig{}, files.NewFileRepo(t.TempDir()))
+	c.SetEventDispatcher(dispatcher)
+	return c
+}
+
+// emittedEvents returns the types of the events recorded in the delivery log, oldest first
+func emittedEvents(t *testing.T, c *Controller) []string {
+	t.Helper()
+	deliveries, err := c.eventDispatcher.Deliveries("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var types []string
+	for _, delivery := range slices.Backward(deliveries) {
+		types = append(types, delivery.Event.Type)
+	}
+	return types
+}
+
+func Test_PublishAction_Success_EmitsReleasePublishedAndCollectionChanged(t *testing.T) {
+	c := newEventsController(t)
+	w := httptest.NewRecorder()
+
+	c.PublishAction(w, createMultipartRequest(t, map[string][]byte{string(models.SourceArchive): testSourceArchive}))
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status code %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	if types := emittedEvents(t, c); !slices.Equal(types, []string{events.ReleasePublished, events.CollectionChanged}) {
+		t.Errorf("expected release.published and collection.changed, got %v", types)
+	}
+}
+
+func Test_PublishAction_InvalidArchive_EmitsPublishFailed(t *testing.T) {
+	c := newEventsController(t)
+	w := httptest.NewRecorder()
+
+	c.PublishAction(w, createMultipartRequest(t, map[string][]byte{string(models.SourceArchive): []byte("not a zip")}))
+
+	deliveries, _ := c.eventDispatcher.Deliveries("", 0)
+	if len(deliveries) != 1 || deliveries[0].Event.Type != events.PublishFailed || deliveries[0].Event.Reason != publishFailureInvalidArchive {
+		t.Errorf("expected publish.failed with reason %s, got %+v", publishFailureInvalidArchive, deliveries)
+	}
+}
+
+func Test_DeleteAction_EmitsReleaseDeleted(t *testing.T) {
+	c := newEventsController(t)
+	c.PublishAction(httptest.NewRecorder(), createMultipartRequest(t, map[string][]byte{string(models.SourceArchive): testSourceArchive}))
+
+	req := httptest.NewRequest("DELETE", "/scope/package/1.0.0", nil)
+	req.Header.Set("Accept", "application/vnd.swift.registry.v1+json")
+	req.SetPathValue("scope", "scope")
+	req.SetPathValue("package", "package")
+	req.SetPathValue("version", "1.0.0")
+	w := httptest.NewRecorder()
+	c.DeleteAction(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected status code %d, got %d: %s", http.StatusNoContent, w.Code, w.Body.String())
+	}
+	types := emittedEvents(t, c)
+	if !slices.Equal(types[2:], []string{events.ReleaseDeleted, events.CollectionChanged}) {
+		t.Errorf("expected release.deleted and collection.changed, got %v", types)
+	}
+}
+
+func Test_WebhookDeliveriesAction_ListsDeliveries(t *testing.T) {
+	c := newEventsController(t)
+	c.PublishAction(httptest.NewRecorder(), createMultipartRequest(t, map[string][]byte{string(models.SourceArchive): testSourceArchive}))
+
+	// wait for the deliveries to finish
+	deadline := time.Now().Add(5 * time.Second)
+	for pending, _ := c.eventDispatcher.Deliveries(events.DeliveryPending, 0); len(pending) > 0 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+		pending, _ = c.eventDispatcher.Deliveries(events.DeliveryPending, 0)
+	}
+
+	w := httptest.NewRecorder()
+	c.WebhookDeliveriesAction(w, httptest.NewRequest("GET", "/webhooks/deliveries?status=delivered&limit=1", nil))
+
+	var deliveries []events.Delivery
+	if err := json.NewDecoder(w.Body).Decode(&deliveries); err != nil || len(deliveries) != 1 || deliveries[0].Webhook != "ci" {
+		t.Errorf("expected one delivered delivery, got %+v (%v)", deliveries, err)
+	}
+
+	w = httptest.NewRecorder()
+	c.WebhookDeliveriesAction(w, httptest.NewRequest("GET", "/webhooks/deliveries?status=unknown", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status code %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
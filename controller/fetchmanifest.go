@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"OpenSPMRegistry/authorizer"
 	"OpenSPMRegistry/mimetypes"
 	"OpenSPMRegistry/models"
 	"OpenSPMRegistry/utils"
@@ -11,6 +12,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 )
 
 func (c *Controller) FetchManifestAction(w http.ResponseWriter, r *http.Request) {
@@ -27,6 +29,10 @@ func (c *Controller) FetchManifestAction(w http.ResponseWriter, r *http.Request)
 	packageName := r.PathValue("package")
 	version := r.PathValue("version")
 
+	if !c.authorize(w, r, scope, authorizer.Read) {
+		return
+	}
+
 	element := models.NewUploadElement(scope, packageName, version, mimetypes.TextXSwift, models.Manifest)
 
 	swiftVersion := r.URL.Query().Get("swift-version")
@@ -37,6 +43,10 @@ func (c *Controller) FetchManifestAction(w http.ResponseWriter, r *http.Request)
 	ctx := requestContext(r)
 	filename := element.FileName()
 
+	if !c.mirrorMissingRelease(w, ctx, scope, packageName, version) {
+		return
+	}
+
 	// load manifest Package.swift file
 	reader, err := c.repo.GetReader(ctx, element)
 	if err != nil {
@@ -69,7 +79,8 @@ func (c *Controller) FetchManifestAction(w http.ResponseWriter, r *http.Request)
 	header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
 	header.Set("Cache-Control", "public, immutable")
 
-	modDate := c.timeProvider.Now()
+	// without publish date Last-Modified is left out (zero time)
+	var modDate time.Time
 	if rawDate, err := c.repo.PublishDate(ctx, element); err == nil {
 		modDate = rawDate
 	} else {
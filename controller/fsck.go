@@ -0,0 +1,45 @@
+package controller
+
+import (
+	"OpenSPMRegistry/authorizer"
+	"OpenSPMRegistry/fsck"
+	"OpenSPMRegistry/mimetypes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// fsckTimeout bounds a check run within a request, larger repositories are checked with the admin CLI
+const fsckTimeout = 5 * time.Minute
+
+// FsckAction checks the integrity of the repository and reports the issues found (admin permission required).
+// Issues are only reported, repairs are left to the admin CLI (admin fsck -repair).
+// The scope query parameter restricts the check to one scope.
+func (c *Controller) FsckAction(w http.ResponseWriter, r *http.Request) {
+	printCallInfo("Fsck", r)
+
+	if !c.authorize(w, r, authorizer.AnyScope, authorizer.Admin) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), fsckTimeout)
+	defer cancel()
+	report, err := fsck.Check(ctx, c.repo, fsck.Options{Scope: r.URL.Query().Get("scope")})
+	if errors.Is(err, context.DeadlineExceeded) {
+		writeErrorWithStatusCode("repository check timed out, use admin fsck", w, http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		slog.Error("Error checking repository:", "error", err)
+		writeError("error checking repository", w)
+		return
+	}
+
+	w.Header().Set("Content-Type", mimetypes.ApplicationJson)
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		slog.Error("Error encoding JSON:", "error", err)
+	}
+}
@@ -0,0 +1,58 @@
+package controller
+
+import (
+	"OpenSPMRegistry/authorizer"
+	"OpenSPMRegistry/config"
+	"OpenSPMRegistry/fsck"
+	"OpenSPMRegistry/mimetypes"
+	"OpenSPMRegistry/models"
+	"OpenSPMRegistry/repo/files"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_FsckAction_ReportsWithoutRepairing(t *testing.T) {
+	r := files.NewFileRepo(t.TempDir())
+	archive := models.NewUploadElement("scope", "package", "1.0.0", mimetypes.ApplicationZip, models.SourceArchive)
+	writer, _ := r.GetWriter(context.Background(), archive)
+	_, _ = writer.Write(testSourceArchive)
+	_ = writer.Close()
+	c := NewController(config.ServerConfig{}, r)
+	t.Cleanup(c.Close)
+
+	for _, test := range []struct {
+		method     string
+		unrepaired int
+	}{
+		{http.MethodGet, 1},
+		{http.MethodPost, 1},
+		{http.MethodGet, 1},
+	} {
+		w := httptest.NewRecorder()
+		c.FsckAction(w, httptest.NewRequest(test.method, "/fsck?scope=scope", nil))
+
+		var report fsck.Report
+		if err := json.NewDecoder(w.Body).Decode(&report); w.Code != http.StatusOK || err != nil {
+			t.Fatalf("%s: expected report, got %d (%v)", test.method, w.Code, err)
+		}
+		if report.Releases != 1 || report.Unrepaired() != test.unrepaired {
+			t.Errorf("%s: expected %d unrepaired issues, got %+v", test.method, test.unrepaired, report)
+		}
+	}
+}
+
+func Test_FsckAction_NotAdmin_ReturnsForbidden(t *testing.T) {
+	c := NewController(config.ServerConfig{}, files.NewFileRepo(t.TempDir()))
+	t.Cleanup(c.Close)
+	c.authorizer = authorizer.NewAuthorizer(config.AuthorizationConfig{Enabled: true})
+	w := httptest.NewRecorder()
+
+	c.FsckAction(w, withPrincipal(httptest.NewRequest(http.MethodGet, "/fsck", nil), "mallory"))
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected status code %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
@@ -1,9 +1,18 @@
 package controller
 
 import (
+	"OpenSPMRegistry/audit"
+	"OpenSPMRegistry/authorizer"
 	"OpenSPMRegistry/config"
+	"OpenSPMRegistry/events"
 	"OpenSPMRegistry/repo"
+	"OpenSPMRegistry/search"
+	"OpenSPMRegistry/signing"
+	"OpenSPMRegistry/tokens"
+	"OpenSPMRegistry/upstream"
 	"OpenSPMRegistry/utils"
+	"context"
+	"log/slog"
 	"net/http"
 )
 
@@ -11,14 +20,46 @@ type Controller struct {
 	config       config.ServerConfig
 	repo         repo.Repo
 	timeProvider utils.TimeProvider
+	publishQueue *publishQueue
+	authorizer   *authorizer.Authorizer
+	tokenStore   *tokens.Store
+	searchIndex  *search.Index
+	// collectionSigner signs package collections, nil serves them unsigned
+	collectionSigner *signing.CollectionSigner
+	// mirror fetches releases missing locally from the upstream registry, nil if no upstream is configured
+	mirror *releaseMirror
+	// archiveVerifier verifies source archive signatures on publication, nil stores them unverified
+	archiveVerifier *signing.ArchiveVerifier
+	// eventDispatcher notifies webhooks of registry events, nil if webhooks are disabled
+	eventDispatcher *events.Dispatcher
+	// auditLog records write and authentication operations, nil if auditing is disabled
+	auditLog *audit.Log
 }
 
 func NewController(config config.ServerConfig, repo repo.Repo) *Controller {
-	return &Controller{
+	c := &Controller{
 		config:       config,
 		repo:         repo,
 		timeProvider: utils.NewRealTimeProvider(),
 	}
+	if config.Auth.Enabled {
+		c.authorizer = authorizer.NewAuthorizer(config.Auth.Authorization)
+	}
+	if config.Publish.Async.Enabled {
+		c.publishQueue = newPublishQueue(config.Publish.Async, c.publishSubmission)
+	}
+	if config.Upstream.Enabled {
+		c.mirror = newReleaseMirror(upstream.NewClient(config.Upstream))
+	}
+	if config.Search.Enabled {
+		c.searchIndex = search.NewIndex()
+		go func() {
+			if err := c.searchIndex.Rebuild(context.Background(), repo); err != nil {
+				slog.Error("Error building search index:", "error", err)
+			}
+		}()
+	}
+	return c
 }
 
 func (c *Controller) MainAction(w http.ResponseWriter, r *http.Request) {
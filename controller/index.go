@@ -0,0 +1,33 @@
+package controller
+
+import (
+	"OpenSPMRegistry/repo"
+	"OpenSPMRegistry/search"
+	"context"
+	"log/slog"
+)
+
+// indexRelease adds a published release to the repository index (if the repository keeps one) and the search index.
+// Failures are only logged, the release is stored already and the index can be rebuilt.
+func (c *Controller) indexRelease(ctx context.Context, scope string, name string, version string) {
+	if indexer, ok := c.repo.(repo.Indexer); ok {
+		if err := indexer.IndexRelease(ctx, scope, name, version); err != nil {
+			slog.Error("Error indexing release:", "scope", scope, "package", name, "version", version, "error", err)
+		}
+	}
+	if c.searchIndex != nil {
+		c.searchIndex.Add(search.NewDocument(ctx, c.repo, scope, name, version))
+	}
+}
+
+// unindexRelease records a deleted release in the repository index and removes it from the search index
+func (c *Controller) unindexRelease(ctx context.Context, scope string, name string, version string) {
+	if indexer, ok := c.repo.(repo.Indexer); ok {
+		if err := indexer.UnindexRelease(ctx, scope, name, version); err != nil {
+			slog.Error("Error unindexing release:", "scope", scope, "package", name, "version", version, "error", err)
+		}
+	}
+	if c.searchIndex != nil {
+		c.searchIndex.Remove(scope, name, version)
+	}
+}
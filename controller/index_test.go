@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+// indexedTestRepo records the releases the controller indexes and unindexes
+type indexedTestRepo struct {
+	*deleteTestRepo
+	indexed   []string
+	unindexed []string
+	err       error
+}
+
+func (r *indexedTestRepo) IndexRelease(_ context.Context, scope string, name string, version string) error {
+	r.indexed = append(r.indexed, scope+"."+name+"@"+version)
+	return r.err
+}
+
+func (r *indexedTestRepo) UnindexRelease(_ context.Context, scope string, name string, version string) error {
+	r.unindexed = append(r.unindexed, scope+"."+name+"@"+version)
+	return r.err
+}
+
+func Test_IndexRelease_IndexFails_OnlyLogs(t *testing.T) {
+	r := &indexedTestRepo{deleteTestRepo: newDeleteTestRepo(), err: errors.New("index unavailable")}
+	c := &Controller{repo: r}
+
+	c.indexRelease(context.Background(), "scope", "package", "1.0.0")
+
+	if !slices.Equal(r.indexed, []string{"scope.package@1.0.0"}) {
+		t.Errorf("expected release to be indexed, got %v", r.indexed)
+	}
+}
+
+func Test_DeleteAction_RepositoryWithIndex_UnindexesRelease(t *testing.T) {
+	r := &indexedTestRepo{deleteTestRepo: newDeleteTestRepo("scope.package-1.0.0.zip")}
+	c := newDeleteController(r.deleteTestRepo)
+	c.repo = r
+	w := httptest.NewRecorder()
+
+	c.DeleteAction(w, newDeleteRequest(http.MethodDelete))
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected status code %d, got %d: %s", http.StatusNoContent, w.Code, w.Body.String())
+	}
+	if !slices.Equal(r.unindexed, []string{"scope.package@1.0.0"}) {
+		t.Errorf("expected release to be unindexed, got %v", r.unindexed)
+	}
+}
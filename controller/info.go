@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"OpenSPMRegistry/authorizer"
 	"OpenSPMRegistry/mimetypes"
 	"OpenSPMRegistry/models"
 	"OpenSPMRegistry/utils"
@@ -24,10 +25,22 @@ func (c *Controller) InfoAction(w http.ResponseWriter, r *http.Request) {
 	packageName := r.PathValue("package")
 	version := utils.StripExtension(r.PathValue("version"), ".json")
 
+	if !c.authorize(w, r, scope, authorizer.Read) {
+		return
+	}
+
 	ctx := requestContext(r)
 	sourceArchive := models.NewUploadElement(scope, packageName, version, mimetypes.ApplicationZip, models.SourceArchive)
 
+	if !c.mirrorMissingRelease(w, ctx, scope, packageName, version) {
+		return
+	}
+
 	if !c.repo.Exists(ctx, sourceArchive) {
+		if isReleaseDeleted(ctx, c, scope, packageName, version) {
+			writeErrorWithStatusCode(fmt.Sprintf("release %s.%s@%s was removed from the registry", scope, packageName, version), w, http.StatusGone)
+			return
+		}
 		writeErrorWithStatusCode(fmt.Sprintf("source archive %s does not exist", sourceArchive.FileName()), w, http.StatusNotFound)
 		return
 	}
@@ -40,7 +53,7 @@ func (c *Controller) InfoAction(w http.ResponseWriter, r *http.Request) {
 		return // error already logged
 	}
 
-	addLinkHeaders(elements, version, c, header)
+	addLinkHeaders(availableReleases(ctx, c, elements), version, c, header)
 
 	metadataResult, err := c.repo.LoadMetadata(ctx, scope, packageName, version)
 	if err != nil && slog.Default().Enabled(context.TODO(), slog.LevelDebug) {
@@ -67,13 +80,12 @@ func (c *Controller) InfoAction(w http.ResponseWriter, r *http.Request) {
 		signatureJson = nil
 	}
 
-	// retrieve publish date from source archive
+	// retrieve publish date from source archive, it is optional and left out
+	// if unknown rather than reporting a date changing with every request
 	dateTime, dateErr := c.repo.PublishDate(ctx, sourceArchive)
 	if dateErr != nil {
-		slog.Debug("Publish Date error:", "err", dateErr)
-		dateTime = c.timeProvider.Now()
+		slog.Warn("Publish Date error:", "err", dateErr)
 	}
-	dateString := dateTime.Format("2006-01-02T15:04:05Z")
 
 	// retrieve checksum of source archive
 	checksum, err := c.repo.Checksum(ctx, sourceArchive)
@@ -93,8 +105,10 @@ func (c *Controller) InfoAction(w http.ResponseWriter, r *http.Request) {
 				"signing":  signatureJson,
 			},
 		},
-		"metadata":    metadataResult,
-		"publishedAt": dateString,
+		"metadata": metadataResult,
+	}
+	if dateErr == nil {
+		result["publishedAt"] = dateTime.UTC().Format("2006-01-02T15:04:05Z")
 	}
 
 	header.Set("Content-Version", "1")
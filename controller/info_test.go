@@ -341,60 +341,34 @@ func Test_InfoAction_ChecksumError_LogsAndContinues(t *testing.T) {
 	}
 }
 
-func Test_InfoAction_PublishDateError_UsesCurrentTime(t *testing.T) {
-	// Create a fixed time for testing
-	fixedTime := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
-	mockTimeProvider := utils.NewMockTimeProvider(fixedTime)
-
-	// Setup controller with mock time provider
+func Test_InfoAction_PublishDateError_OmitsPublishedAt(t *testing.T) {
 	ctrl := &Controller{
 		repo: &MockInfoRepo{
 			exists:         true,
 			publishDateErr: fmt.Errorf("publish date error"),
 		},
-		timeProvider: mockTimeProvider,
+		timeProvider: utils.NewMockTimeProvider(time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)),
 	}
 
-	// Setup request
 	req := httptest.NewRequest(http.MethodGet, "/scope/package/1.0.0.json", nil)
 	req.Header.Set("Accept", "application/vnd.swift.registry.v1+json")
 	req.SetPathValue("scope", "scope")
 	req.SetPathValue("package", "package")
 	req.SetPathValue("version", "1.0.0.json")
-
-	// Setup response recorder
 	w := httptest.NewRecorder()
 
-	// Call the handler
 	ctrl.InfoAction(w, req)
 
-	// Check response
-	resp := w.Result()
-	defer func() { _ = resp.Body.Close() }()
-
-	if resp.StatusCode != http.StatusOK {
-		t.Errorf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status code %d, got %d", http.StatusOK, w.Code)
 	}
-
 	var result map[string]any
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
 		t.Fatalf("failed to decode response body: %v", err)
 	}
-
-	publishedAt, ok := result["publishedAt"].(string)
-	if !ok {
-		t.Fatal("expected publishedAt to be a string")
-	}
-
-	// Parse the publishedAt time
-	publishTime, err := time.Parse(time.RFC3339, publishedAt)
-	if err != nil {
-		t.Fatalf("failed to parse publishedAt time: %v", err)
-	}
-
-	// Compare with fixed time
-	if !publishTime.Equal(fixedTime.UTC()) {
-		t.Errorf("expected publishedAt %v, got %v", fixedTime.UTC(), publishTime)
+	// the current time would change with every request
+	if publishedAt, ok := result["publishedAt"]; ok {
+		t.Errorf("expected publishedAt to be omitted, got %v", publishedAt)
 	}
 }
 
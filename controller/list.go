@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"OpenSPMRegistry/authorizer"
 	"OpenSPMRegistry/mimetypes"
 	"OpenSPMRegistry/models"
 	"OpenSPMRegistry/utils"
@@ -25,15 +26,20 @@ func (c *Controller) ListAction(w http.ResponseWriter, r *http.Request) {
 	scope := r.PathValue("scope")
 	packageName := utils.StripExtension(r.PathValue("package"), ".json")
 
+	if !c.authorize(w, r, scope, authorizer.Read) {
+		return
+	}
+
 	elements, err := listElements(r, w, c, scope, packageName)
 	if err != nil {
 		return // error already logged
 	}
 
 	header := w.Header()
+	ctx := requestContext(r)
 
 	// latest-version link (spec 4.1) - add first so pagination can append
-	addLinkHeaders(elements, "", c, header)
+	addLinkHeaders(availableReleases(ctx, c, elements), "", c, header)
 
 	// Pagination: ?page=N (optional per Swift Registry spec 4.1 example)
 	page, perPage := parseListPagination(r, c.config.ListPageSize)
@@ -48,7 +54,12 @@ func (c *Controller) ListAction(w http.ResponseWriter, r *http.Request) {
 	releaseList := make(map[string]models.Release)
 	for _, element := range toRender {
 		location := locationOfElement(c, element)
-		releaseList[element.Version] = *models.NewRelease(location)
+		// spec 4.1: unavailable releases are listed with a problem
+		if isReleaseDeleted(ctx, c, scope, packageName, element.Version) {
+			releaseList[element.Version] = *models.NewDeletedRelease(location)
+		} else {
+			releaseList[element.Version] = *models.NewRelease(location)
+		}
 	}
 
 	header.Set("Content-Version", "1")
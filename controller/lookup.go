@@ -20,7 +20,7 @@ func (c *Controller) LookupAction(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	identifiers := c.repo.Lookup(ctx, url)
+	identifiers := c.filterReadableIdentifiers(r, c.repo.Lookup(ctx, url))
 
 	if identifiers == nil {
 		writeErrorWithStatusCode(fmt.Sprintf("%s not found", url), w, http.StatusNotFound)
@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"OpenSPMRegistry/mimetypes"
+	"OpenSPMRegistry/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// validateMetadata checks the metadata part against the release metadata schema (see models.ValidateMetadata).
+// Returns the metadata to store, or a 422 publish error listing every field error. content is closed in any case.
+func validateMetadata(element *models.UploadElement, content io.ReadCloser) (io.ReadCloser, *publishError) {
+	data, err := io.ReadAll(content)
+	_ = content.Close()
+	if err != nil {
+		slog.Error("Error reading metadata:", "error", err)
+		return nil, newPublishError(publishFailureStorage, "upload failed, error storing file", http.StatusInternalServerError)
+	}
+
+	if failures := models.ValidateMetadata(data); len(failures) > 0 {
+		msg := fmt.Sprintf("upload failed, metadata of %s.%s@%s is invalid", element.Scope, element.Name, element.Version)
+		slog.Error("Error", "msg", msg, "failures", failures)
+		pubErr := newPublishError(publishFailureInvalidMetadata, msg, http.StatusUnprocessableEntity)
+		pubErr.failures = failures
+		return nil, pubErr
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// enrichMetadata fills in missing metadata fields of a stored release if enabled, creating the metadata if none was uploaded.
+// Releases with a metadata signature are not modified. On failure all stored elements are removed again.
+// returns the metadata element if it was created, to be removed along with the stored elements
+func enrichMetadata(ctx context.Context, c *Controller, storedElements []*models.UploadElement, scope, packageName, version string) (*models.UploadElement, *publishError) {
+	if !c.config.Publish.Metadata.Enrich {
+		return nil, nil
+	}
+	signatureElement := models.NewUploadElement(scope, packageName, version, mimetypes.ApplicationOctetStream, models.MetadataSignature)
+	if c.repo.Exists(ctx, signatureElement) {
+		slog.Debug("Metadata is signed, skipping enrichment", "scope", scope, "package", packageName, "version", version)
+		return nil, nil
+	}
+
+	metadataElement := models.NewUploadElement(scope, packageName, version, mimetypes.ApplicationJson, models.Metadata)
+	metadata := map[string]any{}
+	uploaded := c.repo.Exists(ctx, metadataElement)
+	if uploaded {
+		loaded, err := c.repo.LoadMetadata(ctx, scope, packageName, version)
+		if err != nil {
+			slog.Error("Error loading metadata:", "error", err)
+			cleanupStoredElements(ctx, c, storedElements, scope, packageName, version)
+			return nil, newPublishError(publishFailureStorage, "upload failed, error storing file", http.StatusInternalServerError)
+		}
+		metadata = loaded
+	}
+	if _, ok := metadata[models.OriginalPublicationTime]; ok {
+		return nil, nil
+	}
+	metadata[models.OriginalPublicationTime] = c.timeProvider.Now().UTC().Format(time.RFC3339)
+
+	if err := writeMetadata(ctx, c, metadataElement, metadata); err != nil {
+		slog.Error("Error storing enriched metadata:", "error", err)
+		cleanupStoredElements(ctx, c, append(storedElements, metadataElement), scope, packageName, version)
+		return nil, newPublishError(publishFailureStorage, "upload failed, error storing file", http.StatusInternalServerError)
+	}
+	if uploaded {
+		return nil, nil
+	}
+	return metadataElement, nil
+}
+
+func writeMetadata(ctx context.Context, c *Controller, element *models.UploadElement, metadata map[string]any) error {
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	writer, err := c.repo.GetWriter(ctx, element)
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(data); err != nil {
+		_ = writer.Close()
+		return err
+	}
+	return writer.Close()
+}
@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"OpenSPMRegistry/config"
+	"OpenSPMRegistry/mimetypes"
+	"OpenSPMRegistry/models"
+	"OpenSPMRegistry/repo/files"
+	"OpenSPMRegistry/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordErrorRepo is a files repo failing to write publication records
+type recordErrorRepo struct {
+	*files.FileRepo
+}
+
+func (r *recordErrorRepo) GetWriter(ctx context.Context, element *models.UploadElement) (io.WriteCloser, error) {
+	if element.FileName() == "publication.json" {
+		return nil, errors.New("write failed")
+	}
+	return r.FileRepo.GetWriter(ctx, element)
+}
+
+func Test_PublishAction_InvalidMetadata_ReturnsUnprocessableEntityWithFieldErrors(t *testing.T) {
+	mockRepo := &mockPublishRepo{}
+	ctrl := &Controller{repo: mockRepo, timeProvider: utils.NewRealTimeProvider()}
+	req := createMultipartRequest(t, map[string][]byte{
+		string(models.SourceArchive): testSourceArchive,
+		string(models.Metadata):      []byte(`{"licenseURL":"license","author":{}}`),
+	})
+	w := httptest.NewRecorder()
+
+	ctrl.PublishAction(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status code %d, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+	var problem struct {
+		Errors []string `json:"errors"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&problem); err != nil || len(problem.Errors) != 2 ||
+		!strings.HasPrefix(problem.Errors[0], "author.name") || !strings.HasPrefix(problem.Errors[1], "licenseURL") {
+		t.Errorf("expected problem listing the field errors, got %+v (%v)", problem, err)
+	}
+	if _, ok := mockRepo.storedFiles["metadata.json"]; ok {
+		t.Errorf("expected invalid metadata not to be stored")
+	}
+}
+
+func Test_PublishAction_MetadataEnrichment_AddsOriginalPublicationTime(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		parts    map[string][]byte
+		enriched bool
+	}{
+		{"metadata", map[string][]byte{string(models.Metadata): []byte(`{"description":"networking"}`)}, true},
+		{"no metadata", map[string][]byte{}, true},
+		{"signed metadata", map[string][]byte{
+			string(models.Metadata):          []byte(`{"description":"networking"}`),
+			string(models.MetadataSignature): []byte("metadata signature"),
+		}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := files.NewFileRepo(t.TempDir())
+			ctrl := NewController(config.ServerConfig{Publish: config.PublishConfig{Metadata: config.MetadataConfig{Enrich: true}}}, r)
+			ctrl.timeProvider = utils.NewMockTimeProvider(time.Date(2024, 3, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600)))
+			tc.parts[string(models.SourceArchive)] = testSourceArchive
+			w := httptest.NewRecorder()
+
+			ctrl.PublishAction(w, createMultipartRequest(t, tc.parts))
+
+			if w.Code != http.StatusCreated {
+				t.Fatalf("expected status code %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+			}
+			metadata, _ := r.LoadMetadata(context.Background(), "scope", "package", "1.0.0")
+			publicationTime, ok := metadata[models.OriginalPublicationTime].(string)
+			if ok != tc.enriched {
+				t.Fatalf("expected enriched %t, got metadata %v", tc.enriched, metadata)
+			}
+			if ok && publicationTime != "2024-03-01T11:00:00Z" {
+				t.Errorf("expected ISO 8601 publication time in UTC, got %q", publicationTime)
+			}
+		})
+	}
+}
+
+func Test_PublishAction_MetadataEnrichment_FailedPublication_RemovesCreatedMetadata(t *testing.T) {
+	r := &recordErrorRepo{FileRepo: files.NewFileRepo(t.TempDir())}
+	ctrl := NewController(config.ServerConfig{Publish: config.PublishConfig{Metadata: config.MetadataConfig{Enrich: true}}}, r)
+	w := httptest.NewRecorder()
+
+	ctrl.PublishAction(w, createMultipartRequest(t, map[string][]byte{string(models.SourceArchive): testSourceArchive}))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected status code %d, got %d: %s", http.StatusInternalServerError, w.Code, w.Body.String())
+	}
+	if r.Exists(context.Background(), models.NewUploadElement("scope", "package", "1.0.0", mimetypes.ApplicationJson, models.Metadata)) {
+		t.Errorf("expected created metadata to be removed")
+	}
+}
@@ -0,0 +1,215 @@
+package controller
+
+import (
+	"OpenSPMRegistry/mimetypes"
+	"OpenSPMRegistry/models"
+	"OpenSPMRegistry/upstream"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// releaseMirror fetches releases missing locally from the upstream registry (pull-through proxy)
+type releaseMirror struct {
+	client *upstream.Client
+
+	mu sync.Mutex
+	// inflight holds the running mirror calls per release, concurrent requests for the same release wait for it
+	inflight map[string]*mirrorCall
+}
+
+type mirrorCall struct {
+	done chan struct{}
+	err  error
+}
+
+// mirroredElement is a release element received with the upstream release information
+type mirroredElement struct {
+	element    *models.UploadElement
+	uploadType models.UploadElementType
+	content    []byte
+}
+
+// errChecksumMismatch is returned when a mirrored source archive does not match the upstream checksum
+var errChecksumMismatch = errors.New("checksum mismatch")
+
+func newReleaseMirror(client *upstream.Client) *releaseMirror {
+	return &releaseMirror{client: client, inflight: make(map[string]*mirrorCall)}
+}
+
+// mirrorMissingRelease fetches the release from the upstream registry if it is missing locally and was not deleted.
+// Returns false if the upstream registry failed and an error response was written,
+// if the upstream does not have the release either the caller answers as without upstream.
+func (c *Controller) mirrorMissingRelease(w http.ResponseWriter, ctx context.Context, scope string, name string, version string) bool {
+	if c.mirror == nil {
+		return true
+	}
+	sourceArchive := models.NewUploadElement(scope, name, version, mimetypes.ApplicationZip, models.SourceArchive)
+	if c.repo.Exists(ctx, sourceArchive) || isReleaseDeleted(ctx, c, scope, name, version) {
+		return true
+	}
+
+	err := c.mirror.do(releaseId(scope, name, version), func() error {
+		// another request may have mirrored it in the meantime
+		if c.repo.Exists(ctx, sourceArchive) {
+			return nil
+		}
+		return mirrorRelease(ctx, c, scope, name, version)
+	})
+	switch {
+	case err == nil, errors.Is(err, upstream.ErrNotFound):
+		return true
+	case errors.Is(err, errChecksumMismatch):
+		slog.Error("Mirrored release rejected:", "error", err)
+		writeErrorWithStatusCode(fmt.Sprintf("release %s.%s@%s from upstream registry failed checksum verification", scope, name, version), w, http.StatusBadGateway)
+	default:
+		slog.Error("Error mirroring release:", "scope", scope, "package", name, "version", version, "error", err)
+		writeErrorWithStatusCode(fmt.Sprintf("release %s.%s@%s not available locally and upstream registry failed", scope, name, version), w, http.StatusBadGateway)
+	}
+	return false
+}
+
+// upstreamVersions adds the releases only the upstream registry has to the local ones.
+// Upstream failures are only logged, the local releases are listed then.
+func (c *Controller) upstreamVersions(ctx context.Context, scope string, name string, elements []models.ListElement) []models.ListElement {
+	if c.mirror == nil {
+		return elements
+	}
+	versions, err := c.mirror.client.ListReleases(ctx, scope, name)
+	if err != nil {
+		if !errors.Is(err, upstream.ErrNotFound) {
+			slog.Warn("Listing upstream releases failed:", "scope", scope, "package", name, "error", err)
+		}
+		return elements
+	}
+	local := make(map[string]bool, len(elements))
+	for _, element := range elements {
+		local[element.Version] = true
+	}
+	for _, version := range versions {
+		if !local[version] {
+			elements = append(elements, *models.NewListElement(scope, name, version))
+		}
+	}
+	return elements
+}
+
+// do runs mirror once per release at a time, concurrent callers get the result of the running call
+func (m *releaseMirror) do(key string, mirror func() error) error {
+	m.mu.Lock()
+	if call, ok := m.inflight[key]; ok {
+		m.mu.Unlock()
+		<-call.done
+		return call.err
+	}
+	call := &mirrorCall{done: make(chan struct{})}
+	m.inflight[key] = call
+	m.mu.Unlock()
+
+	call.err = mirror()
+
+	m.mu.Lock()
+	delete(m.inflight, key)
+	m.mu.Unlock()
+	close(call.done)
+	return call.err
+}
+
+// mirrorRelease downloads the source archive, signature and metadata of a release from the upstream registry
+// and stores them like a publication. Nothing is kept if the archive does not match the upstream checksum.
+func mirrorRelease(ctx context.Context, c *Controller, scope string, name string, version string) error {
+	release, err := c.mirror.client.FetchRelease(ctx, scope, name, version)
+	if err != nil {
+		return err
+	}
+	resource := release.SourceArchive()
+	if resource == nil || resource.Checksum == "" {
+		return fmt.Errorf("%w: release %s.%s@%s has no source archive checksum", upstream.ErrNotFound, scope, name, version)
+	}
+
+	archive, err := c.mirror.client.DownloadSourceArchive(ctx, scope, name, version)
+	if err != nil {
+		return err
+	}
+	var stored []*models.UploadElement
+	element := models.NewUploadElement(scope, name, version, mimetypes.ApplicationZip, models.SourceArchive)
+	storedElement, checksum, pubErr := storeElement(ctx, c, element, models.SourceArchive, archive)
+	if storedElement != nil {
+		stored = append(stored, storedElement)
+	}
+	if pubErr != nil {
+		cleanupStoredElements(ctx, c, stored, scope, name, version)
+		return pubErr
+	}
+	if !strings.EqualFold(checksum, resource.Checksum) {
+		cleanupStoredElements(ctx, c, stored, scope, name, version)
+		return fmt.Errorf("%w: %s.%s@%s expected %s, got %s", errChecksumMismatch, scope, name, version, resource.Checksum, checksum)
+	}
+
+	var extras []mirroredElement
+	if resource.Signing != nil && resource.Signing.SignatureBase64Encoded != "" {
+		signature, err := base64.StdEncoding.DecodeString(resource.Signing.SignatureBase64Encoded)
+		if err != nil {
+			slog.Warn("Ignoring invalid upstream signature", "scope", scope, "package", name, "version", version, "error", err)
+		} else {
+			extras = append(extras, mirroredElement{
+				element:    models.NewUploadElement(scope, name, version, mimetypes.ApplicationOctetStream, models.SourceArchiveSignature),
+				uploadType: models.SourceArchiveSignature,
+				content:    signature,
+			})
+		}
+	}
+	if len(release.Metadata) > 0 {
+		metadata, err := json.Marshal(release.Metadata)
+		if err != nil {
+			cleanupStoredElements(ctx, c, stored, scope, name, version)
+			return err
+		}
+		extras = append(extras, mirroredElement{
+			element:    models.NewUploadElement(scope, name, version, mimetypes.ApplicationJson, models.Metadata),
+			uploadType: models.Metadata,
+			content:    metadata,
+		})
+	}
+	for _, extra := range extras {
+		storedElement, _, pubErr := storeElement(ctx, c, extra.element, extra.uploadType, io.NopCloser(bytes.NewReader(extra.content)))
+		if storedElement != nil {
+			stored = append(stored, storedElement)
+		}
+		if pubErr != nil {
+			cleanupStoredElements(ctx, c, stored, scope, name, version)
+			return pubErr
+		}
+	}
+
+	signer, pubErr := checkSignature(ctx, c, stored, scope, name, version)
+	if pubErr != nil {
+		return pubErr
+	}
+	// keep the publish date of the upstream registry if it reports one
+	publishedAt, err := time.Parse(time.RFC3339, release.PublishedAt)
+	if err != nil {
+		publishedAt = c.timeProvider.Now()
+	}
+	publication := models.ReleasePublication{PublishedAt: publishedAt, Checksum: checksum, Signer: signer}
+	if pubErr := recordPublication(ctx, c, stored, element, publication); pubErr != nil {
+		return pubErr
+	}
+
+	c.indexRelease(ctx, scope, name, version)
+	slog.Info("Release mirrored from upstream registry", "scope", scope, "package", name, "version", version)
+	return nil
+}
+
+func releaseId(scope string, name string, version string) string {
+	return strings.ToLower(scope+"."+name) + "@" + version
+}
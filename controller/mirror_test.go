@@ -0,0 +1,187 @@
+package controller
+
+import (
+	"OpenSPMRegistry/config"
+	"OpenSPMRegistry/mimetypes"
+	"OpenSPMRegistry/models"
+	"OpenSPMRegistry/repo/files"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newUpstreamRegistry serves a second registry instance holding acme.network@1.0.0
+func newUpstreamRegistry(t *testing.T) *httptest.Server {
+	t.Helper()
+	r := files.NewFileRepo(filepath.Join(t.TempDir(), "upstream"))
+	ctx := context.Background()
+
+	archive := newTestSourceArchive("acme", "network", "// swift-tools-version:5.9\n")
+	for _, element := range []struct {
+		element *models.UploadElement
+		content []byte
+	}{
+		{models.NewUploadElement("acme", "network", "1.0.0", mimetypes.ApplicationZip, models.SourceArchive), archive},
+		{models.NewUploadElement("acme", "network", "1.0.0", mimetypes.ApplicationJson, models.Metadata), []byte(`{"description":"networking"}`)},
+		{models.NewUploadElement("acme", "network", "1.0.0", mimetypes.ApplicationOctetStream, models.SourceArchiveSignature), []byte("signature")},
+	} {
+		w, err := r.GetWriter(ctx, element.element)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write(element.content)
+		_ = w.Close()
+	}
+	_ = r.ExtractManifestFiles(ctx, models.NewUploadElement("acme", "network", "1.0.0", mimetypes.ApplicationZip, models.SourceArchive))
+	_ = r.SetPublishDate(ctx, models.NewUploadElement("acme", "network", "1.0.0", mimetypes.ApplicationZip, models.SourceArchive), time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+
+	c := NewController(config.ServerConfig{}, r)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{scope}/{package}", c.ListAction)
+	mux.HandleFunc("GET /{scope}/{package}/{version}", func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, ".zip") {
+			c.DownloadSourceArchiveAction(w, r)
+		} else {
+			c.InfoAction(w, r)
+		}
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newMirroringController(t *testing.T, upstreamURL string) (*Controller, *files.FileRepo) {
+	t.Helper()
+	local := files.NewFileRepo(filepath.Join(t.TempDir(), "local"))
+	return NewController(config.ServerConfig{Upstream: config.UpstreamConfig{Enabled: true, URL: upstreamURL}}, local), local
+}
+
+func newReleaseRequest(accept string, version string) *http.Request {
+	req := httptest.NewRequest("GET", "/acme/network/"+version, nil)
+	req.SetPathValue("scope", "acme")
+	req.SetPathValue("package", "network")
+	req.SetPathValue("version", version)
+	req.Header.Set("Accept", accept)
+	return req
+}
+
+func Test_DownloadSourceArchiveAction_MissingLocally_MirrorsFromUpstream(t *testing.T) {
+	upstreamServer := newUpstreamRegistry(t)
+	c, local := newMirroringController(t, upstreamServer.URL)
+	ctx := context.Background()
+
+	w := httptest.NewRecorder()
+	c.DownloadSourceArchiveAction(w, newReleaseRequest("application/vnd.swift.registry.v1+zip", "1.0.0.zip"))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if signature := w.Header().Get("X-Swift-Package-Signature"); signature == "" {
+		t.Errorf("expected mirrored signature header")
+	}
+	for _, element := range []*models.UploadElement{
+		models.NewUploadElement("acme", "network", "1.0.0", mimetypes.ApplicationZip, models.SourceArchive),
+		models.NewUploadElement("acme", "network", "1.0.0", mimetypes.ApplicationJson, models.Metadata),
+		models.NewUploadElement("acme", "network", "1.0.0", mimetypes.TextXSwift, models.Manifest),
+	} {
+		if !local.Exists(ctx, element) {
+			t.Errorf("expected %s to be stored locally", element.FileName())
+		}
+	}
+
+	// served locally once the upstream is gone
+	upstreamServer.Close()
+	w = httptest.NewRecorder()
+	c.InfoAction(w, newReleaseRequest("application/vnd.swift.registry.v1+json", "1.0.0"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var info map[string]any
+	_ = json.NewDecoder(w.Body).Decode(&info)
+	if metadata, _ := info["metadata"].(map[string]any); metadata["description"] != "networking" {
+		t.Errorf("expected mirrored metadata, got %v", info["metadata"])
+	}
+	if info["publishedAt"] != "2024-03-01T12:00:00Z" {
+		t.Errorf("expected publish date of the upstream registry, got %v", info["publishedAt"])
+	}
+}
+
+func Test_FetchManifestAction_MissingLocally_MirrorsFromUpstream(t *testing.T) {
+	upstreamServer := newUpstreamRegistry(t)
+	c, _ := newMirroringController(t, upstreamServer.URL)
+
+	w := httptest.NewRecorder()
+	c.FetchManifestAction(w, newReleaseRequest("application/vnd.swift.registry.v1+swift", "1.0.0"))
+
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "swift-tools-version:5.9") {
+		t.Errorf("expected mirrored Package.swift, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func Test_ListAction_MergesUpstreamReleases(t *testing.T) {
+	upstreamServer := newUpstreamRegistry(t)
+	c, _ := newMirroringController(t, upstreamServer.URL)
+
+	req := httptest.NewRequest("GET", "/acme/network", nil)
+	req.SetPathValue("scope", "acme")
+	req.SetPathValue("package", "network")
+	req.Header.Set("Accept", "application/vnd.swift.registry.v1+json")
+	w := httptest.NewRecorder()
+	c.ListAction(w, req)
+
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"1.0.0"`) {
+		t.Errorf("expected upstream release to be listed, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func Test_InfoAction_UpstreamUnavailable_ReturnsBadGateway(t *testing.T) {
+	upstreamServer := newUpstreamRegistry(t)
+	upstreamServer.Close()
+	c, _ := newMirroringController(t, upstreamServer.URL)
+
+	w := httptest.NewRecorder()
+	c.InfoAction(w, newReleaseRequest("application/vnd.swift.registry.v1+json", "1.0.0"))
+
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("expected status code %d, got %d", http.StatusBadGateway, w.Code)
+	}
+}
+
+func Test_InfoAction_UnknownUpstreamRelease_ReturnsNotFound(t *testing.T) {
+	upstreamServer := newUpstreamRegistry(t)
+	c, _ := newMirroringController(t, upstreamServer.URL)
+
+	w := httptest.NewRecorder()
+	c.InfoAction(w, newReleaseRequest("application/vnd.swift.registry.v1+json", "9.9.9"))
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status code %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func Test_DownloadSourceArchiveAction_UpstreamChecksumMismatch_StoresNothing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, ".zip") {
+			_, _ = w.Write(newTestSourceArchive("acme", "network", "// swift-tools-version:5.9\n// tampered\n"))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"acme.network","version":"1.0.0","resources":[{"name":"source-archive","type":"application/zip","checksum":"0000"}]}`))
+	}))
+	defer server.Close()
+	c, local := newMirroringController(t, server.URL)
+
+	w := httptest.NewRecorder()
+	c.DownloadSourceArchiveAction(w, newReleaseRequest("application/vnd.swift.registry.v1+zip", "1.0.0.zip"))
+
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("expected status code %d, got %d", http.StatusBadGateway, w.Code)
+	}
+	if local.Exists(context.Background(), models.NewUploadElement("acme", "network", "1.0.0", mimetypes.ApplicationZip, models.SourceArchive)) {
+		t.Errorf("expected tampered source archive not to be stored")
+	}
+}
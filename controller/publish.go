@@ -1,10 +1,15 @@
 package controller
 
 import (
+	"OpenSPMRegistry/authorizer"
+	"OpenSPMRegistry/metrics"
 	"OpenSPMRegistry/mimetypes"
 	"OpenSPMRegistry/models"
+	"OpenSPMRegistry/repo"
 	"OpenSPMRegistry/utils"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"log/slog"
@@ -15,11 +20,56 @@ import (
 	"regexp"
 )
 
+// publishError describes a failed publication together with the status code reported to the client
+// and the reason it is counted under in the metrics
+type publishError struct {
+	reason         string
+	errorMessage   string
+	httpStatusCode int
+	// failures lists every validation failure of the upload
+	failures []string
+}
+
+// reasons of failed publications reported as metrics label, keep the number of values small
+const (
+	publishFailureInvalidRequest     = "invalid_request"
+	publishFailureForbidden          = "forbidden"
+	publishFailureReleaseExists      = "release_exists"
+	publishFailureReleaseDeleted     = "release_deleted"
+	publishFailureInProgress         = "in_progress"
+	publishFailureQueueFull          = "queue_full"
+	publishFailureNoSourceArchive    = "no_source_archive"
+	publishFailureMissingPackageJson = "missing_package_json"
+	publishFailureStorage            = "storage_error"
+	publishFailureInvalidArchive     = "invalid_archive"
+	publishFailureInvalidSignature   = "invalid_signature"
+	publishFailureUnsigned           = "unsigned"
+	publishFailureInvalidMetadata    = "invalid_metadata"
+)
+
+func newPublishError(reason string, errorMessage string, httpStatusCode int) *publishError {
+	return &publishError{reason: reason, errorMessage: errorMessage, httpStatusCode: httpStatusCode}
+}
+
+func (e *publishError) Error() string {
+	return e.errorMessage
+}
+
+func (e *publishError) writeResponse(w http.ResponseWriter) {
+	writeErrorsWithStatusCode(e.errorMessage, e.failures, w, e.httpStatusCode)
+}
+
+// recordPublishFailure counts a failed publication
+func recordPublishFailure(reason string) {
+	metrics.PublishFailures.WithLabelValues(reason).Inc()
+}
+
 func (c *Controller) PublishAction(w http.ResponseWriter, r *http.Request) {
 
 	printCallInfo("Publish", r)
 
 	if err := checkHeadersEnforce(r, "json"); err != nil {
+		recordPublishFailure(publishFailureInvalidRequest)
 		err.writeResponse(w)
 		return // error already logged
 	}
@@ -29,22 +79,44 @@ func (c *Controller) PublishAction(w http.ResponseWriter, r *http.Request) {
 	packageName := r.PathValue("package")
 	version := r.PathValue("version")
 	if match, err := regexp.MatchString("\\A[a-zA-Z0-9](?:[a-zA-Z0-9]|-[a-zA-Z0-9]){0,38}\\z", scope); err != nil || !match {
+		recordPublishFailure(publishFailureInvalidRequest)
 		writeErrorWithStatusCode(fmt.Sprint("upload failed, incorrect scope:", scope), w, http.StatusBadRequest)
 		return
 	}
 
 	if match, err := regexp.MatchString("\\A[a-zA-Z0-9](?:[a-zA-Z0-9]|[-_][a-zA-Z0-9]){0,99}\\z", packageName); err != nil || !match {
+		recordPublishFailure(publishFailureInvalidRequest)
 		writeErrorWithStatusCode(fmt.Sprint("upload failed, incorrect package:", packageName), w, http.StatusBadRequest)
 		return
 	}
 
+	// spec 3.6: package releases are identified by a semantic version (SemVer 2.0.0)
+	if _, err := models.ParseVersion(version); err != nil {
+		recordPublishFailure(publishFailureInvalidRequest)
+		writeErrorWithStatusCode(fmt.Sprintf("upload failed, incorrect version %s: %v", version, err), w, http.StatusBadRequest)
+		return
+	}
+
+	if !c.authorize(w, r, scope, authorizer.Publish) {
+		recordPublishFailure(publishFailureForbidden)
+		return
+	}
+
 	reader, err := r.MultipartReader()
 	if err != nil {
+		recordPublishFailure(publishFailureInvalidRequest)
 		writeErrorWithStatusCode("upload failed: invalid multipart form", w, http.StatusBadRequest)
 		return
 	}
 
+	// https://github.com/swiftlang/swift-package-manager/blob/main/Documentation/PackageRegistry/Registry.md#4632-asynchronous-publication
+	if c.publishQueue != nil && preferRespondAsync(r) {
+		c.publishAsync(w, r, reader, scope, packageName, version)
+		return
+	}
+
 	var packageElement *models.UploadElement
+	var packageChecksum string
 	var storedElements []*models.UploadElement
 
 	for {
@@ -56,6 +128,7 @@ func (c *Controller) PublishAction(w http.ResponseWriter, r *http.Request) {
 
 		if part == nil {
 			slog.Error("Error", "msg", err)
+			recordPublishFailure(publishFailureInvalidRequest)
 			writeErrorWithStatusCode("upload failed: invalid multipart form", w, http.StatusBadRequest)
 			return
 		}
@@ -79,37 +152,61 @@ func (c *Controller) PublishAction(w http.ResponseWriter, r *http.Request) {
 		}
 
 		// currently we support only source archive storing
-		element, err := storeElements(r, w, name, scope, packageName, version, mimeType, c, part)
+		element, checksum, err := storeElements(r, w, name, scope, packageName, version, mimeType, c, part)
 		if element != nil {
 			storedElements = append(storedElements, element)
 		}
 		if err != nil {
-			cleanupStoredElements(c, r, storedElements, scope, packageName, version)
+			cleanupStoredElements(requestContext(r), c, storedElements, scope, packageName, version)
 			return // error already logged
 		}
 
 		if name == string(models.SourceArchive) {
 			packageElement = element
+			packageChecksum = checksum
 		}
 	}
 
-	// currently we only support synchronous publishing
+	// synchronous publishing
 	// https://github.com/swiftlang/swift-package-manager/blob/main/Documentation/PackageRegistry/Registry.md#4631-synchronous-publication
 	if packageElement != nil {
 		// Check if Package.json is required and validate its presence
-		// Note: Package.json is extracted from the source archive zip during ExtractManifestFiles
-		// (called in storeElements), so it should exist here if it was in the archive
-		if c.config.PackageCollections.RequirePackageJson {
-			ctx := requestContext(r)
-			packageJsonElement := models.NewUploadElement(scope, packageName, version, mimetypes.ApplicationJson, models.PackageManifestJson)
-			if !c.repo.Exists(ctx, packageJsonElement) {
-				writeErrorWithStatusCode("upload failed: Package.json is required but not found in archive", w, http.StatusUnprocessableEntity)
-				// Clean up all stored elements including extracted manifests
-				cleanupStoredElements(c, r, storedElements, scope, packageName, version)
-				return
-			}
+		if err := checkPackageJson(requestContext(r), c, storedElements, scope, packageName, version); err != nil {
+			c.publishFailed(requestContext(r), scope, packageName, version, err)
+			err.writeResponse(w)
+			return
+		}
+		signer, pubErr := checkSignature(requestContext(r), c, storedElements, scope, packageName, version)
+		if pubErr != nil {
+			c.publishFailed(requestContext(r), scope, packageName, version, pubErr)
+			pubErr.writeResponse(w)
+			return
+		}
+		metadataElement, pubErr := enrichMetadata(requestContext(r), c, storedElements, scope, packageName, version)
+		if pubErr != nil {
+			c.publishFailed(requestContext(r), scope, packageName, version, pubErr)
+			pubErr.writeResponse(w)
+			return
+		}
+		if metadataElement != nil {
+			storedElements = append(storedElements, metadataElement)
+		}
+		publication := models.ReleasePublication{
+			PublishedAt: c.timeProvider.Now(),
+			Publisher:   publisherName(r.Context()),
+			Checksum:    packageChecksum,
+			Signer:      signer,
+		}
+		if err := recordPublication(requestContext(r), c, storedElements, packageElement, publication); err != nil {
+			c.publishFailed(requestContext(r), scope, packageName, version, err)
+			err.writeResponse(w)
+			return
 		}
 
+		c.indexRelease(requestContext(r), scope, packageName, version)
+		c.releasePublished(scope, packageName, version)
+		auditChecksum(requestContext(r), packageChecksum)
+
 		location, err := url.JoinPath(
 			utils.BaseUrl(c.config),
 			scope,
@@ -127,49 +224,87 @@ func (c *Controller) PublishAction(w http.ResponseWriter, r *http.Request) {
 	}
 
 	slog.Error("Error", "msg", "nothing found to store")
-	writeError("upload failed, nothing found to store", w)
+	pubErr := newPublishError(publishFailureNoSourceArchive, "upload failed, nothing found to store", http.StatusInternalServerError)
+	c.publishFailed(requestContext(r), scope, packageName, version, pubErr)
+	pubErr.writeResponse(w)
 }
 
 // storeElements stores the given element in the repository
-// returns the stored element and an error if the element could not be stored
-func storeElements(r *http.Request, w http.ResponseWriter, name string, scope string, packageName string, version string, mimeType string, c *Controller, part *multipart.Part) (*models.UploadElement, error) {
+// returns the stored element, its checksum and an error if the element could not be stored
+func storeElements(r *http.Request, w http.ResponseWriter, name string, scope string, packageName string, version string, mimeType string, c *Controller, part *multipart.Part) (*models.UploadElement, string, error) {
 	uploadType, err := validateUploadType(name)
 	if err != nil {
+		recordPublishFailure(publishFailureInvalidRequest)
 		writeErrorWithStatusCode(err.Error(), w, http.StatusBadRequest)
-		return nil, err
+		return nil, "", err
 	}
 
-	ctx := requestContext(r)
 	element := models.NewUploadElement(scope, packageName, version, mimeType, uploadType)
+	stored, checksum, pubErr := storeElement(requestContext(r), c, element, uploadType, part)
+	if pubErr != nil {
+		c.publishFailed(requestContext(r), scope, packageName, version, pubErr)
+		pubErr.writeResponse(w)
+		return stored, "", pubErr
+	}
+	return stored, checksum, nil
+}
+
+// storeElement writes the content of an upload part as element to the repository
+// and extracts the manifests if the element is a source archive. content is closed in any case.
+// The returned element is non-nil whenever something may have been written and needs cleanup on error.
+// The SHA-256 checksum of the content is computed while it is written and returned on success.
+func storeElement(ctx context.Context, c *Controller, element *models.UploadElement, uploadType models.UploadElementType, content io.ReadCloser) (*models.UploadElement, string, *publishError) {
+	// deleted releases keep a tombstone and must never be published again
+	if isReleaseDeleted(ctx, c, element.Scope, element.Name, element.Version) {
+		_ = content.Close()
+		msg := fmt.Sprintf("upload failed, release %s.%s@%s was deleted and cannot be published again", element.Scope, element.Name, element.Version)
+		slog.Error("Error", "msg", msg)
+		return nil, "", newPublishError(publishFailureReleaseDeleted, msg, http.StatusConflict)
+	}
 
 	// check if file exist in repo
 	if c.repo.Exists(ctx, element) {
+		_ = content.Close()
 		msg := fmt.Sprint("upload failed, package exists:", element.FileName())
 		slog.Error("Error", "msg", msg)
-		writeErrorWithStatusCode(msg, w, http.StatusConflict)
-		return nil, fmt.Errorf("package exists: %s", element.FileName())
+		return nil, "", newPublishError(publishFailureReleaseExists, msg, http.StatusConflict)
+	}
+
+	if uploadType == models.SourceArchive {
+		archive, pubErr := validateSourceArchive(c, element, content)
+		if pubErr != nil {
+			return nil, "", pubErr
+		}
+		content = archive
+	}
+	if uploadType == models.Metadata {
+		metadata, pubErr := validateMetadata(element, content)
+		if pubErr != nil {
+			return nil, "", pubErr
+		}
+		content = metadata
 	}
 
 	writer, err := c.repo.GetWriter(ctx, element)
 	if err != nil {
+		_ = content.Close()
 		slog.Error("Error", "msg", err)
-		writeError("upload failed, error storing file", w)
 		// return element so it get cleaned up
-		return element, fmt.Errorf("error storing file: %s", element.FileName())
+		return element, "", newPublishError(publishFailureStorage, "upload failed, error storing file", http.StatusInternalServerError)
 	}
 
-	_, err1 := io.Copy(writer, part)
+	hash := sha256.New()
+	_, err1 := io.Copy(io.MultiWriter(writer, hash), content)
 	errs := []error{
 		err1,
-		part.Close(),
+		content.Close(),
 	}
 
 	for _, err := range errs {
 		if err != nil {
 			slog.Error("Error", "msg", err)
-			writeError("upload failed, error storing file", w)
 			_ = writer.Close()
-			return element, fmt.Errorf("error storing file: %s", element.FileName())
+			return element, "", newPublishError(publishFailureStorage, "upload failed, error storing file", http.StatusInternalServerError)
 		}
 	}
 
@@ -177,19 +312,56 @@ func storeElements(r *http.Request, w http.ResponseWriter, name string, scope st
 	// file available for GetReader when extracting Package.swift and Package.json.
 	if err := writer.Close(); err != nil {
 		slog.Error("Error closing writer:", "error", err)
-		writeError("upload failed, error storing file", w)
-		return element, fmt.Errorf("error closing writer: %w", err)
+		return element, "", newPublishError(publishFailureStorage, "upload failed, error storing file", http.StatusInternalServerError)
 	}
 
 	// Only extract Package.swift and Package.json from the source archive, not from metadata/signature parts
 	if uploadType == models.SourceArchive {
+		// the archive was validated, so failing to extract its manifests is a storage error
 		if err := c.repo.ExtractManifestFiles(ctx, element); err != nil {
 			slog.Error("Error extracting manifest files:", "error", err)
-			// Continue even if extraction fails
+			return element, "", newPublishError(publishFailureStorage, "upload failed, error extracting manifest files", http.StatusInternalServerError)
 		}
 	}
 
-	return element, nil
+	return element, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// recordPublication stores the publication record of the release (see repo.PublishDate) with the checksum
+// of its source archive and its verified signer, the publish date of the release independent of the timestamps of the storage.
+// On failure all stored elements are removed again.
+func recordPublication(ctx context.Context, c *Controller, storedElements []*models.UploadElement, sourceArchive *models.UploadElement, publication models.ReleasePublication) *publishError {
+	if err := repo.WritePublication(ctx, c.repo, sourceArchive, publication); err != nil {
+		slog.Error("Error storing publication record:", "error", err)
+		cleanupStoredElements(ctx, c, storedElements, sourceArchive.Scope, sourceArchive.Name, sourceArchive.Version)
+		return newPublishError(publishFailureStorage, "upload failed, error storing publication record", http.StatusInternalServerError)
+	}
+	return nil
+}
+
+// publisherName returns the name of the principal publishing, empty if anonymous
+func publisherName(ctx context.Context) string {
+	if principal := authorizer.PrincipalFromContext(ctx); principal != nil {
+		return principal.Name
+	}
+	return ""
+}
+
+// checkPackageJson verifies that Package.json was extracted from the source archive
+// if the configuration requires it. On failure all stored elements are removed again.
+func checkPackageJson(ctx context.Context, c *Controller, storedElements []*models.UploadElement, scope, packageName, version string) *publishError {
+	if !c.config.PackageCollections.RequirePackageJson {
+		return nil
+	}
+	// Note: Package.json is extracted from the source archive zip during ExtractManifestFiles
+	// (called in storeElement), so it should exist here if it was in the archive
+	packageJsonElement := models.NewUploadElement(scope, packageName, version, mimetypes.ApplicationJson, models.PackageManifestJson)
+	if !c.repo.Exists(ctx, packageJsonElement) {
+		// Clean up all stored elements including extracted manifests
+		cleanupStoredElements(ctx, c, storedElements, scope, packageName, version)
+		return newPublishError(publishFailureMissingPackageJson, "upload failed: Package.json is required but not found in archive", http.StatusUnprocessableEntity)
+	}
+	return nil
 }
 
 func validateUploadType(name string) (models.UploadElementType, error) {
@@ -205,15 +377,13 @@ func validateUploadType(name string) (models.UploadElementType, error) {
 // when a publish operation needs to be rolled back.
 //
 // Parameters:
+//   - ctx: Context of the publication (carries the Authorization header for passthrough mode)
 //   - c: Controller instance with repository access
-//   - r: HTTP request for context
 //   - storedElements: Slice of elements that were stored during upload
 //   - scope: Package scope
 //   - packageName: Package name
 //   - version: Package version
-func cleanupStoredElements(c *Controller, r *http.Request, storedElements []*models.UploadElement, scope, packageName, version string) {
-	ctx := requestContext(r)
-
+func cleanupStoredElements(ctx context.Context, c *Controller, storedElements []*models.UploadElement, scope, packageName, version string) {
 	// Remove all stored elements (metadata, signatures, source archive)
 	for _, element := range storedElements {
 		if err := c.repo.Remove(ctx, element); err != nil {
@@ -240,6 +410,14 @@ func cleanupStoredElements(c *Controller, r *http.Request, storedElements []*mod
 		}
 	}
 
+	// Remove the publication record if it was stored
+	publication := models.NewUploadElement(scope, packageName, version, mimetypes.ApplicationJson, models.Publication)
+	if c.repo.Exists(ctx, publication) {
+		if err := c.repo.Remove(ctx, publication); err != nil {
+			slog.Warn("Failed to cleanup publication record during rollback", "error", err)
+		}
+	}
+
 	// Remove Package.json if it was extracted
 	packageJsonElement := models.NewUploadElement(scope, packageName, version, mimetypes.ApplicationJson, models.PackageManifestJson)
 	if c.repo.Exists(ctx, packageJsonElement) {
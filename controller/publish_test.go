@@ -2,9 +2,17 @@ package controller
 
 import (
 	"OpenSPMRegistry/config"
+	"OpenSPMRegistry/metrics"
+	"OpenSPMRegistry/mimetypes"
 	"OpenSPMRegistry/models"
+	"OpenSPMRegistry/repo"
+	"OpenSPMRegistry/repo/files"
+	"OpenSPMRegistry/utils"
+	"archive/zip"
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log/slog"
@@ -12,6 +20,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/textproto"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -38,6 +47,19 @@ type mockReader struct {
 	*bytes.Reader
 }
 
+// testSourceArchive is a minimal valid source archive of scope.package
+var testSourceArchive = newTestSourceArchive("scope", "package", "// swift-tools-version:5.9\n")
+
+// newTestSourceArchive creates a zip with Package.swift in the scope.name directory SwiftPM uses
+func newTestSourceArchive(scope string, name string, packageSwift string) []byte {
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	f, _ := zw.Create(scope + "." + name + "/Package.swift")
+	_, _ = f.Write([]byte(packageSwift))
+	_ = zw.Close()
+	return archive.Bytes()
+}
+
 type publishErrorWriter struct {
 	shouldFail bool
 }
@@ -158,11 +180,11 @@ func createMultipartRequestWithParts(t *testing.T, parts []multipartPart) *http.
 
 func Test_PublishAction_MultipleFiles_StoresAll(t *testing.T) {
 	mockRepo := &mockPublishRepo{}
-	ctrl := &Controller{repo: mockRepo}
+	ctrl := &Controller{repo: mockRepo, timeProvider: utils.NewRealTimeProvider()}
 	req := createMultipartRequest(t, map[string][]byte{
-		string(models.SourceArchive):          []byte("archive data"),
+		string(models.SourceArchive):          testSourceArchive,
 		string(models.SourceArchiveSignature): []byte("signature data"),
-		string(models.Metadata):               []byte("metadata"),
+		string(models.Metadata):               []byte(`{"description":"metadata"}`),
 		string(models.MetadataSignature):      []byte("metadata signature"),
 	})
 	w := httptest.NewRecorder()
@@ -174,9 +196,9 @@ func Test_PublishAction_MultipleFiles_StoresAll(t *testing.T) {
 	}
 
 	expectedFiles := map[string]string{
-		"scope.package-1.0.0.zip": "archive data",
+		"scope.package-1.0.0.zip": string(testSourceArchive),
 		"scope.package-1.0.0.sig": "signature data",
-		"metadata.json":           "metadata",
+		"metadata.json":           `{"description":"metadata"}`,
 		"metadata.sig":            "metadata signature",
 	}
 
@@ -187,9 +209,34 @@ func Test_PublishAction_MultipleFiles_StoresAll(t *testing.T) {
 	}
 }
 
+func Test_PublishAction_StoresPublicationRecord(t *testing.T) {
+	r := files.NewFileRepo(t.TempDir())
+	c := NewController(config.ServerConfig{}, r)
+	t.Cleanup(c.Close)
+	publishedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	c.timeProvider = utils.NewMockTimeProvider(publishedAt)
+	req := withPrincipal(createMultipartRequest(t, map[string][]byte{string(models.SourceArchive): testSourceArchive}), "alice")
+	w := httptest.NewRecorder()
+
+	c.PublishAction(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status code %d, got %d", http.StatusCreated, w.Code)
+	}
+	publication, err := repo.ReadPublication(context.Background(), r, "scope", "package", "1.0.0")
+	if err != nil {
+		t.Fatalf("expected publication record, got %v", err)
+	}
+	// computed while the upload was stored
+	checksum := fmt.Sprintf("%x", sha256.Sum256(testSourceArchive))
+	if publication.Publisher != "alice" || publication.Checksum != checksum || !publication.PublishedAt.Equal(publishedAt) {
+		t.Errorf("unexpected publication record %+v", publication)
+	}
+}
+
 func Test_PublishAction_UnsupportedUploadType_ReturnsBadRequest(t *testing.T) {
 	mockRepo := &mockPublishRepo{}
-	ctrl := &Controller{repo: mockRepo}
+	ctrl := &Controller{repo: mockRepo, timeProvider: utils.NewRealTimeProvider()}
 
 	req := createMultipartRequestWithParts(t, []multipartPart{
 		{
@@ -217,14 +264,14 @@ func Test_PublishAction_UnsupportedUploadType_CleansUpStoredElements(t *testing.
 		storedFiles:  make(map[string][]byte),
 		removedFiles: make([]string, 0),
 	}
-	ctrl := &Controller{repo: mockRepo}
+	ctrl := &Controller{repo: mockRepo, timeProvider: utils.NewRealTimeProvider()}
 
 	req := createMultipartRequestWithParts(t, []multipartPart{
 		{
 			name:        string(models.SourceArchive),
 			filename:    "source-archive.zip",
 			contentType: "application/zip",
-			content:     []byte("archive data"),
+			content:     testSourceArchive,
 		},
 		{
 			name:        "custom-part",
@@ -269,9 +316,9 @@ func Test_PublishAction_InvalidMultipartForm_ReturnsBadRequest(t *testing.T) {
 
 func Test_PublishAction_NoSourceArchive_ReturnsError(t *testing.T) {
 	mockRepo := &mockPublishRepo{}
-	ctrl := &Controller{repo: mockRepo}
+	ctrl := &Controller{repo: mockRepo, timeProvider: utils.NewRealTimeProvider()}
 	req := createMultipartRequest(t, map[string][]byte{
-		string(models.Metadata): []byte("metadata only"),
+		string(models.Metadata): []byte(`{"description":"metadata only"}`),
 	})
 	w := httptest.NewRecorder()
 
@@ -285,7 +332,7 @@ func Test_PublishAction_NoSourceArchive_ReturnsError(t *testing.T) {
 func Test_PublishAction_InvalidScope_ReturnsBadRequest(t *testing.T) {
 	ctrl := &Controller{}
 	req := createMultipartRequest(t, map[string][]byte{
-		string(models.SourceArchive): []byte("test"),
+		string(models.SourceArchive): testSourceArchive,
 	})
 	req.SetPathValue("scope", "invalid@scope") // Invalid character in scope
 	w := httptest.NewRecorder()
@@ -300,7 +347,7 @@ func Test_PublishAction_InvalidScope_ReturnsBadRequest(t *testing.T) {
 func Test_PublishAction_InvalidPackage_ReturnsBadRequest(t *testing.T) {
 	ctrl := &Controller{}
 	req := createMultipartRequest(t, map[string][]byte{
-		string(models.SourceArchive): []byte("test"),
+		string(models.SourceArchive): testSourceArchive,
 	})
 	req.SetPathValue("package", "invalid@package") // Invalid character in package
 	w := httptest.NewRecorder()
@@ -312,10 +359,71 @@ func Test_PublishAction_InvalidPackage_ReturnsBadRequest(t *testing.T) {
 	}
 }
 
+func Test_PublishAction_InvalidSourceArchive_ReturnsUnprocessableEntityWithFailures(t *testing.T) {
+	mockRepo := &mockPublishRepo{}
+	ctrl := &Controller{repo: mockRepo, timeProvider: utils.NewRealTimeProvider()}
+	req := createMultipartRequest(t, map[string][]byte{
+		string(models.SourceArchive): newTestSourceArchive("other", "package", "import PackageDescription\n"),
+		string(models.Metadata):      []byte(`{"description":"metadata"}`),
+	})
+	w := httptest.NewRecorder()
+
+	ctrl.PublishAction(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status code %d, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+	var problem struct {
+		Detail string   `json:"detail"`
+		Errors []string `json:"errors"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&problem); err != nil || len(problem.Errors) != 1 || !strings.Contains(problem.Errors[0], "no Package.swift") {
+		t.Errorf("expected problem listing the missing Package.swift, got %+v (%v)", problem, err)
+	}
+	if _, ok := mockRepo.storedFiles["scope.package-1.0.0.zip"]; ok {
+		t.Errorf("expected invalid source archive not to be stored")
+	}
+}
+
+func Test_validateSourceArchive_SpoolsToConfiguredTempDir(t *testing.T) {
+	tempDir := t.TempDir()
+	ctrl := &Controller{config: config.ServerConfig{Publish: config.PublishConfig{Async: config.AsyncPublishConfig{TempDir: tempDir}}}}
+	element := models.NewUploadElement("scope", "package", "1.0.0", mimetypes.ApplicationZip, models.SourceArchive)
+
+	archive, pubErr := validateSourceArchive(ctrl, element, io.NopCloser(bytes.NewReader(testSourceArchive)))
+	if pubErr != nil {
+		t.Fatalf("unexpected error: %s", pubErr.errorMessage)
+	}
+	spooled, ok := archive.(*spooledArchive)
+	if !ok || filepath.Dir(spooled.Name()) != tempDir {
+		t.Errorf("expected source archive to be spooled to %s, got %v", tempDir, archive)
+	}
+	_ = archive.Close()
+}
+
+func Test_PublishAction_InvalidVersion_ReturnsBadRequest(t *testing.T) {
+	for _, version := range []string{"1.0", "1.0.0-beta..1", "01.0.0"} {
+		t.Run(version, func(t *testing.T) {
+			ctrl := &Controller{}
+			req := createMultipartRequest(t, map[string][]byte{
+				string(models.SourceArchive): testSourceArchive,
+			})
+			req.SetPathValue("version", version)
+			w := httptest.NewRecorder()
+
+			ctrl.PublishAction(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected status code %d, got %d", http.StatusBadRequest, w.Code)
+			}
+		})
+	}
+}
+
 func Test_PublishAction_WriteOperationError_ReturnsInternalError(t *testing.T) {
 	ctrl := &Controller{repo: &publishWriteErrorRepo{}}
 	req := createMultipartRequest(t, map[string][]byte{
-		string(models.SourceArchive): []byte("test data"),
+		string(models.SourceArchive): testSourceArchive,
 	})
 	w := httptest.NewRecorder()
 
@@ -343,7 +451,7 @@ func Test_PublishAction_NilPart_ReturnsBadRequest(t *testing.T) {
 func Test_PublishAction_EmptyScope_ReturnsBadRequest(t *testing.T) {
 	ctrl := &Controller{}
 	req := createMultipartRequest(t, map[string][]byte{
-		string(models.SourceArchive): []byte("test"),
+		string(models.SourceArchive): testSourceArchive,
 	})
 	req.SetPathValue("scope", "") // Empty scope
 	w := httptest.NewRecorder()
@@ -358,7 +466,7 @@ func Test_PublishAction_EmptyScope_ReturnsBadRequest(t *testing.T) {
 func Test_PublishAction_EmptyPackage_ReturnsBadRequest(t *testing.T) {
 	ctrl := &Controller{}
 	req := createMultipartRequest(t, map[string][]byte{
-		string(models.SourceArchive): []byte("test"),
+		string(models.SourceArchive): testSourceArchive,
 	})
 	req.SetPathValue("package", "") // Empty package name
 	w := httptest.NewRecorder()
@@ -376,9 +484,9 @@ func Test_PublishAction_PackageExists_ReturnsConflict(t *testing.T) {
 			"scope.package-1.0.0.zip": []byte("existing package"),
 		},
 	}
-	ctrl := &Controller{repo: mockRepo}
+	ctrl := &Controller{repo: mockRepo, timeProvider: utils.NewRealTimeProvider()}
 	req := createMultipartRequest(t, map[string][]byte{
-		string(models.SourceArchive): []byte("test data"),
+		string(models.SourceArchive): testSourceArchive,
 	})
 	w := httptest.NewRecorder()
 
@@ -389,10 +497,30 @@ func Test_PublishAction_PackageExists_ReturnsConflict(t *testing.T) {
 	}
 }
 
+func Test_PublishAction_PackageExists_CountsFailureReason(t *testing.T) {
+	mockRepo := &mockPublishRepo{
+		storedFiles: map[string][]byte{
+			"scope.package-1.0.0.zip": []byte("existing package"),
+		},
+	}
+	ctrl := &Controller{repo: mockRepo, timeProvider: utils.NewRealTimeProvider()}
+	req := createMultipartRequest(t, map[string][]byte{
+		string(models.SourceArchive): testSourceArchive,
+	})
+	failures := metrics.PublishFailures.WithLabelValues(publishFailureReleaseExists)
+	before := failures.Value()
+
+	ctrl.PublishAction(httptest.NewRecorder(), req)
+
+	if failures.Value() != before+1 {
+		t.Errorf("expected publish failure %s to be counted, got %v (before %v)", publishFailureReleaseExists, failures.Value(), before)
+	}
+}
+
 func Test_PublishAction_InvalidAcceptHeader_ReturnsBadRequest(t *testing.T) {
 	ctrl := &Controller{}
 	req := createMultipartRequest(t, map[string][]byte{
-		string(models.SourceArchive): []byte("test"),
+		string(models.SourceArchive): testSourceArchive,
 	})
 	req.Header.Set("Accept", "invalid/type") // Invalid Accept header
 	w := httptest.NewRecorder()
@@ -428,9 +556,9 @@ func Test_PublishAction_DebugLogging_Success(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(logBuffer, &slog.HandlerOptions{Level: slog.LevelDebug}))
 	slog.SetDefault(logger)
 
-	ctrl := &Controller{repo: &mockPublishRepo{}}
+	ctrl := &Controller{repo: &mockPublishRepo{}, timeProvider: utils.NewRealTimeProvider()}
 	req := createMultipartRequest(t, map[string][]byte{
-		string(models.SourceArchive): []byte("test data"),
+		string(models.SourceArchive): testSourceArchive,
 	})
 	w := httptest.NewRecorder()
 
@@ -448,10 +576,11 @@ func Test_PublishAction_InvalidLocationURL_ReturnsError(t *testing.T) {
 		config: config.ServerConfig{
 			Hostname: string([]byte{0x7f}), // Invalid hostname that will cause URL parsing to fail
 		},
+		timeProvider: utils.NewRealTimeProvider(),
 	}
 
 	req := createMultipartRequest(t, map[string][]byte{
-		string(models.SourceArchive): []byte("test data"),
+		string(models.SourceArchive): testSourceArchive,
 	})
 	w := httptest.NewRecorder()
 
@@ -465,7 +594,7 @@ func Test_PublishAction_InvalidLocationURL_ReturnsError(t *testing.T) {
 func Test_PublishAction_GetWriterError_ReturnsError(t *testing.T) {
 	ctrl := &Controller{repo: &extractErrorRepo{shouldFailGetWriter: true}}
 	req := createMultipartRequest(t, map[string][]byte{
-		string(models.SourceArchive): []byte("test data"),
+		string(models.SourceArchive): testSourceArchive,
 	})
 	w := httptest.NewRecorder()
 
@@ -479,7 +608,7 @@ func Test_PublishAction_GetWriterError_ReturnsError(t *testing.T) {
 func Test_PublishAction_WriteFailure_ReturnsInternalError(t *testing.T) {
 	ctrl := &Controller{repo: &writeErrorRepo{}}
 	req := createMultipartRequest(t, map[string][]byte{
-		string(models.SourceArchive): []byte("test data"),
+		string(models.SourceArchive): testSourceArchive,
 	})
 	w := httptest.NewRecorder()
 
@@ -637,13 +766,14 @@ func Test_PublishAction_RequirePackageJsonFails_CleansUpAllElements(t *testing.T
 				RequirePackageJson: true,
 			},
 		},
+		timeProvider: utils.NewRealTimeProvider(),
 	}
 
 	// Create a request with multiple files
 	req := createMultipartRequest(t, map[string][]byte{
-		string(models.SourceArchive):          []byte("archive data"),
+		string(models.SourceArchive):          testSourceArchive,
 		string(models.SourceArchiveSignature): []byte("signature data"),
-		string(models.Metadata):               []byte("metadata"),
+		string(models.Metadata):               []byte(`{"description":"metadata"}`),
 		string(models.MetadataSignature):      []byte("metadata signature"),
 	})
 
@@ -695,10 +825,11 @@ func Test_PublishAction_RequirePackageJsonSucceeds_NoCleanup(t *testing.T) {
 				RequirePackageJson: true,
 			},
 		},
+		timeProvider: utils.NewRealTimeProvider(),
 	}
 
 	req := createMultipartRequest(t, map[string][]byte{
-		string(models.SourceArchive): []byte("archive data"),
+		string(models.SourceArchive): testSourceArchive,
 	})
 
 	w := httptest.NewRecorder()
@@ -0,0 +1,500 @@
+package controller
+
+import (
+	"OpenSPMRegistry/audit"
+	"OpenSPMRegistry/config"
+	"OpenSPMRegistry/events"
+	"OpenSPMRegistry/mimetypes"
+	"OpenSPMRegistry/models"
+	"OpenSPMRegistry/utils"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type submissionStatus string
+
+// submissionPart is an upload part spooled to a temporary file until a worker stores it
+type submissionPart struct {
+	uploadType models.UploadElementType
+	mimeType   string
+	file       string
+}
+
+// submission is a publication accepted with "Prefer: respond-async"
+type submission struct {
+	id          string
+	scope       string
+	packageName string
+	version     string
+	authHeader  string
+	// publisher is the name of the principal submitting, recorded with the publication
+	publisher string
+	// audit is the audit entry of the accepting request, recorded again with the final outcome
+	audit      *audit.Entry
+	parts      []submissionPart
+	status     submissionStatus
+	err        *publishError
+	location   string
+	finishedAt time.Time
+}
+
+// publishQueue processes asynchronous publications with a fixed pool of workers
+// and keeps the status of each submission until its retention expired
+type publishQueue struct {
+	mu           sync.Mutex
+	wg           sync.WaitGroup
+	jobs         chan *submission
+	closed       bool
+	submissions  map[string]*submission
+	inFlight     map[string]string // release (scope.name@version) -> submission id
+	retention    time.Duration
+	tempDir      string
+	process      func(*submission) (string, *publishError)
+	timeProvider utils.TimeProvider
+}
+
+const (
+	submissionInProgress submissionStatus = "in-progress"
+	submissionCompleted  submissionStatus = "completed"
+	submissionFailed     submissionStatus = "failed"
+
+	defaultPublishWorkers      = 2
+	defaultPublishQueueSize    = 32
+	defaultSubmissionRetention = time.Hour
+	// submissionRetryAfter is the Retry-After value (seconds) sent while a submission is processed
+	submissionRetryAfter = "5"
+)
+
+var (
+	errPublishQueueFull  = errors.New("publish queue is full")
+	errPublishInProgress = errors.New("publication of this release is already in progress")
+)
+
+// newPublishQueue creates the queue and starts its workers.
+// process stores a submission and returns the location of the release or the failure.
+func newPublishQueue(cfg config.AsyncPublishConfig, process func(*submission) (string, *publishError)) *publishQueue {
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = defaultPublishWorkers
+	}
+	queueSize := cfg.QueueSize
+	if queueSize <= 0 {
+		queueSize = defaultPublishQueueSize
+	}
+	retention := defaultSubmissionRetention
+	if cfg.Retention > 0 {
+		retention = time.Duration(cfg.Retention) * time.Second
+	}
+
+	q := &publishQueue{
+		jobs:         make(chan *submission, queueSize),
+		submissions:  make(map[string]*submission),
+		inFlight:     make(map[string]string),
+		retention:    retention,
+		tempDir:      cfg.TempDir,
+		process:      process,
+		timeProvider: utils.NewRealTimeProvider(),
+	}
+
+	for range workers {
+		q.wg.Add(1)
+		go q.work()
+	}
+	return q
+}
+
+func (q *publishQueue) work() {
+	defer q.wg.Done()
+	for sub := range q.jobs {
+		location, err := q.process(sub)
+		sub.removeFiles()
+		q.finish(sub, location, err)
+	}
+}
+
+// enqueue registers the submission and hands it over to the workers.
+// Fails if the same release is already being processed or the queue is full.
+func (q *publishQueue) enqueue(sub *submission) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return errPublishQueueFull
+	}
+	q.purgeExpired()
+
+	release := sub.release()
+	if _, ok := q.inFlight[release]; ok {
+		return errPublishInProgress
+	}
+
+	sub.status = submissionInProgress
+	select {
+	case q.jobs <- sub:
+	default:
+		return errPublishQueueFull
+	}
+	q.submissions[sub.id] = sub
+	q.inFlight[release] = sub.id
+	return nil
+}
+
+func (q *publishQueue) finish(sub *submission, location string, err *publishError) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err != nil {
+		recordPublishFailure(err.reason)
+		sub.status = submissionFailed
+		sub.err = err
+		slog.Error("Asynchronous publication failed", "id", sub.id, "release", sub.release(), "error", err)
+	} else {
+		sub.status = submissionCompleted
+		sub.location = location
+		slog.Info("Asynchronous publication completed", "id", sub.id, "release", sub.release())
+	}
+	sub.finishedAt = q.timeProvider.Now()
+	delete(q.inFlight, sub.release())
+}
+
+// get returns a copy of the submission with the given id
+func (q *publishQueue) get(id string) (submission, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.purgeExpired()
+	sub, ok := q.submissions[id]
+	if !ok {
+		return submission{}, false
+	}
+	return *sub, true
+}
+
+// purgeExpired drops finished submissions older than the retention, q.mu must be held
+func (q *publishQueue) purgeExpired() {
+	now := q.timeProvider.Now()
+	for id, sub := range q.submissions {
+		if sub.status != submissionInProgress && now.Sub(sub.finishedAt) > q.retention {
+			delete(q.submissions, id)
+		}
+	}
+}
+
+// close stops accepting submissions and waits until the queued ones are processed
+func (q *publishQueue) close() {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return
+	}
+	q.closed = true
+	close(q.jobs)
+	q.mu.Unlock()
+	q.wg.Wait()
+}
+
+// context creates the context a submission is processed in, outside the request that submitted it
+func (s *submission) context() context.Context {
+	ctx := context.Background()
+	// passthrough authentication (Maven) needs the credentials of the original request
+	if s.authHeader != "" {
+		ctx = context.WithValue(ctx, config.AuthHeaderContextKey, s.authHeader)
+	}
+	return ctx
+}
+
+func (s *submission) release() string {
+	return fmt.Sprintf("%s.%s@%s", s.scope, s.packageName, s.version)
+}
+
+func (s *submission) removeFiles() {
+	for _, part := range s.parts {
+		if err := os.Remove(part.file); err != nil && !errors.Is(err, os.ErrNotExist) {
+			slog.Warn("Failed to remove spooled upload part", "file", part.file, "error", err)
+		}
+	}
+}
+
+// Close stops the asynchronous publish workers after all accepted submissions are processed
+func (c *Controller) Close() {
+	if c.publishQueue != nil {
+		c.publishQueue.close()
+	}
+}
+
+// SubmissionStatusAction reports the status of an asynchronous publication:
+// 202 while in progress, 301 to the release once completed, problem details if it failed
+// https://github.com/swiftlang/swift-package-manager/blob/main/Documentation/PackageRegistry/Registry.md#4632-asynchronous-publication
+func (c *Controller) SubmissionStatusAction(w http.ResponseWriter, r *http.Request) {
+	printCallInfo("SubmissionStatus", r)
+
+	id := r.PathValue("id")
+	if c.publishQueue == nil {
+		writeErrorWithStatusCode(fmt.Sprintf("submission %s not found", id), w, http.StatusNotFound)
+		return
+	}
+	sub, ok := c.publishQueue.get(id)
+	if !ok {
+		writeErrorWithStatusCode(fmt.Sprintf("submission %s not found", id), w, http.StatusNotFound)
+		return
+	}
+
+	header := w.Header()
+	header.Set("Content-Version", "1")
+	switch sub.status {
+	case submissionCompleted:
+		header.Set("Location", sub.location)
+		w.WriteHeader(http.StatusMovedPermanently)
+	case submissionFailed:
+		sub.err.writeResponse(w)
+	default:
+		header.Set("Retry-After", submissionRetryAfter)
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// publishAsync spools all upload parts to temporary files, queues the submission
+// and responds with 202 and the status location
+func (c *Controller) publishAsync(w http.ResponseWriter, r *http.Request, reader *multipart.Reader, scope, packageName, version string) {
+	ctx := requestContext(r)
+
+	id, err := newSubmissionId()
+	if err != nil {
+		slog.Error("Error creating submission id:", "error", err)
+		recordPublishFailure(publishFailureStorage)
+		writeError("upload failed", w)
+		return
+	}
+	sub := &submission{
+		id:          id,
+		scope:       scope,
+		packageName: packageName,
+		version:     version,
+		authHeader:  r.Header.Get("Authorization"),
+		publisher:   publisherName(r.Context()),
+	}
+
+	hasSourceArchive := false
+	for {
+		part, errPart := reader.NextPart()
+		if errPart == io.EOF {
+			break
+		}
+		if errPart != nil {
+			slog.Error("Error", "msg", errPart)
+			sub.removeFiles()
+			recordPublishFailure(publishFailureInvalidRequest)
+			writeErrorWithStatusCode("upload failed: invalid multipart form", w, http.StatusBadRequest)
+			return
+		}
+
+		uploadType, err := validateUploadType(part.FormName())
+		if err != nil {
+			sub.removeFiles()
+			recordPublishFailure(publishFailureInvalidRequest)
+			writeErrorWithStatusCode(err.Error(), w, http.StatusBadRequest)
+			return
+		}
+
+		mimeType := part.Header.Get("Content-Type")
+		if parsed, _, err := mime.ParseMediaType(mimeType); err == nil {
+			mimeType = parsed
+		}
+
+		// fail early instead of reporting the conflict through the status resource only
+		element := models.NewUploadElement(scope, packageName, version, mimeType, uploadType)
+		if c.repo.Exists(ctx, element) {
+			sub.removeFiles()
+			msg := fmt.Sprint("upload failed, package exists:", element.FileName())
+			slog.Error("Error", "msg", msg)
+			pubErr := newPublishError(publishFailureReleaseExists, msg, http.StatusConflict)
+			c.publishFailed(ctx, scope, packageName, version, pubErr)
+			pubErr.writeResponse(w)
+			return
+		}
+
+		file, err := c.spoolPart(part)
+		if err != nil {
+			slog.Error("Error spooling upload part:", "error", err)
+			sub.removeFiles()
+			recordPublishFailure(publishFailureStorage)
+			writeError("upload failed, error storing file", w)
+			return
+		}
+		sub.parts = append(sub.parts, submissionPart{uploadType: uploadType, mimeType: mimeType, file: file})
+		if uploadType == models.SourceArchive {
+			hasSourceArchive = true
+		}
+	}
+
+	if !hasSourceArchive {
+		sub.removeFiles()
+		slog.Error("Error", "msg", "nothing found to store")
+		pubErr := newPublishError(publishFailureNoSourceArchive, "upload failed, nothing found to store", http.StatusInternalServerError)
+		c.publishFailed(ctx, scope, packageName, version, pubErr)
+		pubErr.writeResponse(w)
+		return
+	}
+
+	if isReleaseDeleted(ctx, c, scope, packageName, version) {
+		sub.removeFiles()
+		pubErr := newPublishError(publishFailureReleaseDeleted, fmt.Sprintf("upload failed, release %s.%s@%s was deleted and cannot be published again", scope, packageName, version), http.StatusConflict)
+		c.publishFailed(ctx, scope, packageName, version, pubErr)
+		pubErr.writeResponse(w)
+		return
+	}
+
+	if entry := audit.FromContext(ctx); entry != nil {
+		entry.Subject = sub.id
+		accepted := *entry
+		sub.audit = &accepted
+	}
+
+	if err := c.publishQueue.enqueue(sub); err != nil {
+		sub.removeFiles()
+		slog.Error("Error queueing submission:", "release", sub.release(), "error", err)
+		if errors.Is(err, errPublishInProgress) {
+			recordPublishFailure(publishFailureInProgress)
+			writeErrorWithStatusCode(fmt.Sprint("upload failed, ", err), w, http.StatusConflict)
+		} else {
+			recordPublishFailure(publishFailureQueueFull)
+			w.Header().Set("Retry-After", submissionRetryAfter)
+			writeErrorWithStatusCode(fmt.Sprint("upload failed, ", err), w, http.StatusServiceUnavailable)
+		}
+		return
+	}
+
+	location, err := url.JoinPath(utils.BaseUrl(c.config), "submissions", sub.id)
+	if err != nil {
+		slog.Error("Error", "msg", err)
+	}
+	header := w.Header()
+	header.Set("Content-Version", "1")
+	header.Set("Location", location)
+	header.Set("Retry-After", submissionRetryAfter)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// spoolPart copies an upload part to a temporary file and returns its path
+func (c *Controller) spoolPart(part *multipart.Part) (string, error) {
+	defer func() { _ = part.Close() }()
+
+	file, err := os.CreateTemp(c.publishQueue.tempDir, "spm-publish-*")
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(file, part); err != nil {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		_ = os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
+
+// publishSubmission processes a submission and notifies its outcome
+func (c *Controller) publishSubmission(sub *submission) (string, *publishError) {
+	location, err := c.processSubmission(sub)
+	if err != nil {
+		c.emit(events.NewPublishFailedEvent(sub.scope, sub.packageName, sub.version, err.reason, err.errorMessage))
+	} else {
+		c.releasePublished(sub.scope, sub.packageName, sub.version)
+	}
+	c.recordSubmission(sub, err)
+	return location, err
+}
+
+// processSubmission stores all parts of a submission like the synchronous publication does
+// and returns the location of the published release
+func (c *Controller) processSubmission(sub *submission) (string, *publishError) {
+	ctx := sub.context()
+
+	var storedElements []*models.UploadElement
+	var archiveChecksum string
+	for _, part := range sub.parts {
+		element := models.NewUploadElement(sub.scope, sub.packageName, sub.version, part.mimeType, part.uploadType)
+
+		file, err := os.Open(part.file)
+		if err != nil {
+			slog.Error("Error opening spooled upload part:", "error", err)
+			cleanupStoredElements(ctx, c, storedElements, sub.scope, sub.packageName, sub.version)
+			return "", newPublishError(publishFailureStorage, "upload failed, error storing file", http.StatusInternalServerError)
+		}
+
+		stored, checksum, pubErr := storeElement(ctx, c, element, part.uploadType, file)
+		if stored != nil {
+			storedElements = append(storedElements, stored)
+		}
+		if pubErr != nil {
+			cleanupStoredElements(ctx, c, storedElements, sub.scope, sub.packageName, sub.version)
+			return "", pubErr
+		}
+		if part.uploadType == models.SourceArchive {
+			archiveChecksum = checksum
+		}
+	}
+
+	if err := checkPackageJson(ctx, c, storedElements, sub.scope, sub.packageName, sub.version); err != nil {
+		return "", err
+	}
+	signer, pubErr := checkSignature(ctx, c, storedElements, sub.scope, sub.packageName, sub.version)
+	if pubErr != nil {
+		return "", pubErr
+	}
+	metadataElement, pubErr := enrichMetadata(ctx, c, storedElements, sub.scope, sub.packageName, sub.version)
+	if pubErr != nil {
+		return "", pubErr
+	}
+	if metadataElement != nil {
+		storedElements = append(storedElements, metadataElement)
+	}
+	archive := models.NewUploadElement(sub.scope, sub.packageName, sub.version, mimetypes.ApplicationZip, models.SourceArchive)
+	publication := models.ReleasePublication{PublishedAt: c.timeProvider.Now(), Publisher: sub.publisher, Checksum: archiveChecksum, Signer: signer}
+	if err := recordPublication(ctx, c, storedElements, archive, publication); err != nil {
+		return "", err
+	}
+	c.indexRelease(ctx, sub.scope, sub.packageName, sub.version)
+
+	location, err := url.JoinPath(utils.BaseUrl(c.config), sub.scope, sub.packageName, sub.version)
+	if err != nil {
+		slog.Error("Error", "msg", err)
+	}
+	return location, nil
+}
+
+// preferRespondAsync checks whether the client asked for asynchronous processing (RFC 7240)
+func preferRespondAsync(r *http.Request) bool {
+	for _, value := range r.Header.Values("Prefer") {
+		for _, preference := range strings.Split(value, ",") {
+			token, _, _ := strings.Cut(preference, ";")
+			if strings.EqualFold(strings.TrimSpace(token), "respond-async") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func newSubmissionId() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
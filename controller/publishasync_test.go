@@ -0,0 +1,265 @@
+package controller
+
+import (
+	"OpenSPMRegistry/config"
+	"OpenSPMRegistry/models"
+	"OpenSPMRegistry/utils"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newAsyncController(t *testing.T, r *mockPublishRepo) (*Controller, string) {
+	t.Helper()
+	tempDir := t.TempDir()
+	c := &Controller{
+		repo:         r,
+		config:       config.ServerConfig{Hostname: "localhost", Port: 8080},
+		timeProvider: utils.NewRealTimeProvider(),
+	}
+	c.publishQueue = newPublishQueue(config.AsyncPublishConfig{Enabled: true, Workers: 1, TempDir: tempDir}, c.processSubmission)
+	t.Cleanup(c.Close)
+	return c, tempDir
+}
+
+func getSubmissionStatus(c *Controller, location string) *httptest.ResponseRecorder {
+	id := location[strings.LastIndex(location, "/")+1:]
+	req := httptest.NewRequest(http.MethodGet, "/submissions/"+id, nil)
+	req.SetPathValue("id", id)
+	w := httptest.NewRecorder()
+	c.SubmissionStatusAction(w, req)
+	return w
+}
+
+func Test_PublishAction_PreferRespondAsync_ReturnsAcceptedAndCompletes(t *testing.T) {
+	mockRepo := &mockPublishRepo{}
+	ctrl, tempDir := newAsyncController(t, mockRepo)
+	req := createMultipartRequest(t, map[string][]byte{
+		string(models.SourceArchive): testSourceArchive,
+		string(models.Metadata):      []byte(`{"description":"metadata"}`),
+	})
+	req.Header.Set("Prefer", "respond-async")
+	w := httptest.NewRecorder()
+
+	ctrl.PublishAction(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected status code %d, got %d: %s", http.StatusAccepted, w.Code, w.Body.String())
+	}
+	location := w.Header().Get("Location")
+	if !strings.HasPrefix(location, "http://localhost:8080/submissions/") {
+		t.Errorf("unexpected status location %s", location)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Errorf("expected Retry-After header")
+	}
+
+	// wait for the worker to process the submission
+	ctrl.Close()
+
+	status := getSubmissionStatus(ctrl, location)
+	if status.Code != http.StatusMovedPermanently {
+		t.Fatalf("expected status code %d, got %d", http.StatusMovedPermanently, status.Code)
+	}
+	if got := status.Header().Get("Location"); got != "http://localhost:8080/scope/package/1.0.0" {
+		t.Errorf("unexpected release location %s", got)
+	}
+	if string(mockRepo.storedFiles["scope.package-1.0.0.zip"]) != string(testSourceArchive) || string(mockRepo.storedFiles["metadata.json"]) != `{"description":"metadata"}` {
+		t.Errorf("expected parts to be stored, got %v", mockRepo.storedFiles)
+	}
+	if entries, _ := os.ReadDir(tempDir); len(entries) != 0 {
+		t.Errorf("expected spooled files to be removed, found %d", len(entries))
+	}
+}
+
+func Test_PublishAction_PreferRespondAsync_FailedSubmission_ReportsProblem(t *testing.T) {
+	mockRepo := &mockPublishRepo{}
+	ctrl, _ := newAsyncController(t, mockRepo)
+	ctrl.config.PackageCollections.RequirePackageJson = true
+	req := createMultipartRequest(t, map[string][]byte{
+		string(models.SourceArchive): testSourceArchive,
+	})
+	req.Header.Set("Prefer", "respond-async")
+	w := httptest.NewRecorder()
+
+	ctrl.PublishAction(w, req)
+	ctrl.Close()
+
+	status := getSubmissionStatus(ctrl, w.Header().Get("Location"))
+	if status.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status code %d, got %d", http.StatusUnprocessableEntity, status.Code)
+	}
+	if status.Header().Get("Content-Type") != "application/problem+json" || !strings.Contains(status.Body.String(), "Package.json is required") {
+		t.Errorf("expected problem details, got %s", status.Body.String())
+	}
+}
+
+func Test_PublishAction_PreferRespondAsync_ExistingRelease_ReturnsConflict(t *testing.T) {
+	mockRepo := &mockPublishRepo{storedFiles: map[string][]byte{"scope.package-1.0.0.zip": []byte("existing")}}
+	ctrl, tempDir := newAsyncController(t, mockRepo)
+	req := createMultipartRequest(t, map[string][]byte{
+		string(models.SourceArchive): testSourceArchive,
+	})
+	req.Header.Set("Prefer", "respond-async")
+	w := httptest.NewRecorder()
+
+	ctrl.PublishAction(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected status code %d, got %d", http.StatusConflict, w.Code)
+	}
+	if entries, _ := os.ReadDir(tempDir); len(entries) != 0 {
+		t.Errorf("expected no spooled files, found %d", len(entries))
+	}
+}
+
+func Test_PublishAction_PreferRespondAsync_UnsupportedUploadType_ReturnsBadRequest(t *testing.T) {
+	ctrl, _ := newAsyncController(t, &mockPublishRepo{})
+	req := createMultipartRequestWithParts(t, []multipartPart{
+		{name: string(models.SourceArchive), filename: "source-archive.zip", contentType: "application/zip", content: testSourceArchive},
+		{name: "custom-part", filename: "custom.zip", contentType: "application/zip", content: []byte("unexpected")},
+	})
+	req.Header.Set("Prefer", "respond-async")
+	w := httptest.NewRecorder()
+
+	ctrl.PublishAction(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status code %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func Test_PublishAction_PreferRespondAsync_NoSourceArchive_ReturnsError(t *testing.T) {
+	ctrl, _ := newAsyncController(t, &mockPublishRepo{})
+	req := createMultipartRequest(t, map[string][]byte{
+		string(models.Metadata): []byte(`{"description":"metadata"}`),
+	})
+	req.Header.Set("Prefer", "respond-async")
+	w := httptest.NewRecorder()
+
+	ctrl.PublishAction(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected status code %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+}
+
+func Test_PublishAction_PreferRespondAsync_AsyncDisabled_PublishesSynchronously(t *testing.T) {
+	ctrl := &Controller{repo: &mockPublishRepo{}, timeProvider: utils.NewRealTimeProvider()}
+	req := createMultipartRequest(t, map[string][]byte{
+		string(models.SourceArchive): testSourceArchive,
+	})
+	req.Header.Set("Prefer", "respond-async")
+	w := httptest.NewRecorder()
+
+	ctrl.PublishAction(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Errorf("expected status code %d, got %d", http.StatusCreated, w.Code)
+	}
+}
+
+func Test_SubmissionStatusAction_InProgress_ReturnsAccepted(t *testing.T) {
+	release := make(chan struct{})
+	ctrl := &Controller{}
+	ctrl.publishQueue = newPublishQueue(config.AsyncPublishConfig{Workers: 1}, func(s *submission) (string, *publishError) {
+		<-release
+		return "http://localhost/scope/package/1.0.0", nil
+	})
+	defer ctrl.Close()
+	defer close(release)
+
+	if err := ctrl.publishQueue.enqueue(&submission{id: "abc", scope: "scope", packageName: "package", version: "1.0.0"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	w := getSubmissionStatus(ctrl, "abc")
+
+	if w.Code != http.StatusAccepted || w.Header().Get("Retry-After") == "" {
+		t.Errorf("expected 202 with Retry-After, got %d", w.Code)
+	}
+}
+
+func Test_SubmissionStatusAction_UnknownSubmission_ReturnsNotFound(t *testing.T) {
+	for _, ctrl := range []*Controller{{}, {publishQueue: newPublishQueue(config.AsyncPublishConfig{}, nil)}} {
+		w := getSubmissionStatus(ctrl, "unknown")
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected status code %d, got %d", http.StatusNotFound, w.Code)
+		}
+		ctrl.Close()
+	}
+}
+
+func Test_publishQueue_enqueue_SameReleaseInProgressOrQueueFull_ReturnsError(t *testing.T) {
+	release := make(chan struct{})
+	q := newPublishQueue(config.AsyncPublishConfig{Workers: 1, QueueSize: 1}, func(s *submission) (string, *publishError) {
+		<-release
+		return "", nil
+	})
+	defer q.close()
+	defer close(release)
+
+	if err := q.enqueue(&submission{id: "1", scope: "scope", packageName: "a", version: "1.0.0"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := q.enqueue(&submission{id: "2", scope: "scope", packageName: "a", version: "1.0.0"}); err != errPublishInProgress {
+		t.Errorf("expected errPublishInProgress, got %v", err)
+	}
+
+	// first submission is either picked up by the worker or still queued, fill up the queue
+	var err error
+	for i := range 3 {
+		err = q.enqueue(&submission{id: fmt.Sprint("x", i), scope: "scope", packageName: fmt.Sprint("b", i), version: "1.0.0"})
+		if err != nil {
+			break
+		}
+	}
+	if err != errPublishQueueFull {
+		t.Errorf("expected errPublishQueueFull, got %v", err)
+	}
+}
+
+func Test_publishQueue_get_ExpiredSubmission_IsPurged(t *testing.T) {
+	q := newPublishQueue(config.AsyncPublishConfig{Retention: 60}, nil)
+	defer q.close()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	q.timeProvider = utils.NewMockTimeProvider(start)
+	sub := &submission{id: "done", scope: "scope", packageName: "a", version: "1.0.0", status: submissionCompleted}
+	q.submissions[sub.id] = sub
+	q.finish(sub, "location", nil)
+
+	if _, ok := q.get("done"); !ok {
+		t.Fatalf("expected submission to be available within retention")
+	}
+
+	q.timeProvider = utils.NewMockTimeProvider(start.Add(2 * time.Minute))
+	if _, ok := q.get("done"); ok {
+		t.Errorf("expected submission to be purged after retention")
+	}
+}
+
+func Test_preferRespondAsync(t *testing.T) {
+	tests := []struct {
+		prefer   []string
+		expected bool
+	}{
+		{prefer: nil, expected: false},
+		{prefer: []string{"respond-async"}, expected: true},
+		{prefer: []string{"return=minimal, Respond-Async; wait=10"}, expected: true},
+		{prefer: []string{"return=minimal", "respond-async"}, expected: true},
+		{prefer: []string{"wait=10"}, expected: false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPut, "/scope/package/1.0.0", nil)
+		for _, value := range tt.prefer {
+			req.Header.Add("Prefer", value)
+		}
+		if got := preferRespondAsync(req); got != tt.expected {
+			t.Errorf("Prefer %v: expected %v, got %v", tt.prefer, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"OpenSPMRegistry/authorizer"
+	"OpenSPMRegistry/mimetypes"
+	"OpenSPMRegistry/search"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// searchResponse is the body of GET /search
+type searchResponse struct {
+	Total   int            `json:"total"`
+	Page    int            `json:"page"`
+	PerPage int            `json:"perPage"`
+	Results []searchResult `json:"results"`
+}
+
+// searchResult is a matching package with the release it was matched by
+type searchResult struct {
+	Id string `json:"id"`
+	search.Hit
+}
+
+const (
+	defaultSearchPerPage = 20
+	maxSearchPerPage     = 100
+)
+
+// SearchAction searches packages across all readable scopes (GET /search).
+// Query parameters:
+//   - q: terms matched against name, scope, description, keywords, product and target names
+//   - scope: only packages of this scope
+//   - platform: only packages supporting the platform at the given version, e.g. ios:15.0
+//   - toolsVersion: only packages buildable with this Swift tools version, e.g. 5.9
+//   - page, perPage: pagination (default 1 and 20, at most 100 per page)
+func (c *Controller) SearchAction(w http.ResponseWriter, r *http.Request) {
+	printCallInfo("Search", r)
+
+	if c.searchIndex == nil {
+		writeErrorWithStatusCode("search is not enabled", w, http.StatusNotFound)
+		return
+	}
+
+	query, err := c.parseSearchQuery(r)
+	if err != nil {
+		writeErrorWithStatusCode(err.Error(), w, http.StatusBadRequest)
+		return
+	}
+
+	result := c.searchIndex.Search(query)
+	response := searchResponse{
+		Total:   result.Total,
+		Page:    query.Page,
+		PerPage: query.PerPage,
+		Results: make([]searchResult, 0, len(result.Hits)),
+	}
+	for _, hit := range result.Hits {
+		response.Results = append(response.Results, searchResult{Id: fmt.Sprintf("%s.%s", hit.Scope, hit.Name), Hit: hit})
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", mimetypes.ApplicationJson)
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.Error("Error encoding JSON:", "error", err)
+	}
+}
+
+func (c *Controller) parseSearchQuery(r *http.Request) (search.Query, error) {
+	params := r.URL.Query()
+	query := search.Query{
+		Text:         params.Get("q"),
+		Scope:        params.Get("scope"),
+		ToolsVersion: params.Get("toolsVersion"),
+		Page:         1,
+		PerPage:      defaultSearchPerPage,
+	}
+
+	if platform := params.Get("platform"); platform != "" {
+		name, version, _ := strings.Cut(platform, ":")
+		if name == "" {
+			return query, fmt.Errorf("invalid platform filter: %s", platform)
+		}
+		query.Platform, query.PlatformVersion = name, version
+	}
+	if page := params.Get("page"); page != "" {
+		value, err := strconv.Atoi(page)
+		if err != nil || value < 1 {
+			return query, fmt.Errorf("invalid page: %s", page)
+		}
+		query.Page = value
+	}
+	if perPage := params.Get("perPage"); perPage != "" {
+		value, err := strconv.Atoi(perPage)
+		if err != nil || value < 1 || value > maxSearchPerPage {
+			return query, fmt.Errorf("perPage must be between 1 and %d", maxSearchPerPage)
+		}
+		query.PerPage = value
+	}
+
+	principal := authorizer.PrincipalFromContext(r.Context())
+	query.Allowed = func(scope string) bool {
+		return c.authorizer.Allowed(principal, scope, authorizer.Read)
+	}
+	return query, nil
+}
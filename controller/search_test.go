@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"OpenSPMRegistry/authorizer"
+	"OpenSPMRegistry/config"
+	"OpenSPMRegistry/search"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newSearchController() *Controller {
+	index := search.NewIndex()
+	index.Add(&search.Document{Scope: "acme", Name: "network", Version: "1.0.0", Description: "HTTP networking"})
+	index.Add(&search.Document{Scope: "secret", Name: "network-internal", Version: "1.0.0"})
+	return &Controller{searchIndex: index}
+}
+
+func Test_SearchAction_ReturnsRankedResults(t *testing.T) {
+	c := newSearchController()
+	w := httptest.NewRecorder()
+
+	c.SearchAction(w, httptest.NewRequest(http.MethodGet, "/search?q=network&perPage=1", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var response searchResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Total != 2 || response.PerPage != 1 || len(response.Results) != 1 || response.Results[0].Id != "acme.network" {
+		t.Errorf("unexpected response %+v", response)
+	}
+}
+
+func Test_SearchAction_FiltersUnreadableScopes(t *testing.T) {
+	c := newSearchController()
+	c.authorizer = authorizer.NewAuthorizer(config.AuthorizationConfig{
+		Enabled: true,
+		Rules:   []config.AuthorizationRule{{Scopes: []string{"acme"}, Users: []string{"*"}, Permissions: []string{"read"}}},
+	})
+	req := httptest.NewRequest(http.MethodGet, "/search?q=network", nil)
+	req = req.WithContext(authorizer.WithPrincipal(req.Context(), &authorizer.Principal{Name: "alice"}))
+	w := httptest.NewRecorder()
+
+	c.SearchAction(w, req)
+
+	var response searchResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Total != 1 || response.Results[0].Scope != "acme" {
+		t.Errorf("expected only readable scope, got %+v", response)
+	}
+}
+
+func Test_SearchAction_InvalidParameters_ReturnBadRequest(t *testing.T) {
+	c := newSearchController()
+
+	for _, target := range []string{"/search?page=0", "/search?perPage=1000", "/search?platform=:15.0"} {
+		w := httptest.NewRecorder()
+		c.SearchAction(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status code %d for %s, got %d", http.StatusBadRequest, target, w.Code)
+		}
+	}
+}
+
+func Test_SearchAction_NotEnabled_ReturnsNotFound(t *testing.T) {
+	c := &Controller{}
+	w := httptest.NewRecorder()
+
+	c.SearchAction(w, httptest.NewRequest(http.MethodGet, "/search?q=network", nil))
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status code %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"OpenSPMRegistry/authorizer"
+	"OpenSPMRegistry/mimetypes"
+	"OpenSPMRegistry/models"
+	"OpenSPMRegistry/signing"
+	"context"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// SetArchiveVerifier enables verification of source archive signatures on publication
+func (c *Controller) SetArchiveVerifier(verifier *signing.ArchiveVerifier) {
+	c.archiveVerifier = verifier
+}
+
+// checkSignature verifies the source archive signature of a stored release and enforces the
+// scope's require-signed policy. On failure all stored elements are removed again.
+// returns the verified signer, nil if the release is unsigned or signatures are not verified
+func checkSignature(ctx context.Context, c *Controller, storedElements []*models.UploadElement, scope, packageName, version string) (*models.Signer, *publishError) {
+	signatureElement := models.NewUploadElement(scope, packageName, version, mimetypes.ApplicationOctetStream, models.SourceArchiveSignature)
+	required := authorizer.MatchesAnyScope(c.config.Publish.Signatures.RequireSigned, strings.ToLower(scope))
+	if !c.repo.Exists(ctx, signatureElement) {
+		if required {
+			cleanupStoredElements(ctx, c, storedElements, scope, packageName, version)
+			return nil, newPublishError(publishFailureUnsigned, fmt.Sprintf("upload failed, releases of scope %s must be signed", scope), http.StatusUnprocessableEntity)
+		}
+		return nil, nil
+	}
+	if c.archiveVerifier == nil {
+		if required {
+			// any bytes would pass as signature without verification
+			slog.Error("Releases must be signed but signature verification is not enabled", "scope", scope)
+			cleanupStoredElements(ctx, c, storedElements, scope, packageName, version)
+			return nil, newPublishError(publishFailureInvalidSignature, fmt.Sprintf("upload failed, signatures of scope %s cannot be verified", scope), http.StatusInternalServerError)
+		}
+		return nil, nil
+	}
+
+	cert, err := verifySignature(ctx, c, signatureElement)
+	if err != nil {
+		slog.Error("Error verifying signature:", "scope", scope, "package", packageName, "version", version, "error", err)
+		cleanupStoredElements(ctx, c, storedElements, scope, packageName, version)
+		pubErr := newPublishError(publishFailureInvalidSignature, fmt.Sprintf("upload failed, signature of %s.%s@%s is invalid", scope, packageName, version), http.StatusUnprocessableEntity)
+		pubErr.failures = []string{err.Error()}
+		return nil, pubErr
+	}
+	slog.Info("Signature verified", "scope", scope, "package", packageName, "version", version, "signer", cert.Subject.CommonName)
+	return signing.NewSigner(cert), nil
+}
+
+// verifySignature reads the stored signature and source archive and verifies them
+// returns (certificate of the signer, error)
+func verifySignature(ctx context.Context, c *Controller, signatureElement *models.UploadElement) (*x509.Certificate, error) {
+	signatureReader, err := c.repo.GetReader(ctx, signatureElement)
+	if err != nil {
+		return nil, fmt.Errorf("reading signature: %w", err)
+	}
+	signature, err := io.ReadAll(signatureReader)
+	_ = signatureReader.Close()
+	if err != nil {
+		return nil, fmt.Errorf("reading signature: %w", err)
+	}
+
+	archiveElement := models.NewUploadElement(signatureElement.Scope, signatureElement.Name, signatureElement.Version, mimetypes.ApplicationZip, models.SourceArchive)
+	archive, err := c.repo.GetReader(ctx, archiveElement)
+	if err != nil {
+		return nil, fmt.Errorf("reading source archive: %w", err)
+	}
+	defer func() { _ = archive.Close() }()
+
+	return c.archiveVerifier.Verify(archive, signature)
+}
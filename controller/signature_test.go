@@ -0,0 +1,160 @@
+package controller
+
+import (
+	"OpenSPMRegistry/config"
+	"OpenSPMRegistry/mimetypes"
+	"OpenSPMRegistry/models"
+	"OpenSPMRegistry/repo"
+	"OpenSPMRegistry/repo/files"
+	"OpenSPMRegistry/signing"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestArchiveVerifier creates a verifier trusting a freshly generated root certificate
+func newTestArchiveVerifier(t *testing.T) *signing.ArchiveVerifier {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Root CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "roots.pem")
+	if err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	verifier, err := signing.NewArchiveVerifier(config.SignatureConfig{TrustedRoots: []string{file}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return verifier
+}
+
+func Test_PublishAction_UnsignedReleaseInRequiredScope_ReturnsUnprocessableEntity(t *testing.T) {
+	r := files.NewFileRepo(t.TempDir())
+	ctrl := NewController(config.ServerConfig{Publish: config.PublishConfig{
+		Signatures: config.SignatureConfig{RequireSigned: []string{"scope"}},
+	}}, r)
+	req := createMultipartRequest(t, map[string][]byte{
+		string(models.SourceArchive): testSourceArchive,
+	})
+	w := httptest.NewRecorder()
+
+	ctrl.PublishAction(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status code %d, got %d: %s", http.StatusUnprocessableEntity, w.Code, w.Body.String())
+	}
+	if r.Exists(context.Background(), models.NewUploadElement("scope", "package", "1.0.0", mimetypes.ApplicationZip, models.SourceArchive)) {
+		t.Errorf("expected unsigned source archive to be removed")
+	}
+}
+
+func Test_PublishAction_UnsignedReleaseInOtherScope_ReturnsCreated(t *testing.T) {
+	ctrl := NewController(config.ServerConfig{Publish: config.PublishConfig{
+		Signatures: config.SignatureConfig{RequireSigned: []string{"acme"}},
+	}}, files.NewFileRepo(t.TempDir()))
+	req := createMultipartRequest(t, map[string][]byte{
+		string(models.SourceArchive): testSourceArchive,
+	})
+	w := httptest.NewRecorder()
+
+	ctrl.PublishAction(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Errorf("expected status code %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+}
+
+func Test_PublishAction_InvalidSignature_ReturnsUnprocessableEntityWithFailures(t *testing.T) {
+	r := files.NewFileRepo(t.TempDir())
+	ctrl := NewController(config.ServerConfig{}, r)
+	ctrl.SetArchiveVerifier(newTestArchiveVerifier(t))
+	req := createMultipartRequest(t, map[string][]byte{
+		string(models.SourceArchive):          testSourceArchive,
+		string(models.SourceArchiveSignature): []byte("signature data"),
+	})
+	w := httptest.NewRecorder()
+
+	ctrl.PublishAction(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status code %d, got %d: %s", http.StatusUnprocessableEntity, w.Code, w.Body.String())
+	}
+	var problem struct {
+		Errors []string `json:"errors"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&problem); err != nil || len(problem.Errors) != 1 || !strings.Contains(problem.Errors[0], "not a valid CMS") {
+		t.Errorf("expected problem listing the invalid signature, got %+v (%v)", problem, err)
+	}
+	if r.Exists(context.Background(), models.NewUploadElement("scope", "package", "1.0.0", mimetypes.ApplicationOctetStream, models.SourceArchiveSignature)) {
+		t.Errorf("expected invalid signature to be removed")
+	}
+}
+
+func Test_PublishAction_SignedReleaseInRequiredScopeWithoutVerifier_ReturnsInternalServerError(t *testing.T) {
+	r := files.NewFileRepo(t.TempDir())
+	ctrl := NewController(config.ServerConfig{Publish: config.PublishConfig{
+		Signatures: config.SignatureConfig{RequireSigned: []string{"scope"}},
+	}}, r)
+	req := createMultipartRequest(t, map[string][]byte{
+		string(models.SourceArchive):          testSourceArchive,
+		string(models.SourceArchiveSignature): []byte("signature data"),
+	})
+	w := httptest.NewRecorder()
+
+	ctrl.PublishAction(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected status code %d, got %d: %s", http.StatusInternalServerError, w.Code, w.Body.String())
+	}
+	if r.Exists(context.Background(), models.NewUploadElement("scope", "package", "1.0.0", mimetypes.ApplicationZip, models.SourceArchive)) {
+		t.Errorf("expected unverified release to be removed")
+	}
+}
+
+func Test_PublishAction_UnverifiedSignature_RecordsNoSigner(t *testing.T) {
+	r := files.NewFileRepo(t.TempDir())
+	ctrl := NewController(config.ServerConfig{}, r)
+	req := createMultipartRequest(t, map[string][]byte{
+		string(models.SourceArchive):          testSourceArchive,
+		string(models.SourceArchiveSignature): []byte("signature data"),
+	})
+	w := httptest.NewRecorder()
+
+	ctrl.PublishAction(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status code %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	publication, err := repo.ReadPublication(context.Background(), r, "scope", "package", "1.0.0")
+	if err != nil || publication.Signer != nil {
+		t.Errorf("expected no signer for an unverified signature, got %+v (%v)", publication, err)
+	}
+}
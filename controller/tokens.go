@@ -0,0 +1,198 @@
+package controller
+
+import (
+	"OpenSPMRegistry/audit"
+	"OpenSPMRegistry/authorizer"
+	"OpenSPMRegistry/mimetypes"
+	"OpenSPMRegistry/tokens"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path"
+	"time"
+)
+
+// createTokenRequest is the body of POST /tokens
+type createTokenRequest struct {
+	Name        string    `json:"name"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+	Scopes      []string  `json:"scopes"`
+	Permissions []string  `json:"permissions"`
+}
+
+// tokenResponse describes a personal access token, the secret is only set once on creation
+type tokenResponse struct {
+	Id          string     `json:"id"`
+	Name        string     `json:"name"`
+	Owner       string     `json:"owner"`
+	Token       string     `json:"token,omitempty"`
+	Scopes      []string   `json:"scopes,omitempty"`
+	Permissions []string   `json:"permissions,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	ExpiresAt   time.Time  `json:"expiresAt"`
+	LastUsedAt  *time.Time `json:"lastUsedAt,omitempty"`
+}
+
+const maxTokenNameLength = 100
+
+// SetTokenStore enables the personal access token endpoints backed by the given store
+func (c *Controller) SetTokenStore(store *tokens.Store) {
+	c.tokenStore = store
+}
+
+// CreateTokenAction issues a personal access token for the authenticated user (POST /tokens).
+// The secret is only returned in this response.
+func (c *Controller) CreateTokenAction(w http.ResponseWriter, r *http.Request) {
+	printCallInfo("CreateToken", r)
+
+	principal := c.tokenOwner(w, r)
+	if principal == nil {
+		return // error already written
+	}
+
+	var request createTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeErrorWithStatusCode("invalid token request", w, http.StatusBadRequest)
+		return
+	}
+	if request.Name == "" || len(request.Name) > maxTokenNameLength {
+		writeErrorWithStatusCode(fmt.Sprintf("token name is required and must not exceed %d characters", maxTokenNameLength), w, http.StatusBadRequest)
+		return
+	}
+	for _, scope := range request.Scopes {
+		if _, err := path.Match(scope, ""); err != nil || scope == "" {
+			writeErrorWithStatusCode(fmt.Sprintf("invalid scope: %s", scope), w, http.StatusBadRequest)
+			return
+		}
+	}
+	for _, permission := range request.Permissions {
+		switch authorizer.Permission(permission) {
+		case authorizer.Read, authorizer.Publish, authorizer.Delete, authorizer.Admin:
+		default:
+			writeErrorWithStatusCode(fmt.Sprintf("invalid permission: %s", permission), w, http.StatusBadRequest)
+			return
+		}
+	}
+
+	token, secret, err := c.tokenStore.Create(principal.Name, principal.Groups, request.Name, request.Scopes, request.Permissions, request.ExpiresAt)
+	if errors.Is(err, tokens.ErrInvalidExpiry) {
+		writeErrorWithStatusCode(err.Error(), w, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		slog.Error("Error creating token:", "error", err)
+		writeError("error creating token", w)
+		return
+	}
+
+	slog.Info("Token created", "owner", token.Owner, "token", token.Id)
+	if entry := audit.FromContext(r.Context()); entry != nil {
+		entry.Subject = token.Id
+	}
+	response := newTokenResponse(*token)
+	response.Token = secret
+	writeTokenJson(w, http.StatusCreated, response)
+}
+
+// ListTokensAction lists the personal access tokens of the authenticated user (GET /tokens).
+// Administrators see the tokens of all users.
+func (c *Controller) ListTokensAction(w http.ResponseWriter, r *http.Request) {
+	printCallInfo("ListTokens", r)
+
+	principal := c.tokenOwner(w, r)
+	if principal == nil {
+		return // error already written
+	}
+
+	owner := principal.Name
+	if c.isTokenAdmin(principal) {
+		owner = ""
+	}
+	list := c.tokenStore.List(owner)
+	response := make([]tokenResponse, 0, len(list))
+	for _, token := range list {
+		response = append(response, newTokenResponse(token))
+	}
+	writeTokenJson(w, http.StatusOK, map[string]any{"tokens": response})
+}
+
+// RevokeTokenAction revokes a personal access token (DELETE /tokens/{id}).
+// Users can revoke their own tokens, administrators any token.
+func (c *Controller) RevokeTokenAction(w http.ResponseWriter, r *http.Request) {
+	printCallInfo("RevokeToken", r)
+
+	principal := c.tokenOwner(w, r)
+	if principal == nil {
+		return // error already written
+	}
+
+	id := r.PathValue("id")
+	token, err := c.tokenStore.Get(id)
+	if err != nil || (token.Owner != principal.Name && !c.isTokenAdmin(principal)) {
+		writeErrorWithStatusCode(fmt.Sprintf("token %s not found", id), w, http.StatusNotFound)
+		return
+	}
+
+	if err := c.tokenStore.Revoke(id); err != nil {
+		if errors.Is(err, tokens.ErrTokenNotFound) {
+			writeErrorWithStatusCode(fmt.Sprintf("token %s not found", id), w, http.StatusNotFound)
+			return
+		}
+		slog.Error("Error revoking token:", "error", err)
+		writeError("error revoking token", w)
+		return
+	}
+
+	slog.Info("Token revoked", "owner", token.Owner, "token", token.Id, "by", principal.Name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// tokenOwner returns the principal tokens are managed for.
+// Tokens can only be managed with the credentials of the primary authenticator, not with another token.
+func (c *Controller) tokenOwner(w http.ResponseWriter, r *http.Request) *authorizer.Principal {
+	if c.tokenStore == nil {
+		writeErrorWithStatusCode("personal access tokens are not enabled", w, http.StatusNotFound)
+		return nil
+	}
+	principal := authorizer.PrincipalFromContext(r.Context())
+	if principal == nil || principal.Name == "" {
+		writeErrorWithStatusCode("tokens can only be managed by authenticated users", w, http.StatusForbidden)
+		return nil
+	}
+	if principal.TokenId != "" {
+		writeErrorWithStatusCode("tokens can not be managed with a personal access token", w, http.StatusForbidden)
+		return nil
+	}
+	return principal
+}
+
+// isTokenAdmin reports whether the principal may manage the tokens of other users,
+// which requires the admin permission on all scopes (authorization enabled)
+func (c *Controller) isTokenAdmin(principal *authorizer.Principal) bool {
+	return c.authorizer != nil && c.authorizer.Allowed(principal, authorizer.AnyScope, authorizer.Admin)
+}
+
+func newTokenResponse(token tokens.Token) tokenResponse {
+	return tokenResponse{
+		Id:          token.Id,
+		Name:        token.Name,
+		Owner:       token.Owner,
+		Scopes:      token.Scopes,
+		Permissions: token.Permissions,
+		CreatedAt:   token.CreatedAt,
+		ExpiresAt:   token.ExpiresAt,
+		LastUsedAt:  token.LastUsedAt,
+	}
+}
+
+func writeTokenJson(w http.ResponseWriter, status int, value any) {
+	header := w.Header()
+	header.Set("Content-Type", mimetypes.ApplicationJson)
+	header.Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		slog.Error("Error encoding JSON:", "error", err)
+	}
+}
@@ -0,0 +1,174 @@
+package controller
+
+import (
+	"OpenSPMRegistry/authorizer"
+	"OpenSPMRegistry/config"
+	"OpenSPMRegistry/tokens"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTokenController(t *testing.T) (*Controller, *tokens.Store) {
+	t.Helper()
+	store, err := tokens.NewStore(config.TokensConfig{Path: filepath.Join(t.TempDir(), "tokens.json")})
+	if err != nil {
+		t.Fatalf("failed to create token store: %v", err)
+	}
+	c := &Controller{
+		authorizer: authorizer.NewAuthorizer(config.AuthorizationConfig{
+			Enabled: true,
+			Rules:   []config.AuthorizationRule{{Scopes: []string{"*"}, Users: []string{"admin"}, Permissions: []string{"admin"}}},
+		}),
+	}
+	c.SetTokenStore(store)
+	return c, store
+}
+
+func newTokenRequest(method string, target string, body string, principal *authorizer.Principal) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if principal != nil {
+		req = req.WithContext(authorizer.WithPrincipal(req.Context(), principal))
+	}
+	return req
+}
+
+func Test_CreateTokenAction_ReturnsSecretOnce(t *testing.T) {
+	c, store := newTokenController(t)
+	body := `{"name":"ci","scopes":["acme"],"permissions":["publish"]}`
+	w := httptest.NewRecorder()
+
+	c.CreateTokenAction(w, newTokenRequest(http.MethodPost, "/tokens", body, &authorizer.Principal{Name: "alice"}))
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status code %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var response tokenResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !strings.HasPrefix(response.Token, tokens.Prefix) || response.Owner != "alice" || response.Name != "ci" {
+		t.Errorf("unexpected response %+v", response)
+	}
+	if _, err := store.Verify(response.Token); err != nil {
+		t.Errorf("expected returned secret to be valid, got %v", err)
+	}
+
+	// the secret is never listed
+	w = httptest.NewRecorder()
+	c.ListTokensAction(w, newTokenRequest(http.MethodGet, "/tokens", "", &authorizer.Principal{Name: "alice"}))
+	if strings.Contains(w.Body.String(), response.Token) {
+		t.Errorf("expected secret not to be listed")
+	}
+	if !strings.Contains(w.Body.String(), `"lastUsedAt"`) {
+		t.Errorf("expected last usage to be listed, got %s", w.Body.String())
+	}
+}
+
+func Test_CreateTokenAction_InvalidRequests_ReturnBadRequest(t *testing.T) {
+	c, _ := newTokenController(t)
+	principal := &authorizer.Principal{Name: "alice"}
+
+	for _, body := range []string{
+		`{invalid`,
+		`{"name":""}`,
+		`{"name":"ci","permissions":["everything"]}`,
+		`{"name":"ci","scopes":["[acme"]}`,
+		`{"name":"ci","expiresAt":"2000-01-01T00:00:00Z"}`,
+	} {
+		w := httptest.NewRecorder()
+		c.CreateTokenAction(w, newTokenRequest(http.MethodPost, "/tokens", body, principal))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status code %d for %s, got %d", http.StatusBadRequest, body, w.Code)
+		}
+	}
+}
+
+func Test_CreateTokenAction_WithToken_ReturnsForbidden(t *testing.T) {
+	c, _ := newTokenController(t)
+	w := httptest.NewRecorder()
+
+	c.CreateTokenAction(w, newTokenRequest(http.MethodPost, "/tokens", `{"name":"ci"}`, &authorizer.Principal{Name: "alice", TokenId: "abc"}))
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected status code %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+func Test_CreateTokenAction_Anonymous_ReturnsForbidden(t *testing.T) {
+	c, _ := newTokenController(t)
+	w := httptest.NewRecorder()
+
+	c.CreateTokenAction(w, newTokenRequest(http.MethodPost, "/tokens", `{"name":"ci"}`, nil))
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected status code %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+func Test_TokenActions_NotEnabled_ReturnNotFound(t *testing.T) {
+	c := &Controller{}
+	w := httptest.NewRecorder()
+
+	c.ListTokensAction(w, newTokenRequest(http.MethodGet, "/tokens", "", &authorizer.Principal{Name: "alice"}))
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status code %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func Test_ListTokensAction_OwnTokensOnlyUnlessAdmin(t *testing.T) {
+	c, store := newTokenController(t)
+	_, _, _ = store.Create("alice", nil, "a", nil, nil, time.Time{})
+	_, _, _ = store.Create("bob", nil, "b", nil, nil, time.Time{})
+
+	list := func(name string) []tokenResponse {
+		w := httptest.NewRecorder()
+		c.ListTokensAction(w, newTokenRequest(http.MethodGet, "/tokens", "", &authorizer.Principal{Name: name}))
+		var response struct {
+			Tokens []tokenResponse `json:"tokens"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return response.Tokens
+	}
+
+	if tokens := list("alice"); len(tokens) != 1 || tokens[0].Owner != "alice" {
+		t.Errorf("expected alice's token only, got %v", tokens)
+	}
+	if tokens := list("admin"); len(tokens) != 2 {
+		t.Errorf("expected admin to see all tokens, got %v", tokens)
+	}
+}
+
+func Test_RevokeTokenAction_OwnerOrAdmin(t *testing.T) {
+	c, store := newTokenController(t)
+	aliceToken, _, _ := store.Create("alice", nil, "a", nil, nil, time.Time{})
+	bobToken, _, _ := store.Create("bob", nil, "b", nil, nil, time.Time{})
+
+	revoke := func(id string, name string) int {
+		req := newTokenRequest(http.MethodDelete, "/tokens/"+id, "", &authorizer.Principal{Name: name})
+		req.SetPathValue("id", id)
+		w := httptest.NewRecorder()
+		c.RevokeTokenAction(w, req)
+		return w.Code
+	}
+
+	if code := revoke(bobToken.Id, "alice"); code != http.StatusNotFound {
+		t.Errorf("expected foreign token to be hidden, got %d", code)
+	}
+	if code := revoke(aliceToken.Id, "alice"); code != http.StatusNoContent {
+		t.Errorf("expected owner to revoke token, got %d", code)
+	}
+	if code := revoke(bobToken.Id, "admin"); code != http.StatusNoContent {
+		t.Errorf("expected admin to revoke token, got %d", code)
+	}
+	if code := revoke(aliceToken.Id, "alice"); code != http.StatusNotFound {
+		t.Errorf("expected revoked token to be gone, got %d", code)
+	}
+}
@@ -111,22 +111,16 @@ func listElements(r *http.Request, w http.ResponseWriter, c *Controller, scope s
 		writeError(fmt.Sprintf("error listing package %s.%s", scope, packageName), w)
 		return nil, err
 	}
+	elements = c.upstreamVersions(ctx, scope, packageName, elements)
 	// Spec 4.1: "Otherwise, a server SHOULD respond with 404 (Not Found)" when package has no releases
 	if len(elements) == 0 {
 		writeErrorWithStatusCode(fmt.Sprintf("error package %s.%s was not found", scope, packageName), w, http.StatusNotFound)
 		return nil, fmt.Errorf("package %s.%s not found", scope, packageName)
 	}
 
-	slices.SortFunc(elements, func(a models.ListElement, b models.ListElement) int {
-		v1, err := models.ParseVersion(a.Version)
-		if err != nil {
-			return 0
-		}
-		v2, err := models.ParseVersion(b.Version)
-		if err != nil {
-			return 0
-		}
-		return v2.Compare(v1)
+	// highest SemVer precedence first, invalid versions at the end
+	slices.SortStableFunc(elements, func(a models.ListElement, b models.ListElement) int {
+		return models.CompareVersions(b.Version, a.Version)
 	})
 	return elements, nil
 }
@@ -198,6 +192,11 @@ func writeError(msg string, w http.ResponseWriter) {
 }
 
 func writeErrorWithStatusCode(msg string, w http.ResponseWriter, status int) {
+	writeErrorsWithStatusCode(msg, nil, w, status)
+}
+
+// writeErrorsWithStatusCode writes a problem listing all errors found validating the request
+func writeErrorsWithStatusCode(msg string, errors []string, w http.ResponseWriter, status int) {
 	header := w.Header()
 	header.Set("Content-Type", "application/problem+json")
 	header.Set("Content-Language", "en")
@@ -205,6 +204,7 @@ func writeErrorWithStatusCode(msg string, w http.ResponseWriter, status int) {
 	w.WriteHeader(status)
 	err := json.NewEncoder(w).Encode(responses.Error{
 		Detail: msg,
+		Errors: errors,
 	})
 	if err != nil {
 		slog.Error("Error writing response:", "error", err)
@@ -257,6 +257,24 @@ func Test_ListElements_MultipleElements_ReturnsElements(t *testing.T) {
 	}
 }
 
+func Test_ListElements_Prereleases_ReturnsSemVerOrder(t *testing.T) {
+	w := httptest.NewRecorder()
+	var elements []models.ListElement
+	for _, version := range []string{"1.0.0-rc.2", "1.0.0", "1.0.0-rc.10", "1.0.0-beta-2+build.1", "0.9.0"} {
+		elements = append(elements, models.ListElement{Scope: "testScope", PackageName: "testPackage", Version: version})
+	}
+	c := &Controller{repo: &MockListElementsRepo{elements: elements}}
+
+	result, _ := listElements(httptest.NewRequest("GET", "/", nil), w, c, "testScope", "testPackage")
+
+	expected := []string{"1.0.0", "1.0.0-rc.10", "1.0.0-rc.2", "1.0.0-beta-2+build.1", "0.9.0"}
+	for i, element := range result {
+		if element.Version != expected[i] {
+			t.Errorf("expected version %s at %d, got %s", expected[i], i, element.Version)
+		}
+	}
+}
+
 func Test_AddLinkHeaders_EmptyElements_DoesNotSetHeader(t *testing.T) {
 	header := http.Header{}
 	c := &Controller{}
@@ -0,0 +1,374 @@
+package events
+
+import (
+	"OpenSPMRegistry/authorizer"
+	"OpenSPMRegistry/config"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Dispatcher delivers events to the configured webhooks.
+// Every delivery is recorded in the delivery log before it is attempted and retried with exponential backoff
+// until it succeeds or the maximum number of attempts is reached. Pending deliveries are resumed when the
+// dispatcher is created again, e.g. after a restart.
+type Dispatcher struct {
+	hooks       map[string]config.WebhookConfig
+	order       []string // hook names in configuration order
+	log         *DeliveryLog
+	client      *http.Client
+	maxAttempts int
+	backoff     time.Duration
+	retention   time.Duration
+
+	queue  chan *Delivery
+	stop   chan struct{}
+	wg     sync.WaitGroup
+	mu     sync.Mutex
+	timers map[string]*time.Timer
+	closed bool
+}
+
+const (
+	// SignatureHeader carries the HMAC-SHA256 of the payload with the webhook secret ("sha256=<hex>")
+	SignatureHeader = "X-Registry-Signature-256"
+	// EventHeader carries the event type
+	EventHeader = "X-Registry-Event"
+	// DeliveryHeader carries the delivery id, identical for all attempts of a delivery
+	DeliveryHeader = "X-Registry-Delivery"
+
+	defaultDeliveryLog    = "webhooks.db"
+	defaultMaxAttempts    = 8
+	defaultInitialBackoff = 5  // seconds
+	defaultTimeout        = 10 // seconds
+	defaultRetention      = 30 // days
+	maxBackoff            = time.Hour
+	deliveryWorkers       = 2
+	deliveryQueueSize     = 256
+	pruneInterval         = time.Hour
+	// maxResponseBody limits how much of a webhook response is read
+	maxResponseBody = 4096
+)
+
+var errWebhookRemoved = errors.New("webhook is no longer configured")
+
+// NewDispatcher validates the configured webhooks, opens the delivery log and resumes pending deliveries
+func NewDispatcher(cfg config.WebhooksConfig) (*Dispatcher, error) {
+	hooks := make(map[string]config.WebhookConfig, len(cfg.Hooks))
+	var order []string
+	for _, hook := range cfg.Hooks {
+		if hook.Name == "" {
+			hook.Name = hook.URL
+		}
+		if err := validateWebhook(hook); err != nil {
+			return nil, fmt.Errorf("webhook %s: %w", hook.Name, err)
+		}
+		if _, ok := hooks[hook.Name]; ok {
+			return nil, fmt.Errorf("webhook %s is configured twice", hook.Name)
+		}
+		hooks[hook.Name] = hook
+		order = append(order, hook.Name)
+	}
+
+	path := cfg.DeliveryLog
+	if path == "" {
+		path = defaultDeliveryLog
+	}
+	log, err := OpenDeliveryLog(path)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Dispatcher{
+		hooks:       hooks,
+		order:       order,
+		log:         log,
+		client:      &http.Client{Timeout: time.Duration(orDefault(cfg.Timeout, defaultTimeout)) * time.Second},
+		maxAttempts: orDefault(cfg.MaxAttempts, defaultMaxAttempts),
+		backoff:     time.Duration(orDefault(cfg.InitialBackoff, defaultInitialBackoff)) * time.Second,
+		retention:   time.Duration(orDefault(cfg.Retention, defaultRetention)) * 24 * time.Hour,
+		queue:       make(chan *Delivery, deliveryQueueSize),
+		stop:        make(chan struct{}),
+		timers:      make(map[string]*time.Timer),
+	}
+	d.prune()
+
+	pending, err := log.List(DeliveryPending, 0)
+	if err != nil {
+		_ = log.Close()
+		return nil, err
+	}
+	for _, delivery := range pending {
+		var wait time.Duration
+		if delivery.NextAttemptAt != nil {
+			wait = time.Until(*delivery.NextAttemptAt)
+		}
+		d.schedule(delivery, wait)
+	}
+	if len(pending) > 0 {
+		slog.Info("Resuming pending webhook deliveries", "count", len(pending))
+	}
+
+	for range deliveryWorkers {
+		d.wg.Add(1)
+		go d.work()
+	}
+	d.wg.Add(1)
+	go d.pruneRegularly()
+	return d, nil
+}
+
+// Emit records a delivery of the event for every webhook subscribed to it and queues them.
+// It does not wait for the deliveries.
+func (d *Dispatcher) Emit(event Event) {
+	for _, name := range d.order {
+		hook := d.hooks[name]
+		if !subscribed(hook, event) {
+			continue
+		}
+		now := time.Now().UTC()
+		delivery := &Delivery{
+			Id:        newDeliveryId(now),
+			Webhook:   hook.Name,
+			URL:       hook.URL,
+			Event:     event,
+			Status:    DeliveryPending,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if d.isClosed() {
+			slog.Warn("Webhook dispatcher closed, dropping event", "event", event.Type, "webhook", hook.Name)
+			return
+		}
+		if err := d.log.Put(delivery); err != nil {
+			slog.Error("Error recording webhook delivery:", "webhook", hook.Name, "error", err)
+		}
+		d.enqueue(delivery)
+	}
+}
+
+// Deliveries returns up to limit recorded deliveries with status (all if empty), newest first
+func (d *Dispatcher) Deliveries(status string, limit int) ([]*Delivery, error) {
+	return d.log.List(status, limit)
+}
+
+// Close stops delivering and closes the delivery log.
+// Deliveries not finished yet stay pending and are resumed by the next dispatcher.
+func (d *Dispatcher) Close() error {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return nil
+	}
+	d.closed = true
+	for _, timer := range d.timers {
+		timer.Stop()
+	}
+	close(d.stop)
+	d.mu.Unlock()
+
+	d.wg.Wait()
+	return d.log.Close()
+}
+
+// Sign returns the value of the signature header for a payload signed with secret
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (d *Dispatcher) isClosed() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.closed
+}
+
+// enqueue hands the delivery to the workers, or retries later if the queue is full
+func (d *Dispatcher) enqueue(delivery *Delivery) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return
+	}
+	select {
+	case d.queue <- delivery:
+	default:
+		d.scheduleLocked(delivery, d.backoff)
+	}
+}
+
+// schedule enqueues the delivery after wait
+func (d *Dispatcher) schedule(delivery *Delivery, wait time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.scheduleLocked(delivery, wait)
+}
+
+func (d *Dispatcher) scheduleLocked(delivery *Delivery, wait time.Duration) {
+	if d.closed {
+		return
+	}
+	d.timers[delivery.Id] = time.AfterFunc(max(wait, 0), func() {
+		d.mu.Lock()
+		delete(d.timers, delivery.Id)
+		d.mu.Unlock()
+		d.enqueue(delivery)
+	})
+}
+
+func (d *Dispatcher) work() {
+	defer d.wg.Done()
+	for {
+		select {
+		case <-d.stop:
+			return
+		case delivery := <-d.queue:
+			d.deliver(delivery)
+		}
+	}
+}
+
+// retryWait is the time to wait after the failed attempts, doubling from the configured backoff up to maxBackoff.
+// Doubled step by step, shifting by the attempts would overflow with many attempts configured.
+func (d *Dispatcher) retryWait(attempts int) time.Duration {
+	wait := d.backoff
+	for i := 1; i < attempts && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, maxBackoff)
+}
+
+// deliver attempts the delivery once, records the outcome and schedules a retry if needed
+func (d *Dispatcher) deliver(delivery *Delivery) {
+	statusCode, retry, err := d.post(delivery)
+	delivery.Attempts++
+	delivery.StatusCode = statusCode
+	delivery.UpdatedAt = time.Now().UTC()
+	delivery.NextAttemptAt = nil
+	delivery.Error = ""
+
+	var wait time.Duration
+	switch {
+	case err == nil:
+		delivery.Status = DeliveryDelivered
+	case retry && delivery.Attempts < d.maxAttempts:
+		delivery.Error = err.Error()
+		wait = d.retryWait(delivery.Attempts)
+		next := delivery.UpdatedAt.Add(wait)
+		delivery.NextAttemptAt = &next
+		slog.Warn("Webhook delivery failed, retrying", "webhook", delivery.Webhook, "event", delivery.Event.Type, "attempt", delivery.Attempts, "retryIn", wait, "error", err)
+	default:
+		delivery.Status = DeliveryFailed
+		delivery.Error = err.Error()
+		slog.Error("Webhook delivery failed:", "webhook", delivery.Webhook, "event", delivery.Event.Type, "attempts", delivery.Attempts, "error", err)
+	}
+
+	if err := d.log.Put(delivery); err != nil {
+		slog.Error("Error recording webhook delivery:", "webhook", delivery.Webhook, "error", err)
+	}
+	if delivery.Status == DeliveryPending {
+		d.schedule(delivery, wait)
+	}
+}
+
+// post sends the event to the webhook of the delivery
+// returns (HTTP status code if any, whether a retry may succeed, error)
+func (d *Dispatcher) post(delivery *Delivery) (int, bool, error) {
+	hook, ok := d.hooks[delivery.Webhook]
+	if !ok {
+		return 0, false, errWebhookRemoved
+	}
+	payload, err := json.Marshal(delivery.Event)
+	if err != nil {
+		return 0, false, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "OpenSPMRegistry-Webhook")
+	req.Header.Set(EventHeader, delivery.Event.Type)
+	req.Header.Set(DeliveryHeader, delivery.Id)
+	if hook.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(hook.Secret, payload))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, true, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp.StatusCode, false, nil
+	}
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests
+	return resp.StatusCode, retry, fmt.Errorf("webhook responded with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+func (d *Dispatcher) prune() {
+	removed, err := d.log.Prune(time.Now().Add(-d.retention))
+	if err != nil {
+		slog.Error("Error pruning webhook delivery log:", "error", err)
+	} else if removed > 0 {
+		slog.Debug("Pruned webhook delivery log", "removed", removed)
+	}
+}
+
+func (d *Dispatcher) pruneRegularly() {
+	defer d.wg.Done()
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-ticker.C:
+			d.prune()
+		}
+	}
+}
+
+// subscribed checks whether the webhook wants the event by type and scope
+func subscribed(hook config.WebhookConfig, event Event) bool {
+	if len(hook.Events) > 0 && !slices.Contains(hook.Events, event.Type) {
+		return false
+	}
+	return len(hook.Scopes) == 0 || authorizer.MatchesAnyScope(hook.Scopes, event.Scope)
+}
+
+func validateWebhook(hook config.WebhookConfig) error {
+	u, err := url.Parse(hook.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid url %q", hook.URL)
+	}
+	for _, eventType := range hook.Events {
+		if !slices.Contains(Types, eventType) {
+			return fmt.Errorf("unknown event %q, expected one of %s", eventType, strings.Join(Types, ", "))
+		}
+	}
+	return nil
+}
+
+func orDefault(value int, defaultValue int) int {
+	if value <= 0 {
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,205 @@
+package events
+
+import (
+	"OpenSPMRegistry/config"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// webhookReceiver records the requests of a webhook and answers with the given status codes in order
+// (200 once they are used up)
+type webhookReceiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rec *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.requests = append(rec.requests, r)
+	rec.bodies = append(rec.bodies, body)
+	if len(rec.statuses) > 0 {
+		w.WriteHeader(rec.statuses[0])
+		rec.statuses = rec.statuses[1:]
+	}
+}
+
+func (rec *webhookReceiver) count() int {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return len(rec.requests)
+}
+
+func newTestDispatcher(t *testing.T, hooks ...config.WebhookConfig) *Dispatcher {
+	t.Helper()
+	d, err := NewDispatcher(config.WebhooksConfig{
+		DeliveryLog: filepath.Join(t.TempDir(), "webhooks.db"),
+		MaxAttempts: 3,
+		Hooks:       hooks,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.backoff = 10 * time.Millisecond
+	t.Cleanup(func() { _ = d.Close() })
+	return d
+}
+
+// waitForDelivery waits until the only recorded delivery is finished
+func waitForDelivery(t *testing.T, d *Dispatcher) *Delivery {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		deliveries, err := d.Deliveries("", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(deliveries) == 1 && deliveries[0].Status != DeliveryPending {
+			return deliveries[0]
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("delivery did not finish in time")
+	return nil
+}
+
+func Test_Emit_DeliversSignedPayload(t *testing.T) {
+	receiver := &webhookReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+	d := newTestDispatcher(t, config.WebhookConfig{Name: "ci", URL: server.URL, Secret: "s3cret"})
+	event := NewReleaseEvent(ReleasePublished, "acme", "sdk", "1.2.0")
+
+	d.Emit(event)
+
+	delivery := waitForDelivery(t, d)
+	if delivery.Status != DeliveryDelivered || delivery.Attempts != 1 || delivery.StatusCode != http.StatusOK {
+		t.Fatalf("expected delivery on first attempt, got %+v", delivery)
+	}
+	req, body := receiver.requests[0], receiver.bodies[0]
+	if signature := req.Header.Get(SignatureHeader); signature != Sign("s3cret", body) {
+		t.Errorf("expected payload signature %s, got %s", Sign("s3cret", body), signature)
+	}
+	if req.Header.Get(EventHeader) != ReleasePublished || req.Header.Get(DeliveryHeader) != delivery.Id {
+		t.Errorf("unexpected event headers %v", req.Header)
+	}
+	var received Event
+	if err := json.Unmarshal(body, &received); err != nil || received.Id != event.Id || received.Scope != "acme" || received.Version != "1.2.0" {
+		t.Errorf("expected event payload, got %s (%v)", body, err)
+	}
+}
+
+func Test_Emit_ServerErrors_RetriesUntilDelivered(t *testing.T) {
+	receiver := &webhookReceiver{statuses: []int{http.StatusInternalServerError, http.StatusTooManyRequests}}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+	d := newTestDispatcher(t, config.WebhookConfig{URL: server.URL})
+
+	d.Emit(NewReleaseEvent(ReleaseDeleted, "acme", "sdk", "1.2.0"))
+
+	delivery := waitForDelivery(t, d)
+	if delivery.Status != DeliveryDelivered || delivery.Attempts != 3 {
+		t.Errorf("expected delivery on third attempt, got %+v", delivery)
+	}
+	if receiver.requests[0].Header.Get(DeliveryHeader) != receiver.requests[2].Header.Get(DeliveryHeader) {
+		t.Errorf("expected the same delivery id for every attempt")
+	}
+}
+
+func Test_Emit_ExhaustedOrClientError_GivesUp(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		statuses []int
+		attempts int
+	}{
+		{"client error", []int{http.StatusBadRequest}, 1},
+		{"attempts exhausted", []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway}, 3},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			receiver := &webhookReceiver{statuses: tc.statuses}
+			server := httptest.NewServer(receiver)
+			defer server.Close()
+			d := newTestDispatcher(t, config.WebhookConfig{URL: server.URL})
+
+			d.Emit(NewPublishFailedEvent("acme", "sdk", "1.2.0", "invalid_archive", "upload failed"))
+
+			delivery := waitForDelivery(t, d)
+			if delivery.Status != DeliveryFailed || delivery.Attempts != tc.attempts || delivery.Error == "" {
+				t.Errorf("expected failed delivery after %d attempts, got %+v", tc.attempts, delivery)
+			}
+		})
+	}
+}
+
+func Test_Emit_FiltersByEventAndScope(t *testing.T) {
+	receiver := &webhookReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+	d := newTestDispatcher(t, config.WebhookConfig{URL: server.URL, Events: []string{ReleasePublished}, Scopes: []string{"acme-*"}})
+
+	d.Emit(NewReleaseEvent(ReleaseDeleted, "acme-ios", "sdk", "1.0.0"))
+	d.Emit(NewReleaseEvent(ReleasePublished, "other", "sdk", "1.0.0"))
+	d.Emit(NewReleaseEvent(ReleasePublished, "acme-ios", "sdk", "1.0.0"))
+
+	delivery := waitForDelivery(t, d)
+	if delivery.Event.Type != ReleasePublished || delivery.Event.Scope != "acme-ios" || receiver.count() != 1 {
+		t.Errorf("expected only the subscribed event to be delivered, got %+v", delivery.Event)
+	}
+}
+
+func Test_NewDispatcher_ResumesPendingDeliveries(t *testing.T) {
+	receiver := &webhookReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+	path := filepath.Join(t.TempDir(), "webhooks.db")
+	log, err := OpenDeliveryLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	_ = log.Put(&Delivery{Id: newDeliveryId(now), Webhook: server.URL, URL: server.URL, Status: DeliveryPending, Attempts: 1,
+		Event: NewCollectionChangedEvent("acme"), CreatedAt: now, UpdatedAt: now, NextAttemptAt: &now})
+	_ = log.Close()
+
+	d, err := NewDispatcher(config.WebhooksConfig{DeliveryLog: path, Hooks: []config.WebhookConfig{{URL: server.URL}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = d.Close() }()
+
+	delivery := waitForDelivery(t, d)
+	if delivery.Status != DeliveryDelivered || delivery.Attempts != 2 || receiver.count() != 1 {
+		t.Errorf("expected pending delivery to be resumed, got %+v", delivery)
+	}
+}
+
+func Test_NewDispatcher_InvalidWebhook_ReturnsError(t *testing.T) {
+	for _, hooks := range [][]config.WebhookConfig{
+		{{URL: "ftp://example.com"}},
+		{{URL: "https://example.com", Events: []string{"release.updated"}}},
+		{{Name: "ci", URL: "https://example.com/a"}, {Name: "ci", URL: "https://example.com/b"}},
+	} {
+		if _, err := NewDispatcher(config.WebhooksConfig{DeliveryLog: filepath.Join(t.TempDir(), "webhooks.db"), Hooks: hooks}); err == nil {
+			t.Errorf("expected error for %+v", hooks)
+		}
+	}
+}
+
+func Test_retryWait_DoublesUpToMaxBackoff(t *testing.T) {
+	d := &Dispatcher{backoff: time.Second}
+
+	for attempts, expected := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 4: 8 * time.Second, 13: maxBackoff, 70: maxBackoff} {
+		if wait := d.retryWait(attempts); wait != expected {
+			t.Errorf("attempt %d: expected %v, got %v", attempts, expected, wait)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package events
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// Event is a change of the registry notified to webhooks
+type Event struct {
+	Id      string    `json:"id"`
+	Type    string    `json:"type"`
+	Time    time.Time `json:"time"`
+	Scope   string    `json:"scope"`
+	Name    string    `json:"name,omitempty"`    // empty for collection changes
+	Version string    `json:"version,omitempty"` // empty for collection changes
+	// Reason and Message describe why a publication failed
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// types of events, webhooks subscribe to them by these names
+const (
+	ReleasePublished  = "release.published"
+	ReleaseDeleted    = "release.deleted"
+	PublishFailed     = "publish.failed"
+	CollectionChanged = "collection.changed"
+)
+
+// Types lists every event type
+var Types = []string{ReleasePublished, ReleaseDeleted, PublishFailed, CollectionChanged}
+
+// NewReleaseEvent creates an event of type about the release scope.name@version
+func NewReleaseEvent(eventType string, scope string, name string, version string) Event {
+	return Event{Id: newId(), Type: eventType, Time: time.Now().UTC(), Scope: scope, Name: name, Version: version}
+}
+
+// NewPublishFailedEvent creates a publish.failed event for the release scope.name@version
+func NewPublishFailedEvent(scope string, name string, version string, reason string, message string) Event {
+	event := NewReleaseEvent(PublishFailed, scope, name, version)
+	event.Reason = reason
+	event.Message = message
+	return event
+}
+
+// NewCollectionChangedEvent creates a collection.changed event for the package collection of scope
+func NewCollectionChangedEvent(scope string) Event {
+	return Event{Id: newId(), Type: CollectionChanged, Time: time.Now().UTC(), Scope: scope}
+}
+
+// newId returns a random identifier for events and deliveries
+func newId() string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
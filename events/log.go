@@ -0,0 +1,128 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Delivery is the state of delivering an event to a webhook
+type Delivery struct {
+	Id            string     `json:"id"`
+	Webhook       string     `json:"webhook"`
+	URL           string     `json:"url"`
+	Event         Event      `json:"event"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	StatusCode    int        `json:"statusCode,omitempty"` // HTTP status of the last attempt
+	Error         string     `json:"error,omitempty"`      // Error of the last attempt
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+	NextAttemptAt *time.Time `json:"nextAttemptAt,omitempty"` // Set while a retry is pending
+}
+
+// DeliveryLog persists webhook deliveries in a bbolt database, keyed by id in creation order
+type DeliveryLog struct {
+	db *bolt.DB
+}
+
+// states of a delivery
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+var bucketDeliveries = []byte("deliveries")
+
+// OpenDeliveryLog opens (or creates) the delivery log database at path
+func OpenDeliveryLog(path string) (*DeliveryLog, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("opening delivery log %s: %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucketDeliveries)
+		return err
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("initializing delivery log %s: %w", path, err)
+	}
+	return &DeliveryLog{db: db}, nil
+}
+
+// Close closes the database
+func (l *DeliveryLog) Close() error {
+	return l.db.Close()
+}
+
+// Put stores the delivery, replacing an older state
+func (l *DeliveryLog) Put(delivery *Delivery) error {
+	data, err := json.Marshal(delivery)
+	if err != nil {
+		return err
+	}
+	return l.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketDeliveries).Put([]byte(delivery.Id), data)
+	})
+}
+
+// List returns up to limit deliveries with status (all if empty), newest first
+func (l *DeliveryLog) List(status string, limit int) ([]*Delivery, error) {
+	var deliveries []*Delivery
+	err := l.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(bucketDeliveries).Cursor()
+		for key, value := cursor.Last(); key != nil && (limit <= 0 || len(deliveries) < limit); key, value = cursor.Prev() {
+			var delivery Delivery
+			if err := json.Unmarshal(value, &delivery); err != nil {
+				return fmt.Errorf("decoding delivery %s: %w", key, err)
+			}
+			if status == "" || delivery.Status == status {
+				deliveries = append(deliveries, &delivery)
+			}
+		}
+		return nil
+	})
+	return deliveries, err
+}
+
+// Prune removes finished deliveries last updated before the given time
+// returns (number of removed deliveries, error)
+func (l *DeliveryLog) Prune(before time.Time) (int, error) {
+	var expired [][]byte
+	err := l.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketDeliveries)
+		err := bucket.ForEach(func(key, value []byte) error {
+			var delivery Delivery
+			if err := json.Unmarshal(value, &delivery); err != nil {
+				return fmt.Errorf("decoding delivery %s: %w", key, err)
+			}
+			if delivery.Status != DeliveryPending && delivery.UpdatedAt.Before(before) {
+				expired = append(expired, key)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		// deleting while iterating skips keys
+		for _, key := range expired {
+			if err := bucket.Delete(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(expired), nil
+}
+
+// newDeliveryId returns an id sorting deliveries by creation time
+func newDeliveryId(createdAt time.Time) string {
+	return fmt.Sprintf("%016x-%s", createdAt.UnixNano(), newId()[:8])
+}
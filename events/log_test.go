@@ -0,0 +1,46 @@
+package events
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_DeliveryLog_ListAndPrune(t *testing.T) {
+	log, err := OpenDeliveryLog(filepath.Join(t.TempDir(), "webhooks.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = log.Close() }()
+
+	now := time.Now().UTC()
+	old := now.Add(-48 * time.Hour)
+	for i, delivery := range []*Delivery{
+		{Status: DeliveryDelivered, UpdatedAt: old},
+		{Status: DeliveryPending, UpdatedAt: old},
+		{Status: DeliveryFailed, UpdatedAt: now},
+		{Status: DeliveryDelivered, UpdatedAt: now},
+	} {
+		delivery.CreatedAt = old.Add(time.Duration(i) * time.Minute)
+		delivery.Id = newDeliveryId(delivery.CreatedAt)
+		if err := log.Put(delivery); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deliveries, _ := log.List("", 2)
+	if len(deliveries) != 2 || deliveries[0].Status != DeliveryDelivered || deliveries[1].Status != DeliveryFailed {
+		t.Errorf("expected the two newest deliveries, got %+v", deliveries)
+	}
+	if delivered, _ := log.List(DeliveryDelivered, 0); len(delivered) != 2 {
+		t.Errorf("expected 2 delivered deliveries, got %d", len(delivered))
+	}
+
+	removed, err := log.Prune(now.Add(-24 * time.Hour))
+	if err != nil || removed != 1 {
+		t.Fatalf("expected the old delivered delivery to be pruned, removed %d (%v)", removed, err)
+	}
+	if remaining, _ := log.List("", 0); len(remaining) != 3 {
+		t.Errorf("expected pending and recent deliveries to be kept, got %d", len(remaining))
+	}
+}
@@ -0,0 +1,494 @@
+// Package fsck verifies the integrity of a repository through repo.Repo and repairs what can be derived again:
+// manifests are re-extracted from their source archive, the package index and the repository URL mapping are
+// rebuilt from the releases found.
+package fsck
+
+import (
+	"OpenSPMRegistry/mimetypes"
+	"OpenSPMRegistry/models"
+	"OpenSPMRegistry/repo"
+	"OpenSPMRegistry/repo/files"
+	"archive/zip"
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Options of a check
+type Options struct {
+	// Scope restricts the check to one scope, every scope is checked if empty
+	Scope string
+	// Repair re-extracts the manifests of releases with manifest issues and rebuilds the package index
+	// and repository URL mapping
+	Repair bool
+}
+
+// Issue is an inconsistency found in the repository
+type Issue struct {
+	Kind    string `json:"kind"`
+	Scope   string `json:"scope,omitempty"`
+	Package string `json:"package,omitempty"`
+	Version string `json:"version,omitempty"`
+	File    string `json:"file,omitempty"`
+	Detail  string `json:"detail"`
+	// Repaired is set if the issue was fixed by the repair mode
+	Repaired bool `json:"repaired,omitempty"`
+}
+
+// Report is the result of a check
+type Report struct {
+	Releases int     `json:"releases"` // number of releases checked, deleted ones excluded
+	Issues   []Issue `json:"issues"`
+}
+
+// zipError is returned by readArchive if the source archive is no valid zip file
+type zipError struct {
+	error
+}
+
+// archiveContents is what a check needs to know of a source archive
+type archiveContents struct {
+	checksum string
+	// manifests maps the file names of the manifests in the archive to their sha256 checksum
+	manifests map[string]string
+}
+
+const (
+	KindMissingArchive     = "missing-archive"
+	KindInvalidArchive     = "invalid-archive"
+	KindOrphanedManifest   = "orphaned-manifest"
+	KindOrphanedSignature  = "orphaned-signature"
+	KindManifestMismatch   = "manifest-mismatch"
+	KindInvalidMetadata    = "invalid-metadata"
+	KindChecksumMismatch   = "checksum-mismatch"
+	KindDanglingIndexEntry = "dangling-index-entry"
+	KindMissingIndexEntry  = "missing-index-entry"
+	// KindUnreadable is reported for files the check failed to read
+	KindUnreadable = "unreadable"
+)
+
+// Unrepaired returns the number of issues the report still has
+func (r *Report) Unrepaired() int {
+	count := 0
+	for _, issue := range r.Issues {
+		if !issue.Repaired {
+			count++
+		}
+	}
+	return count
+}
+
+// Check walks every release of the repository (of opts.Scope if given) and reports
+// missing or invalid source archives, manifests and signatures without source archive, manifests not matching
+// the source archive, unparsable metadata, checksums stored next to files (repo.ChecksumSidecars) or recorded
+// on publication not matching them, package index entries without releases (repo.PackageIndex) and
+// repository URL mappings not matching the metadata of the releases (repo.RepositoryURLIndex).
+// The files are checked on the backend of r (see repo.Backend), releases repaired are indexed again if r is a repo.Indexer.
+// Deleted releases are skipped. The error is only set if the releases could not be listed.
+func Check(ctx context.Context, r repo.Repo, opts Options) (*Report, error) {
+	indexer, _ := r.(repo.Indexer)
+	r = repo.Backend(r)
+
+	var releases []models.ListElement
+	var err error
+	if opts.Scope != "" {
+		releases, err = r.ListInScope(ctx, opts.Scope)
+	} else {
+		releases, err = r.ListAll(ctx)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("listing releases: %w", err)
+	}
+	slices.SortFunc(releases, func(a, b models.ListElement) int {
+		return cmp.Or(strings.Compare(a.Scope, b.Scope), strings.Compare(a.PackageName, b.PackageName), strings.Compare(a.Version, b.Version))
+	})
+
+	report := &Report{Issues: []Issue{}}
+	var checked []models.ListElement
+	for _, release := range releases {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if repo.IsReleaseDeleted(ctx, r, release.Scope, release.PackageName, release.Version) {
+			continue
+		}
+		report.Releases++
+		report.Issues = append(report.Issues, checkRelease(ctx, r, release, opts.Repair, indexer)...)
+		checked = append(checked, release)
+	}
+
+	if index, ok := r.(repo.PackageIndex); ok {
+		report.Issues = append(report.Issues, checkPackageIndex(ctx, r, index, opts)...)
+	}
+	if index, ok := r.(repo.RepositoryURLIndex); ok {
+		report.Issues = append(report.Issues, checkRepositoryURLIndex(ctx, r, index, checked, opts)...)
+	}
+	return report, nil
+}
+
+// checkRelease checks the files of a release, re-extracting its manifests on manifest issues if repair is set
+func checkRelease(ctx context.Context, r repo.Repo, release models.ListElement, repair bool, indexer repo.Indexer) []Issue {
+	scope, name, version := release.Scope, release.PackageName, release.Version
+	newIssue := func(kind string, element *models.UploadElement, detail string) Issue {
+		return Issue{Kind: kind, Scope: scope, Package: name, Version: version, File: element.FileName(), Detail: detail}
+	}
+
+	archive := models.NewUploadElement(scope, name, version, mimetypes.ApplicationZip, models.SourceArchive)
+	signature := models.NewUploadElement(scope, name, version, mimetypes.ApplicationZip, models.SourceArchiveSignature)
+	metadata := models.NewUploadElement(scope, name, version, mimetypes.ApplicationJson, models.Metadata)
+	metadataSignature := models.NewUploadElement(scope, name, version, mimetypes.ApplicationJson, models.MetadataSignature)
+	stored := repo.ReleaseManifests(ctx, r, scope, name, version)
+
+	var issues []Issue
+	if r.Exists(ctx, metadata) {
+		if _, err := r.LoadMetadata(ctx, scope, name, version); err != nil {
+			issues = append(issues, newIssue(KindInvalidMetadata, metadata, err.Error()))
+		}
+	}
+
+	if !r.Exists(ctx, archive) {
+		issues = append(issues, newIssue(KindMissingArchive, archive, "source archive not found"))
+		for _, manifest := range stored {
+			issues = append(issues, newIssue(KindOrphanedManifest, manifest, "manifest without source archive"))
+		}
+		if r.Exists(ctx, signature) {
+			issues = append(issues, newIssue(KindOrphanedSignature, signature, "signature without source archive"))
+		}
+		return issues
+	}
+
+	contents, err := readArchive(ctx, r, archive)
+	if err != nil {
+		kind := KindUnreadable
+		if errors.As(err, &zipError{}) {
+			kind = KindInvalidArchive
+		}
+		return append(issues, newIssue(kind, archive, err.Error()))
+	}
+
+	var manifestIssues []Issue
+	for _, manifest := range stored {
+		expected, inArchive := contents.manifests[manifest.FileName()]
+		if !inArchive {
+			manifestIssues = append(manifestIssues, newIssue(KindManifestMismatch, manifest, "manifest not in source archive"))
+			continue
+		}
+		actual, err := readChecksum(ctx, r, manifest)
+		if err != nil {
+			issues = append(issues, newIssue(KindUnreadable, manifest, err.Error()))
+		} else if actual != expected {
+			manifestIssues = append(manifestIssues, newIssue(KindManifestMismatch, manifest, "manifest differs from source archive"))
+		}
+	}
+	for fileName := range contents.manifests {
+		if !slices.ContainsFunc(stored, func(manifest *models.UploadElement) bool { return manifest.FileName() == fileName }) {
+			manifest := manifestElement(scope, name, version, fileName)
+			manifestIssues = append(manifestIssues, newIssue(KindManifestMismatch, manifest, "manifest of source archive not extracted"))
+		}
+	}
+	slices.SortFunc(manifestIssues, func(a, b Issue) int { return strings.Compare(a.File, b.File) })
+
+	if sidecars, ok := r.(repo.ChecksumSidecars); ok {
+		elements := []*models.UploadElement{archive}
+		for _, element := range []*models.UploadElement{signature, metadata, metadataSignature} {
+			if r.Exists(ctx, element) {
+				elements = append(elements, element)
+			}
+		}
+		for _, element := range append(elements, stored...) {
+			issue, ok := checkSidecar(ctx, r, sidecars, element, contents.checksum, element == archive)
+			if !ok {
+				continue
+			}
+			issue.Scope, issue.Package, issue.Version = scope, name, version
+			issues = append(issues, issue)
+		}
+	}
+
+	// clients are served the checksum recorded on publication
+	publication := models.NewUploadElement(scope, name, version, mimetypes.ApplicationJson, models.Publication)
+	if r.Exists(ctx, publication) {
+		if record, err := repo.ReadPublication(ctx, r, scope, name, version); err != nil {
+			issues = append(issues, newIssue(KindUnreadable, publication, err.Error()))
+		} else if record.Checksum != "" && record.Checksum != contents.checksum {
+			issues = append(issues, newIssue(KindChecksumMismatch, publication, fmt.Sprintf("recorded checksum %s, source archive has %s", record.Checksum, contents.checksum)))
+		}
+	}
+
+	// checksums of manifests are written again with them
+	manifestSidecar := func(issue Issue) bool {
+		return issue.Kind == KindChecksumMismatch && slices.ContainsFunc(stored, func(manifest *models.UploadElement) bool {
+			return issue.File == manifest.FileName()+".sha256"
+		})
+	}
+	if repair && (len(manifestIssues) > 0 || slices.ContainsFunc(issues, manifestSidecar)) {
+		if err := repairManifests(ctx, r, archive, stored, contents, indexer); err != nil {
+			slog.Warn("Error repairing manifests", "release", fmt.Sprintf("%s.%s@%s", scope, name, version), "error", err)
+		} else {
+			for i := range manifestIssues {
+				manifestIssues[i].Repaired = true
+			}
+			for i := range issues {
+				issues[i].Repaired = manifestSidecar(issues[i])
+			}
+		}
+	}
+	return append(issues, manifestIssues...)
+}
+
+// checkSidecar compares the checksum stored next to the element with its contents,
+// the checksum of the source archive is known already. Returns false if they match.
+func checkSidecar(ctx context.Context, r repo.Repo, sidecars repo.ChecksumSidecars, element *models.UploadElement, archiveChecksum string, isArchive bool) (Issue, bool) {
+	stored, err := sidecars.StoredChecksum(ctx, element)
+	if err != nil {
+		return Issue{Kind: KindUnreadable, File: element.FileName() + ".sha256", Detail: err.Error()}, true
+	}
+	if stored == "" {
+		return Issue{}, false
+	}
+	actual := archiveChecksum
+	if !isArchive {
+		if actual, err = readChecksum(ctx, r, element); err != nil {
+			return Issue{Kind: KindUnreadable, File: element.FileName(), Detail: err.Error()}, true
+		}
+	}
+	if stored == actual {
+		return Issue{}, false
+	}
+	return Issue{
+		Kind:   KindChecksumMismatch,
+		File:   element.FileName() + ".sha256",
+		Detail: fmt.Sprintf("stored checksum %s, file has %s", stored, actual),
+	}, true
+}
+
+// checkPackageIndex reports index entries of packages without releases, removing them if repair is set
+func checkPackageIndex(ctx context.Context, r repo.Repo, index repo.PackageIndex, opts Options) []Issue {
+	packages, err := index.IndexedPackages(ctx)
+	if err != nil {
+		return []Issue{{Kind: KindUnreadable, Scope: opts.Scope, Detail: fmt.Sprintf("package index: %v", err)}}
+	}
+
+	var issues []Issue
+	rebuilt := make(map[string][]string, len(packages))
+	for scope, names := range packages {
+		if opts.Scope != "" && scope != opts.Scope {
+			rebuilt[scope] = names
+			continue
+		}
+		for _, name := range names {
+			releases, err := r.List(ctx, scope, name)
+			if err == nil && len(releases) == 0 {
+				issues = append(issues, Issue{Kind: KindDanglingIndexEntry, Scope: scope, Package: name, Detail: "package in index has no releases"})
+				continue
+			}
+			rebuilt[scope] = append(rebuilt[scope], name)
+		}
+	}
+	slices.SortFunc(issues, func(a, b Issue) int {
+		return cmp.Or(strings.Compare(a.Scope, b.Scope), strings.Compare(a.Package, b.Package))
+	})
+
+	if opts.Repair && len(issues) > 0 {
+		if err := index.RebuildPackageIndex(ctx, rebuilt); err != nil {
+			slog.Warn("Error rebuilding package index", "error", err)
+		} else {
+			for i := range issues {
+				issues[i].Repaired = true
+			}
+		}
+	}
+	return issues
+}
+
+// checkRepositoryURLIndex reports repository URLs in the metadata of the releases missing in the mapping
+// and mapped ones of releases no longer listing them (or deleted), rebuilding it from the metadata if repair is set
+func checkRepositoryURLIndex(ctx context.Context, r repo.Repo, index repo.RepositoryURLIndex, releases []models.ListElement, opts Options) []Issue {
+	indexed, err := index.IndexedRepositoryURLs(ctx)
+	if err != nil {
+		return []Issue{{Kind: KindUnreadable, Scope: opts.Scope, Detail: fmt.Sprintf("repository URL index: %v", err)}}
+	}
+
+	// releases of other scopes are kept as they are
+	rebuilt := make(map[string]map[string][]string)
+	add := func(url, identifier, version string) {
+		if rebuilt[url] == nil {
+			rebuilt[url] = make(map[string][]string)
+		}
+		if !slices.Contains(rebuilt[url][identifier], version) {
+			rebuilt[url][identifier] = append(rebuilt[url][identifier], version)
+			slices.Sort(rebuilt[url][identifier])
+		}
+	}
+	for url, identifiers := range indexed {
+		for identifier, versions := range identifiers {
+			if scope, _, _ := strings.Cut(identifier, "."); opts.Scope != "" && scope != opts.Scope {
+				for _, version := range versions {
+					add(url, identifier, version)
+				}
+			}
+		}
+	}
+
+	var issues []Issue
+	for _, release := range releases {
+		metadata := models.NewUploadElement(release.Scope, release.PackageName, release.Version, mimetypes.ApplicationJson, models.Metadata)
+		if !r.Exists(ctx, metadata) {
+			continue
+		}
+		loaded, err := r.LoadMetadata(ctx, release.Scope, release.PackageName, release.Version)
+		if err != nil {
+			continue // reported as invalid metadata
+		}
+		identifier := release.Scope + "." + release.PackageName
+		for _, url := range metadataRepositoryURLs(loaded) {
+			add(url, identifier, release.Version)
+			if !slices.Contains(indexed[url][identifier], release.Version) {
+				issues = append(issues, Issue{Kind: KindMissingIndexEntry, Scope: release.Scope, Package: release.PackageName, Version: release.Version,
+					File: metadata.FileName(), Detail: fmt.Sprintf("repository URL %s is not in the repository URL index", url)})
+			}
+		}
+	}
+	for url, identifiers := range indexed {
+		for identifier, versions := range identifiers {
+			scope, name, _ := strings.Cut(identifier, ".")
+			for _, version := range versions {
+				if !slices.Contains(rebuilt[url][identifier], version) {
+					issues = append(issues, Issue{Kind: KindDanglingIndexEntry, Scope: scope, Package: name, Version: version,
+						Detail: fmt.Sprintf("repository URL %s in index is not in the metadata of the release", url)})
+				}
+			}
+		}
+	}
+	slices.SortFunc(issues, func(a, b Issue) int {
+		return cmp.Or(strings.Compare(a.Scope, b.Scope), strings.Compare(a.Package, b.Package), strings.Compare(a.Version, b.Version), strings.Compare(a.Detail, b.Detail))
+	})
+
+	if opts.Repair && len(issues) > 0 {
+		if err := index.RebuildRepositoryURLIndex(ctx, rebuilt); err != nil {
+			slog.Warn("Error rebuilding repository URL index", "error", err)
+		} else {
+			for i := range issues {
+				issues[i].Repaired = true
+			}
+		}
+	}
+	return issues
+}
+
+// metadataRepositoryURLs returns the repositoryURLs of release metadata
+func metadataRepositoryURLs(metadata map[string]any) []string {
+	var urls []string
+	if values, ok := metadata["repositoryURLs"].([]any); ok {
+		for _, value := range values {
+			if url, ok := value.(string); ok {
+				urls = append(urls, url)
+			}
+		}
+	}
+	return urls
+}
+
+// repairManifests removes the stored manifests not in the source archive and extracts the manifests again,
+// indexing the release again if there is an indexer
+func repairManifests(ctx context.Context, r repo.Repo, archive *models.UploadElement, stored []*models.UploadElement, contents *archiveContents, indexer repo.Indexer) error {
+	for _, manifest := range stored {
+		if _, ok := contents.manifests[manifest.FileName()]; ok {
+			continue
+		}
+		if err := r.Remove(ctx, manifest); err != nil {
+			return fmt.Errorf("removing %s: %w", manifest.FileName(), err)
+		}
+	}
+	if err := r.ExtractManifestFiles(ctx, archive); err != nil {
+		return err
+	}
+	if indexer != nil {
+		if err := indexer.IndexRelease(ctx, archive.Scope, archive.Name, archive.Version); err != nil {
+			slog.Warn("Error indexing repaired release", "error", err)
+		}
+	}
+	return nil
+}
+
+// manifestElement returns the element a manifest of the source archive is stored as
+func manifestElement(scope string, name string, version string, fileName string) *models.UploadElement {
+	if strings.EqualFold(fileName, "Package.json") {
+		return models.NewUploadElement(scope, name, version, mimetypes.ApplicationJson, models.PackageManifestJson)
+	}
+	return models.NewUploadElement(scope, name, version, mimetypes.TextXSwift, models.Manifest).
+		SetFilenameOverwrite(strings.TrimSuffix(fileName, filepath.Ext(fileName)))
+}
+
+// readArchive computes the checksum of the source archive and of the manifests in it.
+// Archives whose reader does not support random access are spooled to a temporary file.
+func readArchive(ctx context.Context, r repo.Repo, archive *models.UploadElement) (*archiveContents, error) {
+	reader, err := r.GetReader(ctx, archive)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = reader.Close() }()
+
+	hash := sha256.New()
+	readerAt, isReaderAt := reader.(io.ReaderAt)
+	var size int64
+	if isReaderAt {
+		if size, err = io.Copy(hash, reader); err != nil {
+			return nil, err
+		}
+	} else {
+		spool, err := os.CreateTemp("", "fsck-source-archive-*.zip")
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			_ = spool.Close()
+			_ = os.Remove(spool.Name())
+		}()
+		if size, err = io.Copy(io.MultiWriter(spool, hash), reader); err != nil {
+			return nil, err
+		}
+		readerAt = spool
+	}
+
+	zipReader, err := zip.NewReader(readerAt, size)
+	if err != nil {
+		return nil, zipError{fmt.Errorf("source archive is not a valid zip file: %w", err)}
+	}
+	contents := &archiveContents{checksum: fmt.Sprintf("%x", hash.Sum(nil)), manifests: map[string]string{}}
+	err = files.ExtractManifestFilesFromZipReader(archive, zipReader, func(name string, r io.ReadCloser) error {
+		manifestHash := sha256.New()
+		if _, err := io.Copy(manifestHash, r); err != nil {
+			return err
+		}
+		contents.manifests[manifestElement(archive.Scope, archive.Name, archive.Version, name).FileName()] = fmt.Sprintf("%x", manifestHash.Sum(nil))
+		return nil
+	})
+	if err != nil {
+		return nil, zipError{fmt.Errorf("reading manifests from source archive: %w", err)}
+	}
+	return contents, nil
+}
+
+// readChecksum computes the sha256 checksum of the contents of the element
+func readChecksum(ctx context.Context, r repo.Repo, element *models.UploadElement) (string, error) {
+	reader, err := r.GetReader(ctx, element)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = reader.Close() }()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, reader); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
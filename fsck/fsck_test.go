@@ -0,0 +1,266 @@
+package fsck
+
+import (
+	"OpenSPMRegistry/mimetypes"
+	"OpenSPMRegistry/models"
+	"OpenSPMRegistry/repo"
+	"OpenSPMRegistry/repo/files"
+	"OpenSPMRegistry/repo/index"
+	"archive/zip"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// indexedRepo is a files repo with checksum sidecars and a package index, like the Maven backend
+type indexedRepo struct {
+	*files.FileRepo
+	sidecars map[string]string
+	index    map[string][]string
+}
+
+const packageSwift = "// swift-tools-version:5.9\n"
+
+func (r *indexedRepo) StoredChecksum(_ context.Context, element *models.UploadElement) (string, error) {
+	return r.sidecars[element.FileName()], nil
+}
+
+func (r *indexedRepo) IndexedPackages(_ context.Context) (map[string][]string, error) {
+	return r.index, nil
+}
+
+func (r *indexedRepo) RebuildPackageIndex(_ context.Context, packages map[string][]string) error {
+	r.index = packages
+	return nil
+}
+
+// urlIndexedRepo is a files repo with a repository URL mapping, like the Maven backend
+type urlIndexedRepo struct {
+	*files.FileRepo
+	urls map[string]map[string][]string
+}
+
+func (r *urlIndexedRepo) IndexedRepositoryURLs(context.Context) (map[string]map[string][]string, error) {
+	return r.urls, nil
+}
+
+func (r *urlIndexedRepo) RebuildRepositoryURLIndex(_ context.Context, urls map[string]map[string][]string) error {
+	r.urls = urls
+	return nil
+}
+
+// publish stores a source archive with the files given and extracts its manifests
+func publish(t *testing.T, r repo.Repo, scope string, name string, version string, archiveFiles map[string]string) {
+	t.Helper()
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	for fileName, content := range archiveFiles {
+		f, _ := zw.Create(scope + "." + name + "/" + fileName)
+		_, _ = f.Write([]byte(content))
+	}
+	_ = zw.Close()
+
+	element := models.NewUploadElement(scope, name, version, mimetypes.ApplicationZip, models.SourceArchive)
+	write(t, r, element, archive.Bytes())
+	if err := r.ExtractManifestFiles(context.Background(), element); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func write(t *testing.T, r repo.Repo, element *models.UploadElement, data []byte) {
+	t.Helper()
+	writer, err := r.GetWriter(context.Background(), element)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = writer.Write(data)
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func issueKinds(report *Report) map[string]int {
+	kinds := map[string]int{}
+	for _, issue := range report.Issues {
+		kinds[issue.Kind]++
+	}
+	return kinds
+}
+
+func Test_Check_ConsistentRepo_ReportsNoIssues(t *testing.T) {
+	r := files.NewFileRepo(t.TempDir())
+	publish(t, r, "acme", "sdk", "1.0.0", map[string]string{"Package.swift": packageSwift, "Package.json": "{}"})
+	publish(t, r, "acme", "sdk", "1.1.0", map[string]string{"Package.swift": packageSwift})
+	write(t, r, models.NewUploadElement("acme", "sdk", "1.1.0", mimetypes.ApplicationJson, models.Metadata), []byte(`{"description":"SDK"}`))
+
+	report, err := Check(context.Background(), r, Options{})
+
+	if err != nil || report.Releases != 2 || len(report.Issues) != 0 {
+		t.Errorf("expected 2 releases without issues, got %+v (%v)", report, err)
+	}
+}
+
+func Test_Check_MissingArchive_ReportsOrphans(t *testing.T) {
+	r := files.NewFileRepo(t.TempDir())
+	publish(t, r, "acme", "sdk", "1.0.0", map[string]string{"Package.swift": packageSwift})
+	write(t, r, models.NewUploadElement("acme", "sdk", "1.0.0", mimetypes.ApplicationZip, models.SourceArchiveSignature), []byte("signature"))
+	write(t, r, models.NewUploadElement("acme", "sdk", "1.0.0", mimetypes.ApplicationJson, models.Metadata), []byte("{invalid"))
+	_ = r.Remove(context.Background(), models.NewUploadElement("acme", "sdk", "1.0.0", mimetypes.ApplicationZip, models.SourceArchive))
+
+	report, err := Check(context.Background(), r, Options{Repair: true})
+
+	kinds := issueKinds(report)
+	if err != nil || len(report.Issues) != 4 || kinds[KindMissingArchive] != 1 || kinds[KindOrphanedManifest] != 1 ||
+		kinds[KindOrphanedSignature] != 1 || kinds[KindInvalidMetadata] != 1 || report.Unrepaired() != 4 {
+		t.Errorf("unexpected issues %+v (%v)", report.Issues, err)
+	}
+}
+
+func Test_Check_DeletedRelease_IsSkipped(t *testing.T) {
+	r := files.NewFileRepo(t.TempDir())
+	publish(t, r, "acme", "sdk", "1.0.0", map[string]string{"Package.swift": packageSwift})
+	archive := models.NewUploadElement("acme", "sdk", "1.0.0", mimetypes.ApplicationZip, models.SourceArchive)
+	_ = repo.WriteTombstone(context.Background(), r, archive, "", time.Time{})
+	_ = repo.RemoveRelease(context.Background(), r, "acme", "sdk", "1.0.0")
+
+	report, err := Check(context.Background(), r, Options{})
+
+	if err != nil || report.Releases != 0 || len(report.Issues) != 0 {
+		t.Errorf("expected deleted release to be skipped, got %+v (%v)", report, err)
+	}
+}
+
+func Test_Check_ManifestMismatch_RepairReextracts(t *testing.T) {
+	dir := t.TempDir()
+	r := files.NewFileRepo(dir)
+	publish(t, r, "acme", "sdk", "1.0.0", map[string]string{"Package.swift": packageSwift, "Package@swift-5.8.swift": packageSwift, "Package.json": "{}"})
+	release := filepath.Join(dir, "acme", "sdk", "1.0.0")
+	_ = os.WriteFile(filepath.Join(release, "Package.swift"), []byte("// swift-tools-version:5.7\n"), 0o644)
+	_ = os.Remove(filepath.Join(release, "Package@swift-5.8.swift"))
+	_ = os.WriteFile(filepath.Join(release, "Package@swift-6.0.swift"), []byte(packageSwift), 0o644)
+
+	report, err := Check(context.Background(), r, Options{})
+	if err != nil || len(report.Issues) != 3 || issueKinds(report)[KindManifestMismatch] != 3 || report.Unrepaired() != 3 {
+		t.Fatalf("expected three manifest mismatches, got %+v (%v)", report.Issues, err)
+	}
+
+	report, err = Check(context.Background(), r, Options{Repair: true})
+	if err != nil || len(report.Issues) != 3 || report.Unrepaired() != 0 {
+		t.Fatalf("expected manifest mismatches to be repaired, got %+v (%v)", report.Issues, err)
+	}
+
+	report, err = Check(context.Background(), r, Options{})
+	if err != nil || len(report.Issues) != 0 {
+		t.Errorf("expected no issues after repair, got %+v (%v)", report.Issues, err)
+	}
+}
+
+func Test_Check_InvalidArchive_Reported(t *testing.T) {
+	r := files.NewFileRepo(t.TempDir())
+	write(t, r, models.NewUploadElement("acme", "sdk", "1.0.0", mimetypes.ApplicationZip, models.SourceArchive), []byte("not a zip"))
+
+	report, err := Check(context.Background(), r, Options{})
+
+	if err != nil || len(report.Issues) != 1 || report.Issues[0].Kind != KindInvalidArchive {
+		t.Errorf("expected invalid archive, got %+v (%v)", report.Issues, err)
+	}
+}
+
+func Test_Check_PublicationRecord_ChecksumMismatchReported(t *testing.T) {
+	r := files.NewFileRepo(t.TempDir())
+	publish(t, r, "acme", "sdk", "1.0.0", map[string]string{"Package.swift": packageSwift})
+	archive := models.NewUploadElement("acme", "sdk", "1.0.0", mimetypes.ApplicationZip, models.SourceArchive)
+	if err := repo.WritePublication(context.Background(), r, archive, models.ReleasePublication{PublishedAt: time.Now(), Checksum: "outdated"}); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := Check(context.Background(), r, Options{Repair: true})
+
+	if err != nil || len(report.Issues) != 1 || report.Issues[0].Kind != KindChecksumMismatch || report.Issues[0].File != "publication.json" || report.Unrepaired() != 1 {
+		t.Errorf("expected unrepaired checksum mismatch of the publication record, got %+v (%v)", report.Issues, err)
+	}
+}
+
+func Test_Check_SidecarsAndIndex(t *testing.T) {
+	r := &indexedRepo{FileRepo: files.NewFileRepo(t.TempDir()), index: map[string][]string{
+		"acme":  {"gone", "sdk"},
+		"other": {"tool"},
+	}}
+	publish(t, r, "acme", "sdk", "1.0.0", map[string]string{"Package.swift": packageSwift})
+	publish(t, r, "other", "tool", "1.0.0", map[string]string{"Package.swift": packageSwift})
+	archive := models.NewUploadElement("acme", "sdk", "1.0.0", mimetypes.ApplicationZip, models.SourceArchive)
+	checksum, _ := r.Checksum(context.Background(), archive)
+	r.sidecars = map[string]string{
+		archive.FileName(): checksum,
+		"Package.swift":    "0000000000000000000000000000000000000000000000000000000000000000",
+	}
+
+	report, err := Check(context.Background(), r, Options{Scope: "acme"})
+	kinds := issueKinds(report)
+	if err != nil || report.Releases != 1 || len(report.Issues) != 2 || kinds[KindChecksumMismatch] != 1 || kinds[KindDanglingIndexEntry] != 1 {
+		t.Fatalf("unexpected issues %+v (%v)", report.Issues, err)
+	}
+	if issue := report.Issues[0]; issue.File != "Package.swift.sha256" {
+		t.Errorf("expected checksum mismatch of the manifest, got %+v", issue)
+	}
+
+	report, _ = Check(context.Background(), r, Options{Scope: "acme", Repair: true})
+	if report.Unrepaired() != 0 {
+		t.Errorf("expected issues to be repaired, got %+v", report.Issues)
+	}
+	if len(r.index["acme"]) != 1 || r.index["acme"][0] != "sdk" || len(r.index["other"]) != 1 {
+		t.Errorf("expected dangling entry to be removed from index, got %v", r.index)
+	}
+}
+
+func Test_Check_MetadataIndex_ChecksBackend(t *testing.T) {
+	backend := &indexedRepo{FileRepo: files.NewFileRepo(t.TempDir()), index: map[string][]string{"acme": {"sdk"}}}
+	publish(t, backend, "acme", "sdk", "1.0.0", map[string]string{"Package.swift": packageSwift})
+	metadataIndex, err := index.Open(filepath.Join(t.TempDir(), "index.db"), backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = metadataIndex.Close() }()
+
+	report, err := Check(context.Background(), metadataIndex, Options{})
+
+	if err != nil || report.Releases != 1 || len(report.Issues) != 0 {
+		t.Errorf("expected the release not indexed yet to be checked, got %+v (%v)", report, err)
+	}
+}
+
+func Test_Check_RepositoryURLIndex_RepairAddsReleasesStoredBefore(t *testing.T) {
+	r := &urlIndexedRepo{FileRepo: files.NewFileRepo(t.TempDir()), urls: map[string]map[string][]string{
+		"https://example.com/acme/old.git":   {"acme.sdk": {"1.0.0"}},
+		"https://example.com/other/tool.git": {"other.tool": {"1.0.0"}},
+	}}
+	publish(t, r, "acme", "sdk", "1.0.0", map[string]string{"Package.swift": packageSwift})
+	write(t, r, models.NewUploadElement("acme", "sdk", "1.0.0", mimetypes.ApplicationJson, models.Metadata),
+		[]byte(`{"repositoryURLs":["https://example.com/acme/sdk.git","git@example.com:acme/sdk.git"]}`))
+
+	report, err := Check(context.Background(), r, Options{Scope: "acme"})
+	kinds := issueKinds(report)
+	if err != nil || len(report.Issues) != 3 || kinds[KindMissingIndexEntry] != 2 || kinds[KindDanglingIndexEntry] != 1 {
+		t.Fatalf("unexpected issues %+v (%v)", report.Issues, err)
+	}
+
+	report, _ = Check(context.Background(), r, Options{Scope: "acme", Repair: true})
+	if report.Unrepaired() != 0 {
+		t.Errorf("expected issues to be repaired, got %+v", report.Issues)
+	}
+	expected := map[string]map[string][]string{
+		"https://example.com/acme/sdk.git":   {"acme.sdk": {"1.0.0"}},
+		"git@example.com:acme/sdk.git":       {"acme.sdk": {"1.0.0"}},
+		"https://example.com/other/tool.git": {"other.tool": {"1.0.0"}},
+	}
+	if !reflect.DeepEqual(r.urls, expected) {
+		t.Errorf("expected mapping rebuilt from the metadata keeping other scopes, got %v", r.urls)
+	}
+	if report, _ := Check(context.Background(), r, Options{Scope: "acme"}); len(report.Issues) != 0 {
+		t.Errorf("expected no issues after repair, got %+v", report.Issues)
+	}
+}
@@ -5,8 +5,10 @@ go 1.23
 require (
 	github.com/coreos/go-oidc/v3 v3.11.0
 	github.com/go-jose/go-jose/v4 v4.0.2
+	go.etcd.io/bbolt v1.3.11
+	golang.org/x/crypto v0.31.0
 	golang.org/x/oauth2 v0.21.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
-require golang.org/x/crypto v0.31.0 // indirect
+require golang.org/x/sys v0.28.0 // indirect
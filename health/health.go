@@ -0,0 +1,119 @@
+package health
+
+import (
+	"OpenSPMRegistry/mimetypes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Checker is implemented by components that can verify they are able to serve requests,
+// e.g. repositories probing their storage backend
+type Checker interface {
+	// CheckHealth returns an error if the component is not usable
+	CheckHealth(ctx context.Context) error
+}
+
+// Check is a named readiness check
+type Check struct {
+	Name    string
+	Checker Checker
+}
+
+// CheckResult is the outcome of a single check
+type CheckResult struct {
+	Name      string  `json:"name"`
+	Status    Status  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the response of the health endpoints
+type Report struct {
+	Status Status        `json:"status"`
+	Checks []CheckResult `json:"checks"`
+}
+
+// Status of a check or the whole report
+type Status string
+
+const (
+	StatusUp   Status = "up"
+	StatusDown Status = "down"
+
+	// checkTimeout limits how long a single check may take
+	checkTimeout = 5 * time.Second
+)
+
+// LivenessHandler reports that the process is able to serve requests (GET /healthz).
+// It does not probe any dependency, so a failing backend does not get the server restarted.
+func LivenessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeReport(w, Report{Status: StatusUp, Checks: []CheckResult{}})
+	}
+}
+
+// ReadinessHandler runs all checks concurrently and reports 503 if any of them fails (GET /readyz)
+func ReadinessHandler(checks []Check) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeReport(w, Run(r.Context(), checks))
+	}
+}
+
+// Run executes the checks concurrently, each limited by checkTimeout
+func Run(ctx context.Context, checks []Check) Report {
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = runCheck(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusUp, Checks: results}
+	for _, result := range results {
+		if result.Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+	return report
+}
+
+func runCheck(ctx context.Context, check Check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Checker.CheckHealth(ctx)
+	result := CheckResult{
+		Name:      check.Name,
+		Status:    StatusUp,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		slog.Warn("Health check failed", "check", check.Name, "error", err)
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func writeReport(w http.ResponseWriter, report Report) {
+	header := w.Header()
+	header.Set("Content-Type", mimetypes.ApplicationJson)
+	header.Set("Cache-Control", "no-store")
+	if report.Status == StatusUp {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		slog.Error("Error encoding JSON:", "error", err)
+	}
+}
@@ -0,0 +1,84 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type checkerFunc func(ctx context.Context) error
+
+func (f checkerFunc) CheckHealth(ctx context.Context) error {
+	return f(ctx)
+}
+
+func Test_ReadinessHandler_AllChecksUp_ReturnsOK(t *testing.T) {
+	checks := []Check{
+		{Name: "storage", Checker: checkerFunc(func(ctx context.Context) error { return nil })},
+		{Name: "auth", Checker: checkerFunc(func(ctx context.Context) error { return nil })},
+	}
+	w := httptest.NewRecorder()
+
+	ReadinessHandler(checks)(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	var report Report
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("failed to decode report: %v", err)
+	}
+	if report.Status != StatusUp || len(report.Checks) != 2 || report.Checks[0].Name != "storage" || report.Checks[1].Name != "auth" {
+		t.Errorf("unexpected report %+v", report)
+	}
+}
+
+func Test_ReadinessHandler_FailingCheck_ReturnsServiceUnavailable(t *testing.T) {
+	checks := []Check{
+		{Name: "storage", Checker: checkerFunc(func(ctx context.Context) error { return errors.New("disk full") })},
+		{Name: "auth", Checker: checkerFunc(func(ctx context.Context) error { return nil })},
+	}
+	w := httptest.NewRecorder()
+
+	ReadinessHandler(checks)(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected status code %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+	var report Report
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("failed to decode report: %v", err)
+	}
+	if report.Status != StatusDown || report.Checks[0].Status != StatusDown || report.Checks[0].Error != "disk full" || report.Checks[1].Status != StatusUp {
+		t.Errorf("unexpected report %+v", report)
+	}
+}
+
+func Test_Run_CheckHasDeadline(t *testing.T) {
+	checks := []Check{{Name: "slow", Checker: checkerFunc(func(ctx context.Context) error {
+		if _, ok := ctx.Deadline(); !ok {
+			return errors.New("no deadline")
+		}
+		return nil
+	})}}
+
+	if report := Run(context.Background(), checks); report.Status != StatusUp {
+		t.Errorf("expected check to run with a deadline, got %+v", report)
+	}
+}
+
+func Test_LivenessHandler_ReturnsOK(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	LivenessHandler()(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	if w.Body.String() != "{\"status\":\"up\",\"checks\":[]}\n" {
+		t.Errorf("unexpected body %s", w.Body.String())
+	}
+}
@@ -1,14 +1,24 @@
 package main
 
 import (
+	"OpenSPMRegistry/admin"
+	"OpenSPMRegistry/audit"
 	"OpenSPMRegistry/authenticator"
 	"OpenSPMRegistry/config"
 	"OpenSPMRegistry/controller"
+	"OpenSPMRegistry/events"
+	"OpenSPMRegistry/health"
+	"OpenSPMRegistry/metrics"
 	"OpenSPMRegistry/middleware"
 	"OpenSPMRegistry/repo"
 	"OpenSPMRegistry/repo/files"
+	"OpenSPMRegistry/repo/index"
 	"OpenSPMRegistry/repo/maven"
+	"OpenSPMRegistry/repo/s3"
+	"OpenSPMRegistry/signing"
+	"OpenSPMRegistry/tokens"
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
@@ -30,8 +40,9 @@ type headResponseWriter struct {
 }
 
 var (
-	verboseFlag bool
-	configPath  string
+	verboseFlag      bool
+	configPath       string
+	rebuildIndexFlag bool
 )
 
 func (h *headResponseWriter) Write(b []byte) (int, error) {
@@ -47,6 +58,11 @@ func loadServerConfig() (*config.ServerRoot, error) {
 			path = "config.yml"
 		}
 	}
+	return readServerConfig(path)
+}
+
+// readServerConfig reads the config file at path, with the defaults of the settings it does not set
+func readServerConfig(path string) (*config.ServerRoot, error) {
 	yamlData, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
@@ -57,6 +73,17 @@ func loadServerConfig() (*config.ServerRoot, error) {
 			Auth: config.AuthConfig{
 				Enabled: true, // enable authentication by default
 			},
+			Publish: config.PublishConfig{
+				Async: config.AsyncPublishConfig{
+					Enabled: true, // honor "Prefer: respond-async" by default
+				},
+			},
+			Metrics: config.MetricsConfig{
+				Enabled: true, // expose /metrics by default
+			},
+			Search: config.SearchConfig{
+				Enabled: true, // index packages for GET /search by default
+			},
 		},
 	}
 	if err := yaml.Unmarshal(yamlData, &serverRoot); err != nil {
@@ -65,9 +92,104 @@ func loadServerConfig() (*config.ServerRoot, error) {
 	return serverRoot, nil
 }
 
+// readinessChecks collects the readiness checks of the storage backend and the authenticator
+func readinessChecks(r repo.Repo, auth authenticator.Authenticator) []health.Check {
+	var checks []health.Check
+	if checker, ok := r.(health.Checker); ok {
+		checks = append(checks, health.Check{Name: "storage", Checker: checker})
+	}
+	if checker, ok := authenticator.Primary(auth).(health.Checker); ok {
+		checks = append(checks, health.Check{Name: "auth", Checker: checker})
+	}
+	return checks
+}
+
+// newRepo creates the storage backend configured
+func newRepo(repoConfig config.Repo) (repo.Repo, error) {
+	switch repoConfig.Type {
+	case "file":
+		return files.NewFileRepo(repoConfig.Path), nil
+	case "maven":
+		mavenRepo, err := maven.NewMavenRepo(repoConfig.Maven)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Maven repository: %w", err)
+		}
+		return mavenRepo, nil
+	case "s3":
+		s3Repo, err := s3.NewS3Repo(repoConfig.S3)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create S3 repository: %w", err)
+		}
+		return s3Repo, nil
+	default:
+		return nil, fmt.Errorf("unsupported repo type: %s", repoConfig.Type)
+	}
+}
+
+// runAdmin runs an admin command on the configured repository (and metadata index) and returns its exit code
+func runAdmin(serverConfig *config.ServerRoot, args []string) int {
+	var metadataIndexes []*index.Repo
+	open := func(repoConfig config.Repo) (repo.Repo, error) {
+		r, err := newRepo(repoConfig)
+		if err != nil || !repoConfig.Index.Enabled {
+			return r, err
+		}
+		// keep the index of the server in sync, fails while the server holds it
+		metadataIndex, err := openIndex(repoConfig.Index, r, false)
+		if err != nil {
+			return nil, err
+		}
+		metadataIndexes = append(metadataIndexes, metadataIndex)
+		return metadataIndex, nil
+	}
+
+	code := admin.Run(context.Background(), args, admin.Env{
+		Config:   serverConfig.Server,
+		OpenRepo: func() (repo.Repo, error) { return open(serverConfig.Server.Repo) },
+		OpenTargetRepo: func(configFile string) (repo.Repo, error) {
+			targetConfig, err := readServerConfig(configFile)
+			if err != nil {
+				return nil, err
+			}
+			return open(targetConfig.Server.Repo)
+		},
+		Stdin:  os.Stdin,
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	})
+	for _, metadataIndex := range metadataIndexes {
+		if err := metadataIndex.Close(); err != nil {
+			slog.Error("Error closing metadata index", "error", err)
+		}
+	}
+	return code
+}
+
+// openIndex wraps the repository with the metadata index, building it from the backend
+// if requested or if it was never built (e.g. enabled for an existing tree)
+func openIndex(indexConfig config.IndexConfig, backend repo.Repo, rebuild bool) (*index.Repo, error) {
+	path := indexConfig.Path
+	if path == "" {
+		path = "index.db"
+	}
+	indexed, err := index.Open(path, backend)
+	if err != nil {
+		return nil, err
+	}
+	if rebuild || !indexed.Built() {
+		slog.Info("Building metadata index", "path", path)
+		if _, err := indexed.Rebuild(context.Background()); err != nil {
+			_ = indexed.Close()
+			return nil, err
+		}
+	}
+	return indexed, nil
+}
+
 func main() {
 	flag.BoolVar(&verboseFlag, "v", false, "show more information")
 	flag.StringVar(&configPath, "config", "", "path to config file (default: config.local.yml or config.yml)")
+	flag.BoolVar(&rebuildIndexFlag, "rebuild-index", false, "rebuild the metadata index from the storage backend and exit")
 	flag.Parse()
 
 	if verboseFlag {
@@ -80,60 +202,148 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
+	if flag.Arg(0) == "admin" {
+		os.Exit(runAdmin(serverConfig, flag.Args()[1:]))
+	}
 
 	registryMux := http.NewServeMux()
 	collectionMux := http.NewServeMux()
 
 	repoConfig := serverConfig.Server.Repo
 
-	var r repo.Repo
-	switch repoConfig.Type {
-	case "file":
-		r = files.NewFileRepo(repoConfig.Path)
-	case "maven":
-		mavenRepo, err := maven.NewMavenRepo(repoConfig.Maven)
+	r, err := newRepo(repoConfig)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if rebuildIndexFlag && !repoConfig.Index.Enabled {
+		log.Fatal("Metadata index is not enabled (repo.index.enabled)")
+	}
+	var metadataIndex *index.Repo
+	if repoConfig.Index.Enabled {
+		metadataIndex, err = openIndex(repoConfig.Index, r, rebuildIndexFlag)
 		if err != nil {
-			log.Fatalf("Failed to create Maven repository: %v", err)
+			log.Fatalf("Failed to open metadata index: %v", err)
 		}
-		r = mavenRepo
-	default:
-		log.Fatalf("Unsupported repo type: %s", repoConfig.Type)
+		if rebuildIndexFlag {
+			if err := metadataIndex.Close(); err != nil {
+				log.Fatalf("Failed to close metadata index: %v", err)
+			}
+			return
+		}
+		r = metadataIndex
 	}
-	a := middleware.NewAuthentication(authenticator.CreateAuthenticator(serverConfig.Server), registryMux)
+	auth := authenticator.CreateAuthenticator(serverConfig.Server)
+	var tokenStore *tokens.Store
+	if serverConfig.Server.Auth.Enabled && serverConfig.Server.Auth.Tokens.Enabled {
+		store, err := tokens.NewStore(serverConfig.Server.Auth.Tokens)
+		if err != nil {
+			log.Fatalf("Failed to load personal access tokens: %v", err)
+		}
+		tokenStore = store
+		auth = authenticator.NewTokenAuthenticator(tokenStore, auth)
+	}
+	a := middleware.NewAuthentication(auth, registryMux)
 	c := controller.NewController(serverConfig.Server, r)
+	// a nil audit log records nothing and leaves handlers unwrapped
+	var auditLog *audit.Log
+	if serverConfig.Server.Audit.Enabled {
+		auditLog, err = audit.Open(serverConfig.Server.Audit)
+		if err != nil {
+			log.Fatalf("Failed to open audit log: %v", err)
+		}
+		a.SetAuditLog(auditLog)
+		c.SetAuditLog(auditLog)
+	}
+	if serverConfig.Server.Publish.Signatures.Enabled {
+		verifier, err := signing.NewArchiveVerifier(serverConfig.Server.Publish.Signatures)
+		if err != nil {
+			log.Fatalf("Failed to load trusted root certificates: %v", err)
+		}
+		c.SetArchiveVerifier(verifier)
+	} else if len(serverConfig.Server.Publish.Signatures.RequireSigned) > 0 {
+		log.Fatalf("publish.signatures.requireSigned needs signature verification (publish.signatures.enabled)")
+	}
+	var eventDispatcher *events.Dispatcher
+	if serverConfig.Server.Webhooks.Enabled {
+		eventDispatcher, err = events.NewDispatcher(serverConfig.Server.Webhooks)
+		if err != nil {
+			log.Fatalf("Failed to set up webhooks: %v", err)
+		}
+		c.SetEventDispatcher(eventDispatcher)
+	}
 
 	// Package Collections on a separate mux so Go 1.22+ ServeMux does not conflict with /{scope}/{package}.
 	// GET also matches HEAD per Go 1.22+ routing.
 	allowAuthQueryParam := serverConfig.Server.PackageCollections.AllowAuthQueryParam
 	if serverConfig.Server.PackageCollections.Enabled {
+		if serverConfig.Server.PackageCollections.Signing.Enabled {
+			signer, err := signing.NewCollectionSigner(serverConfig.Server.PackageCollections.Signing)
+			if err != nil {
+				log.Fatalf("Failed to load collection signing certificate: %v", err)
+			}
+			c.SetCollectionSigner(signer)
+		}
+		globalCollection := metrics.InstrumentHandler("collection", c.GlobalCollectionAction)
+		scopeCollection := metrics.InstrumentHandler("scope_collection", c.ScopeCollectionAction)
 		if serverConfig.Server.PackageCollections.PublicRead {
-			collectionMux.HandleFunc("GET /collection", c.GlobalCollectionAction)
-			collectionMux.HandleFunc("GET /collection/{scope}", c.ScopeCollectionAction)
+			collectionMux.HandleFunc("GET /collection", globalCollection)
+			collectionMux.HandleFunc("GET /collection/{scope}", scopeCollection)
 		} else {
-			collectionMux.HandleFunc("GET /collection", a.WrapHandler(c.GlobalCollectionAction, allowAuthQueryParam))
-			collectionMux.HandleFunc("GET /collection/{scope}", a.WrapHandler(c.ScopeCollectionAction, allowAuthQueryParam))
+			collectionMux.HandleFunc("GET /collection", a.WrapHandler(globalCollection, allowAuthQueryParam))
+			collectionMux.HandleFunc("GET /collection/{scope}", a.WrapHandler(scopeCollection, allowAuthQueryParam))
 		}
 	}
 
 	// authorized routes (registry only). GET matches HEAD automatically.
-	a.HandleFunc("POST /login", c.LoginAction)
-	a.HandleFunc("GET /{scope}/{package}", c.ListAction)
+	// Handlers are instrumented after authentication, rejected requests are counted as auth failures.
+	download := metrics.InstrumentHandler("download", c.DownloadSourceArchiveAction)
+	info := metrics.InstrumentHandler("info", c.InfoAction)
+	a.HandleFunc("POST /login", auditLog.Handler(audit.ActionLogin, c.LoginAction))
+	a.HandleFunc("GET /{scope}/{package}", metrics.InstrumentHandler("list", c.ListAction))
 	a.HandleFunc("GET /{scope}/{package}/{version}", func(w http.ResponseWriter, r *http.Request) {
 		if strings.HasSuffix(r.URL.Path, ".zip") {
-			c.DownloadSourceArchiveAction(w, r)
+			download(w, r)
 		} else {
-			c.InfoAction(w, r)
+			info(w, r)
 		}
 	})
-	a.HandleFunc("GET /{scope}/{package}/{version}/Package.swift", c.FetchManifestAction)
-	a.HandleFunc("GET /identifiers", c.LookupAction)
-	a.HandleFunc("PUT /{scope}/{package}/{version}", c.PublishAction)
+	a.HandleFunc("GET /{scope}/{package}/{version}/Package.swift", metrics.InstrumentHandler("manifest", c.FetchManifestAction))
+	a.HandleFunc("GET /identifiers", metrics.InstrumentHandler("lookup", c.LookupAction))
+	a.HandleFunc("PUT /{scope}/{package}/{version}", metrics.InstrumentHandler("publish", auditLog.Handler(audit.ActionPublish, c.PublishAction)))
+	a.HandleFunc("DELETE /{scope}/{package}/{version}", metrics.InstrumentHandler("delete", auditLog.Handler(audit.ActionDelete, c.DeleteAction)))
+	if serverConfig.Server.Search.Enabled {
+		a.HandleFunc("GET /search", metrics.InstrumentHandler("search", c.SearchAction))
+	}
+	a.HandleFunc("GET /submissions/{id}", metrics.InstrumentHandler("submission", c.SubmissionStatusAction))
+	// hook URLs, payloads and errors, only offered if it is restricted to admins
+	if serverConfig.Server.Webhooks.Enabled && serverConfig.Server.Auth.Enabled && serverConfig.Server.Auth.Authorization.Enabled {
+		a.HandleFunc("GET /webhooks/deliveries", c.WebhookDeliveriesAction)
+	}
+	// principals and source addresses, only offered if it is restricted to admins
+	if auditLog != nil && serverConfig.Server.Auth.Enabled && serverConfig.Server.Auth.Authorization.Enabled {
+		a.HandleFunc("GET /audit", c.AuditLogAction)
+	}
+	// the check walks the whole repository, only offered if it is restricted to admins
+	if serverConfig.Server.Auth.Enabled && serverConfig.Server.Auth.Authorization.Enabled {
+		a.HandleFunc("GET /fsck", c.FsckAction)
+	}
+	if tokenStore != nil {
+		c.SetTokenStore(tokenStore)
+		a.HandleFunc("POST /tokens", auditLog.Handler(audit.ActionTokenCreate, c.CreateTokenAction))
+		a.HandleFunc("GET /tokens", c.ListTokensAction)
+		a.HandleFunc("DELETE /tokens/{id}", auditLog.Handler(audit.ActionTokenRevoke, c.RevokeTokenAction))
+	}
 
 	// public and static routes on registry mux
 	registryMux.HandleFunc("GET /", c.MainAction)
 	registryMux.HandleFunc("GET /favicon.ico", c.StaticAction)
 	registryMux.HandleFunc("GET /favicon.svg", c.StaticAction)
 	registryMux.HandleFunc("GET /output.css", c.StaticAction)
+	registryMux.HandleFunc("GET /healthz", health.LivenessHandler())
+	registryMux.HandleFunc("GET /readyz", health.ReadinessHandler(readinessChecks(r, auth)))
+	if serverConfig.Server.Metrics.Enabled {
+		registryMux.HandleFunc("GET /metrics", metrics.Default.Handler())
+	}
 
 	// Path dispatcher: for HEAD, discard response body; then /collection* -> collectionMux, else -> auth-wrapped registryMux.
 	// Go 1.22+ matches HEAD to GET patterns, so the same handler runs; we only strip the body.
@@ -161,7 +371,10 @@ func main() {
 	signal.Notify(sigChannel, os.Interrupt, syscall.SIGTERM)
 
 	const shutdownTimeout = 30 * time.Second
+	// closed once the shutdown is complete, the server returns as soon as it stops accepting connections
+	shutdownDone := make(chan struct{})
 	go func() {
+		defer close(shutdownDone)
 		<-sigChannel
 		slog.Info("Shutting down server...")
 		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
@@ -169,16 +382,35 @@ func main() {
 		if err := srv.Shutdown(ctx); err != nil {
 			slog.Error("Error shutting down server", "error", err)
 		}
-		os.Exit(1)
+		// finish asynchronous publications already accepted
+		c.Close()
+		if eventDispatcher != nil {
+			if err := eventDispatcher.Close(); err != nil {
+				slog.Error("Error closing webhook delivery log", "error", err)
+			}
+		}
+		if err := auditLog.Close(); err != nil {
+			slog.Error("Error closing audit log", "error", err)
+		}
+		if metadataIndex != nil {
+			if err := metadataIndex.Close(); err != nil {
+				slog.Error("Error closing metadata index", "error", err)
+			}
+		}
 	}()
 
 	if serverConfig.Server.TlsEnabled {
 		slog.Info("Starting HTTPS server on", "port", srv.Addr)
 		certFile := serverConfig.Server.Certs.CertFile
 		keyFile := serverConfig.Server.Certs.KeyFile
-		log.Fatal(srv.ListenAndServeTLS(certFile, keyFile))
+		err = srv.ListenAndServeTLS(certFile, keyFile)
 	} else {
 		slog.Info("Starting HTTP server on", "port", srv.Addr)
-		log.Fatal(srv.ListenAndServe())
+		err = srv.ListenAndServe()
+	}
+	if !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
 	}
+	<-shutdownDone
+	slog.Info("Server stopped")
 }
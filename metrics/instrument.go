@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// statusRecorder captures the status code and the number of body bytes written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+// countingBody counts the bytes read from a request body
+type countingBody struct {
+	io.ReadCloser
+	bytes int64
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	if s.status == 0 {
+		s.status = status
+	}
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	n, err := s.ResponseWriter.Write(b)
+	s.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap gives http.ResponseController access to the underlying writer (e.g. for flushing)
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+func (c *countingBody) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.bytes += int64(n)
+	return n, err
+}
+
+// InstrumentHandler records request count, latency and transferred bytes of the handler under the given name
+func InstrumentHandler(name string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w}
+		var body *countingBody
+		if r.Body != nil && r.Body != http.NoBody {
+			body = &countingBody{ReadCloser: r.Body}
+			r.Body = body
+		}
+
+		next(recorder, r)
+
+		status := recorder.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		HTTPRequests.WithLabelValues(name, r.Method, strconv.Itoa(status)).Inc()
+		HTTPRequestDuration.WithLabelValues(name, r.Method).Observe(time.Since(start).Seconds())
+		// bodies of HEAD requests are discarded, so nothing was served
+		if r.Method != http.MethodHead {
+			BytesServed.WithLabelValues(name).Add(float64(recorder.bytes))
+		}
+		if body != nil {
+			BytesUploaded.WithLabelValues(name).Add(float64(body.bytes))
+		}
+	}
+}
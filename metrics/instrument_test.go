@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_InstrumentHandler_RecordsStatusLatencyAndBytes(t *testing.T) {
+	handler := InstrumentHandler("test_upload", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("done"))
+	})
+
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, "/a/b/1.0.0", strings.NewReader("0123456789")))
+
+	if v := HTTPRequests.WithLabelValues("test_upload", http.MethodPut, "201").Value(); v != 1 {
+		t.Errorf("expected 1 request, got %v", v)
+	}
+	if v := BytesUploaded.WithLabelValues("test_upload").Value(); v != 10 {
+		t.Errorf("expected 10 bytes uploaded, got %v", v)
+	}
+	if v := BytesServed.WithLabelValues("test_upload").Value(); v != 4 {
+		t.Errorf("expected 4 bytes served, got %v", v)
+	}
+	if _, count, _ := HTTPRequestDuration.WithLabelValues("test_upload", http.MethodPut).snapshot(); count != 1 {
+		t.Errorf("expected 1 latency observation, got %d", count)
+	}
+}
+
+func Test_InstrumentHandler_NoExplicitStatus_CountsOK(t *testing.T) {
+	handler := InstrumentHandler("test_implicit", func(w http.ResponseWriter, r *http.Request) {})
+
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if v := HTTPRequests.WithLabelValues("test_implicit", http.MethodGet, "200").Value(); v != 1 {
+		t.Errorf("expected 1 request with status 200, got %v", v)
+	}
+}
@@ -0,0 +1,278 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Registry holds metrics and writes them in the Prometheus text exposition format (version 0.0.4)
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+}
+
+type metric interface {
+	write(w io.Writer)
+}
+
+// CounterVec is a counter partitioned by label values
+type CounterVec struct {
+	name   string
+	help   string
+	labels []string
+	mu     sync.Mutex
+	series map[string]*Counter
+}
+
+// Counter is a monotonically increasing value of a CounterVec
+type Counter struct {
+	labelValues []string
+	mu          sync.Mutex
+	value       float64
+}
+
+// HistogramVec is a histogram partitioned by label values
+type HistogramVec struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*Histogram
+}
+
+// Histogram counts observations in buckets of a HistogramVec
+type Histogram struct {
+	labelValues []string
+	buckets     []float64
+	mu          sync.Mutex
+	counts      []uint64
+	count       uint64
+	sum         float64
+}
+
+// ContentType of the Prometheus text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are the latency buckets in seconds used for request durations
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// NewCounterVec creates and registers a counter with the given label names
+func (r *Registry) NewCounterVec(name string, help string, labels ...string) *CounterVec {
+	c := &CounterVec{name: name, help: help, labels: labels, series: make(map[string]*Counter)}
+	r.register(c)
+	return c
+}
+
+// NewHistogramVec creates and registers a histogram with the given upper bucket bounds and label names
+func (r *Registry) NewHistogramVec(name string, help string, buckets []float64, labels ...string) *HistogramVec {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	h := &HistogramVec{name: name, help: help, labels: labels, buckets: sorted, series: make(map[string]*Histogram)}
+	r.register(h)
+	return h
+}
+
+func (r *Registry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics = append(r.metrics, m)
+}
+
+// Write writes all metrics of the registry in the text exposition format
+func (r *Registry) Write(w io.Writer) {
+	r.mu.Lock()
+	metrics := append([]metric(nil), r.metrics...)
+	r.mu.Unlock()
+
+	for _, m := range metrics {
+		m.write(w)
+	}
+}
+
+// Handler serves the metrics of the registry
+func (r *Registry) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		r.Write(w)
+	}
+}
+
+// WithLabelValues returns the counter for the given label values (in the order of the label names)
+func (c *CounterVec) WithLabelValues(values ...string) *Counter {
+	checkLabelValues(c.name, c.labels, values)
+	key := strings.Join(values, "\xff")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	counter, ok := c.series[key]
+	if !ok {
+		counter = &Counter{labelValues: append([]string(nil), values...)}
+		c.series[key] = counter
+	}
+	return counter
+}
+
+// Inc increments the counter by one
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// Add increases the counter, negative values are ignored
+func (c *Counter) Add(value float64) {
+	if value < 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.value += value
+}
+
+// Value returns the current value of the counter
+func (c *Counter) Value() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.value
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	writeHeader(w, c.name, c.help, "counter")
+
+	c.mu.Lock()
+	series := make([]*Counter, 0, len(c.series))
+	for _, counter := range c.series {
+		series = append(series, counter)
+	}
+	c.mu.Unlock()
+	sortSeries(series, func(s *Counter) []string { return s.labelValues })
+
+	for _, counter := range series {
+		_, _ = fmt.Fprintf(w, "%s%s %s\n", c.name, formatLabels(c.labels, counter.labelValues, "", ""), formatValue(counter.Value()))
+	}
+}
+
+// WithLabelValues returns the histogram for the given label values (in the order of the label names)
+func (h *HistogramVec) WithLabelValues(values ...string) *Histogram {
+	checkLabelValues(h.name, h.labels, values)
+	key := strings.Join(values, "\xff")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	histogram, ok := h.series[key]
+	if !ok {
+		histogram = &Histogram{
+			labelValues: append([]string(nil), values...),
+			buckets:     h.buckets,
+			counts:      make([]uint64, len(h.buckets)),
+		}
+		h.series[key] = histogram
+	}
+	return histogram
+}
+
+// Observe adds a single observation to the histogram
+func (h *Histogram) Observe(value float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, bound := range h.buckets {
+		if value <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += value
+}
+
+// snapshot returns the cumulative bucket counts, the total count and the sum of all observations
+func (h *Histogram) snapshot() ([]uint64, uint64, float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]uint64(nil), h.counts...), h.count, h.sum
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	writeHeader(w, h.name, h.help, "histogram")
+
+	h.mu.Lock()
+	series := make([]*Histogram, 0, len(h.series))
+	for _, histogram := range h.series {
+		series = append(series, histogram)
+	}
+	h.mu.Unlock()
+	sortSeries(series, func(s *Histogram) []string { return s.labelValues })
+
+	for _, histogram := range series {
+		counts, count, sum := histogram.snapshot()
+		for i, bound := range h.buckets {
+			_, _ = fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, histogram.labelValues, "le", formatValue(bound)), counts[i])
+		}
+		_, _ = fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, histogram.labelValues, "le", "+Inf"), count)
+		_, _ = fmt.Fprintf(w, "%s_sum%s %s\n", h.name, formatLabels(h.labels, histogram.labelValues, "", ""), formatValue(sum))
+		_, _ = fmt.Fprintf(w, "%s_count%s %d\n", h.name, formatLabels(h.labels, histogram.labelValues, "", ""), count)
+	}
+}
+
+// checkLabelValues panics on a mismatch of label names and values, which is a programming error
+func checkLabelValues(name string, labels []string, values []string) {
+	if len(labels) != len(values) {
+		panic(fmt.Sprintf("metric %s expects %d label values, got %d", name, len(labels), len(values)))
+	}
+}
+
+func writeHeader(w io.Writer, name string, help string, metricType string) {
+	help = strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+	_, _ = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+// formatLabels formats the labels as {name="value",...}, extraName/extraValue are appended if set (e.g. le)
+func formatLabels(names []string, values []string, extraName string, extraValue string) string {
+	if len(names) == 0 && extraName == "" {
+		return ""
+	}
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `%s="%s"`, name, escaper.Replace(values[i]))
+	}
+	if extraName != "" {
+		if len(names) > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `%s="%s"`, extraName, extraValue)
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
+
+// sortSeries orders series by their label values so the output is stable
+func sortSeries[T any](series []T, labelValues func(T) []string) {
+	sort.Slice(series, func(i, j int) bool {
+		return strings.Join(labelValues(series[i]), "\xff") < strings.Join(labelValues(series[j]), "\xff")
+	})
+}
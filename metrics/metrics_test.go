@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_CounterVec_Write_ExpositionFormat(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounterVec("test_requests_total", "Number of requests.", "handler", "code")
+	c.WithLabelValues("list", "200").Inc()
+	c.WithLabelValues("list", "200").Add(2)
+	c.WithLabelValues("info", "404").Inc()
+	c.WithLabelValues("info", "404").Add(-1) // ignored
+
+	var b strings.Builder
+	r.Write(&b)
+
+	expected := `# HELP test_requests_total Number of requests.
+# TYPE test_requests_total counter
+test_requests_total{handler="info",code="404"} 1
+test_requests_total{handler="list",code="200"} 3
+`
+	if b.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, b.String())
+	}
+}
+
+func Test_HistogramVec_Write_CumulativeBuckets(t *testing.T) {
+	r := NewRegistry()
+	h := r.NewHistogramVec("test_duration_seconds", "Duration.", []float64{1, 0.1}, "method")
+	h.WithLabelValues("GET").Observe(0.05)
+	h.WithLabelValues("GET").Observe(0.5)
+	h.WithLabelValues("GET").Observe(5)
+
+	var b strings.Builder
+	r.Write(&b)
+
+	expected := `# HELP test_duration_seconds Duration.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{method="GET",le="0.1"} 1
+test_duration_seconds_bucket{method="GET",le="1"} 2
+test_duration_seconds_bucket{method="GET",le="+Inf"} 3
+test_duration_seconds_sum{method="GET"} 5.55
+test_duration_seconds_count{method="GET"} 3
+`
+	if b.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, b.String())
+	}
+}
+
+func Test_CounterVec_Write_EscapesLabelValues(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("test_total", "Help with \\ and\nnewline.", "reason").WithLabelValues("a\"b\\c\nd").Inc()
+
+	var b strings.Builder
+	r.Write(&b)
+
+	if !strings.Contains(b.String(), `# HELP test_total Help with \\ and\nnewline.`) {
+		t.Errorf("expected escaped help, got %s", b.String())
+	}
+	if !strings.Contains(b.String(), `test_total{reason="a\"b\\c\nd"} 1`) {
+		t.Errorf("expected escaped label value, got %s", b.String())
+	}
+}
+
+func Test_CounterVec_WithLabelValues_WrongCount_Panics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic")
+		}
+	}()
+	NewRegistry().NewCounterVec("test_total", "Help.", "a", "b").WithLabelValues("a")
+}
+
+func Test_Registry_Handler_SetsContentType(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("test_total", "Help.").WithLabelValues().Inc()
+	w := httptest.NewRecorder()
+
+	r.Handler()(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if w.Header().Get("Content-Type") != ContentType {
+		t.Errorf("expected content type %s, got %s", ContentType, w.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(w.Body.String(), "test_total 1\n") {
+		t.Errorf("expected unlabeled counter, got %s", w.Body.String())
+	}
+}
@@ -0,0 +1,31 @@
+package metrics
+
+// Default is the registry served at /metrics
+var Default = NewRegistry()
+
+var (
+	// HTTPRequests counts handled requests by handler, method and status code
+	HTTPRequests = Default.NewCounterVec("spmregistry_http_requests_total",
+		"Number of HTTP requests handled.", "handler", "method", "code")
+	// HTTPRequestDuration observes the time to handle a request by handler and method
+	HTTPRequestDuration = Default.NewHistogramVec("spmregistry_http_request_duration_seconds",
+		"Duration of HTTP requests in seconds.", DefaultBuckets, "handler", "method")
+	// BytesServed counts response body bytes by handler
+	BytesServed = Default.NewCounterVec("spmregistry_http_response_bytes_total",
+		"Number of response body bytes served.", "handler")
+	// BytesUploaded counts request body bytes by handler
+	BytesUploaded = Default.NewCounterVec("spmregistry_http_request_bytes_total",
+		"Number of request body bytes uploaded.", "handler")
+	// PublishFailures counts failed publications by reason
+	PublishFailures = Default.NewCounterVec("spmregistry_publish_failures_total",
+		"Number of failed publications.", "reason")
+	// AuthFailures counts failed authentications by authenticator type
+	AuthFailures = Default.NewCounterVec("spmregistry_auth_failures_total",
+		"Number of failed authentications.", "authenticator")
+	// MavenRequests counts requests to the Maven backend by method and status code ("error" if no response was received)
+	MavenRequests = Default.NewCounterVec("spmregistry_maven_requests_total",
+		"Number of HTTP requests sent to the Maven backend.", "method", "code")
+	// MavenRequestDuration observes the latency of requests to the Maven backend by method
+	MavenRequestDuration = Default.NewHistogramVec("spmregistry_maven_request_duration_seconds",
+		"Duration of HTTP requests to the Maven backend in seconds.", DefaultBuckets, "method")
+)
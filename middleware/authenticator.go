@@ -1,7 +1,10 @@
 package middleware
 
 import (
+	"OpenSPMRegistry/audit"
 	"OpenSPMRegistry/authenticator"
+	"OpenSPMRegistry/authorizer"
+	"OpenSPMRegistry/metrics"
 	"context"
 	"encoding/base64"
 	"fmt"
@@ -11,8 +14,9 @@ import (
 )
 
 type Authentication struct {
-	auth  authenticator.Authenticator
-	muxer *http.ServeMux
+	auth     authenticator.Authenticator
+	muxer    *http.ServeMux
+	auditLog *audit.Log
 }
 
 // NewAuthentication creates a new authentication middleware based on the provided authenticator.
@@ -25,19 +29,27 @@ func NewAuthentication(auth authenticator.Authenticator, router *http.ServeMux)
 		muxer: router,
 	}
 
+	// routes are provided by the primary authenticator, e.g. behind the personal access token authenticator
+	primary := authenticator.Primary(auth)
+
 	// Register the callback handler for the token authenticator
-	tokenAuth, ok := auth.(any).(authenticator.OidcAuthenticatorCode)
+	tokenAuth, ok := primary.(any).(authenticator.OidcAuthenticatorCode)
 	if ok {
-		router.HandleFunc("GET /callback", tokenAuth.Callback)
+		router.HandleFunc("GET /callback", a.audited(audit.ActionLogin, tokenAuth.Callback))
 	}
-	oidcAuth, ok := auth.(any).(authenticator.OidcAuthenticator)
+	oidcAuth, ok := primary.(any).(authenticator.OidcAuthenticator)
 	if ok {
-		router.HandleFunc("GET /login", oidcAuth.Login)
+		router.HandleFunc("GET /login", a.audited(audit.ActionLogin, oidcAuth.Login))
 	}
 
 	return a
 }
 
+// SetAuditLog records failed authentications and logins in the audit log
+func (a *Authentication) SetAuditLog(log *audit.Log) {
+	a.auditLog = log
+}
+
 func (a *Authentication) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	a.muxer.ServeHTTP(w, r)
 }
@@ -57,6 +69,7 @@ func (a *Authentication) WrapHandler(h http.HandlerFunc, allowAuthQueryParam boo
 // based on the provided authenticator
 // if the request is not authorized, it returns a 401 status code
 // if the request is authorized, it calls the next handler
+// with the principal (if the authenticator provides one) stored in the request context
 func (a *Authentication) authenticate(next http.HandlerFunc, allowAuthQueryParam bool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if allowAuthQueryParam && r.Header.Get("Authorization") == "" {
@@ -69,11 +82,27 @@ func (a *Authentication) authenticate(next http.HandlerFunc, allowAuthQueryParam
 				}
 			}
 		}
-		_, err := a.auth.Authenticate(w, r)
+		var principal *authorizer.Principal
+		var err error
+		if principalAuth, ok := a.auth.(authenticator.PrincipalAuthenticator); ok {
+			principal, err = principalAuth.AuthenticatePrincipal(w, r)
+		} else {
+			// other authenticators only know the user name, which still identifies the principal
+			var name string
+			name, err = a.auth.Authenticate(w, r)
+			if _, noop := a.auth.(*authenticator.NoOpAuthenticator); err == nil && !noop && name != "" {
+				principal = &authorizer.Principal{Name: name}
+			}
+		}
 		if err != nil {
+			metrics.AuthFailures.WithLabelValues(authenticator.TypeName(a.auth, r)).Inc()
+			a.recordAuthenticationFailure(r, err)
 			writeAuthorizationHeaderError(w, err)
 			return
 		}
+		if principal != nil {
+			r = r.WithContext(authorizer.WithPrincipal(r.Context(), principal))
+		}
 
 		if slog.Default().Enabled(context.TODO(), slog.LevelDebug) {
 			slog.Debug("Request authorized")
@@ -83,6 +112,33 @@ func (a *Authentication) authenticate(next http.HandlerFunc, allowAuthQueryParam
 	}
 }
 
+// audited records the handler in the audit log, if one is set by the time of the request
+func (a *Authentication) audited(action string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		a.auditLog.Handler(action, next)(w, r)
+	}
+}
+
+// recordAuthenticationFailure records a rejected request in the audit log,
+// with the user name tried if basic authentication was used
+func (a *Authentication) recordAuthenticationFailure(r *http.Request, err error) {
+	if a.auditLog == nil {
+		return
+	}
+	action := audit.ActionAuthenticate
+	if r.URL.Path == "/login" {
+		action = audit.ActionLogin
+	}
+	entry := a.auditLog.NewEntry(r, action)
+	if username, _, ok := r.BasicAuth(); ok {
+		entry.Principal = username
+	}
+	entry.Outcome = audit.OutcomeDenied
+	entry.Status = http.StatusUnauthorized
+	entry.Reason = err.Error()
+	a.auditLog.Record(entry)
+}
+
 func writeAuthorizationHeaderError(w http.ResponseWriter, err error) {
 	slog.Error("Error parsing authorization header:", "error", err)
 	http.Error(w, fmt.Sprintf("Authentication failed: %s", err), http.StatusUnauthorized)
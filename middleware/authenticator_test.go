@@ -1,11 +1,17 @@
 package middleware
 
 import (
+	"OpenSPMRegistry/audit"
+	"OpenSPMRegistry/authenticator"
+	"OpenSPMRegistry/authorizer"
+	"OpenSPMRegistry/config"
+	"OpenSPMRegistry/metrics"
 	"encoding/base64"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"testing"
 
 	"golang.org/x/oauth2"
@@ -24,6 +30,21 @@ type MockAuthenticator struct {
 	methodCallCount    map[string]int
 }
 
+type MockWrappingAuthenticator struct {
+	MockAuthenticator
+	primary authenticator.Authenticator
+}
+
+type MockPrincipalAuthenticator struct {
+	MockAuthenticator
+	principal *authorizer.Principal
+}
+
+// MockNamedAuthenticator only returns the name of the user
+type MockNamedAuthenticator struct {
+	name string
+}
+
 func Test_NewAuthentication_TokenAuthenticator_RegistersCallbackHandler(t *testing.T) {
 	router := http.NewServeMux()
 	auth := &MockTokenAuthenticator{}
@@ -277,3 +298,138 @@ func Test_AuthQueryParam_NotPromoted_InvalidScheme(t *testing.T) {
 		t.Errorf("expected no auth promotion for invalid scheme, got %q", receivedAuth)
 	}
 }
+
+func Test_HandleFunc_PrincipalAuthenticator_StoresPrincipalInContext(t *testing.T) {
+	router := http.NewServeMux()
+	auth := &MockPrincipalAuthenticator{
+		MockAuthenticator: MockAuthenticator{shouldAuthenticate: true},
+		principal:         &authorizer.Principal{Name: "alice", Groups: []string{"ios-team"}},
+	}
+	a := NewAuthentication(auth, router)
+
+	var received *authorizer.Principal
+	a.HandleFunc("/protected", func(w http.ResponseWriter, r *http.Request) {
+		received = authorizer.PrincipalFromContext(r.Context())
+	})
+
+	req := httptest.NewRequest("GET", "/protected", nil)
+	w := httptest.NewRecorder()
+	a.ServeHTTP(w, req)
+
+	if count := auth.methodCallCount["AuthenticatePrincipal"]; count != 1 {
+		t.Errorf("expected AuthenticatePrincipal to be called once, got %d", count)
+	}
+	if count := auth.methodCallCount["Authenticate"]; count != 0 {
+		t.Errorf("expected Authenticate not to be called, got %d", count)
+	}
+	if received != auth.principal {
+		t.Errorf("expected principal %v in context, got %v", auth.principal, received)
+	}
+}
+
+func Test_HandleFunc_PrincipalAuthenticatorFails_Returns401(t *testing.T) {
+	router := http.NewServeMux()
+	auth := &MockPrincipalAuthenticator{MockAuthenticator: MockAuthenticator{shouldAuthenticate: false}}
+	a := NewAuthentication(auth, router)
+
+	called := false
+	a.HandleFunc("/protected", func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+
+	req := httptest.NewRequest("GET", "/protected", nil)
+	w := httptest.NewRecorder()
+	a.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status Unauthorized, got %v", w.Code)
+	}
+	if called {
+		t.Errorf("expected handler not to be called")
+	}
+}
+
+func Test_HandleFunc_UnauthorizedRequest_CountsAuthFailure(t *testing.T) {
+	router := http.NewServeMux()
+	a := NewAuthentication(&MockAuthenticator{shouldAuthenticate: false}, router)
+	a.HandleFunc("/protected", func(w http.ResponseWriter, r *http.Request) {})
+	failures := metrics.AuthFailures.WithLabelValues("other")
+	before := failures.Value()
+
+	a.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/protected", nil))
+
+	if failures.Value() != before+1 {
+		t.Errorf("expected auth failure to be counted, got %v (before %v)", failures.Value(), before)
+	}
+}
+
+func Test_NewAuthentication_WrappedOidcAuthenticator_RegistersLoginHandler(t *testing.T) {
+	router := http.NewServeMux()
+	oidcAuth := &MockOidcAuthenticator{}
+	NewAuthentication(&MockWrappingAuthenticator{primary: oidcAuth}, router)
+
+	req := httptest.NewRequest("GET", "/login", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if count, ok := oidcAuth.methodCallCount["Login"]; !ok || count == 0 {
+		t.Errorf("expected Login of the primary authenticator to be called")
+	}
+}
+
+func (m *MockWrappingAuthenticator) Unwrap() authenticator.Authenticator {
+	return m.primary
+}
+
+func (m *MockPrincipalAuthenticator) AuthenticatePrincipal(w http.ResponseWriter, r *http.Request) (*authorizer.Principal, error) {
+	m.increaseCallCount("AuthenticatePrincipal")
+	if !m.shouldAuthenticate {
+		return nil, fmt.Errorf("unauthorized")
+	}
+	return m.principal, nil
+}
+
+func (m *MockNamedAuthenticator) Authenticate(w http.ResponseWriter, r *http.Request) (string, error) {
+	return m.name, nil
+}
+
+func Test_HandleFunc_Authenticator_StoresUserNameAsPrincipal(t *testing.T) {
+	router := http.NewServeMux()
+	a := NewAuthentication(&MockNamedAuthenticator{name: "alice"}, router)
+
+	var received *authorizer.Principal
+	a.HandleFunc("/protected", func(w http.ResponseWriter, r *http.Request) {
+		received = authorizer.PrincipalFromContext(r.Context())
+	})
+	a.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/protected", nil))
+
+	if received == nil || received.Name != "alice" {
+		t.Errorf("expected principal alice in context, got %v", received)
+	}
+}
+
+func Test_HandleFunc_UnauthorizedRequest_RecordsAuditEntry(t *testing.T) {
+	auditLog, err := audit.Open(config.AuditConfig{Path: filepath.Join(t.TempDir(), "audit.log")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = auditLog.Close() }()
+	router := http.NewServeMux()
+	a := NewAuthentication(&MockAuthenticator{shouldAuthenticate: false}, router)
+	a.SetAuditLog(auditLog)
+	a.HandleFunc("PUT /{scope}/{package}/{version}", func(w http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest("PUT", "/acme/sdk/1.0.0", nil)
+	req.SetBasicAuth("mallory", "guess")
+	a.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries, _ := auditLog.Query(audit.Filter{}, 0)
+	if len(entries) != 1 {
+		t.Fatalf("expected one audit entry, got %d", len(entries))
+	}
+	entry := entries[0]
+	if entry.Action != audit.ActionAuthenticate || entry.Outcome != audit.OutcomeDenied || entry.Principal != "mallory" ||
+		entry.Scope != "acme" || entry.Version != "1.0.0" || entry.Reason != "unauthorized" {
+		t.Errorf("unexpected audit entry %+v", entry)
+	}
+}
@@ -0,0 +1,274 @@
+// Package migrate copies the releases of one repository to another, e.g. from the file backend to a Maven repository.
+// Files already present in the target with the same checksum are skipped, so an interrupted migration
+// is resumed by running it again.
+package migrate
+
+import (
+	"OpenSPMRegistry/mimetypes"
+	"OpenSPMRegistry/models"
+	"OpenSPMRegistry/repo"
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+// Options of a migration
+type Options struct {
+	// Scope restricts the migration to one scope, every scope is migrated if empty
+	Scope string
+	// Progress is called with the result of every release once it is migrated
+	Progress func(Result)
+}
+
+// Result is the outcome of the migration of one release
+type Result struct {
+	Release string `json:"release"` // scope.name@version
+	Status  string `json:"status"`
+	// Copied and Skipped count the files of the release written to the target, and those it had already
+	Copied  int    `json:"copied"`
+	Skipped int    `json:"skipped"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Report is the result of a migration
+type Report struct {
+	Results []Result `json:"results"`
+	// Migrated, Unchanged and Failed count the releases by status
+	Migrated  int `json:"migrated"`
+	Unchanged int `json:"unchanged"`
+	Failed    int `json:"failed"`
+}
+
+// release is a release being migrated
+type release struct {
+	scope, name, version string
+	source, target       repo.Repo
+	result               *Result
+}
+
+const (
+	// StatusMigrated is reported for releases with files written to the target
+	StatusMigrated = "migrated"
+	// StatusUnchanged is reported for releases the target had already (from an earlier run)
+	StatusUnchanged = "unchanged"
+	StatusFailed    = "failed"
+)
+
+// ErrChecksumMismatch is returned if a file copied does not have the checksum of the source in the target
+var ErrChecksumMismatch = errors.New("checksum mismatch after copy")
+
+// Run copies every release of source (of opts.Scope if given) to target: the source archive, its signature, metadata
+// and metadata signature, every manifest variant, Package.json and the publication record (keeping the publish date).
+// Deleted releases are copied as tombstone. Files are verified by their checksum after the copy.
+// Both are worked on through their backend (see repo.Backend), the release is indexed if target is a repo.Indexer.
+// A failing release does not stop the migration, the error is only set if the releases could not be listed.
+func Run(ctx context.Context, source repo.Repo, target repo.Repo, opts Options) (*Report, error) {
+	indexer, _ := target.(repo.Indexer)
+	source, target = repo.Backend(source), repo.Backend(target)
+
+	var elements []models.ListElement
+	var err error
+	if opts.Scope != "" {
+		elements, err = source.ListInScope(ctx, opts.Scope)
+	} else {
+		elements, err = source.ListAll(ctx)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("listing releases: %w", err)
+	}
+	slices.SortFunc(elements, func(a, b models.ListElement) int {
+		return cmp.Or(strings.Compare(a.Scope, b.Scope), strings.Compare(a.PackageName, b.PackageName), strings.Compare(a.Version, b.Version))
+	})
+
+	report := &Report{Results: []Result{}}
+	for _, element := range elements {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		result := Result{Release: fmt.Sprintf("%s.%s@%s", element.Scope, element.PackageName, element.Version)}
+		rel := &release{scope: element.Scope, name: element.PackageName, version: element.Version, source: source, target: target, result: &result}
+
+		deleted, err := rel.migrate(ctx)
+		if err == nil && indexer != nil && result.Copied > 0 {
+			if deleted {
+				err = indexer.UnindexRelease(ctx, rel.scope, rel.name, rel.version)
+			} else {
+				err = indexer.IndexRelease(ctx, rel.scope, rel.name, rel.version)
+			}
+			if err != nil {
+				err = fmt.Errorf("indexing release: %w", err)
+			}
+		}
+
+		switch {
+		case err != nil:
+			result.Status = StatusFailed
+			result.Error = err.Error()
+			report.Failed++
+			slog.Warn("Error migrating release", "release", result.Release, "error", err)
+		case result.Copied > 0:
+			result.Status = StatusMigrated
+			report.Migrated++
+		default:
+			result.Status = StatusUnchanged
+			report.Unchanged++
+		}
+		report.Results = append(report.Results, result)
+		if opts.Progress != nil {
+			opts.Progress(result)
+		}
+	}
+	return report, nil
+}
+
+// migrate copies the files of the release, returns whether it is a deleted one
+func (r *release) migrate(ctx context.Context) (bool, error) {
+	tombstone := models.NewUploadElement(r.scope, r.name, r.version, mimetypes.ApplicationJson, models.Tombstone)
+	if r.source.Exists(ctx, tombstone) {
+		return true, r.copy(ctx, tombstone)
+	}
+	if r.target.Exists(ctx, tombstone) {
+		return false, errors.New("release was deleted in the target")
+	}
+
+	archive := models.NewUploadElement(r.scope, r.name, r.version, mimetypes.ApplicationZip, models.SourceArchive)
+	if !r.source.Exists(ctx, archive) {
+		return false, errors.New("source archive not found")
+	}
+
+	// the source archive comes first, its manifests are extracted like on publication
+	// (which a backend may rely on) and then replaced by those of the source
+	copied := r.result.Copied
+	if err := r.copy(ctx, archive); err != nil {
+		return false, err
+	}
+	if r.result.Copied > copied {
+		if err := r.target.ExtractManifestFiles(ctx, archive); err != nil {
+			return false, fmt.Errorf("extracting manifests: %w", err)
+		}
+	}
+
+	elements := []*models.UploadElement{
+		models.NewUploadElement(r.scope, r.name, r.version, mimetypes.ApplicationZip, models.SourceArchiveSignature),
+		models.NewUploadElement(r.scope, r.name, r.version, mimetypes.ApplicationJson, models.Metadata),
+		models.NewUploadElement(r.scope, r.name, r.version, mimetypes.ApplicationJson, models.MetadataSignature),
+	}
+	elements = append(elements, repo.ReleaseManifests(ctx, r.source, r.scope, r.name, r.version)...)
+	for _, element := range elements {
+		if !r.source.Exists(ctx, element) {
+			continue
+		}
+		if err := r.copy(ctx, element); err != nil {
+			return false, err
+		}
+	}
+	return false, r.copyPublication(ctx, archive)
+}
+
+// copy copies the element to the target unless it has it with the same checksum already
+// and verifies the checksum of the copy
+func (r *release) copy(ctx context.Context, element *models.UploadElement) error {
+	checksum, err := r.source.Checksum(ctx, element)
+	if err != nil {
+		return fmt.Errorf("%s: checksum of source: %w", element.FileName(), err)
+	}
+
+	if r.target.Exists(ctx, element) {
+		if existing, err := r.target.Checksum(ctx, element); err == nil && existing == checksum {
+			r.result.Skipped++
+			return nil
+		}
+	}
+
+	written, err := r.write(ctx, element)
+	if err != nil {
+		return fmt.Errorf("%s: %w", element.FileName(), err)
+	}
+	if written != checksum {
+		// the checksum of the source was stored next to it and is outdated
+		slog.Warn("Source file does not match its stored checksum", "file", element.FileName(), "stored", checksum, "actual", written)
+	}
+	// hashed again, Checksum may return the checksum recorded on publication
+	copied, err := hashContents(ctx, r.target, element)
+	if err != nil {
+		return fmt.Errorf("%s: checksum of copy: %w", element.FileName(), err)
+	}
+	if copied != written {
+		return fmt.Errorf("%s: %w: %s, expected %s", element.FileName(), ErrChecksumMismatch, copied, written)
+	}
+	r.result.Copied++
+	return nil
+}
+
+// write copies the contents of the element from the source to the target, returns its checksum
+func (r *release) write(ctx context.Context, element *models.UploadElement) (string, error) {
+	reader, err := r.source.GetReader(ctx, element)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = reader.Close() }()
+
+	writer, err := r.target.GetWriter(ctx, element)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(writer, hash), reader); err != nil {
+		_ = writer.Close()
+		return "", err
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
+// copyPublication copies the publication record of the release once all its files are copied,
+// the target then serves the publish date and checksum of the source. The source records releases published before records
+// were kept when their publish date is read (see repo.PublishDate), if it cannot store the record
+// (e.g. read-only) it is written to the target directly.
+func (r *release) copyPublication(ctx context.Context, archive *models.UploadElement) error {
+	published, err := r.source.PublishDate(ctx, archive)
+	if err != nil {
+		return fmt.Errorf("publish date of source: %w", err)
+	}
+	publication := models.NewUploadElement(r.scope, r.name, r.version, mimetypes.ApplicationJson, models.Publication)
+	if r.source.Exists(ctx, publication) {
+		return r.copy(ctx, publication)
+	}
+	if r.target.Exists(ctx, publication) {
+		r.result.Skipped++
+		return nil
+	}
+
+	checksum, err := r.source.Checksum(ctx, archive)
+	if err != nil {
+		return fmt.Errorf("%s: checksum of source: %w", archive.FileName(), err)
+	}
+	if err := repo.WritePublication(ctx, r.target, archive, models.ReleasePublication{PublishedAt: published, Checksum: checksum}); err != nil {
+		return fmt.Errorf("%s: %w", publication.FileName(), err)
+	}
+	r.result.Copied++
+	return nil
+}
+
+// hashContents computes the checksum of the contents of the element
+func hashContents(ctx context.Context, r repo.Repo, element *models.UploadElement) (string, error) {
+	reader, err := r.GetReader(ctx, element)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = reader.Close() }()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, reader); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
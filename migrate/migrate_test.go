@@ -0,0 +1,198 @@
+package migrate
+
+import (
+	"OpenSPMRegistry/mimetypes"
+	"OpenSPMRegistry/models"
+	"OpenSPMRegistry/repo"
+	"OpenSPMRegistry/repo/files"
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// corruptingRepo is a files repo changing the first byte of every file written
+type corruptingRepo struct {
+	*files.FileRepo
+}
+
+// readOnlyRepo is a files repo failing to write
+type readOnlyRepo struct {
+	*files.FileRepo
+}
+
+// corruptingWriter changes the first byte written
+type corruptingWriter struct {
+	io.WriteCloser
+	written bool
+}
+
+var published = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func (r *corruptingRepo) GetWriter(ctx context.Context, element *models.UploadElement) (io.WriteCloser, error) {
+	writer, err := r.FileRepo.GetWriter(ctx, element)
+	return &corruptingWriter{WriteCloser: writer}, err
+}
+
+func (r *readOnlyRepo) GetWriter(context.Context, *models.UploadElement) (io.WriteCloser, error) {
+	return nil, errors.New("read-only")
+}
+
+func (w *corruptingWriter) Write(p []byte) (int, error) {
+	if !w.written && len(p) > 0 {
+		w.written = true
+		p = append([]byte{p[0] ^ 0xff}, p[1:]...)
+	}
+	return w.WriteCloser.Write(p)
+}
+
+func element(uploadType models.UploadElementType, mimeType string) *models.UploadElement {
+	return models.NewUploadElement("acme", "sdk", "1.0.0", mimeType, uploadType)
+}
+
+func write(t *testing.T, r repo.Repo, element *models.UploadElement, data []byte) {
+	t.Helper()
+	writer, err := r.GetWriter(context.Background(), element)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = writer.Write(data)
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newSourceRepo creates a files repo with acme.sdk 1.0.0 (every kind of file) and the deleted acme.sdk 0.9.0
+func newSourceRepo(t *testing.T) *files.FileRepo {
+	t.Helper()
+	r := files.NewFileRepo(t.TempDir())
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	for _, name := range []string{"Package.swift", "Package@swift-5.8.swift"} {
+		f, _ := zw.Create("acme.sdk/" + name)
+		_, _ = f.Write([]byte("// swift-tools-version:5.9\n"))
+	}
+	_ = zw.Close()
+
+	write(t, r, element(models.SourceArchive, mimetypes.ApplicationZip), archive.Bytes())
+	if err := r.ExtractManifestFiles(context.Background(), element(models.SourceArchive, mimetypes.ApplicationZip)); err != nil {
+		t.Fatal(err)
+	}
+	write(t, r, element(models.SourceArchiveSignature, mimetypes.ApplicationZip), []byte("signature"))
+	write(t, r, element(models.Metadata, mimetypes.ApplicationJson), []byte(`{"description":"SDK"}`))
+	write(t, r, element(models.PackageManifestJson, mimetypes.ApplicationJson), []byte(`{"name":"sdk"}`))
+	if err := r.SetPublishDate(context.Background(), element(models.SourceArchive, mimetypes.ApplicationZip), published); err != nil {
+		t.Fatal(err)
+	}
+
+	deleted := models.NewUploadElement("acme", "sdk", "0.9.0", mimetypes.ApplicationZip, models.SourceArchive)
+	if err := repo.WriteTombstone(context.Background(), r, deleted, "abc", published); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func Test_Run_CopiesReleasesWithPublishDates(t *testing.T) {
+	source := newSourceRepo(t)
+	target := files.NewFileRepo(t.TempDir())
+	var progress []string
+
+	report, err := Run(context.Background(), source, target, Options{Progress: func(result Result) { progress = append(progress, result.Release) }})
+
+	if err != nil || report.Migrated != 2 || report.Failed != 0 || len(progress) != 2 {
+		t.Fatalf("expected two migrated releases, got %+v (%v)", report, err)
+	}
+	if result := report.Results[1]; result.Release != "acme.sdk@1.0.0" || result.Copied != 5 || result.Skipped != 2 {
+		t.Errorf("expected publication record, archive, signature, metadata and Package.json to be copied and the extracted manifests to be kept, got %+v", result)
+	}
+	ctx := context.Background()
+	for _, e := range []*models.UploadElement{
+		element(models.SourceArchive, mimetypes.ApplicationZip),
+		element(models.SourceArchiveSignature, mimetypes.ApplicationZip),
+		element(models.Metadata, mimetypes.ApplicationJson),
+		element(models.PackageManifestJson, mimetypes.ApplicationJson),
+		element(models.Manifest, mimetypes.TextXSwift).SetFilenameOverwrite("Package@swift-5.8"),
+	} {
+		expected, _ := source.Checksum(ctx, e)
+		if actual, err := target.Checksum(ctx, e); err != nil || actual != expected {
+			t.Errorf("expected %s to be copied, got %q (%v)", e.FileName(), actual, err)
+		}
+	}
+	if date, _ := target.PublishDate(ctx, element(models.SourceArchive, mimetypes.ApplicationZip)); !date.Equal(published) {
+		t.Errorf("expected publish date %v, got %v", published, date)
+	}
+	if !repo.IsReleaseDeleted(ctx, target, "acme", "sdk", "0.9.0") {
+		t.Error("expected tombstone of the deleted release to be copied")
+	}
+}
+
+func Test_Run_ReadOnlySourceWithoutRecord_RecordsPublishDateInTarget(t *testing.T) {
+	source := &readOnlyRepo{FileRepo: newSourceRepo(t)}
+	target := files.NewFileRepo(t.TempDir())
+
+	report, err := Run(context.Background(), source, target, Options{})
+
+	if err != nil || report.Migrated != 2 || report.Results[1].Copied != 5 {
+		t.Fatalf("expected two migrated releases, got %+v (%v)", report, err)
+	}
+	// the copies are newer, the publish date is kept by the record
+	if date, _ := target.PublishDate(context.Background(), element(models.SourceArchive, mimetypes.ApplicationZip)); !date.Equal(published) {
+		t.Errorf("expected publish date %v, got %v", published, date)
+	}
+}
+
+func Test_Run_Again_IsIdempotent(t *testing.T) {
+	source := newSourceRepo(t)
+	target := files.NewFileRepo(t.TempDir())
+	if _, err := Run(context.Background(), source, target, Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := Run(context.Background(), source, target, Options{})
+
+	if err != nil || report.Unchanged != 2 || report.Migrated != 0 || report.Results[1].Skipped != 7 {
+		t.Errorf("expected nothing to be copied again, got %+v (%v)", report, err)
+	}
+}
+
+func Test_Run_PartialTarget_Resumes(t *testing.T) {
+	source := newSourceRepo(t)
+	target := files.NewFileRepo(t.TempDir())
+	write(t, target, element(models.Metadata, mimetypes.ApplicationJson), []byte(`{"description":"SDK"}`))
+	write(t, target, element(models.SourceArchiveSignature, mimetypes.ApplicationZip), []byte("outdated"))
+
+	report, err := Run(context.Background(), source, target, Options{Scope: "acme"})
+
+	if err != nil || report.Migrated != 2 || report.Results[1].Copied != 4 || report.Results[1].Skipped != 3 {
+		t.Errorf("expected the missing and outdated files to be copied, got %+v (%v)", report, err)
+	}
+}
+
+func Test_Run_DeletedInTarget_Fails(t *testing.T) {
+	source := newSourceRepo(t)
+	target := files.NewFileRepo(t.TempDir())
+	if err := repo.WriteTombstone(context.Background(), target, element(models.SourceArchive, mimetypes.ApplicationZip), "", published); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := Run(context.Background(), source, target, Options{})
+
+	if err != nil || report.Failed != 1 || report.Results[1].Status != StatusFailed || report.Results[1].Copied != 0 {
+		t.Errorf("expected release deleted in the target to fail, got %+v (%v)", report, err)
+	}
+}
+
+func Test_Run_CorruptedCopy_Fails(t *testing.T) {
+	source := newSourceRepo(t)
+	target := &corruptingRepo{FileRepo: files.NewFileRepo(t.TempDir())}
+
+	report, err := Run(context.Background(), source, target, Options{})
+
+	if err != nil || report.Failed != 2 || !strings.Contains(report.Results[1].Error, ErrChecksumMismatch.Error()) {
+		t.Errorf("expected checksum mismatch, got %+v (%v)", report, err)
+	}
+}
@@ -13,6 +13,33 @@ type PackageCollection struct {
 	GeneratedBy   GeneratedBy         `json:"generatedBy,omitempty"`
 }
 
+// SignedPackageCollection is a package collection with the signature of its publisher
+type SignedPackageCollection struct {
+	PackageCollection
+	Signature CollectionSignature `json:"signature"`
+}
+
+// CollectionSignature is the signature block of a signed collection.
+// Signature is a JWS (compact serialization) over the collection carrying the certificate chain in its x5c header.
+type CollectionSignature struct {
+	Signature   string               `json:"signature"`
+	Certificate SignatureCertificate `json:"certificate"`
+}
+
+// SignatureCertificate describes the certificate the collection was signed with
+type SignatureCertificate struct {
+	Subject CertificateName `json:"subject"`
+	Issuer  CertificateName `json:"issuer"`
+}
+
+// CertificateName is the distinguished name of a certificate subject or issuer
+type CertificateName struct {
+	UserID             string `json:"userID,omitempty"`
+	CommonName         string `json:"commonName,omitempty"`
+	OrganizationalUnit string `json:"organizationalUnit,omitempty"`
+	Organization       string `json:"organization,omitempty"`
+}
+
 // GeneratedBy describes who generated the collection
 type GeneratedBy struct {
 	Name string `json:"name,omitempty"`
@@ -91,8 +118,9 @@ type License struct {
 	URL  string `json:"url"`
 }
 
-// Signer represents package signing information
+// Signer represents package signing information (the subject of the signing certificate)
 type Signer struct {
-	CommonName   string `json:"commonName,omitempty"`
-	Organization string `json:"organization,omitempty"`
+	CommonName         string `json:"commonName,omitempty"`
+	OrganizationalUnit string `json:"organizationalUnitName,omitempty"`
+	Organization       string `json:"organizationName,omitempty"`
 }
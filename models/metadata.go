@@ -0,0 +1,107 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"time"
+)
+
+// OriginalPublicationTime is the release metadata field holding the time a release was first published
+const OriginalPublicationTime = "originalPublicationTime"
+
+// ValidateMetadata checks release metadata against the schema of the registry specification (4.6):
+// author, description, licenseURL, originalPublicationTime, readmeURL and repositoryURLs.
+// Fields not defined by the schema are allowed.
+// Returns an error for every invalid field, nil if the metadata is valid.
+func ValidateMetadata(data []byte) []string {
+	var metadata map[string]any
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return []string{fmt.Sprintf("metadata is not a valid JSON object: %v", err)}
+	}
+	if metadata == nil {
+		return []string{"metadata is not a valid JSON object"}
+	}
+
+	var failures []string
+	fail := func(field string, format string, args ...any) {
+		failures = append(failures, field+": "+fmt.Sprintf(format, args...))
+	}
+
+	if author, ok := metadata["author"]; ok {
+		validateContact("author", author, fail)
+	}
+	if description, ok := metadata["description"]; ok {
+		if _, ok := description.(string); !ok {
+			fail("description", "must be a string")
+		}
+	}
+	for _, field := range []string{"licenseURL", "readmeURL"} {
+		if value, ok := metadata[field]; ok {
+			validateURL(field, value, fail)
+		}
+	}
+	if value, ok := metadata[OriginalPublicationTime]; ok {
+		if s, ok := value.(string); !ok {
+			fail(OriginalPublicationTime, "must be a string")
+		} else if _, err := time.Parse(time.RFC3339, s); err != nil {
+			fail(OriginalPublicationTime, "%q is not an ISO 8601 date-time", s)
+		}
+	}
+	if value, ok := metadata["repositoryURLs"]; ok {
+		urls, ok := value.([]any)
+		if !ok {
+			fail("repositoryURLs", "must be an array of strings")
+		}
+		for i, repositoryURL := range urls {
+			// SCP-like git URLs (git@github.com:mona/LinkedList.git) are valid repository URLs
+			if s, ok := repositoryURL.(string); !ok || s == "" {
+				fail(fmt.Sprintf("repositoryURLs[%d]", i), "must be a non-empty string")
+			}
+		}
+	}
+	return failures
+}
+
+// validateContact checks an author or organization object, both require a name
+func validateContact(field string, value any, fail func(field string, format string, args ...any)) {
+	contact, ok := value.(map[string]any)
+	if !ok {
+		fail(field, "must be an object")
+		return
+	}
+	if name, ok := contact["name"].(string); !ok || name == "" {
+		fail(field+".name", "is required")
+	}
+	if description, ok := contact["description"]; ok {
+		if _, ok := description.(string); !ok {
+			fail(field+".description", "must be a string")
+		}
+	}
+	if email, ok := contact["email"]; ok {
+		if s, ok := email.(string); !ok {
+			fail(field+".email", "must be a string")
+		} else if address, err := mail.ParseAddress(s); err != nil || address.Address != s {
+			fail(field+".email", "%q is not an email address", s)
+		}
+	}
+	if u, ok := contact["url"]; ok {
+		validateURL(field+".url", u, fail)
+	}
+	if organization, ok := contact["organization"]; ok && field == "author" {
+		validateContact(field+".organization", organization, fail)
+	}
+}
+
+// validateURL checks that value is an absolute URL
+func validateURL(field string, value any, fail func(field string, format string, args ...any)) {
+	s, ok := value.(string)
+	if !ok {
+		fail(field, "must be a string")
+		return
+	}
+	if u, err := url.Parse(s); err != nil || u.Scheme == "" || u.Host == "" {
+		fail(field, "%q is not an absolute URL", s)
+	}
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_ValidateMetadata_ValidMetadata_ReturnsNoFailures(t *testing.T) {
+	for _, metadata := range []string{
+		`{}`,
+		`{"description":"networking","licenseURL":"https://github.com/mona/LinkedList/license","readmeURL":"https://github.com/mona/LinkedList/readme",
+		  "repositoryURLs":["https://github.com/mona/LinkedList","git@github.com:mona/LinkedList.git"],"originalPublicationTime":"2023-01-08T12:00:00Z",
+		  "author":{"name":"Mona","email":"mona@example.com","url":"https://example.com","organization":{"name":"GitHub","email":"hello@github.com"}},
+		  "custom":1}`,
+	} {
+		if failures := ValidateMetadata([]byte(metadata)); failures != nil {
+			t.Errorf("expected no failures for %s, got %v", metadata, failures)
+		}
+	}
+}
+
+func Test_ValidateMetadata_NotAnObject_ReturnsFailure(t *testing.T) {
+	for _, metadata := range []string{"metadata", "null", "[]", `"description"`} {
+		failures := ValidateMetadata([]byte(metadata))
+
+		if len(failures) != 1 || !strings.Contains(failures[0], "not a valid JSON object") {
+			t.Errorf("expected invalid JSON failure for %s, got %v", metadata, failures)
+		}
+	}
+}
+
+func Test_ValidateMetadata_InvalidFields_ReturnsEveryFieldError(t *testing.T) {
+	metadata := `{"description":1,"licenseURL":"license","readmeURL":"https://example.com/readme",
+		"repositoryURLs":["https://github.com/mona/LinkedList",""],"originalPublicationTime":"yesterday",
+		"author":{"email":"mona","organization":{"url":"github"}}}`
+
+	failures := ValidateMetadata([]byte(metadata))
+
+	expected := []string{
+		"author.name: is required",
+		"author.email:",
+		"author.organization.name: is required",
+		"author.organization.url:",
+		"description: must be a string",
+		"licenseURL:",
+		"originalPublicationTime:",
+		"repositoryURLs[1]:",
+	}
+	if len(failures) != len(expected) {
+		t.Fatalf("expected %d failures, got %v", len(expected), failures)
+	}
+	for i, prefix := range expected {
+		if !strings.HasPrefix(failures[i], prefix) {
+			t.Errorf("expected failure %d to start with %q, got %q", i, prefix, failures[i])
+		}
+	}
+}
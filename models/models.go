@@ -9,15 +9,18 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"time"
 )
 
-// Version represents a version of a package.
-// It is composed of a major, minor, patch version and an optional suffix.
+// Version represents a semantic version (SemVer 2.0.0) of a package.
+// It is composed of a major, minor, patch version, an optional pre-release suffix
+// and optional build metadata, which is ignored for precedence.
 type Version struct {
 	Major  int
 	Minor  int
 	Patch  int
 	Suffix string
+	Build  string
 }
 
 type UploadElementType string
@@ -38,7 +41,32 @@ type ListElement struct {
 }
 
 type Release struct {
-	Url string `json:"url"`
+	Url     string   `json:"url"`
+	Problem *Problem `json:"problem,omitempty"`
+}
+
+// Problem reports why a listed release is unavailable (problem details, spec 4.1)
+type Problem struct {
+	Status int    `json:"status"`
+	Title  string `json:"title,omitempty"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// ReleaseTombstone is stored in place of a deleted release.
+// It keeps the version from being published again with different contents.
+type ReleaseTombstone struct {
+	DeletedAt time.Time `json:"deletedAt"`
+	Checksum  string    `json:"checksum,omitempty"`
+}
+
+// ReleasePublication is stored next to a release when it is published.
+// It is the source of truth of the publish date, which storage timestamps do not keep (e.g. on backup restores).
+type ReleasePublication struct {
+	PublishedAt time.Time `json:"publishedAt"`
+	Publisher   string    `json:"publisher,omitempty"`
+	Checksum    string    `json:"checksum,omitempty"`
+	// Signer of the source archive, only set if its signature was verified on publication
+	Signer *Signer `json:"signer,omitempty"`
 }
 
 type ListRelease struct {
@@ -52,8 +80,12 @@ const (
 	MetadataSignature      UploadElementType = "metadata-signature"
 	Manifest               UploadElementType = "manifest"
 	PackageManifestJson    UploadElementType = "package-manifest-json"
+	Tombstone              UploadElementType = "tombstone"
+	Publication            UploadElementType = "publication"
 )
 
+// Compare returns the precedence of v relative to v1 (SemVer 2.0.0 section 11):
+// negative if v is lower, 0 if equal and positive if v is higher. Build metadata is ignored.
 func (v Version) Compare(v1 *Version) int {
 	if v.Major != v1.Major {
 		return v.Major - v1.Major
@@ -67,15 +99,57 @@ func (v Version) Compare(v1 *Version) int {
 	if v.Suffix == v1.Suffix {
 		return 0
 	}
-	// we have a suffix
+	// a pre-release version has lower precedence than the associated normal version
+	if v.Suffix == "" {
+		return 1
+	}
+	if v1.Suffix == "" {
+		return -1
+	}
+	return comparePrerelease(v.Suffix, v1.Suffix)
+}
+
+// String returns the version in SemVer notation
+func (v Version) String() string {
+	version := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
 	if v.Suffix != "" {
-		if v1.Suffix != "" {
-			return strings.Compare(v.Suffix, v1.Suffix)
+		version += "-" + v.Suffix
+	}
+	if v.Build != "" {
+		version += "+" + v.Build
+	}
+	return version
+}
+
+// comparePrerelease compares dot separated pre-release identifiers from left to right:
+// numeric identifiers numerically, alphanumeric ones lexically in ASCII order, numeric lower than alphanumeric,
+// and a smaller set of identifiers is lower if all preceding identifiers are equal
+func comparePrerelease(a string, b string) int {
+	identifiersA := strings.Split(a, ".")
+	identifiersB := strings.Split(b, ".")
+	for i := 0; i < len(identifiersA) && i < len(identifiersB); i++ {
+		idA, idB := identifiersA[i], identifiersB[i]
+		numericA, numericB := isNumeric(idA), isNumeric(idB)
+		switch {
+		case numericA && numericB:
+			// no leading zeros, so the longer number is the bigger one
+			if len(idA) != len(idB) {
+				return len(idA) - len(idB)
+			}
+			if c := strings.Compare(idA, idB); c != 0 {
+				return c
+			}
+		case numericA:
+			return -1
+		case numericB:
+			return 1
+		default:
+			if c := strings.Compare(idA, idB); c != 0 {
+				return c
+			}
 		}
-		return -1
 	}
-	// v1 has a suffix but v not, otherwise we would not reach this point
-	return 1
+	return len(identifiersA) - len(identifiersB)
 }
 
 func NewListElement(scope string, packageName string, version string) *ListElement {
@@ -101,6 +175,12 @@ func NewUploadElement(scope string, name string, version string, mimeType string
 	case PackageManifestJson:
 		element.SetFilenameOverwrite("Package")
 		element.SetExtOverwrite(".json")
+	case Tombstone:
+		element.SetFilenameOverwrite("tombstone")
+		element.SetExtOverwrite(".json")
+	case Publication:
+		element.SetFilenameOverwrite("publication")
+		element.SetExtOverwrite(".json")
 	default:
 		// No overwrite needed
 	}
@@ -171,6 +251,18 @@ func NewRelease(url string) *Release {
 	return &Release{Url: url}
 }
 
+// NewDeletedRelease creates a release entry reporting the release as gone (410)
+func NewDeletedRelease(url string) *Release {
+	return &Release{
+		Url: url,
+		Problem: &Problem{
+			Status: 410,
+			Title:  "Gone",
+			Detail: "this release was removed from the registry",
+		},
+	}
+}
+
 func NewListRelease(releases map[string]Release) *ListRelease {
 	return &ListRelease{Releases: releases}
 }
@@ -231,60 +323,102 @@ func (l *ListRelease) MarshalJSON() ([]byte, error) {
 	return b.Bytes(), nil
 }
 
-// ParseVersion parses a version string and returns a Version struct
-// If the version string contains a suffix, it is stored in the Version struct
-// as well.
-// The version string must have at least 1 part.
-// If the version string has less than 2 parts, the missing parts are set to 0.
+// ParseVersion parses a semantic version string (SemVer 2.0.0, e.g. 1.2.3-beta.2+build.5)
+// and returns a Version struct. Pre-release and build metadata are stored in Suffix and Build.
+// Major, minor and patch version are required and must not have leading zeros.
 // In case of invalid version string, an error is returned.
 func ParseVersion(versionStr string) (*Version, error) {
 	v := &Version{}
-	if strings.Contains(versionStr, "-") {
-		split := strings.Split(versionStr, "-")
-		if len(split) > 2 {
-			return nil, errors.New("version string must have at only 1 suffix")
+	versionStr, build, hasBuild := strings.Cut(versionStr, "+")
+	if hasBuild {
+		if err := validateIdentifiers(build, "build metadata", false); err != nil {
+			return nil, err
 		}
-		versionStr = split[0]
-		v.Suffix = split[1]
-		if v.Suffix == "" {
+		v.Build = build
+	}
+	versionStr, suffix, hasSuffix := strings.Cut(versionStr, "-")
+	if hasSuffix {
+		if suffix == "" {
 			return nil, errors.New("suffix cannot be empty once specified")
 		}
+		if err := validateIdentifiers(suffix, "pre-release", true); err != nil {
+			return nil, err
+		}
+		v.Suffix = suffix
 	}
-	split := strings.Split(versionStr, ".")
-	// as we have at least 1 part, no need to check for empty array
 
-	if len(split) < 2 {
-		split = append(split, "0")
+	split := strings.Split(versionStr, ".")
+	if len(split) != 3 {
+		return nil, fmt.Errorf("version %q must have major, minor and patch version", versionStr)
 	}
-	if len(split) < 3 {
-		split = append(split, "0")
+	parts := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range split {
+		if !isNumeric(part) {
+			return nil, fmt.Errorf("invalid version number %q", part)
+		}
+		if len(part) > 1 && part[0] == '0' {
+			return nil, fmt.Errorf("version number %q must not have leading zeros", part)
+		}
+		number, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, err
+		}
+		*parts[i] = number
 	}
+	return v, nil
+}
 
-	var err error
-
-	if v.Major, err = strconv.Atoi(split[0]); err != nil {
-		return nil, err
-	}
-	if v.Minor, err = strconv.Atoi(split[1]); err != nil {
-		return nil, err
-	}
-	if v.Patch, err = strconv.Atoi(split[2]); err != nil {
-		return nil, err
+// CompareVersions compares two version strings by precedence.
+// Invalid versions are lower than valid ones and equal among each other.
+func CompareVersions(a string, b string) int {
+	v1, err1 := ParseVersion(a)
+	v2, err2 := ParseVersion(b)
+	switch {
+	case err1 != nil && err2 != nil:
+		return 0
+	case err1 != nil:
+		return -1
+	case err2 != nil:
+		return 1
 	}
-	return v, nil
+	return v1.Compare(v2)
 }
 
+// SortVersions sorts the versions highest first, invalid versions are kept in their order at the end
 func SortVersions(versions []string) []string {
-	slices.SortFunc(versions, func(a string, b string) int {
-		v1, err := ParseVersion(a)
-		if err != nil {
-			return 1
-		}
-		v2, err := ParseVersion(b)
-		if err != nil {
-			return -1
-		}
-		return v2.Compare(v1)
+	slices.SortStableFunc(versions, func(a string, b string) int {
+		return CompareVersions(b, a)
 	})
 	return versions
 }
+
+// validateIdentifiers checks dot separated pre-release or build identifiers: non-empty, [0-9A-Za-z-]
+// and, for pre-release, numeric identifiers without leading zeros
+func validateIdentifiers(identifiers string, kind string, noLeadingZeros bool) error {
+	for _, identifier := range strings.Split(identifiers, ".") {
+		if identifier == "" {
+			return fmt.Errorf("%s identifier cannot be empty", kind)
+		}
+		for _, r := range identifier {
+			if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '-') {
+				return fmt.Errorf("%s identifier %q contains invalid character %q", kind, identifier, r)
+			}
+		}
+		if noLeadingZeros && isNumeric(identifier) && len(identifier) > 1 && identifier[0] == '0' {
+			return fmt.Errorf("numeric %s identifier %q must not have leading zeros", kind, identifier)
+		}
+	}
+	return nil
+}
+
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"OpenSPMRegistry/mimetypes"
+	"encoding/json"
 	"testing"
 )
 
@@ -197,12 +198,64 @@ func Test_ParseVersion_InvalidSuffix_ReturnsError(t *testing.T) {
 	}
 }
 
-func Test_ParseVersion_MultipleSuffixes_ReturnsError(t *testing.T) {
+func Test_ParseVersion_HyphenInSuffix_ReturnsVersion(t *testing.T) {
 	versionStr := "1.2.3-alpha-beta"
+	expected := &Version{Major: 1, Minor: 2, Patch: 3, Suffix: "alpha-beta"}
 
-	_, err := ParseVersion(versionStr)
-	if err == nil {
-		t.Errorf("expected error, got nil")
+	result, err := ParseVersion(versionStr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *result != *expected {
+		t.Errorf("expected %v, got %v", expected, result)
+	}
+}
+
+func Test_ParseVersion_BuildMetadata_ReturnsVersion(t *testing.T) {
+	versionStr := "1.0.0-beta.2+exp.sha.5114f85"
+	expected := &Version{Major: 1, Minor: 0, Patch: 0, Suffix: "beta.2", Build: "exp.sha.5114f85"}
+
+	result, err := ParseVersion(versionStr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *result != *expected {
+		t.Errorf("expected %v, got %v", expected, result)
+	}
+	if result.String() != versionStr {
+		t.Errorf("expected %s, got %s", versionStr, result.String())
+	}
+}
+
+func Test_ParseVersion_NotSemVer_ReturnsError(t *testing.T) {
+	versionStrings := []string{"1", "1.2", "1.2.3.4", "01.2.3", "1.02.3", "1.2.03", "1.2.3-01", "1.2.3-beta..1",
+		"1.2.3-beta_1", "1.2.3+", "1.2.3+build.", "-1.2.3", "1.2.3 ", "v1.2.3"}
+
+	for _, versionStr := range versionStrings {
+		if _, err := ParseVersion(versionStr); err == nil {
+			t.Errorf("expected error for %q, got nil", versionStr)
+		}
+	}
+}
+
+func Test_Compare_PrereleasePrecedence_FollowsSemVer(t *testing.T) {
+	// SemVer 2.0.0 section 11 example, lowest first
+	ordered := []string{"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta", "1.0.0-beta.2",
+		"1.0.0-beta.11", "1.0.0-rc.1", "1.0.0-rc.2", "1.0.0-rc.10", "1.0.0"}
+
+	for i := 0; i < len(ordered)-1; i++ {
+		if result := CompareVersions(ordered[i], ordered[i+1]); result >= 0 {
+			t.Errorf("expected %s < %s, got %d", ordered[i], ordered[i+1], result)
+		}
+		if result := CompareVersions(ordered[i+1], ordered[i]); result <= 0 {
+			t.Errorf("expected %s > %s, got %d", ordered[i+1], ordered[i], result)
+		}
+	}
+}
+
+func Test_Compare_BuildMetadata_IsIgnored(t *testing.T) {
+	if result := CompareVersions("1.0.0+build.1", "1.0.0+build.2"); result != 0 {
+		t.Errorf("expected equal precedence, got %d", result)
 	}
 }
 
@@ -326,6 +379,18 @@ func Test_SortVersions_NoVersions_ReturnsEmpty(t *testing.T) {
 	}
 }
 
+func Test_SortVersions_NumericPrerelease_ReturnsSorted(t *testing.T) {
+	versions := []string{"1.0.0-rc.2", "1.0.0-rc.10", "1.0.0-rc.1", "1.0.0"}
+	expected := []string{"1.0.0", "1.0.0-rc.10", "1.0.0-rc.2", "1.0.0-rc.1"}
+
+	result := SortVersions(versions)
+	for i, v := range result {
+		if v != expected[i] {
+			t.Errorf("expected at pos %d %s, got %s", i, expected[i], v)
+		}
+	}
+}
+
 func Test_SortVersions_ContainsInvalidVersions_ReturnsPartlyUnsorted(t *testing.T) {
 	versions := []string{"1.0.0", "non-valid", "invalid", "2.0.0", "not-a-version"}
 	expected := []string{"2.0.0", "1.0.0", "non-valid", "invalid", "not-a-version"}
@@ -582,3 +647,35 @@ func Test_NewUploadElement_UploadTypePackageManifestJson_ReturnsUploadElement(t
 		t.Errorf("expected Package.json, got %s", element.FileName())
 	}
 }
+
+func Test_NewDeletedRelease_ReportsGoneProblem(t *testing.T) {
+	release := NewDeletedRelease("http://example.com")
+
+	data, err := json.Marshal(release)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `{"url":"http://example.com","problem":{"status":410,"title":"Gone","detail":"this release was removed from the registry"}}`
+	if string(data) != expected {
+		t.Errorf("expected %s, got %s", expected, data)
+	}
+	if data, _ := json.Marshal(NewRelease("http://example.com")); string(data) != `{"url":"http://example.com"}` {
+		t.Errorf("expected no problem for available release, got %s", data)
+	}
+}
+
+func Test_NewUploadElement_Tombstone_FileName(t *testing.T) {
+	element := NewUploadElement("scope", "name", "1.0.0", mimetypes.ApplicationJson, Tombstone)
+
+	if element.FileName() != "tombstone.json" {
+		t.Errorf("expected tombstone.json, got %s", element.FileName())
+	}
+}
+
+func Test_NewUploadElement_Publication_FileName(t *testing.T) {
+	element := NewUploadElement("scope", "name", "1.0.0", mimetypes.ApplicationJson, Publication)
+
+	if element.FileName() != "publication.json" {
+		t.Errorf("expected publication.json, got %s", element.FileName())
+	}
+}
@@ -0,0 +1,120 @@
+package repo
+
+import (
+	"OpenSPMRegistry/mimetypes"
+	"OpenSPMRegistry/models"
+	"container/list"
+	"context"
+	"path"
+	"sync"
+)
+
+// ChecksumCache is a bounded in-memory cache of source archive checksums in front of the publication records
+// they are stored in. Published source archives do not change, entries are dropped when the archive is written
+// or removed and the least recently used one once the cache is full. A nil cache caches nothing.
+type ChecksumCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List // most recently used first
+}
+
+type checksumEntry struct {
+	key      string
+	checksum string
+}
+
+// DefaultChecksumCacheSize is the number of source archive checksums a backend keeps in memory
+const DefaultChecksumCacheSize = 10000
+
+// NewChecksumCache creates a cache keeping the checksums of up to capacity source archives
+func NewChecksumCache(capacity int) *ChecksumCache {
+	return &ChecksumCache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Checksum returns the checksum of element. Checksums of source archives are taken from the cache or the
+// publication record (computed while the archive was published), compute is called for other elements and
+// source archives without record (e.g. reading a checksum file or hashing the contents).
+// Backends implement Repo.Checksum with it.
+func (c *ChecksumCache) Checksum(ctx context.Context, r Repo, element *models.UploadElement, compute func(context.Context, *models.UploadElement) (string, error)) (string, error) {
+	if !isSourceArchive(element) {
+		return compute(ctx, element)
+	}
+	key := checksumKey(element)
+	if checksum, ok := c.get(key); ok {
+		return checksum, nil
+	}
+
+	var checksum string
+	if publication, err := ReadPublication(ctx, r, element.Scope, element.Name, element.Version); err == nil {
+		checksum = publication.Checksum
+	}
+	if checksum == "" {
+		var err error
+		if checksum, err = compute(ctx, element); err != nil {
+			return "", err
+		}
+	}
+	c.add(key, checksum)
+	return checksum, nil
+}
+
+// Forget drops the checksum of element, e.g. as it is written or removed
+func (c *ChecksumCache) Forget(element *models.UploadElement) {
+	if c == nil || !isSourceArchive(element) {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry, ok := c.entries[checksumKey(element)]; ok {
+		c.order.Remove(entry)
+		delete(c.entries, checksumKey(element))
+	}
+}
+
+func (c *ChecksumCache) get(key string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	c.order.MoveToFront(entry)
+	return entry.Value.(*checksumEntry).checksum, true
+}
+
+func (c *ChecksumCache) add(key string, checksum string) {
+	if c == nil || c.capacity <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry, ok := c.entries[key]; ok {
+		entry.Value.(*checksumEntry).checksum = checksum
+		c.order.MoveToFront(entry)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&checksumEntry{key: key, checksum: checksum})
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*checksumEntry).key)
+	}
+}
+
+// isSourceArchive checks whether element is the source archive of its release
+func isSourceArchive(element *models.UploadElement) bool {
+	archive := models.NewUploadElement(element.Scope, element.Name, element.Version, mimetypes.ApplicationZip, models.SourceArchive)
+	return element.FileName() == archive.FileName()
+}
+
+func checksumKey(element *models.UploadElement) string {
+	return path.Join(element.Scope, element.Name, element.Version, element.FileName())
+}
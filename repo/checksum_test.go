@@ -0,0 +1,73 @@
+package repo
+
+import (
+	"OpenSPMRegistry/mimetypes"
+	"OpenSPMRegistry/models"
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+// noRecordRepo is a repository without publication records
+type noRecordRepo struct {
+	Repo
+}
+
+func (r noRecordRepo) GetReader(context.Context, *models.UploadElement) (io.ReadSeekCloser, error) {
+	return nil, errors.New("not found")
+}
+
+func TestChecksumCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewChecksumCache(2)
+	computed := map[string]int{}
+	compute := func(_ context.Context, element *models.UploadElement) (string, error) {
+		computed[element.Version]++
+		return "checksum-" + element.Version, nil
+	}
+	checksum := func(version string) string {
+		element := models.NewUploadElement("scope", "name", version, mimetypes.ApplicationZip, models.SourceArchive)
+		result, err := cache.Checksum(context.Background(), noRecordRepo{}, element, compute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	checksum("1.0.0")
+	checksum("2.0.0")
+	checksum("1.0.0")
+	checksum("3.0.0") // evicts 2.0.0
+	checksum("1.0.0")
+	checksum("2.0.0")
+
+	if computed["1.0.0"] != 1 || computed["2.0.0"] != 2 || computed["3.0.0"] != 1 {
+		t.Errorf("unexpected computations %v", computed)
+	}
+	if result := checksum("2.0.0"); result != "checksum-2.0.0" {
+		t.Errorf("unexpected checksum %s", result)
+	}
+}
+
+func TestChecksumCache_Forget_ComputesAgain(t *testing.T) {
+	cache := NewChecksumCache(DefaultChecksumCacheSize)
+	archive := models.NewUploadElement("scope", "name", "1.0.0", mimetypes.ApplicationZip, models.SourceArchive)
+	manifest := models.NewUploadElement("scope", "name", "1.0.0", mimetypes.TextXSwift, models.Manifest)
+	computed := 0
+	compute := func(context.Context, *models.UploadElement) (string, error) {
+		computed++
+		return "checksum", nil
+	}
+
+	_, _ = cache.Checksum(context.Background(), noRecordRepo{}, archive, compute)
+	_, _ = cache.Checksum(context.Background(), noRecordRepo{}, archive, compute)
+	cache.Forget(archive)
+	_, _ = cache.Checksum(context.Background(), noRecordRepo{}, archive, compute)
+	// only source archives are cached
+	_, _ = cache.Checksum(context.Background(), noRecordRepo{}, manifest, compute)
+	_, _ = cache.Checksum(context.Background(), noRecordRepo{}, manifest, compute)
+
+	if computed != 4 {
+		t.Errorf("expected 4 computations, got %d", computed)
+	}
+}
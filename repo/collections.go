@@ -130,47 +130,25 @@ func buildCollectionPackage(ctx context.Context, r Repo, scope string, name stri
 	return collectionPackage, nil
 }
 
-// sortVersionsDesc sorts list elements by semantic-ish version in descending order (best effort)
+// sortVersionsDesc sorts list elements by SemVer precedence in descending order, invalid versions at the end
 func sortVersionsDesc(versionElements []models.ListElement) {
-	slices.SortFunc(versionElements, func(a models.ListElement, b models.ListElement) int {
-		v1, err := models.ParseVersion(strings.TrimSpace(a.Version))
-		if err != nil {
-			return 0
-		}
-		v2, err := models.ParseVersion(strings.TrimSpace(b.Version))
-		if err != nil {
-			return 0
-		}
+	slices.SortStableFunc(versionElements, func(a models.ListElement, b models.ListElement) int {
 		// Descending: newer (larger) first
-		return v2.Compare(v1)
+		return models.CompareVersions(strings.TrimSpace(b.Version), strings.TrimSpace(a.Version))
 	})
 }
 
 // buildPackageVersion builds a PackageVersion from a specific version
 func buildPackageVersion(ctx context.Context, r Repo, scope string, name string, version string) (*models.PackageVersion, error) {
-	// Load Package.json
-	packageJson, err := r.LoadPackageJson(ctx, scope, name, version)
+	manifest, err := ReleaseManifest(ctx, r, scope, name, version)
 	if err != nil {
 		return nil, err
 	}
-
-	// Get tools version from Package.swift
-	manifestElement := models.NewUploadElement(scope, name, version, mimetypes.TextXSwift, models.Manifest)
-	toolsVersionStr, err := r.GetSwiftToolVersion(ctx, manifestElement)
-	if err != nil {
-		slog.Warn("Could not get tools version", "package", fmt.Sprintf("%s.%s@%s", scope, name, version), "error", err)
-		toolsVersionStr = "5.0" // default
-	}
-
-	// Strip patch version from tools version (e.g., "5.10.0" -> "5.10")
-	toolsVersion := stripPatchVersion(strings.TrimSpace(toolsVersionStr))
-
-	// Convert Package.json to manifest
-	manifest := convertPackageJsonToManifest(packageJson, toolsVersion)
+	toolsVersion := manifest.ToolsVersion
 
 	// Build manifests map
 	manifests := map[string]models.PackageManifest{
-		toolsVersion: manifest,
+		toolsVersion: *manifest,
 	}
 
 	// Get metadata for author info
@@ -202,12 +180,51 @@ func buildPackageVersion(ctx context.Context, r Repo, scope string, name string,
 		DefaultToolsVersion: toolsVersion,
 		Author:              author,
 		License:             license,
+		Signer:              releaseSigner(ctx, r, scope, name, version),
 		CreatedAt:           publishDate.UTC().Format(time.RFC3339),
 	}
 
 	return packageVersion, nil
 }
 
+// releaseSigner describes the signer of the source archive as verified on publication,
+// nil if the release is unsigned or its signature was not verified
+func releaseSigner(ctx context.Context, r Repo, scope string, name string, version string) *models.Signer {
+	if !r.Exists(ctx, models.NewUploadElement(scope, name, version, mimetypes.ApplicationOctetStream, models.SourceArchiveSignature)) {
+		return nil
+	}
+	publication, err := ReadPublication(ctx, r, scope, name, version)
+	if err != nil {
+		return nil
+	}
+	return publication.Signer
+}
+
+// ReleaseManifest builds the SE-0291 manifest of a release from its Package.json
+// and the tools version of its Package.swift (5.0 if unknown)
+func ReleaseManifest(ctx context.Context, r Repo, scope string, name string, version string) (*models.PackageManifest, error) {
+	// Load Package.json
+	packageJson, err := r.LoadPackageJson(ctx, scope, name, version)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get tools version from Package.swift
+	manifestElement := models.NewUploadElement(scope, name, version, mimetypes.TextXSwift, models.Manifest)
+	toolsVersionStr, err := r.GetSwiftToolVersion(ctx, manifestElement)
+	if err != nil {
+		slog.Warn("Could not get tools version", "package", fmt.Sprintf("%s.%s@%s", scope, name, version), "error", err)
+		toolsVersionStr = "5.0" // default
+	}
+
+	// Strip patch version from tools version (e.g., "5.10.0" -> "5.10")
+	toolsVersion := stripPatchVersion(strings.TrimSpace(toolsVersionStr))
+
+	// Convert Package.json to manifest
+	manifest := convertPackageJsonToManifest(packageJson, toolsVersion)
+	return &manifest, nil
+}
+
 // convertPackageJsonToManifest converts Package.json (swift package dump-package output) to SE-0291 manifest format
 func convertPackageJsonToManifest(packageJson map[string]any, toolsVersion string) models.PackageManifest {
 	manifest := models.PackageManifest{
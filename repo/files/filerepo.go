@@ -25,6 +25,7 @@ type FileRepo struct {
 	path         string
 	osModule     OsAdapter
 	timeProvider utils.TimeProvider
+	checksums    *repo.ChecksumCache
 }
 
 func NewFileRepo(path string) *FileRepo {
@@ -37,6 +38,7 @@ func NewFileRepo(path string) *FileRepo {
 			osModule: osModule,
 		},
 		timeProvider: utils.NewRealTimeProvider(),
+		checksums:    repo.NewChecksumCache(repo.DefaultChecksumCacheSize),
 	}
 }
 
@@ -118,7 +120,14 @@ func (f *FileRepo) EncodeBase64(ctx context.Context, element *models.UploadEleme
 	return base64.StdEncoding.EncodeToString(b), nil
 }
 
+// PublishDate returns the publish date of the release from its publication record,
+// the modification time of releases published before records were kept
 func (f *FileRepo) PublishDate(ctx context.Context, element *models.UploadElement) (time.Time, error) {
+	return repo.PublishDate(ctx, f, element, f.modTime)
+}
+
+// modTime returns the modification time of the element
+func (f *FileRepo) modTime(ctx context.Context, element *models.UploadElement) (time.Time, error) {
 	pathFolder := filepath.Join(f.path, element.Scope, element.Name, element.Version)
 	if !f.Exists(ctx, element) {
 		return f.timeProvider.Now(), fmt.Errorf("path does not exists: %s", pathFolder)
@@ -176,11 +185,17 @@ func (f *FileRepo) LoadMetadata(ctx context.Context, scope string, name string,
 	return metadataResult, nil
 }
 
+// Checksum returns the SHA-256 checksum of the element, for source archives the one recorded on publication
+// (see repo.ChecksumCache), other files are hashed
 func (f *FileRepo) Checksum(ctx context.Context, element *models.UploadElement) (string, error) {
 	if !f.Exists(ctx, element) {
 		return "", fmt.Errorf("file not exists: %s", element.FileName())
 	}
+	return f.checksums.Checksum(ctx, f, element, f.hashFile)
+}
 
+// hashFile computes the SHA-256 checksum of the contents of the element
+func (f *FileRepo) hashFile(_ context.Context, element *models.UploadElement) (string, error) {
 	pathFile := filepath.Join(f.path, element.Scope, element.Name, element.Version, element.FileName())
 	file, err := f.osModule.Open(pathFile)
 	if err != nil {
@@ -303,14 +318,45 @@ func (f *FileRepo) Lookup(ctx context.Context, url string) []string {
 	return result
 }
 
+// GetWriter returns a writer storing the element, a cached checksum of it is dropped
+func (f *FileRepo) GetWriter(ctx context.Context, element *models.UploadElement) (io.WriteCloser, error) {
+	f.checksums.Forget(element)
+	return f.Access.GetWriter(ctx, element)
+}
+
 func (f *FileRepo) Remove(ctx context.Context, element *models.UploadElement) error {
 	path := filepath.Join(f.path, element.Scope, element.Name, element.Version, element.FileName())
+	f.checksums.Forget(element)
 	if f.Exists(ctx, element) {
 		return os.Remove(path)
 	}
 	return fmt.Errorf("file not exists: %s", element.FileName())
 }
 
+// SetPublishDate sets the modification time of the element,
+// which is the publish date of releases without publication record
+func (f *FileRepo) SetPublishDate(ctx context.Context, element *models.UploadElement, date time.Time) error {
+	if !f.Exists(ctx, element) {
+		return fmt.Errorf("file not exists: %s", element.FileName())
+	}
+	path := filepath.Join(f.path, element.Scope, element.Name, element.Version, element.FileName())
+	return os.Chtimes(path, date, date)
+}
+
+// CheckHealth verifies the repository path is writable by creating and removing a temporary file
+func (f *FileRepo) CheckHealth(_ context.Context) error {
+	file, err := os.CreateTemp(f.path, ".healthz-*")
+	if err != nil {
+		return fmt.Errorf("repository path not writable: %w", err)
+	}
+	name := file.Name()
+	errClose := file.Close()
+	if err := os.Remove(name); err != nil {
+		return fmt.Errorf("repository path not writable: %w", err)
+	}
+	return errClose
+}
+
 func writePackageSwiftFiles(pathFolder string) func(name string, r io.ReadCloser) error {
 	return func(name string, r io.ReadCloser) error {
 		// write to file
@@ -3,6 +3,7 @@ package files
 import (
 	"OpenSPMRegistry/mimetypes"
 	"OpenSPMRegistry/models"
+	"OpenSPMRegistry/repo"
 	"archive/zip"
 	"context"
 	"encoding/base64"
@@ -653,6 +654,65 @@ func Test_PublishDate_ValidFile_ReturnsModTime(t *testing.T) {
 	}
 }
 
+func Test_SetPublishDate_ValidFile_SetsModTime(t *testing.T) {
+	defer teardown(t)
+
+	fileRepo := NewFileRepo("/tmp/openspmsreg_tests")
+	element := models.NewUploadElement("testScope", "testName", "1.0.0", mimetypes.TextXSwift, models.Manifest)
+	path := filepath.Join("/tmp/openspmsreg_tests", element.Scope, element.Name, element.Version)
+	if err := os.MkdirAll(path, os.ModePerm); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(path, element.FileName()), nil, 0o644); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+
+	published := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := fileRepo.SetPublishDate(context.Background(), element, published); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	date, err := fileRepo.PublishDate(context.Background(), element)
+	if err != nil || !date.Equal(published) {
+		t.Errorf("expected publish date %v, got %v (%v)", published, date, err)
+	}
+	if err := fileRepo.SetPublishDate(context.Background(), models.NewUploadElement("testScope", "testName", "2.0.0", mimetypes.TextXSwift, models.Manifest), published); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func Test_PublishDate_NoRecord_RecordsModTimeOnce(t *testing.T) {
+	fileRepo := NewFileRepo(t.TempDir())
+	ctx := context.Background()
+	archive := models.NewUploadElement("testScope", "testName", "1.0.0", mimetypes.ApplicationZip, models.SourceArchive)
+	writer, _ := fileRepo.GetWriter(ctx, archive)
+	_, _ = writer.Write([]byte("archive"))
+	_ = writer.Close()
+	published := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := fileRepo.SetPublishDate(ctx, archive, published); err != nil {
+		t.Fatal(err)
+	}
+
+	date, err := fileRepo.PublishDate(ctx, archive)
+
+	if err != nil || !date.Equal(published) {
+		t.Fatalf("expected publish date %v, got %v (%v)", published, date, err)
+	}
+	publication, err := repo.ReadPublication(ctx, fileRepo, "testScope", "testName", "1.0.0")
+	checksum, _ := fileRepo.Checksum(ctx, archive)
+	if err != nil || !publication.PublishedAt.Equal(published) || publication.Checksum != checksum {
+		t.Fatalf("expected publication record, got %+v (%v)", publication, err)
+	}
+	// e.g. copied by rsync without keeping times
+	if err := fileRepo.SetPublishDate(ctx, archive, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	manifest := models.NewUploadElement("testScope", "testName", "1.0.0", mimetypes.TextXSwift, models.Manifest)
+	if date, err := fileRepo.PublishDate(ctx, manifest); err != nil || !date.Equal(published) {
+		t.Errorf("expected recorded publish date %v, got %v (%v)", published, date, err)
+	}
+}
+
 func Test_PublishDate_PathDoesNotExist_ReturnsError(t *testing.T) {
 	defer teardown(t)
 
@@ -753,6 +813,39 @@ func Test_Checksum_FileExists_ReturnsChecksum(t *testing.T) {
 	}
 }
 
+func Test_Checksum_SourceArchive_ServedFromRecordUntilRewritten(t *testing.T) {
+	fileRepo := NewFileRepo(t.TempDir())
+	ctx := context.Background()
+	archive := models.NewUploadElement("testScope", "testName", "1.0.0", mimetypes.ApplicationZip, models.SourceArchive)
+	writeArchive := func(data string) {
+		writer, err := fileRepo.GetWriter(ctx, archive)
+		if err != nil {
+			t.Fatalf("failed to get writer: %v", err)
+		}
+		_, _ = writer.Write([]byte(data))
+		if err := writer.Close(); err != nil {
+			t.Fatalf("failed to write: %v", err)
+		}
+	}
+	writeArchive("test data")
+	if err := repo.WritePublication(ctx, fileRepo, archive, models.ReleasePublication{PublishedAt: time.Now(), Checksum: "recorded"}); err != nil {
+		t.Fatalf("failed to write publication record: %v", err)
+	}
+
+	if checksum, err := fileRepo.Checksum(ctx, archive); err != nil || checksum != "recorded" {
+		t.Errorf("expected recorded checksum, got %q (%v)", checksum, err)
+	}
+
+	// rewriting the archive drops the cached checksum, the record is read again
+	writeArchive("other data")
+	if err := repo.WritePublication(ctx, fileRepo, archive, models.ReleasePublication{PublishedAt: time.Now()}); err != nil {
+		t.Fatalf("failed to write publication record: %v", err)
+	}
+	if checksum, err := fileRepo.Checksum(ctx, archive); err != nil || checksum == "recorded" || checksum == "" {
+		t.Errorf("expected checksum of the rewritten archive, got %q (%v)", checksum, err)
+	}
+}
+
 func Test_Checksum_FileReadError_ReturnsError(t *testing.T) {
 	defer teardown(t)
 
@@ -1134,3 +1227,24 @@ func Test_Remove_FileDoesNotExist_ReturnsError(t *testing.T) {
 		t.Errorf("expected error, got nil")
 	}
 }
+
+func Test_CheckHealth_WritablePath_ReturnsNil(t *testing.T) {
+	dir := t.TempDir()
+	repo := NewFileRepo(dir)
+
+	if err := repo.CheckHealth(context.Background()); err != nil {
+		t.Errorf("expected writable path to be healthy, got %v", err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("expected probe file to be removed, got %v", entries)
+	}
+}
+
+func Test_CheckHealth_MissingPath_ReturnsError(t *testing.T) {
+	repo := NewFileRepo(filepath.Join(t.TempDir(), "missing"))
+
+	if err := repo.CheckHealth(context.Background()); err == nil {
+		t.Errorf("expected missing path to be unhealthy")
+	}
+}
@@ -22,19 +22,7 @@ func ExtractManifestFilesFromZipReader(element *models.UploadElement, zipReader
 		dir := path.Dir(cleanName)
 		base := path.Base(cleanName)
 		ext := path.Ext(base)
-		scope := element.Scope
-		name := element.Name
-		version := element.Version
-		id := fmt.Sprintf("%s.%s", scope, name)
-
-		// Accept manifests at archive root or in a single top-level directory.
-		// Official SPM (swift package archive-source / package-registry publish) uses scope.name as top-level dir
-		// (e.g. example.UtilsPackage/Package.swift). We also accept root and "Name-version" as fallbacks for
-		// other clients or SPM versions; the Registry spec does not mandate zip layout.
-		atRoot := dir == "." || dir == ""
-		singleTopLevel := dir == id || dir == name+"-"+version
-		nestedUnderId := strings.HasPrefix(dir, id) && !strings.Contains(strings.TrimPrefix(strings.ToLower(dir), strings.ToLower(id)), "/")
-		if !atRoot && !singleTopLevel && !nestedUnderId {
+		if !isManifestDir(element, dir) {
 			continue
 		}
 
@@ -80,6 +68,20 @@ func ExtractManifestFilesFromZipReader(element *models.UploadElement, zipReader
 	return nil
 }
 
+// isManifestDir checks whether dir is a directory of the archive manifests are accepted in
+func isManifestDir(element *models.UploadElement, dir string) bool {
+	id := fmt.Sprintf("%s.%s", element.Scope, element.Name)
+
+	// Accept manifests at archive root or in a single top-level directory.
+	// Official SPM (swift package archive-source / package-registry publish) uses scope.name as top-level dir
+	// (e.g. example.UtilsPackage/Package.swift). We also accept root and "Name-version" as fallbacks for
+	// other clients or SPM versions; the Registry spec does not mandate zip layout.
+	atRoot := dir == "." || dir == ""
+	singleTopLevel := dir == id || dir == element.Name+"-"+element.Version
+	nestedUnderId := strings.HasPrefix(dir, id) && !strings.Contains(strings.TrimPrefix(strings.ToLower(dir), strings.ToLower(id)), "/")
+	return atRoot || singleTopLevel || nestedUnderId
+}
+
 func ExtractPackageSwiftFiles(element *models.UploadElement, fileLocation string, fileExtractor func(name string, r io.ReadCloser) error) error {
 	// extract Package Swifts
 	if element.MimeType == mimetypes.ApplicationZip {
@@ -0,0 +1,124 @@
+package files
+
+import (
+	"OpenSPMRegistry/models"
+	"archive/zip"
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// ArchiveLimits bounds the contents of a source archive (zip bomb protection)
+type ArchiveLimits struct {
+	// MaxUncompressedSize is the maximum total uncompressed size of all entries in bytes
+	MaxUncompressedSize int64
+	// MaxEntries is the maximum number of entries
+	MaxEntries int
+}
+
+// maxToolsVersionLine limits how much of a manifest is read to find the tools version line
+const maxToolsVersionLine = 4096
+
+// toolsVersionPattern matches the swift-tools-version comment SwiftPM requires on the first line of a manifest
+var toolsVersionPattern = regexp.MustCompile(`^//\s*swift-tools-version\s*:\s*\d+\.\d+(\.\d+)?\s*(;.*)?$`)
+
+// ValidateSourceArchive checks that the source archive of element is a valid zip file
+// containing a Package.swift at a location ExtractManifestFilesFromZipReader accepts,
+// without path traversal or symlink entries, within limits and with parsable tools versions in its manifests.
+// Returns every failure found, nil if the archive is valid.
+func ValidateSourceArchive(element *models.UploadElement, r io.ReaderAt, size int64, limits ArchiveLimits) []string {
+	zipReader, err := zip.NewReader(r, size)
+	if err != nil {
+		return []string{fmt.Sprintf("source archive is not a valid zip file: %v", err)}
+	}
+
+	var failures []string
+	if limits.MaxEntries > 0 && len(zipReader.File) > limits.MaxEntries {
+		failures = append(failures, fmt.Sprintf("source archive has %d entries, at most %d are allowed", len(zipReader.File), limits.MaxEntries))
+	}
+
+	var uncompressedSize uint64
+	tooLarge := false
+	hasPackageSwift := false
+	for _, file := range zipReader.File {
+		// the declared sizes are enforced by archive/zip when reading an entry. Summed until the limit
+		// is exceeded, each capped to just above it, so forged sizes cannot wrap the total around
+		if limits.MaxUncompressedSize > 0 && !tooLarge {
+			uncompressedSize += min(file.UncompressedSize64, uint64(limits.MaxUncompressedSize)+1)
+			tooLarge = uncompressedSize > uint64(limits.MaxUncompressedSize)
+		}
+
+		if !isSafeEntryName(file.Name) {
+			failures = append(failures, fmt.Sprintf("entry %q points outside the archive", file.Name))
+			continue
+		}
+		if file.Mode()&fs.ModeSymlink != 0 {
+			failures = append(failures, fmt.Sprintf("entry %q is a symbolic link", file.Name))
+			continue
+		}
+		if file.FileInfo().IsDir() {
+			continue
+		}
+
+		cleanName := path.Clean(file.Name)
+		base := path.Base(cleanName)
+		if !isManifestDir(element, path.Dir(cleanName)) || !isManifestName(base) {
+			continue
+		}
+		if base == "Package.swift" {
+			hasPackageSwift = true
+		}
+		if err := checkToolsVersion(file); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", file.Name, err))
+		}
+	}
+
+	if tooLarge {
+		failures = append(failures, fmt.Sprintf("source archive uncompresses to more than the %d bytes allowed", limits.MaxUncompressedSize))
+	}
+	if !hasPackageSwift {
+		failures = append(failures, fmt.Sprintf("source archive contains no Package.swift at the root or in a %s.%s directory", element.Scope, element.Name))
+	}
+	return failures
+}
+
+// isSafeEntryName rejects absolute names, names escaping the archive root and Windows separators
+func isSafeEntryName(name string) bool {
+	if name == "" || strings.Contains(name, "\\") || path.IsAbs(name) {
+		return false
+	}
+	for _, element := range strings.Split(name, "/") {
+		if element == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// isManifestName matches Package.swift and version specific manifests (Package@swift-5.9.swift)
+func isManifestName(name string) bool {
+	return name == "Package.swift" || strings.HasPrefix(name, "Package@swift-") && strings.HasSuffix(name, ".swift")
+}
+
+// checkToolsVersion verifies the first line of the manifest is a valid swift-tools-version comment
+func checkToolsVersion(file *zip.File) error {
+	reader, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("cannot be read: %w", err)
+	}
+	defer func() { _ = reader.Close() }()
+
+	line, err := bufio.NewReader(io.LimitReader(reader, maxToolsVersionLine)).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("cannot be read: %w", err)
+	}
+	line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+	if !toolsVersionPattern.MatchString(line) {
+		return fmt.Errorf("first line %q is not a valid swift-tools-version specification", line)
+	}
+	return nil
+}
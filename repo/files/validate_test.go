@@ -0,0 +1,126 @@
+package files
+
+import (
+	"OpenSPMRegistry/mimetypes"
+	"OpenSPMRegistry/models"
+	"archive/zip"
+	"bytes"
+	"io/fs"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type zipEntry struct {
+	name    string
+	content string
+	mode    fs.FileMode
+}
+
+func newZip(t *testing.T, entries ...zipEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, entry := range entries {
+		header := &zip.FileHeader{Name: entry.name, Method: zip.Deflate}
+		if entry.mode != 0 {
+			header.SetMode(entry.mode)
+		}
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write([]byte(entry.content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func validate(data []byte, limits ArchiveLimits) []string {
+	element := models.NewUploadElement("acme", "network", "1.0.0", mimetypes.ApplicationZip, models.SourceArchive)
+	return ValidateSourceArchive(element, bytes.NewReader(data), int64(len(data)), limits)
+}
+
+func Test_ValidateSourceArchive_ValidArchive_ReturnsNoFailures(t *testing.T) {
+	for _, dir := range []string{"", "acme.network/", "network-1.0.0/"} {
+		t.Run(dir, func(t *testing.T) {
+			data := newZip(t,
+				zipEntry{name: dir + "Package.swift", content: "// swift-tools-version:5.9\nimport PackageDescription\n"},
+				zipEntry{name: dir + "Package@swift-5.7.swift", content: "// swift-tools-version: 5.7.1; (swiftLanguageVersions)\n"},
+				zipEntry{name: dir + "Sources/Network/Network.swift", content: "struct Network {}"},
+			)
+
+			if failures := validate(data, ArchiveLimits{MaxUncompressedSize: 1 << 20, MaxEntries: 10}); failures != nil {
+				t.Errorf("expected no failures, got %v", failures)
+			}
+		})
+	}
+}
+
+func Test_ValidateSourceArchive_NotAZip_ReturnsFailure(t *testing.T) {
+	failures := validate([]byte("not a zip"), ArchiveLimits{})
+
+	if len(failures) != 1 || !strings.Contains(failures[0], "not a valid zip file") {
+		t.Errorf("expected invalid zip failure, got %v", failures)
+	}
+}
+
+func Test_ValidateSourceArchive_InvalidEntries_ReturnsEveryFailure(t *testing.T) {
+	data := newZip(t,
+		zipEntry{name: "other/Package.swift", content: "// swift-tools-version:5.9\n"},
+		zipEntry{name: "acme.network/Package@swift-5.8.swift", content: "import PackageDescription\n"},
+		zipEntry{name: "../../etc/passwd", content: "root"},
+		zipEntry{name: "/absolute", content: "x"},
+		zipEntry{name: "acme.network/link", content: "/etc/passwd", mode: fs.ModeSymlink | 0o777},
+	)
+
+	failures := validate(data, ArchiveLimits{})
+
+	expected := []string{"Package@swift-5.8.swift", "../../etc/passwd", "/absolute", "symbolic link", "no Package.swift"}
+	if len(failures) != len(expected) {
+		t.Fatalf("expected %d failures, got %v", len(expected), failures)
+	}
+	for i, substring := range expected {
+		if !strings.Contains(failures[i], substring) {
+			t.Errorf("expected failure %d to mention %q, got %q", i, substring, failures[i])
+		}
+	}
+}
+
+func Test_ValidateSourceArchive_ExceedsLimits_ReturnsFailures(t *testing.T) {
+	data := newZip(t,
+		zipEntry{name: "Package.swift", content: "// swift-tools-version:5.9\n"},
+		zipEntry{name: "Sources/Big.swift", content: strings.Repeat("a", 4096)},
+		zipEntry{name: "Sources/Other.swift", content: "b"},
+	)
+
+	failures := validate(data, ArchiveLimits{MaxUncompressedSize: 1024, MaxEntries: 2})
+
+	if len(failures) != 2 || !strings.Contains(failures[0], "3 entries") || !strings.Contains(failures[1], "uncompresses to") {
+		t.Errorf("expected entry count and size failures, got %v", failures)
+	}
+}
+
+func Test_ValidateSourceArchive_ForgedSizes_ReturnsFailure(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range []string{"Package.swift", "Sources/Big.swift"} {
+		// two entries declaring 2^63 bytes each would wrap an unchecked total around to 0
+		w, err := zw.CreateRaw(&zip.FileHeader{Name: name, Method: zip.Store, UncompressedSize64: 1 << 63})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write([]byte("// swift-tools-version:5.9\n"))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	failures := validate(buf.Bytes(), ArchiveLimits{MaxUncompressedSize: 1024})
+
+	if !slices.ContainsFunc(failures, func(failure string) bool { return strings.Contains(failure, "uncompresses to") }) {
+		t.Errorf("expected size failure, got %v", failures)
+	}
+}
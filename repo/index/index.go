@@ -0,0 +1,397 @@
+package index
+
+import (
+	"OpenSPMRegistry/health"
+	"OpenSPMRegistry/mimetypes"
+	"OpenSPMRegistry/models"
+	"OpenSPMRegistry/repo"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Record is the indexed state of a release
+type Record struct {
+	Scope          string          `json:"scope"`
+	Name           string          `json:"name"`
+	Version        string          `json:"version"`
+	Checksum       string          `json:"checksum,omitempty"`
+	PublishedAt    time.Time       `json:"publishedAt"`
+	RepositoryURLs []string        `json:"repositoryURLs,omitempty"`
+	ToolsVersion   string          `json:"toolsVersion,omitempty"` // of Package.swift
+	Metadata       json.RawMessage `json:"metadata,omitempty"`
+	PackageJson    json.RawMessage `json:"packageJson,omitempty"`
+	// Deleted releases are still listed (and reported as gone), but not found by lookups
+	Deleted bool `json:"deleted,omitempty"`
+}
+
+// Repo serves listings, lookups and release metadata of a storage backend from an embedded bbolt database,
+// so requests do not have to walk the backend. Everything else is passed through to the backend.
+// The index is only updated by this process, it must not be shared by several instances.
+type Repo struct {
+	repo.Repo
+	db *bolt.DB
+}
+
+const (
+	// keySeparator separates the parts of keys, it cannot be part of scopes, names or versions
+	keySeparator = "\x00"
+	// openTimeout limits how long to wait for another process holding the database
+	openTimeout = 5 * time.Second
+)
+
+var (
+	bucketReleases = []byte("releases") // scope/name/version -> Record
+	bucketURLs     = []byte("urls")     // repository URL/scope/name/version -> empty
+	bucketMeta     = []byte("meta")
+	keyBuiltAt     = []byte("builtAt")
+)
+
+// Open opens (or creates) the index database at path for the backend
+func Open(path string, backend repo.Repo) (*Repo, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: openTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("opening index %s: %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketReleases, bucketURLs, bucketMeta} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("initializing index %s: %w", path, err)
+	}
+	return &Repo{Repo: backend, db: db}, nil
+}
+
+// Close closes the database
+func (r *Repo) Close() error {
+	return r.db.Close()
+}
+
+// Backend returns the storage backend of the index
+func (r *Repo) Backend() repo.Repo {
+	return r.Repo
+}
+
+// Built returns whether the index was ever (re)built from the backend.
+// Until then it only knows releases published since it was created.
+func (r *Repo) Built() bool {
+	built := false
+	_ = r.db.View(func(tx *bolt.Tx) error {
+		built = tx.Bucket(bucketMeta).Get(keyBuiltAt) != nil
+		return nil
+	})
+	return built
+}
+
+// Rebuild replaces the index with all releases of the backend.
+// It walks the whole backend, so it should only run before requests are served.
+// returns (number of indexed releases, error)
+func (r *Repo) Rebuild(ctx context.Context) (int, error) {
+	start := time.Now()
+	elements, err := r.Repo.ListAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+	records := make([]*Record, 0, len(elements))
+	for _, element := range elements {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		records = append(records, r.loadRecord(ctx, element.Scope, element.PackageName, element.Version))
+	}
+
+	err = r.db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketReleases, bucketURLs} {
+			if err := tx.DeleteBucket(name); err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+				return err
+			}
+			if _, err := tx.CreateBucket(name); err != nil {
+				return err
+			}
+		}
+		for _, record := range records {
+			if err := putRecord(tx, record); err != nil {
+				return err
+			}
+		}
+		return tx.Bucket(bucketMeta).Put(keyBuiltAt, []byte(time.Now().UTC().Format(time.RFC3339)))
+	})
+	if err != nil {
+		return 0, err
+	}
+	slog.Info("Metadata index built", "releases", len(records), "duration", time.Since(start))
+	return len(records), nil
+}
+
+// IndexRelease reads the release from the backend and records it
+func (r *Repo) IndexRelease(ctx context.Context, scope string, name string, version string) error {
+	record := r.loadRecord(ctx, scope, name, version)
+	return r.db.Update(func(tx *bolt.Tx) error {
+		if err := deleteRecord(tx, scope, name, version); err != nil {
+			return err
+		}
+		return putRecord(tx, record)
+	})
+}
+
+// UnindexRelease keeps the release listed as deleted and drops everything else recorded about it
+func (r *Repo) UnindexRelease(ctx context.Context, scope string, name string, version string) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		if err := deleteRecord(tx, scope, name, version); err != nil {
+			return err
+		}
+		return putRecord(tx, &Record{Scope: scope, Name: name, Version: version, Deleted: true})
+	})
+}
+
+// Record returns the indexed release
+// returns (record|nil if not indexed, error)
+func (r *Repo) Record(scope string, name string, version string) (*Record, error) {
+	var record *Record
+	err := r.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucketReleases).Get(releaseKey(scope, name, version))
+		if data == nil {
+			return nil
+		}
+		record = &Record{}
+		return json.Unmarshal(data, record)
+	})
+	return record, err
+}
+
+// List returns the indexed versions of the package
+func (r *Repo) List(_ context.Context, scope string, name string) ([]models.ListElement, error) {
+	return r.listPrefix(scope + keySeparator + name + keySeparator)
+}
+
+// ListScopes returns the scopes having indexed releases
+func (r *Repo) ListScopes(_ context.Context) ([]string, error) {
+	var scopes []string
+	err := r.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketReleases).ForEach(func(k, _ []byte) error {
+			scope, _, _ := bytes.Cut(k, []byte(keySeparator))
+			if len(scopes) == 0 || scopes[len(scopes)-1] != string(scope) {
+				scopes = append(scopes, string(scope))
+			}
+			return nil
+		})
+	})
+	return scopes, err
+}
+
+// ListInScope returns the indexed releases of all packages in the scope
+func (r *Repo) ListInScope(_ context.Context, scope string) ([]models.ListElement, error) {
+	return r.listPrefix(scope + keySeparator)
+}
+
+// ListAll returns all indexed releases
+func (r *Repo) ListAll(_ context.Context) ([]models.ListElement, error) {
+	return r.listPrefix("")
+}
+
+// Lookup returns the identifiers of the packages having a release with the repository URL
+func (r *Repo) Lookup(_ context.Context, url string) []string {
+	var result []string
+	err := r.db.View(func(tx *bolt.Tx) error {
+		prefix := []byte(url + keySeparator)
+		cursor := tx.Bucket(bucketURLs).Cursor()
+		for k, _ := cursor.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = cursor.Next() {
+			parts := bytes.Split(k[len(prefix):], []byte(keySeparator))
+			if len(parts) != 3 {
+				continue
+			}
+			id := fmt.Sprintf("%s.%s", parts[0], parts[1])
+			if len(result) == 0 || result[len(result)-1] != id {
+				result = append(result, id)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Error("Error reading index:", "error", err)
+	}
+	return result
+}
+
+// LoadMetadata returns the indexed metadata, releases without are looked up in the backend
+func (r *Repo) LoadMetadata(ctx context.Context, scope string, name string, version string) (map[string]any, error) {
+	if record, err := r.Record(scope, name, version); err == nil && record != nil && record.Metadata != nil {
+		var metadata map[string]any
+		if err := json.Unmarshal(record.Metadata, &metadata); err == nil {
+			return metadata, nil
+		}
+	}
+	return r.Repo.LoadMetadata(ctx, scope, name, version)
+}
+
+// LoadPackageJson returns the indexed Package.json, releases without are looked up in the backend
+func (r *Repo) LoadPackageJson(ctx context.Context, scope string, name string, version string) (map[string]any, error) {
+	if record, err := r.Record(scope, name, version); err == nil && record != nil && record.PackageJson != nil {
+		var packageJson map[string]any
+		if err := json.Unmarshal(record.PackageJson, &packageJson); err == nil {
+			return packageJson, nil
+		}
+	}
+	return r.Repo.LoadPackageJson(ctx, scope, name, version)
+}
+
+// PublishDate returns the indexed publish date of source archives, other elements are looked up in the backend
+func (r *Repo) PublishDate(ctx context.Context, element *models.UploadElement) (time.Time, error) {
+	if record := r.sourceArchiveRecord(element); record != nil && !record.PublishedAt.IsZero() {
+		return record.PublishedAt, nil
+	}
+	return r.Repo.PublishDate(ctx, element)
+}
+
+// Checksum returns the indexed checksum of source archives, other elements are looked up in the backend
+func (r *Repo) Checksum(ctx context.Context, element *models.UploadElement) (string, error) {
+	if record := r.sourceArchiveRecord(element); record != nil && record.Checksum != "" {
+		return record.Checksum, nil
+	}
+	return r.Repo.Checksum(ctx, element)
+}
+
+// GetSwiftToolVersion returns the indexed tools version of Package.swift, alternative manifests are looked up in the backend
+func (r *Repo) GetSwiftToolVersion(ctx context.Context, manifest *models.UploadElement) (string, error) {
+	main := models.NewUploadElement(manifest.Scope, manifest.Name, manifest.Version, mimetypes.TextXSwift, models.Manifest)
+	if manifest.FileName() == main.FileName() {
+		if record, err := r.Record(manifest.Scope, manifest.Name, manifest.Version); err == nil && record != nil && record.ToolsVersion != "" {
+			return record.ToolsVersion, nil
+		}
+	}
+	return r.Repo.GetSwiftToolVersion(ctx, manifest)
+}
+
+// CheckHealth verifies the database is readable and the backend is healthy
+func (r *Repo) CheckHealth(ctx context.Context) error {
+	if err := r.db.View(func(tx *bolt.Tx) error { return nil }); err != nil {
+		return fmt.Errorf("index: %w", err)
+	}
+	if checker, ok := r.Repo.(health.Checker); ok {
+		return checker.CheckHealth(ctx)
+	}
+	return nil
+}
+
+// sourceArchiveRecord returns the record of the release if element is its source archive
+func (r *Repo) sourceArchiveRecord(element *models.UploadElement) *Record {
+	archive := models.NewUploadElement(element.Scope, element.Name, element.Version, mimetypes.ApplicationZip, models.SourceArchive)
+	if element.FileName() != archive.FileName() {
+		return nil
+	}
+	record, err := r.Record(element.Scope, element.Name, element.Version)
+	if err != nil {
+		slog.Error("Error reading index:", "error", err)
+		return nil
+	}
+	return record
+}
+
+// loadRecord reads everything indexed about a release from the backend.
+// Missing parts (e.g. of deleted releases) are left empty.
+func (r *Repo) loadRecord(ctx context.Context, scope string, name string, version string) *Record {
+	record := &Record{Scope: scope, Name: name, Version: version}
+
+	tombstone := models.NewUploadElement(scope, name, version, mimetypes.ApplicationJson, models.Tombstone)
+	if r.Repo.Exists(ctx, tombstone) {
+		record.Deleted = true
+		return record
+	}
+
+	archive := models.NewUploadElement(scope, name, version, mimetypes.ApplicationZip, models.SourceArchive)
+	if checksum, err := r.Repo.Checksum(ctx, archive); err == nil {
+		record.Checksum = checksum
+	}
+	if publishedAt, err := r.Repo.PublishDate(ctx, archive); err == nil {
+		record.PublishedAt = publishedAt.UTC()
+	}
+	manifest := models.NewUploadElement(scope, name, version, mimetypes.TextXSwift, models.Manifest)
+	if toolsVersion, err := r.Repo.GetSwiftToolVersion(ctx, manifest); err == nil {
+		record.ToolsVersion = toolsVersion
+	}
+	if metadata, err := r.Repo.LoadMetadata(ctx, scope, name, version); err == nil {
+		record.Metadata, _ = json.Marshal(metadata)
+		if urls, ok := metadata["repositoryURLs"].([]any); ok {
+			for _, url := range urls {
+				if urlStr, ok := url.(string); ok {
+					record.RepositoryURLs = append(record.RepositoryURLs, urlStr)
+				}
+			}
+		}
+	}
+	if packageJson, err := r.Repo.LoadPackageJson(ctx, scope, name, version); err == nil {
+		record.PackageJson, _ = json.Marshal(packageJson)
+	}
+	return record
+}
+
+func (r *Repo) listPrefix(prefix string) ([]models.ListElement, error) {
+	elements := []models.ListElement{}
+	err := r.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(bucketReleases).Cursor()
+		for k, _ := cursor.Seek([]byte(prefix)); k != nil && bytes.HasPrefix(k, []byte(prefix)); k, _ = cursor.Next() {
+			parts := bytes.Split(k, []byte(keySeparator))
+			if len(parts) != 3 {
+				continue
+			}
+			elements = append(elements, *models.NewListElement(string(parts[0]), string(parts[1]), string(parts[2])))
+		}
+		return nil
+	})
+	return elements, err
+}
+
+func putRecord(tx *bolt.Tx, record *Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if err := tx.Bucket(bucketReleases).Put(releaseKey(record.Scope, record.Name, record.Version), data); err != nil {
+		return err
+	}
+	for _, url := range record.RepositoryURLs {
+		if err := tx.Bucket(bucketURLs).Put(urlKey(url, record.Scope, record.Name, record.Version), []byte{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteRecord removes the release and its repository URLs
+func deleteRecord(tx *bolt.Tx, scope string, name string, version string) error {
+	releases := tx.Bucket(bucketReleases)
+	key := releaseKey(scope, name, version)
+	data := releases.Get(key)
+	if data == nil {
+		return nil
+	}
+	var record Record
+	if err := json.Unmarshal(data, &record); err == nil {
+		for _, url := range record.RepositoryURLs {
+			if err := tx.Bucket(bucketURLs).Delete(urlKey(url, scope, name, version)); err != nil {
+				return err
+			}
+		}
+	}
+	return releases.Delete(key)
+}
+
+func releaseKey(scope string, name string, version string) []byte {
+	return []byte(scope + keySeparator + name + keySeparator + version)
+}
+
+func urlKey(url string, scope string, name string, version string) []byte {
+	return []byte(url + keySeparator + scope + keySeparator + name + keySeparator + version)
+}
@@ -0,0 +1,199 @@
+package index
+
+import (
+	"OpenSPMRegistry/mimetypes"
+	"OpenSPMRegistry/models"
+	"OpenSPMRegistry/repo/files"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeElement(t *testing.T, r *files.FileRepo, element *models.UploadElement, content string) {
+	t.Helper()
+	w, err := r.GetWriter(context.Background(), element)
+	if err != nil {
+		t.Fatalf("failed to create %s: %v", element.FileName(), err)
+	}
+	_, _ = w.Write([]byte(content))
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to write %s: %v", element.FileName(), err)
+	}
+}
+
+func writeRelease(t *testing.T, r *files.FileRepo, scope string, name string, version string, repositoryURL string) {
+	t.Helper()
+	writeElement(t, r, models.NewUploadElement(scope, name, version, mimetypes.ApplicationZip, models.SourceArchive), "zip")
+	writeElement(t, r, models.NewUploadElement(scope, name, version, mimetypes.ApplicationJson, models.PackageManifestJson), `{"name":"`+name+`"}`)
+	writeElement(t, r, models.NewUploadElement(scope, name, version, mimetypes.TextXSwift, models.Manifest), "// swift-tools-version:5.9\n")
+	writeElement(t, r, models.NewUploadElement(scope, name, version, mimetypes.ApplicationJson, models.Metadata),
+		`{"description":"`+name+`","repositoryURLs":["`+repositoryURL+`"]}`)
+}
+
+// newTestIndex creates a file repository with two packages and an index rebuilt from it
+func newTestIndex(t *testing.T) (*Repo, string) {
+	t.Helper()
+	dir := t.TempDir()
+	backend := files.NewFileRepo(filepath.Join(dir, "files"))
+	writeRelease(t, backend, "acme", "network", "1.0.0", "https://github.com/acme/network")
+	writeRelease(t, backend, "acme", "network", "1.1.0", "https://github.com/acme/network")
+	writeRelease(t, backend, "other", "utils", "2.0.0", "https://github.com/other/utils")
+
+	r, err := Open(filepath.Join(dir, "index.db"), backend)
+	if err != nil {
+		t.Fatalf("failed to open index: %v", err)
+	}
+	t.Cleanup(func() { _ = r.Close() })
+	if _, err := r.Rebuild(context.Background()); err != nil {
+		t.Fatalf("failed to rebuild index: %v", err)
+	}
+	return r, filepath.Join(dir, "files")
+}
+
+func Test_Rebuild_IndexesAllReleases(t *testing.T) {
+	r, _ := newTestIndex(t)
+	ctx := context.Background()
+
+	if !r.Built() {
+		t.Errorf("expected index to be built")
+	}
+	all, err := r.ListAll(ctx)
+	if err != nil || len(all) != 3 {
+		t.Fatalf("expected 3 releases, got %v (%v)", all, err)
+	}
+	scopes, err := r.ListScopes(ctx)
+	if err != nil || !reflect.DeepEqual(scopes, []string{"acme", "other"}) {
+		t.Errorf("expected scopes acme and other, got %v (%v)", scopes, err)
+	}
+	inScope, err := r.ListInScope(ctx, "acme")
+	if err != nil || len(inScope) != 2 {
+		t.Errorf("expected 2 releases in scope acme, got %v (%v)", inScope, err)
+	}
+	versions, err := r.List(ctx, "acme", "network")
+	expected := []models.ListElement{*models.NewListElement("acme", "network", "1.0.0"), *models.NewListElement("acme", "network", "1.1.0")}
+	if err != nil || !reflect.DeepEqual(versions, expected) {
+		t.Errorf("expected %v, got %v (%v)", expected, versions, err)
+	}
+	if missing, err := r.List(ctx, "acme", "missing"); err != nil || len(missing) != 0 {
+		t.Errorf("expected empty list for unknown package, got %v (%v)", missing, err)
+	}
+
+	record, err := r.Record("acme", "network", "1.0.0")
+	if err != nil || record == nil {
+		t.Fatalf("expected record, got %v (%v)", record, err)
+	}
+	if record.Checksum == "" || record.PublishedAt.IsZero() || record.ToolsVersion != "5.9" ||
+		!reflect.DeepEqual(record.RepositoryURLs, []string{"https://github.com/acme/network"}) {
+		t.Errorf("unexpected record %+v", record)
+	}
+}
+
+func Test_Lookup_ReturnsPackageOnceWithoutReadingBackend(t *testing.T) {
+	r, path := newTestIndex(t)
+	if err := os.RemoveAll(path); err != nil {
+		t.Fatal(err)
+	}
+
+	identifiers := r.Lookup(context.Background(), "https://github.com/acme/network")
+
+	if !reflect.DeepEqual(identifiers, []string{"acme.network"}) {
+		t.Errorf("expected acme.network, got %v", identifiers)
+	}
+	if identifiers := r.Lookup(context.Background(), "https://github.com/acme"); identifiers != nil {
+		t.Errorf("expected no identifiers for a URL prefix, got %v", identifiers)
+	}
+}
+
+func Test_LoadMetadata_ServedFromIndex(t *testing.T) {
+	r, path := newTestIndex(t)
+	if err := os.RemoveAll(path); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	metadata, err := r.LoadMetadata(ctx, "other", "utils", "2.0.0")
+	if err != nil || metadata["description"] != "utils" {
+		t.Errorf("expected indexed metadata, got %v (%v)", metadata, err)
+	}
+	packageJson, err := r.LoadPackageJson(ctx, "other", "utils", "2.0.0")
+	if err != nil || packageJson["name"] != "utils" {
+		t.Errorf("expected indexed Package.json, got %v (%v)", packageJson, err)
+	}
+	manifest := models.NewUploadElement("other", "utils", "2.0.0", mimetypes.TextXSwift, models.Manifest)
+	if toolsVersion, err := r.GetSwiftToolVersion(ctx, manifest); err != nil || toolsVersion != "5.9" {
+		t.Errorf("expected indexed tools version, got %q (%v)", toolsVersion, err)
+	}
+	archive := models.NewUploadElement("other", "utils", "2.0.0", mimetypes.ApplicationZip, models.SourceArchive)
+	if checksum, err := r.Checksum(ctx, archive); err != nil || checksum == "" {
+		t.Errorf("expected indexed checksum, got %q (%v)", checksum, err)
+	}
+}
+
+func Test_IndexRelease_AddsPublishedRelease(t *testing.T) {
+	r, path := newTestIndex(t)
+	ctx := context.Background()
+	writeRelease(t, files.NewFileRepo(path), "acme", "network", "2.0.0", "https://github.com/acme/network-next")
+
+	if err := r.IndexRelease(ctx, "acme", "network", "2.0.0"); err != nil {
+		t.Fatalf("failed to index release: %v", err)
+	}
+
+	versions, _ := r.List(ctx, "acme", "network")
+	if len(versions) != 3 {
+		t.Errorf("expected 3 versions, got %v", versions)
+	}
+	if identifiers := r.Lookup(ctx, "https://github.com/acme/network-next"); !reflect.DeepEqual(identifiers, []string{"acme.network"}) {
+		t.Errorf("expected acme.network, got %v", identifiers)
+	}
+}
+
+func Test_UnindexRelease_KeepsReleaseListedAsDeleted(t *testing.T) {
+	r, _ := newTestIndex(t)
+	ctx := context.Background()
+
+	if err := r.UnindexRelease(ctx, "other", "utils", "2.0.0"); err != nil {
+		t.Fatalf("failed to unindex release: %v", err)
+	}
+
+	if versions, _ := r.List(ctx, "other", "utils"); len(versions) != 1 {
+		t.Errorf("expected deleted release to stay listed, got %v", versions)
+	}
+	if record, _ := r.Record("other", "utils", "2.0.0"); record == nil || !record.Deleted || record.Metadata != nil {
+		t.Errorf("expected deleted record without metadata, got %+v", record)
+	}
+	if identifiers := r.Lookup(ctx, "https://github.com/other/utils"); identifiers != nil {
+		t.Errorf("expected deleted release not to be found, got %v", identifiers)
+	}
+}
+
+func Test_Open_ExistingIndex_KeepsRecords(t *testing.T) {
+	dir := t.TempDir()
+	backend := files.NewFileRepo(filepath.Join(dir, "files"))
+	writeRelease(t, backend, "acme", "network", "1.0.0", "https://github.com/acme/network")
+	r, err := Open(filepath.Join(dir, "index.db"), backend)
+	if err != nil {
+		t.Fatalf("failed to open index: %v", err)
+	}
+	if r.Built() {
+		t.Errorf("expected new index not to be built")
+	}
+	if _, err := r.Rebuild(context.Background()); err != nil {
+		t.Fatalf("failed to rebuild index: %v", err)
+	}
+	_ = r.Close()
+
+	r, err = Open(filepath.Join(dir, "index.db"), backend)
+	if err != nil {
+		t.Fatalf("failed to reopen index: %v", err)
+	}
+	defer func() { _ = r.Close() }()
+
+	if !r.Built() {
+		t.Errorf("expected reopened index to be built")
+	}
+	if all, _ := r.ListAll(context.Background()); len(all) != 1 {
+		t.Errorf("expected 1 release, got %v", all)
+	}
+}
@@ -8,10 +8,13 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"log/slog"
 	"net/http"
+	"slices"
 	"sort"
 	"sync"
 )
@@ -26,9 +29,12 @@ type access struct {
 	metadataKeys map[string]*sync.Mutex
 	// indexMu serializes read-modify-write of SPM registry index so concurrent publishes don't overwrite each other
 	indexMu sync.Mutex
+	// urlIndexMu serializes read-modify-write of the repository URL mapping
+	urlIndexMu sync.Mutex
 }
 
-// mavenWriter implements io.WriteCloser and uploads data via PUT on Close()
+// mavenWriter implements io.WriteCloser and streams data via PUT while it is written.
+// The upload is started on the first Write and completed on Close().
 type mavenWriter struct {
 	client      *client
 	config      config.MavenConfig
@@ -36,10 +42,23 @@ type mavenWriter struct {
 	path        string
 	element     *models.UploadElement
 	contentType string
-	buffer      []byte
 	ctx         context.Context
+	// pipe feeds the PUT request running in the background, result receives its outcome
+	pipe   *io.PipeWriter
+	result chan error
+	closed bool
+	hash   hash.Hash
+	// metadata buffers a metadata.json (small) to index its repository URLs
+	metadata []byte
 }
 
+var (
+	// errUploadEnded is returned by writes after the backend answered the upload before receiving all data
+	errUploadEnded = errors.New("upload ended before all data was sent")
+	// errWriterClosed is returned when writing to or closing a mavenWriter again
+	errWriterClosed = errors.New("writer already closed")
+)
+
 // newAccess creates a new Maven access implementation
 func newAccess(client *client, cfg config.MavenConfig) *access {
 	return &access{
@@ -143,8 +162,8 @@ func newMavenWriter(ctx context.Context, client *client, cfg config.MavenConfig,
 		path:        path,
 		element:     element,
 		contentType: element.MimeType,
-		buffer:      make([]byte, 0),
 		ctx:         ctx,
+		hash:        sha256.New(),
 	}
 }
 
@@ -179,19 +198,99 @@ func (a *access) updateSPMRegistryIndex(ctx context.Context, scope, packageName
 		packages[scope] = pkgList
 	}
 
+	if err := a.writeSPMRegistryIndex(ctx, packages); err != nil {
+		slog.Warn("failed to update SPM registry index", "path", spmRegistryIndexPath, "error", err)
+	}
+}
+
+// writeSPMRegistryIndex replaces the SPM registry index by the packages given per scope, the caller holds indexMu
+func (a *access) writeSPMRegistryIndex(ctx context.Context, packages map[string][]string) error {
 	body, err := json.Marshal(spmRegistryIndexResponse{Packages: packages})
 	if err != nil {
-		slog.Warn("failed to marshal SPM registry index", "error", err)
-		return
+		return fmt.Errorf("failed to marshal SPM registry index: %w", err)
 	}
 	if err := a.client.DELETE(ctx, spmRegistryIndexPath); err != nil {
 		if slog.Default().Enabled(context.Background(), slog.LevelDebug) {
 			slog.Debug("DELETE index before PUT (ignore if missing)", "path", spmRegistryIndexPath, "error", err)
 		}
 	}
-	if err := a.client.PUT(ctx, spmRegistryIndexPath, bytes.NewReader(body), "application/json"); err != nil {
-		slog.Warn("failed to update SPM registry index", "path", spmRegistryIndexPath, "error", err)
+	return a.client.PUT(ctx, spmRegistryIndexPath, bytes.NewReader(body), "application/json")
+}
+
+// updateRepositoryURLIndex GETs the repository URL mapping (repositoryURLIndexPath), replaces the URLs of the release
+// with repositoryURLs (none removes the release) and PUTs it back.
+// On 404 the current mapping is treated as empty, on other errors it is left untouched (so it is not overwritten).
+// Logs warnings on failure; does not fail the publish.
+func (a *access) updateRepositoryURLIndex(ctx context.Context, scope, packageName, version string, repositoryURLs []string) {
+	a.urlIndexMu.Lock()
+	defer a.urlIndexMu.Unlock()
+
+	index, err := a.readRepositoryURLIndex(ctx)
+	if err != nil {
+		slog.Warn("failed to read repository URL index, not updating it", "path", repositoryURLIndexPath, "error", err)
+		return
+	}
+
+	identifier := scope + "." + packageName
+	changed := false
+	for url, identifiers := range index.RepositoryURLs {
+		versions := slices.DeleteFunc(slices.Clone(identifiers[identifier]), func(v string) bool { return v == version })
+		if len(versions) == len(identifiers[identifier]) {
+			continue
+		}
+		changed = true
+		if len(versions) > 0 {
+			identifiers[identifier] = versions
+		} else {
+			delete(identifiers, identifier)
+		}
+		if len(identifiers) == 0 {
+			delete(index.RepositoryURLs, url)
+		}
+	}
+	for _, url := range repositoryURLs {
+		identifiers := index.RepositoryURLs[url]
+		if identifiers == nil {
+			identifiers = make(map[string][]string)
+			index.RepositoryURLs[url] = identifiers
+		}
+		if !slices.Contains(identifiers[identifier], version) {
+			identifiers[identifier] = append(identifiers[identifier], version)
+			sort.Strings(identifiers[identifier])
+			changed = true
+		}
+	}
+	if !changed {
+		return
+	}
+
+	if err := a.writeRepositoryURLIndex(ctx, index); err != nil {
+		slog.Warn("failed to update repository URL index", "path", repositoryURLIndexPath, "error", err)
+	}
+}
+
+// readRepositoryURLIndex GETs the repository URL mapping, empty if there is none yet (404)
+func (a *access) readRepositoryURLIndex(ctx context.Context) (*repositoryURLIndex, error) {
+	index, err := a.client.getRepositoryURLIndex(ctx)
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+		return &repositoryURLIndex{RepositoryURLs: make(map[string]map[string][]string)}, nil
+	}
+	return index, err
+}
+
+// writeRepositoryURLIndex replaces the repository URL mapping, the caller holds urlIndexMu
+func (a *access) writeRepositoryURLIndex(ctx context.Context, index *repositoryURLIndex) error {
+	body, err := json.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("failed to marshal repository URL index: %w", err)
+	}
+	if err := a.client.DELETE(ctx, repositoryURLIndexPath); err != nil {
+		if slog.Default().Enabled(context.Background(), slog.LevelDebug) {
+			slog.Debug("DELETE index before PUT (ignore if missing)", "path", repositoryURLIndexPath, "error", err)
+		}
 	}
+	return a.client.PUT(ctx, repositoryURLIndexPath, bytes.NewReader(body), "application/json")
 }
 
 // updateMetadataLocked updates maven-metadata.xml under a per-(groupId, artifactId) lock to prevent lost updates from concurrent uploads
@@ -210,21 +309,52 @@ func (a *access) updateMetadataLocked(ctx context.Context, groupId, artifactId,
 	return updateMetadata(ctx, a.client, groupId, artifactId, version)
 }
 
+// start begins the streaming PUT of the element
+func (w *mavenWriter) start() {
+	reader, writer := io.Pipe()
+	w.pipe = writer
+	w.result = make(chan error, 1)
+	go func() {
+		err := w.client.PUT(w.ctx, w.path, reader, w.contentType)
+		// unblock pending writes if the request ended before the whole body was sent
+		if err != nil {
+			_ = reader.CloseWithError(err)
+		} else {
+			_ = reader.CloseWithError(errUploadEnded)
+		}
+		w.result <- err
+	}()
+}
+
 func (w *mavenWriter) Write(p []byte) (n int, err error) {
-	w.buffer = append(w.buffer, p...)
-	return len(p), nil
+	if w.closed {
+		return 0, errWriterClosed
+	}
+	if w.pipe == nil {
+		w.start()
+	}
+	n, err = w.pipe.Write(p)
+	w.hash.Write(p[:n])
+	if isMetadata(w.element) {
+		w.metadata = append(w.metadata, p[:n]...)
+	}
+	return n, err
 }
 
 func (w *mavenWriter) Close() error {
-	reader := bytes.NewReader(w.buffer)
-	if err := w.client.PUT(w.ctx, w.path, reader, w.contentType); err != nil {
+	if w.closed {
+		return errWriterClosed
+	}
+	w.closed = true
+	if w.pipe == nil {
+		w.start()
+	}
+	_ = w.pipe.Close()
+	if err := <-w.result; err != nil {
 		return err
 	}
 
-	// Calculate SHA256 checksum for the uploaded data
-	hash := sha256.New()
-	hash.Write(w.buffer)
-	checksum := fmt.Sprintf("%x", hash.Sum(nil))
+	checksum := fmt.Sprintf("%x", w.hash.Sum(nil))
 
 	// Upload .sha256 checksum file (Maven convention)
 	checksumPath := w.path + ".sha256"
@@ -238,8 +368,8 @@ func (w *mavenWriter) Close() error {
 
 	// Update maven-metadata.xml after successful upload of source archives
 	// This ensures the metadata file is created/updated when packages are published
-	// Source archives are identified by: application/zip MIME type
-	if w.element.MimeType == mimetypes.ApplicationZip {
+	// Source archives are identified by their file name, signatures may share the application/zip MIME type
+	if isSourceArchive(w.element) {
 		groupId := buildGroupId(w.element.Scope, w.config)
 		artifactId := buildArtifactId(w.element.Name)
 		version := buildVersion(w.element.Version)
@@ -253,5 +383,33 @@ func (w *mavenWriter) Close() error {
 		w.access.updateSPMRegistryIndex(w.ctx, w.element.Scope, w.element.Name)
 	}
 
+	// Map the repository URLs of metadata.json to the package so lookups need a single GET
+	if isMetadata(w.element) {
+		w.access.updateRepositoryURLIndex(w.ctx, w.element.Scope, w.element.Name, w.element.Version, repositoryURLs(w.metadata))
+	}
+
 	return nil
 }
+
+// isSourceArchive checks whether element is the source archive of its release
+func isSourceArchive(element *models.UploadElement) bool {
+	archive := models.NewUploadElement(element.Scope, element.Name, element.Version, mimetypes.ApplicationZip, models.SourceArchive)
+	return element.FileName() == archive.FileName()
+}
+
+// isMetadata checks whether the element is the metadata.json of a release
+func isMetadata(element *models.UploadElement) bool {
+	metadata := models.NewUploadElement(element.Scope, element.Name, element.Version, mimetypes.ApplicationJson, models.Metadata)
+	return element.FileName() == metadata.FileName()
+}
+
+// repositoryURLs returns the repositoryURLs of a metadata.json, none if it cannot be parsed
+func repositoryURLs(metadata []byte) []string {
+	var parsed struct {
+		RepositoryURLs []string `json:"repositoryURLs"`
+	}
+	if err := json.Unmarshal(metadata, &parsed); err != nil {
+		return nil
+	}
+	return parsed.RepositoryURLs
+}
@@ -4,12 +4,17 @@ import (
 	"OpenSPMRegistry/config"
 	"OpenSPMRegistry/mimetypes"
 	"OpenSPMRegistry/models"
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -39,6 +44,19 @@ func Test_buildMavenPathForElement_Metadata_ReturnsCorrectPath(t *testing.T) {
 	}
 }
 
+func Test_buildMavenPathForElement_Publication_ReturnsCorrectPath(t *testing.T) {
+	cfg := config.MavenConfig{}
+	c, _ := newClient(cfg)
+	a := newAccess(c, cfg)
+
+	element := models.NewUploadElement("testScope", "my-package", "1.0.0", mimetypes.ApplicationJson, models.Publication)
+	path := a.buildMavenPathForElement(element)
+	expected := "testScope/my-package/1.0.0/my-package-1.0.0-publication.json"
+	if path != expected {
+		t.Errorf("expected '%s', got '%s'", expected, path)
+	}
+}
+
 func Test_buildMavenPathForElement_PackageSwift_ReturnsCorrectPath(t *testing.T) {
 	cfg := config.MavenConfig{}
 	c, _ := newClient(cfg)
@@ -553,6 +571,70 @@ func Test_mavenWriter_Close_UploadsData(t *testing.T) {
 	}
 }
 
+func Test_mavenWriter_Close_StreamsDataAndUploadsChecksum(t *testing.T) {
+	var mu sync.Mutex
+	uploadedByPath := make(map[string][]byte)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PUT" {
+			data, _ := io.ReadAll(r.Body)
+			mu.Lock()
+			uploadedByPath[r.URL.Path] = data
+			mu.Unlock()
+		}
+	}))
+	defer server.Close()
+
+	cfg := config.MavenConfig{BaseURL: server.URL}
+	c, _ := newClient(cfg)
+	a := newAccess(c, cfg)
+
+	element := models.NewUploadElement("testScope", "my-package", "1.0.0", mimetypes.ApplicationOctetStream, models.SourceArchiveSignature)
+	path := a.buildMavenPathForElement(element)
+	writer := newMavenWriter(context.Background(), c, cfg, a, path, element)
+	chunk := bytes.Repeat([]byte("0123456789abcdef"), 4096)
+	var expected []byte
+	for i := 0; i < 16; i++ {
+		if _, err := writer.Write(chunk); err != nil {
+			t.Fatalf("unexpected error writing: %v", err)
+		}
+		expected = append(expected, chunk...)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !bytes.Equal(uploadedByPath["/"+path], expected) {
+		t.Errorf("expected %d bytes to be uploaded, got %d", len(expected), len(uploadedByPath["/"+path]))
+	}
+	if checksum := fmt.Sprintf("%x", sha256.Sum256(expected)); string(uploadedByPath["/"+path+".sha256"]) != checksum {
+		t.Errorf("expected checksum %s, got %s", checksum, uploadedByPath["/"+path+".sha256"])
+	}
+	if err := writer.Close(); err == nil {
+		t.Errorf("expected error closing the writer again")
+	}
+}
+
+func Test_mavenWriter_UploadFails_ReturnsError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	cfg := config.MavenConfig{BaseURL: server.URL}
+	c, _ := newClient(cfg)
+	a := newAccess(c, cfg)
+
+	element := models.NewUploadElement("testScope", "my-package", "1.0.0", mimetypes.ApplicationZip, models.SourceArchive)
+	path := a.buildMavenPathForElement(element)
+	writer := newMavenWriter(context.Background(), c, cfg, a, path, element)
+	_, _ = writer.Write([]byte("zip"))
+
+	if err := writer.Close(); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("expected upload error, got %v", err)
+	}
+}
+
 func Test_GetWriter_ErrorBuildingPath_ReturnsError(t *testing.T) {
 	// This test would require a way to force buildMavenPathForElement to fail
 	// Since it doesn't currently return errors, we'll test the normal case
@@ -637,3 +719,96 @@ func Test_mavenWriter_Close_UpdatesSPMRegistryIndexWithScopeAndPackage(t *testin
 		}
 	}
 }
+
+func Test_mavenWriter_Close_Metadata_MapsRepositoryURLs(t *testing.T) {
+	var mappingPUTBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		if r.Method == "GET" && strings.HasSuffix(path, "index-1-urls.json") {
+			_, _ = w.Write([]byte(`{"repositoryURLs":{"https://github.com/acme/old":{"acme.network":["1.0.0"]}}}`))
+			return
+		}
+		if r.Method == "PUT" && strings.HasSuffix(path, "index-1-urls.json") {
+			mappingPUTBody, _ = io.ReadAll(r.Body)
+		}
+	}))
+	defer server.Close()
+
+	cfg := config.MavenConfig{BaseURL: server.URL}
+	c, _ := newClient(cfg)
+	a := newAccess(c, cfg)
+
+	element := models.NewUploadElement("acme", "network", "1.1.0", mimetypes.ApplicationJson, models.Metadata)
+	writer, _ := a.GetWriter(context.Background(), element)
+	_, _ = writer.Write([]byte(`{"repositoryURLs":["https://github.com/acme/network","git@github.com:acme/network.git"]}`))
+	if err := writer.Close(); err != nil {
+		t.Fatalf("unexpected error closing: %v", err)
+	}
+
+	var mapping repositoryURLIndex
+	if err := json.Unmarshal(mappingPUTBody, &mapping); err != nil {
+		t.Fatalf("expected mapping to be PUT, got %q: %v", mappingPUTBody, err)
+	}
+	expected := map[string]map[string][]string{
+		"https://github.com/acme/old":     {"acme.network": {"1.0.0"}},
+		"https://github.com/acme/network": {"acme.network": {"1.1.0"}},
+		"git@github.com:acme/network.git": {"acme.network": {"1.1.0"}},
+	}
+	if !reflect.DeepEqual(mapping.RepositoryURLs, expected) {
+		t.Errorf("expected %v, got %v", expected, mapping.RepositoryURLs)
+	}
+}
+
+func Test_updateRepositoryURLIndex_ReadFails_DoesNotOverwrite(t *testing.T) {
+	put := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PUT" {
+			put = true
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	cfg := config.MavenConfig{BaseURL: server.URL}
+	c, _ := newClient(cfg)
+	a := newAccess(c, cfg)
+
+	a.updateRepositoryURLIndex(context.Background(), "acme", "network", "1.0.0", []string{"https://github.com/acme/network"})
+
+	if put {
+		t.Errorf("expected mapping not to be written when it cannot be read")
+	}
+}
+
+func Test_mavenWriter_ZipSignature_DoesNotUpdateMetadata(t *testing.T) {
+	var mu sync.Mutex
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested = append(requested, r.URL.Path)
+		mu.Unlock()
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	cfg := config.MavenConfig{BaseURL: server.URL}
+	c, _ := newClient(cfg)
+	a := newAccess(c, cfg)
+
+	element := models.NewUploadElement("testScope", "my-package", "1.0.0", mimetypes.ApplicationZip, models.SourceArchiveSignature)
+	path := a.buildMavenPathForElement(element)
+	writer := newMavenWriter(context.Background(), c, cfg, a, path, element)
+	_, _ = writer.Write([]byte("signature"))
+	if err := writer.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, p := range requested {
+		if strings.HasSuffix(p, "maven-metadata.xml") {
+			t.Errorf("expected signature upload not to update maven-metadata.xml, requested %v", requested)
+		}
+	}
+}
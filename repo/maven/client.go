@@ -2,6 +2,7 @@ package maven
 
 import (
 	"OpenSPMRegistry/config"
+	"OpenSPMRegistry/metrics"
 	"context"
 	"encoding/base64"
 	"encoding/json"
@@ -12,6 +13,7 @@ import (
 	"net/http"
 	"net/url"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -30,14 +32,29 @@ type spmRegistryIndexResponse struct {
 	Packages map[string][]string `json:"packages,omitempty"`
 }
 
+// repositoryURLIndex is the JSON structure of the repository URL mapping (repositoryURLIndexPath) answering lookups:
+// repository URL -> package identifier (scope.name) -> versions whose metadata lists the URL.
+type repositoryURLIndex struct {
+	RepositoryURLs map[string]map[string][]string `json:"repositoryURLs"`
+}
+
+// instrumentedTransport records latency and status code of every request to the Maven backend
+type instrumentedTransport struct {
+	next http.RoundTripper
+}
+
 // HTTPStatusError carries the HTTP status code for failed requests.
 type HTTPStatusError struct {
 	StatusCode int
 }
 
-// spmRegistryIndexPath is the well-known path for the SPM registry scope index (relative to repo base URL).
-// Uses Maven 2 layout (groupId/artifactId/version/file) so strict Maven repos (e.g. Nexus) accept PUT/GET.
-const spmRegistryIndexPath = "com/spm/registry/index/1/index-1.json"
+const (
+	// spmRegistryIndexPath is the well-known path for the SPM registry scope index (relative to repo base URL).
+	// Uses Maven 2 layout (groupId/artifactId/version/file) so strict Maven repos (e.g. Nexus) accept PUT/GET.
+	spmRegistryIndexPath = "com/spm/registry/index/1/index-1.json"
+	// repositoryURLIndexPath is the repository URL mapping next to the scope index (classifier "urls")
+	repositoryURLIndexPath = "com/spm/registry/index/1/index-1-urls.json"
+)
 
 // ErrHTTPStatus is returned when the server responds with status >= 400.
 // Callers can use errors.As to detect specific status codes (e.g. 404).
@@ -53,7 +70,8 @@ func newClient(cfg config.MavenConfig) (*client, error) {
 
 	return &client{
 		httpClient: &http.Client{
-			Timeout: timeout,
+			Timeout:   timeout,
+			Transport: &instrumentedTransport{next: http.DefaultTransport},
 		},
 		baseURL: strings.TrimSuffix(cfg.BaseURL, "/"),
 		config:  cfg,
@@ -114,6 +132,18 @@ func (c *client) makeRequest(ctx context.Context, method, path string, body io.R
 	return req, nil
 }
 
+func (t *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	code := "error"
+	if err == nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+	metrics.MavenRequests.WithLabelValues(req.Method, code).Inc()
+	metrics.MavenRequestDuration.WithLabelValues(req.Method).Observe(time.Since(start).Seconds())
+	return resp, err
+}
+
 func (e *HTTPStatusError) Error() string {
 	return fmt.Sprintf("request failed with status %d", e.StatusCode)
 }
@@ -208,6 +238,29 @@ func (c *client) getSPMRegistryIndex(ctx context.Context) ([]string, error) {
 	return out, nil
 }
 
+// getRepositoryURLIndex fetches the repository URL mapping (repositoryURLIndexPath).
+// On 404 or non-200 status returns (nil, error). A missing or null mapping is normalized to empty.
+func (c *client) getRepositoryURLIndex(ctx context.Context) (*repositoryURLIndex, error) {
+	resp, err := c.GET(ctx, repositoryURLIndexPath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("request failed with status %d: %s", resp.StatusCode, resp.Status)
+	}
+
+	var index repositoryURLIndex
+	if err := json.NewDecoder(resp.Body).Decode(&index); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", repositoryURLIndexPath, err)
+	}
+	if index.RepositoryURLs == nil {
+		index.RepositoryURLs = make(map[string]map[string][]string)
+	}
+	return &index, nil
+}
+
 // PUT performs a PUT request to upload artifacts
 func (c *client) PUT(ctx context.Context, path string, body io.Reader, contentType string) error {
 	req, err := c.makeRequest(ctx, "PUT", path, body)
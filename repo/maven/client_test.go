@@ -2,6 +2,7 @@ package maven
 
 import (
 	"OpenSPMRegistry/config"
+	"OpenSPMRegistry/metrics"
 	"context"
 	"encoding/base64"
 	"net/http"
@@ -514,3 +515,24 @@ func Test_getSPMRegistryIndexFull_OnlyScopes_PackagesEmpty(t *testing.T) {
 		t.Errorf("expected empty packages map when omitted, got %v", index.Packages)
 	}
 }
+
+func Test_newClient_RecordsBackendMetrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+	c, err := newClient(config.MavenConfig{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	requests := metrics.MavenRequests.WithLabelValues(http.MethodGet, "404")
+	before := requests.Value()
+
+	if _, err := c.GET(context.Background(), "missing"); err == nil {
+		t.Fatalf("expected error for 404")
+	}
+
+	if requests.Value() != before+1 {
+		t.Errorf("expected backend request to be counted, got %v (before %v)", requests.Value(), before)
+	}
+}
@@ -101,10 +101,10 @@ func mavenClassifierFromFilename(filename string) string {
 }
 
 // pathPartsForElement returns the Maven classifier and extension for an element.
-// Sidecars (metadata, manifests) get a classifier; main artifact does not.
+// Sidecars (metadata, manifests, tombstone, publication record) get a classifier; main artifact does not.
 func pathPartsForElement(element *models.UploadElement) (classifier, ext string) {
 	fn := element.FilenameWithoutExtension()
-	isSidecar := fn == "metadata" || strings.HasPrefix(strings.ToLower(fn), "package")
+	isSidecar := fn == "metadata" || fn == "tombstone" || fn == "publication" || strings.HasPrefix(strings.ToLower(fn), "package")
 	if isSidecar {
 		classifier = mavenClassifierFromFilename(fn)
 	}
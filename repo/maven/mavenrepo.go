@@ -9,7 +9,6 @@ import (
 	"OpenSPMRegistry/utils"
 	"archive/zip"
 	"bufio"
-	"bytes"
 	"context"
 	"crypto/sha256"
 	"encoding/base64"
@@ -20,7 +19,10 @@ import (
 	"log/slog"
 	"mime"
 	"net/http"
+	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 )
@@ -31,6 +33,7 @@ type MavenRepo struct {
 	client       *client
 	config       config.MavenConfig
 	timeProvider utils.TimeProvider
+	checksums    *repo.ChecksumCache
 }
 
 // NewMavenRepo creates a new Maven repository instance
@@ -47,6 +50,7 @@ func NewMavenRepo(cfg config.MavenConfig) (*MavenRepo, error) {
 		client:       client,
 		config:       cfg,
 		timeProvider: utils.NewRealTimeProvider(),
+		checksums:    repo.NewChecksumCache(repo.DefaultChecksumCacheSize),
 	}, nil
 }
 
@@ -57,20 +61,24 @@ func (m *MavenRepo) ExtractManifestFiles(ctx context.Context, element *models.Up
 		return errors.New("unsupported mime type")
 	}
 
-	// Download source archive
-	reader, err := m.GetReader(ctx, element)
+	// Download the source archive to a temporary file so memory use does not grow with its size
+	spoolPath, err := m.spoolSourceArchive(ctx, element)
 	if err != nil {
-		return fmt.Errorf("failed to get source archive: %w", err)
+		return err
 	}
-	defer func() { _ = reader.Close() }()
+	defer removeSpool(spoolPath)
 
-	// Read source archive into memory (SPM packages are typically small)
-	data, err := io.ReadAll(reader)
+	spool, err := os.Open(spoolPath)
+	if err != nil {
+		return fmt.Errorf("failed to read source archive: %w", err)
+	}
+	defer func() { _ = spool.Close() }()
+	info, err := spool.Stat()
 	if err != nil {
 		return fmt.Errorf("failed to read source archive: %w", err)
 	}
 
-	zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	zipReader, err := zip.NewReader(spool, info.Size())
 	if err != nil {
 		return fmt.Errorf("failed to open zip: %w", err)
 	}
@@ -123,6 +131,35 @@ func (m *MavenRepo) ExtractManifestFiles(ctx context.Context, element *models.Up
 	return files.ExtractManifestFilesFromZipReader(element, zipReader, fileExtractor)
 }
 
+// spoolSourceArchive downloads the source archive to a temporary file and returns its path, the caller removes it
+func (m *MavenRepo) spoolSourceArchive(ctx context.Context, element *models.UploadElement) (string, error) {
+	reader, err := m.GetReader(ctx, element)
+	if err != nil {
+		return "", fmt.Errorf("failed to get source archive: %w", err)
+	}
+	defer func() { _ = reader.Close() }()
+
+	spool, err := os.CreateTemp("", "maven-source-archive-*.zip")
+	if err != nil {
+		return "", fmt.Errorf("failed to spool source archive: %w", err)
+	}
+	_, err = io.Copy(spool, reader)
+	if closeErr := spool.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		removeSpool(spool.Name())
+		return "", fmt.Errorf("failed to read source archive: %w", err)
+	}
+	return spool.Name(), nil
+}
+
+func removeSpool(file string) {
+	if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Warn("Failed to remove spooled source archive", "file", file, "error", err)
+	}
+}
+
 // List returns all versions of a package
 func (m *MavenRepo) List(ctx context.Context, scope string, name string) ([]models.ListElement, error) {
 	groupId := buildGroupId(scope, m.config)
@@ -162,8 +199,14 @@ func (m *MavenRepo) EncodeBase64(ctx context.Context, element *models.UploadElem
 	return base64.StdEncoding.EncodeToString(data), nil
 }
 
-// PublishDate returns the publish date from Last-Modified header
+// PublishDate returns the publish date of the release from its publication record,
+// the Last-Modified header of releases published before records were kept
 func (m *MavenRepo) PublishDate(ctx context.Context, element *models.UploadElement) (time.Time, error) {
+	return repo.PublishDate(ctx, m, element, m.lastModified)
+}
+
+// lastModified returns the Last-Modified header of the element
+func (m *MavenRepo) lastModified(ctx context.Context, element *models.UploadElement) (time.Time, error) {
 	path := m.Access.(*access).buildMavenPathForElement(element)
 
 	resp, err := m.client.HEAD(ctx, path)
@@ -221,14 +264,19 @@ func (m *MavenRepo) LoadMetadata(ctx context.Context, scope string, name string,
 	return metadata, nil
 }
 
-// Checksum computes SHA256 checksum of the element
-// First tries to read from the .sha256 checksum file (Maven convention)
-// Falls back to calculating the checksum if the file doesn't exist
+// Checksum returns the SHA256 checksum of the element, for source archives the one recorded
+// on publication (see repo.ChecksumCache)
 func (m *MavenRepo) Checksum(ctx context.Context, element *models.UploadElement) (string, error) {
 	if !m.Exists(ctx, element) {
 		return "", fmt.Errorf("file does not exist: %s", element.FileName())
 	}
+	return m.checksums.Checksum(ctx, m, element, m.computeChecksum)
+}
 
+// computeChecksum computes SHA256 checksum of the element
+// First tries to read from the .sha256 checksum file (Maven convention)
+// Falls back to calculating the checksum if the file doesn't exist
+func (m *MavenRepo) computeChecksum(ctx context.Context, element *models.UploadElement) (string, error) {
 	// Try to read from .sha256 checksum file first (more efficient)
 	path := m.Access.(*access).buildMavenPathForElement(element)
 
@@ -322,19 +370,43 @@ func (m *MavenRepo) GetSwiftToolVersion(ctx context.Context, manifest *models.Up
 	return "", errors.New("swift-tools-version not found")
 }
 
-// Lookup finds packages by repository URL (not well supported by Maven)
+// Lookup finds packages by repository URL with a single GET of the repository URL mapping,
+// which is updated whenever a metadata.json is published or removed
 func (m *MavenRepo) Lookup(ctx context.Context, url string) []string {
-	// Maven doesn't support this directly
-	// Would need to scan all metadata files, which is expensive
-	// Return empty for now
-	return []string{}
+	index, err := m.client.getRepositoryURLIndex(ctx)
+	if err != nil {
+		if !errors.Is(err, ErrHTTPStatus) {
+			slog.Warn("Error reading repository URL index", "error", err)
+		}
+		return nil
+	}
+
+	var result []string
+	for identifier := range index.RepositoryURLs[url] {
+		result = append(result, identifier)
+	}
+	sort.Strings(result)
+	return result
 }
 
-// Remove deletes an element from the Maven repository
-func (m *MavenRepo) Remove(ctx context.Context, element *models.UploadElement) error {
-	path := m.Access.(*access).buildMavenPathForElement(element)
+// GetWriter returns a writer uploading the element, a cached checksum of it is dropped
+func (m *MavenRepo) GetWriter(ctx context.Context, element *models.UploadElement) (io.WriteCloser, error) {
+	m.checksums.Forget(element)
+	return m.Access.GetWriter(ctx, element)
+}
 
-	return m.client.DELETE(ctx, path)
+// Remove deletes an element from the Maven repository.
+// Removing metadata.json also removes the release from the repository URL mapping.
+func (m *MavenRepo) Remove(ctx context.Context, element *models.UploadElement) error {
+	m.checksums.Forget(element)
+	a := m.Access.(*access)
+	if err := m.client.DELETE(ctx, a.buildMavenPathForElement(element)); err != nil {
+		return err
+	}
+	if isMetadata(element) {
+		a.updateRepositoryURLIndex(ctx, element.Scope, element.Name, element.Version, nil)
+	}
+	return nil
 }
 
 // ListScopes returns all available scopes from .spm-registry/index.json only.
@@ -412,3 +484,98 @@ func (m *MavenRepo) LoadPackageJson(ctx context.Context, scope string, name stri
 
 	return packageJson, nil
 }
+
+// CheckHealth verifies the Maven backend answers and the SPM registry index is readable.
+// A missing index (nothing published yet) is fine, so is an authentication challenge in passthrough mode
+// where the index can only be read with the credentials of a client request.
+func (m *MavenRepo) CheckHealth(ctx context.Context) error {
+	_, err := m.client.getSPMRegistryIndexFull(ctx)
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		switch {
+		case statusErr.StatusCode == http.StatusNotFound:
+			return nil
+		case m.config.AuthMode == "passthrough" &&
+			(statusErr.StatusCode == http.StatusUnauthorized || statusErr.StatusCode == http.StatusForbidden):
+			return nil
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("SPM registry index not readable: %w", err)
+	}
+	return nil
+}
+
+// StoredChecksum reads the .sha256 checksum file stored next to the element (Maven convention).
+// Returns an empty checksum if there is none.
+func (m *MavenRepo) StoredChecksum(ctx context.Context, element *models.UploadElement) (string, error) {
+	checksumPath := m.Access.(*access).buildMavenPathForElement(element) + ".sha256"
+	resp, err := m.client.GET(ctx, checksumPath)
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return "", err
+	}
+	// some tools write "<checksum>  <file name>"
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return "", nil
+	}
+	return strings.ToLower(fields[0]), nil
+}
+
+// IndexedPackages returns the artifactIds per scope listed in the SPM registry index, none if it does not exist
+func (m *MavenRepo) IndexedPackages(ctx context.Context) (map[string][]string, error) {
+	index, err := m.client.getSPMRegistryIndexFull(ctx)
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+		return map[string][]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return index.Packages, nil
+}
+
+// RebuildPackageIndex replaces the SPM registry index by the packages given per scope
+func (m *MavenRepo) RebuildPackageIndex(ctx context.Context, packages map[string][]string) error {
+	a := m.Access.(*access)
+	a.indexMu.Lock()
+	defer a.indexMu.Unlock()
+
+	index := make(map[string][]string, len(packages))
+	for scope, names := range packages {
+		if len(names) == 0 {
+			continue
+		}
+		names = slices.Clone(names)
+		sort.Strings(names)
+		index[scope] = slices.Compact(names)
+	}
+	return a.writeSPMRegistryIndex(ctx, index)
+}
+
+// IndexedRepositoryURLs returns the repository URL mapping answering lookups
+func (m *MavenRepo) IndexedRepositoryURLs(ctx context.Context) (map[string]map[string][]string, error) {
+	index, err := m.Access.(*access).readRepositoryURLIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return index.RepositoryURLs, nil
+}
+
+// RebuildRepositoryURLIndex replaces the repository URL mapping, e.g. to add releases stored before it was kept
+func (m *MavenRepo) RebuildRepositoryURLIndex(ctx context.Context, urls map[string]map[string][]string) error {
+	a := m.Access.(*access)
+	a.urlIndexMu.Lock()
+	defer a.urlIndexMu.Unlock()
+	return a.writeRepositoryURLIndex(ctx, &repositoryURLIndex{RepositoryURLs: urls})
+}
@@ -15,6 +15,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -535,6 +536,28 @@ func Test_PublishDate_NoLastModified_ReturnsCurrentTime(t *testing.T) {
 	}
 }
 
+func Test_PublishDate_PublicationRecord_ReturnsRecordedDate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Last-Modified", time.Now().Format(time.RFC1123))
+		if strings.HasSuffix(r.URL.Path, "-publication.json") && r.Method == http.MethodGet {
+			_, _ = w.Write([]byte(`{"publishedAt":"2023-01-02T03:04:05Z","publisher":"alice"}`))
+		}
+	}))
+	defer server.Close()
+
+	cfg := config.MavenConfig{BaseURL: server.URL}
+	repo, err := NewMavenRepo(cfg)
+	if err != nil {
+		t.Fatalf("failed to create repo: %v", err)
+	}
+
+	element := models.NewUploadElement("testScope", "my-package", "1.0.0", mimetypes.ApplicationZip, models.SourceArchive)
+	result, err := repo.PublishDate(context.Background(), element)
+	if err != nil || !result.Equal(time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("expected recorded publish date, got %v (%v)", result, err)
+	}
+}
+
 func Test_PublishDate_FileDoesNotExist_ReturnsError(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusNotFound)
@@ -744,16 +767,109 @@ func Test_GetAlternativeManifests_ReturnsEmptyList(t *testing.T) {
 	}
 }
 
-func Test_Lookup_ReturnsEmptyList(t *testing.T) {
-	cfg := config.MavenConfig{BaseURL: "https://repo.example.com"}
-	repo, err := NewMavenRepo(cfg)
+func Test_Lookup_MappedURL_ReturnsIdentifiersWithSingleGET(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Method == "GET" && strings.HasSuffix(r.URL.Path, "index-1-urls.json") {
+			_, _ = w.Write([]byte(`{"repositoryURLs":{"https://github.com/acme/network":{"acme.network":["1.0.0"],"fork.network":["2.0.0"]}}}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	repo, err := NewMavenRepo(config.MavenConfig{BaseURL: server.URL})
 	if err != nil {
 		t.Fatalf("failed to create repo: %v", err)
 	}
 
-	result := repo.Lookup(context.Background(), "https://example.com/repo")
-	if len(result) != 0 {
-		t.Errorf("expected empty list, got %d items", len(result))
+	result := repo.Lookup(context.Background(), "https://github.com/acme/network")
+
+	if len(result) != 2 || result[0] != "acme.network" || result[1] != "fork.network" {
+		t.Errorf("expected [acme.network fork.network], got %v", result)
+	}
+	if requests != 1 {
+		t.Errorf("expected a single request, got %d", requests)
+	}
+}
+
+func Test_Lookup_NoMapping_ReturnsNil(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	repo, err := NewMavenRepo(config.MavenConfig{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("failed to create repo: %v", err)
+	}
+
+	if result := repo.Lookup(context.Background(), "https://example.com/repo"); result != nil {
+		t.Errorf("expected nil, got %v", result)
+	}
+}
+
+func Test_Remove_Metadata_RemovesReleaseFromURLMapping(t *testing.T) {
+	var mappingPUTBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && strings.HasSuffix(r.URL.Path, "index-1-urls.json"):
+			_, _ = w.Write([]byte(`{"repositoryURLs":{"https://github.com/acme/network":{"acme.network":["1.0.0","1.1.0"]}}}`))
+		case r.Method == "PUT":
+			mappingPUTBody, _ = io.ReadAll(r.Body)
+		}
+	}))
+	defer server.Close()
+
+	repo, err := NewMavenRepo(config.MavenConfig{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("failed to create repo: %v", err)
+	}
+
+	element := models.NewUploadElement("acme", "network", "1.0.0", mimetypes.ApplicationJson, models.Metadata)
+	if err := repo.Remove(context.Background(), element); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var mapping repositoryURLIndex
+	if err := json.Unmarshal(mappingPUTBody, &mapping); err != nil {
+		t.Fatalf("expected mapping to be PUT, got %q: %v", mappingPUTBody, err)
+	}
+	versions := mapping.RepositoryURLs["https://github.com/acme/network"]["acme.network"]
+	if len(versions) != 1 || versions[0] != "1.1.0" {
+		t.Errorf("expected only 1.1.0 to remain, got %v", mapping.RepositoryURLs)
+	}
+}
+
+func Test_RebuildRepositoryURLIndex_ReplacesMapping(t *testing.T) {
+	var mappingPUTBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "PUT" && strings.HasSuffix(r.URL.Path, "index-1-urls.json"):
+			mappingPUTBody, _ = io.ReadAll(r.Body)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	repo, err := NewMavenRepo(config.MavenConfig{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("failed to create repo: %v", err)
+	}
+
+	if urls, err := repo.IndexedRepositoryURLs(context.Background()); err != nil || len(urls) != 0 {
+		t.Errorf("expected empty mapping without index file, got %v (%v)", urls, err)
+	}
+	urls := map[string]map[string][]string{"https://github.com/acme/network": {"acme.network": {"1.0.0"}}}
+	if err := repo.RebuildRepositoryURLIndex(context.Background(), urls); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var mapping repositoryURLIndex
+	if err := json.Unmarshal(mappingPUTBody, &mapping); err != nil || len(mapping.RepositoryURLs["https://github.com/acme/network"]["acme.network"]) != 1 {
+		t.Errorf("expected mapping to be PUT, got %q (%v)", mappingPUTBody, err)
 	}
 }
 
@@ -971,6 +1087,55 @@ func Test_ExtractManifestFiles_ValidZip_ExtractsFiles(t *testing.T) {
 	}
 }
 
+func Test_ExtractManifestFiles_AfterUpload_DownloadsUploadedArchive(t *testing.T) {
+	var zipBuf bytes.Buffer
+	zipWriter := zip.NewWriter(&zipBuf)
+	packageSwift, _ := zipWriter.Create("testScope.my-package/Package.swift")
+	_, _ = packageSwift.Write([]byte("// swift-tools-version:6.0\n"))
+	_ = zipWriter.Close()
+
+	var mu sync.Mutex
+	uploadedFiles := make(map[string][]byte)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Method == "PUT":
+			data, _ := io.ReadAll(r.Body)
+			uploadedFiles[strings.TrimPrefix(r.URL.Path, "/")] = data
+		case r.Method == "GET" && uploadedFiles[strings.TrimPrefix(r.URL.Path, "/")] != nil:
+			_, _ = w.Write(uploadedFiles[strings.TrimPrefix(r.URL.Path, "/")])
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	repo, err := NewMavenRepo(config.MavenConfig{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("failed to create repo: %v", err)
+	}
+	ctx := context.Background()
+	element := models.NewUploadElement("testScope", "my-package", "1.0.0", mimetypes.ApplicationZip, models.SourceArchive)
+	writer, _ := repo.GetWriter(ctx, element)
+	_, _ = writer.Write(zipBuf.Bytes())
+	if err := writer.Close(); err != nil {
+		t.Fatalf("unexpected error uploading: %v", err)
+	}
+
+	if err := repo.ExtractManifestFiles(ctx, element); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	manifest := models.NewUploadElement("testScope", "my-package", "1.0.0", mimetypes.TextXSwift, models.Manifest)
+	manifestPath := repo.Access.(*access).buildMavenPathForElement(manifest)
+	if !strings.Contains(string(uploadedFiles[manifestPath]), "swift-tools-version:6.0") {
+		t.Errorf("expected Package.swift to be uploaded to %s, uploaded paths: %v", manifestPath, uploadedFiles)
+	}
+}
+
 func Test_ExtractManifestFiles_RealZipFile_ExtractsFiles(t *testing.T) {
 	// Test with actual zip file from test data
 	// This zip has: test.TestLib/Package.swift, Package@swift-5.7.0.swift, Package@swift-5.swift
@@ -1068,3 +1233,113 @@ func Test_ExtractManifestFiles_UnsupportedMimeType_ReturnsError(t *testing.T) {
 		t.Errorf("expected error for unsupported mime type, got nil")
 	}
 }
+
+func Test_CheckHealth_IndexStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		authMode string
+		status   int
+		healthy  bool
+	}{
+		{"index readable", "", http.StatusOK, true},
+		{"nothing published yet", "", http.StatusNotFound, true},
+		{"invalid credentials", "config", http.StatusUnauthorized, false},
+		{"passthrough without credentials", "passthrough", http.StatusUnauthorized, true},
+		{"server error", "", http.StatusInternalServerError, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if !strings.HasSuffix(r.URL.Path, spmRegistryIndexPath) {
+					t.Errorf("unexpected request %s", r.URL.Path)
+				}
+				w.WriteHeader(test.status)
+				_, _ = w.Write([]byte(`{"packages":{}}`))
+			}))
+			defer server.Close()
+			repo, err := NewMavenRepo(config.MavenConfig{BaseURL: server.URL, AuthMode: test.authMode})
+			if err != nil {
+				t.Fatalf("failed to create repo: %v", err)
+			}
+
+			err = repo.CheckHealth(context.Background())
+
+			if (err == nil) != test.healthy {
+				t.Errorf("expected healthy=%v, got error %v", test.healthy, err)
+			}
+		})
+	}
+}
+
+func Test_CheckHealth_BackendDown_ReturnsError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+	repo, err := NewMavenRepo(config.MavenConfig{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("failed to create repo: %v", err)
+	}
+
+	if err := repo.CheckHealth(context.Background()); err == nil {
+		t.Errorf("expected unreachable backend to be unhealthy")
+	}
+}
+
+func Test_StoredChecksum_ReadsSidecar(t *testing.T) {
+	checksum := strings.Repeat("ab", 32)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, ".zip.sha256") {
+			_, _ = w.Write([]byte(strings.ToUpper(checksum) + "  test-package-1.0.0.zip\n"))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	repo, _ := NewMavenRepo(config.MavenConfig{BaseURL: server.URL})
+
+	stored, err := repo.StoredChecksum(context.Background(), models.NewUploadElement("com.example", "test-package", "1.0.0", mimetypes.ApplicationZip, models.SourceArchive))
+	if err != nil || stored != checksum {
+		t.Errorf("expected checksum %s, got %q (%v)", checksum, stored, err)
+	}
+
+	stored, err = repo.StoredChecksum(context.Background(), models.NewUploadElement("com.example", "test-package", "1.0.0", mimetypes.ApplicationJson, models.Metadata))
+	if err != nil || stored != "" {
+		t.Errorf("expected no checksum without sidecar, got %q (%v)", stored, err)
+	}
+}
+
+func Test_RebuildPackageIndex_ReplacesIndex(t *testing.T) {
+	var mu sync.Mutex
+	index := []byte(`{"packages":{"com.example":["stale","test-package"]}}`)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, spmRegistryIndexPath) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodGet:
+			_, _ = w.Write(index)
+		case http.MethodPut:
+			index, _ = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	defer server.Close()
+
+	repo, _ := NewMavenRepo(config.MavenConfig{BaseURL: server.URL})
+
+	packages, err := repo.IndexedPackages(context.Background())
+	if err != nil || len(packages["com.example"]) != 2 {
+		t.Fatalf("expected indexed packages, got %v (%v)", packages, err)
+	}
+	if err := repo.RebuildPackageIndex(context.Background(), map[string][]string{"com.example": {"test-package"}, "empty": nil}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	packages, err = repo.IndexedPackages(context.Background())
+	if err != nil || len(packages) != 1 || len(packages["com.example"]) != 1 || packages["com.example"][0] != "test-package" {
+		t.Errorf("expected rebuilt index, got %v (%v)", packages, err)
+	}
+}
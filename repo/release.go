@@ -0,0 +1,158 @@
+package repo
+
+import (
+	"OpenSPMRegistry/mimetypes"
+	"OpenSPMRegistry/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// IsReleaseDeleted checks whether a tombstone exists for the release
+func IsReleaseDeleted(ctx context.Context, r Repo, scope string, name string, version string) bool {
+	tombstone := models.NewUploadElement(scope, name, version, mimetypes.ApplicationJson, models.Tombstone)
+	return r.Exists(ctx, tombstone)
+}
+
+// WriteTombstone stores the tombstone of the release the source archive belongs to,
+// so the version can never be published again
+func WriteTombstone(ctx context.Context, r Repo, sourceArchive *models.UploadElement, checksum string, deletedAt time.Time) error {
+	tombstone := models.NewUploadElement(sourceArchive.Scope, sourceArchive.Name, sourceArchive.Version, mimetypes.ApplicationJson, models.Tombstone)
+	return writeJson(ctx, r, tombstone, models.ReleaseTombstone{
+		DeletedAt: deletedAt.UTC(),
+		Checksum:  checksum,
+	})
+}
+
+// WritePublication stores the publication record of the release the source archive belongs to
+func WritePublication(ctx context.Context, r Repo, sourceArchive *models.UploadElement, publication models.ReleasePublication) error {
+	publication.PublishedAt = publication.PublishedAt.UTC()
+	element := models.NewUploadElement(sourceArchive.Scope, sourceArchive.Name, sourceArchive.Version, mimetypes.ApplicationJson, models.Publication)
+	return writeJson(ctx, r, element, publication)
+}
+
+// ReadPublication reads the publication record of a release
+func ReadPublication(ctx context.Context, r Repo, scope string, name string, version string) (*models.ReleasePublication, error) {
+	reader, err := r.GetReader(ctx, models.NewUploadElement(scope, name, version, mimetypes.ApplicationJson, models.Publication))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = reader.Close() }()
+
+	var publication models.ReleasePublication
+	if err := json.NewDecoder(reader).Decode(&publication); err != nil {
+		return nil, fmt.Errorf("reading publication record: %w", err)
+	}
+	return &publication, nil
+}
+
+// PublishDate returns the publish date of the release element belongs to from its publication record.
+// Releases published before records were kept get one on first access, from the date stored returns
+// for their source archive (e.g. the file modification time) and its checksum.
+// Backends implement Repo.PublishDate with it, stored being the date they keep with their files.
+func PublishDate(ctx context.Context, r Repo, element *models.UploadElement, stored func(context.Context, *models.UploadElement) (time.Time, error)) (time.Time, error) {
+	record := models.NewUploadElement(element.Scope, element.Name, element.Version, mimetypes.ApplicationJson, models.Publication)
+	if r.Exists(ctx, record) {
+		publication, err := ReadPublication(ctx, r, element.Scope, element.Name, element.Version)
+		if err == nil {
+			return publication.PublishedAt, nil
+		}
+		slog.Warn("Publication record not readable:", "file", record.FileName(), "error", err)
+		return stored(ctx, element)
+	}
+
+	archive := models.NewUploadElement(element.Scope, element.Name, element.Version, mimetypes.ApplicationZip, models.SourceArchive)
+	if !r.Exists(ctx, archive) {
+		return stored(ctx, element)
+	}
+	published, err := stored(ctx, archive)
+	if err != nil {
+		return published, err
+	}
+	checksum, err := r.Checksum(ctx, archive)
+	if err != nil {
+		slog.Warn("Checksum of release not available for its publication record:", "error", err)
+	}
+	publication := models.ReleasePublication{PublishedAt: published, Checksum: checksum}
+	if err := WritePublication(ctx, r, archive, publication); err != nil {
+		slog.Warn("Publication record of release not stored:", "scope", element.Scope, "package", element.Name, "version", element.Version, "error", err)
+	}
+	return published, nil
+}
+
+// RemoveRelease removes every file of a release (archive, signatures, metadata, manifests, Package.json and publication record)
+// except its tombstone. All files are attempted, the errors of those failing are joined.
+func RemoveRelease(ctx context.Context, r Repo, scope string, name string, version string) error {
+	elements := []*models.UploadElement{
+		models.NewUploadElement(scope, name, version, mimetypes.ApplicationZip, models.SourceArchive),
+		models.NewUploadElement(scope, name, version, mimetypes.ApplicationZip, models.SourceArchiveSignature),
+		models.NewUploadElement(scope, name, version, mimetypes.ApplicationJson, models.Metadata),
+		models.NewUploadElement(scope, name, version, mimetypes.ApplicationJson, models.MetadataSignature),
+		models.NewUploadElement(scope, name, version, mimetypes.ApplicationJson, models.PackageManifestJson),
+		models.NewUploadElement(scope, name, version, mimetypes.ApplicationJson, models.Publication),
+	}
+
+	manifest := models.NewUploadElement(scope, name, version, mimetypes.TextXSwift, models.Manifest)
+	alternatives, err := r.GetAlternativeManifests(ctx, manifest)
+	if err != nil {
+		slog.Warn("Alternative manifests not found:", "error", err)
+	}
+	for i := range alternatives {
+		elements = append(elements, &alternatives[i])
+	}
+	elements = append(elements, manifest)
+
+	var errs []error
+	for _, element := range elements {
+		if !r.Exists(ctx, element) {
+			continue
+		}
+		if err := r.Remove(ctx, element); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", element.FileName(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ReleaseManifests returns the manifests stored for a release: Package.swift,
+// its swift version variants (Package@swift-*.swift) and Package.json
+func ReleaseManifests(ctx context.Context, r Repo, scope string, name string, version string) []*models.UploadElement {
+	var manifests []*models.UploadElement
+	manifest := models.NewUploadElement(scope, name, version, mimetypes.TextXSwift, models.Manifest)
+	if r.Exists(ctx, manifest) {
+		manifests = append(manifests, manifest)
+	}
+	alternatives, _ := r.GetAlternativeManifests(ctx, manifest)
+	for i := range alternatives {
+		// some backends list the variants of other versions as well
+		if alternatives[i].Version == version && r.Exists(ctx, &alternatives[i]) {
+			manifests = append(manifests, &alternatives[i])
+		}
+	}
+	packageJson := models.NewUploadElement(scope, name, version, mimetypes.ApplicationJson, models.PackageManifestJson)
+	if r.Exists(ctx, packageJson) {
+		manifests = append(manifests, packageJson)
+	}
+	return manifests
+}
+
+// writeJson stores value JSON encoded as element
+func writeJson(ctx context.Context, r Repo, element *models.UploadElement, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	writer, err := r.GetWriter(ctx, element)
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(data); err != nil {
+		_ = writer.Close()
+		return err
+	}
+	return writer.Close()
+}
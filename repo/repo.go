@@ -97,4 +97,60 @@ type (
 		// returns (json data as map|nil if not exists, error)
 		LoadPackageJson(ctx context.Context, scope string, name string, version string) (map[string]any, error)
 	}
+
+	// Indexer is implemented by repositories keeping an index of their releases.
+	// The controller updates it once a release is completely published or deleted.
+	Indexer interface {
+		// IndexRelease records the published release
+		IndexRelease(ctx context.Context, scope string, name string, version string) error
+
+		// UnindexRelease records the release as deleted
+		UnindexRelease(ctx context.Context, scope string, name string, version string) error
+	}
+
+	// ChecksumSidecars is implemented by repositories storing the checksum of a file next to it
+	// (e.g. Maven .sha256 files), which can get out of sync with the file
+	ChecksumSidecars interface {
+		// StoredChecksum returns the sha256 checksum stored next to the element
+		// returns (checksum string|empty string if there is none, error)
+		StoredChecksum(ctx context.Context, element *models.UploadElement) (string, error)
+	}
+
+	// PackageIndex is implemented by repositories listing their packages in an index of their own
+	// (e.g. the Maven SPM registry index), as they cannot enumerate them otherwise
+	PackageIndex interface {
+		// IndexedPackages returns the package names listed in the index per scope
+		IndexedPackages(ctx context.Context) (map[string][]string, error)
+
+		// RebuildPackageIndex replaces the index by the package names given per scope
+		RebuildPackageIndex(ctx context.Context, packages map[string][]string) error
+	}
+
+	// RepositoryURLIndex is implemented by repositories answering lookups from a repository URL mapping of their own
+	// (e.g. the Maven backend), updated as metadata is stored. Releases stored before it was kept are missing.
+	RepositoryURLIndex interface {
+		// IndexedRepositoryURLs returns the mapping: repository URL -> package identifier (scope.name) -> versions
+		IndexedRepositoryURLs(ctx context.Context) (map[string]map[string][]string, error)
+
+		// RebuildRepositoryURLIndex replaces the mapping
+		RebuildRepositoryURLIndex(ctx context.Context, urls map[string]map[string][]string) error
+	}
+
+	// Wrapper is implemented by repositories serving the files of another one (e.g. the metadata index).
+	// Tools checking or copying the stored files work on the backend, as the wrapper may not know all of them.
+	Wrapper interface {
+		// Backend returns the wrapped repository
+		Backend() Repo
+	}
 )
+
+// Backend returns the repository storing the files of r, unwrapping any Wrapper
+func Backend(r Repo) Repo {
+	for {
+		wrapper, ok := r.(Wrapper)
+		if !ok {
+			return r
+		}
+		r = wrapper.Backend()
+	}
+}
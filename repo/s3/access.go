@@ -0,0 +1,204 @@
+package s3
+
+import (
+	"OpenSPMRegistry/models"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log/slog"
+	"path"
+	"strings"
+)
+
+type access struct {
+	client   *client
+	prefix   string
+	partSize int64
+}
+
+// s3Writer implements io.WriteCloser. Data is buffered up to one part; objects smaller than
+// a part are uploaded with a single PUT on Close(), larger ones via multipart upload so memory
+// stays bounded by the part size regardless of the object size.
+type s3Writer struct {
+	client      *client
+	ctx         context.Context
+	key         string
+	contentType string
+	partSize    int64
+	buffer      []byte
+	hash        hash.Hash
+	uploadId    string
+	parts       []completedPart
+	failed      bool
+	closed      bool
+}
+
+const (
+	// minPartSize is the S3 minimum size for all parts but the last one
+	minPartSize = 5 * 1024 * 1024
+	// defaultPartSize is used when no part size is configured
+	defaultPartSize = 8 * 1024 * 1024
+	// checksumSuffix is appended to object keys to store the SHA-256 checksum sidecar
+	checksumSuffix = ".sha256"
+)
+
+// newAccess creates a new S3 access implementation.
+// partSizeMiB <= 0 selects the default part size; values below the S3 minimum are raised to 5 MiB.
+func newAccess(client *client, prefix string, partSizeMiB int64) *access {
+	partSize := int64(defaultPartSize)
+	if partSizeMiB > 0 {
+		partSize = max(partSizeMiB*1024*1024, minPartSize)
+	}
+	return &access{
+		client:   client,
+		prefix:   normalizePrefix(prefix),
+		partSize: partSize,
+	}
+}
+
+// normalizePrefix strips leading slashes and makes sure a non-empty prefix ends with exactly one slash
+func normalizePrefix(prefix string) string {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return ""
+	}
+	return prefix + "/"
+}
+
+// keyForElement builds the object key for an element: {prefix}{scope}/{name}/{version}/{filename}
+// (same layout as the file repository)
+func (a *access) keyForElement(element *models.UploadElement) string {
+	return a.prefix + path.Join(element.Scope, element.Name, element.Version, element.FileName())
+}
+
+// Exists checks whether the element exists in the bucket
+func (a *access) Exists(ctx context.Context, element *models.UploadElement) bool {
+	_, err := a.client.HEAD(ctx, a.keyForElement(element))
+	if err != nil && !isNotFound(err) && slog.Default().Enabled(ctx, slog.LevelDebug) {
+		slog.Debug("S3 HEAD failed", "key", a.keyForElement(element), "error", err)
+	}
+	return err == nil
+}
+
+// GetReader returns a seekable reader backed by ranged GET requests
+func (a *access) GetReader(ctx context.Context, element *models.UploadElement) (io.ReadSeekCloser, error) {
+	reader, err := newObjectReader(ctx, a.client, a.keyForElement(element))
+	if err != nil {
+		if isNotFound(err) {
+			return nil, fmt.Errorf("file not exists: %s", element.FileName())
+		}
+		return nil, err
+	}
+	return reader, nil
+}
+
+// GetWriter returns a writer that uploads the element on Close()
+func (a *access) GetWriter(ctx context.Context, element *models.UploadElement) (io.WriteCloser, error) {
+	return &s3Writer{
+		client:      a.client,
+		ctx:         ctx,
+		key:         a.keyForElement(element),
+		contentType: element.MimeType,
+		partSize:    a.partSize,
+		buffer:      make([]byte, 0, min(a.partSize, 64*1024)),
+		hash:        sha256.New(),
+	}, nil
+}
+
+func (w *s3Writer) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, io.ErrClosedPipe
+	}
+	if w.failed {
+		return 0, errors.New("s3 upload already failed")
+	}
+
+	written := 0
+	for len(p) > 0 {
+		space := int(w.partSize) - len(w.buffer)
+		chunk := min(space, len(p))
+		w.buffer = append(w.buffer, p[:chunk]...)
+		w.hash.Write(p[:chunk])
+		p = p[chunk:]
+		written += chunk
+
+		if int64(len(w.buffer)) >= w.partSize {
+			if err := w.flushPart(); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+// flushPart uploads the buffered data as the next part, starting the multipart upload if needed
+func (w *s3Writer) flushPart() error {
+	if w.uploadId == "" {
+		uploadId, err := w.client.createMultipartUpload(w.ctx, w.key, w.contentType)
+		if err != nil {
+			w.failed = true
+			return err
+		}
+		w.uploadId = uploadId
+	}
+
+	partNumber := len(w.parts) + 1
+	etag, err := w.client.uploadPart(w.ctx, w.key, w.uploadId, partNumber, w.buffer)
+	if err != nil {
+		w.abort()
+		return err
+	}
+	w.parts = append(w.parts, completedPart{PartNumber: partNumber, ETag: etag})
+	w.buffer = w.buffer[:0]
+	return nil
+}
+
+// abort cancels a started multipart upload so no orphaned parts are kept (and billed)
+func (w *s3Writer) abort() {
+	w.failed = true
+	if w.uploadId == "" {
+		return
+	}
+	if err := w.client.abortMultipartUpload(w.ctx, w.key, w.uploadId); err != nil {
+		slog.Warn("Failed to abort S3 multipart upload", "key", w.key, "error", err)
+	}
+}
+
+// Close uploads the remaining data and writes the .sha256 checksum sidecar
+func (w *s3Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if w.failed {
+		return errors.New("s3 upload failed")
+	}
+
+	if w.uploadId == "" {
+		if err := w.client.PUT(w.ctx, w.key, w.buffer, w.contentType); err != nil {
+			return err
+		}
+	} else {
+		if len(w.buffer) > 0 {
+			if err := w.flushPart(); err != nil {
+				return err
+			}
+		}
+		if err := w.client.completeMultipartUpload(w.ctx, w.key, w.uploadId, w.parts); err != nil {
+			w.abort()
+			return err
+		}
+	}
+	w.buffer = nil
+
+	checksum := hex.EncodeToString(w.hash.Sum(nil))
+	if err := w.client.PUT(w.ctx, w.key+checksumSuffix, []byte(checksum), "text/plain"); err != nil {
+		// Log warning but don't fail the upload, checksum is recalculated on demand
+		slog.Warn("Failed to upload .sha256 checksum object", "key", w.key+checksumSuffix, "error", err)
+	}
+	return nil
+}
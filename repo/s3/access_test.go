@@ -0,0 +1,155 @@
+package s3
+
+import (
+	"OpenSPMRegistry/mimetypes"
+	"OpenSPMRegistry/models"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func newTestAccess(t *testing.T, partSizeMiB int64) (*fakeS3, *access) {
+	t.Helper()
+	fake, server := newFakeS3(t)
+	c, err := newClient(testConfig(server))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	return fake, newAccess(c, "spm", partSizeMiB)
+}
+
+func Test_newAccess_PartSize_DefaultAndMinimum(t *testing.T) {
+	if a := newAccess(nil, "", 0); a.partSize != defaultPartSize {
+		t.Errorf("expected default part size, got %d", a.partSize)
+	}
+	if a := newAccess(nil, "", 1); a.partSize != minPartSize {
+		t.Errorf("expected minimum part size, got %d", a.partSize)
+	}
+	if a := newAccess(nil, "/spm/", 16); a.partSize != 16*1024*1024 || a.prefix != "spm/" {
+		t.Errorf("unexpected access %d %q", a.partSize, a.prefix)
+	}
+}
+
+func Test_keyForElement_UsesFileRepoLayout(t *testing.T) {
+	a := newAccess(nil, "spm", 0)
+	element := models.NewUploadElement("scope", "name", "1.0.0", mimetypes.ApplicationZip, models.SourceArchive)
+
+	if got := a.keyForElement(element); got != "spm/scope/name/1.0.0/scope.name-1.0.0.zip" {
+		t.Errorf("unexpected key %s", got)
+	}
+}
+
+func Test_GetWriter_SmallObject_SinglePutWithChecksum(t *testing.T) {
+	fake, a := newTestAccess(t, 0)
+	element := models.NewUploadElement("scope", "name", "1.0.0", mimetypes.ApplicationZip, models.SourceArchive)
+
+	writer, _ := a.GetWriter(context.Background(), element)
+	if _, err := writer.Write([]byte("archive")); err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("unexpected close error: %v", err)
+	}
+
+	key := "spm/scope/name/1.0.0/scope.name-1.0.0.zip"
+	if data, _ := fake.get(key); string(data) != "archive" {
+		t.Errorf("expected stored archive, got %q", data)
+	}
+	expected := fmt.Sprintf("%x", sha256.Sum256([]byte("archive")))
+	if data, _ := fake.get(key + ".sha256"); string(data) != expected {
+		t.Errorf("expected checksum %s, got %s", expected, data)
+	}
+	if fake.requestCount("uploads") != 0 {
+		t.Errorf("expected no multipart upload for small object")
+	}
+}
+
+func Test_GetWriter_LargeObject_UsesMultipartUpload(t *testing.T) {
+	fake, a := newTestAccess(t, 5)
+	element := models.NewUploadElement("scope", "name", "1.0.0", mimetypes.ApplicationZip, models.SourceArchive)
+	content := bytes.Repeat([]byte("0123456789abcdef"), (minPartSize*2+1024)/16)
+
+	writer, _ := a.GetWriter(context.Background(), element)
+	// write in odd sized chunks to cross part boundaries
+	for chunk := range slices(content, 1000003) {
+		if _, err := writer.Write(chunk); err != nil {
+			t.Fatalf("unexpected write error: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("unexpected close error: %v", err)
+	}
+
+	data, _ := fake.get("spm/scope/name/1.0.0/scope.name-1.0.0.zip")
+	if !bytes.Equal(data, content) {
+		t.Errorf("stored object differs from written content (%d vs %d bytes)", len(data), len(content))
+	}
+	if fake.requestCount("partNumber=") != 3 {
+		t.Errorf("expected 3 parts, got %d", fake.requestCount("partNumber="))
+	}
+}
+
+func Test_GetWriter_PartUploadFails_AbortsUpload(t *testing.T) {
+	fake, a := newTestAccess(t, 5)
+	fake.failOn = "partNumber=2"
+	element := models.NewUploadElement("scope", "name", "1.0.0", mimetypes.ApplicationZip, models.SourceArchive)
+	content := bytes.Repeat([]byte("x"), minPartSize*2)
+
+	writer, _ := a.GetWriter(context.Background(), element)
+	_, writeErr := writer.Write(content)
+	closeErr := writer.Close()
+
+	if writeErr == nil || closeErr == nil {
+		t.Errorf("expected write and close errors, got %v / %v", writeErr, closeErr)
+	}
+	if fake.requestCount("DELETE") != 1 {
+		t.Errorf("expected multipart upload to be aborted")
+	}
+	if _, ok := fake.get("spm/scope/name/1.0.0/scope.name-1.0.0.zip"); ok {
+		t.Errorf("expected no object to be stored")
+	}
+}
+
+func Test_Exists_And_GetReader(t *testing.T) {
+	fake, a := newTestAccess(t, 0)
+	fake.put("spm/scope/name/1.0.0/metadata.json", []byte("{}"))
+	metadata := models.NewUploadElement("scope", "name", "1.0.0", mimetypes.ApplicationJson, models.Metadata)
+	missing := models.NewUploadElement("scope", "name", "2.0.0", mimetypes.ApplicationJson, models.Metadata)
+
+	if !a.Exists(context.Background(), metadata) {
+		t.Errorf("expected metadata to exist")
+	}
+	if a.Exists(context.Background(), missing) {
+		t.Errorf("expected missing element to not exist")
+	}
+
+	reader, err := a.GetReader(context.Background(), metadata)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = reader.Close() }()
+	data, _ := io.ReadAll(reader)
+	if string(data) != "{}" {
+		t.Errorf("expected {}, got %s", data)
+	}
+
+	if _, err := a.GetReader(context.Background(), missing); err == nil {
+		t.Errorf("expected error for missing element")
+	}
+}
+
+// slices splits data into chunks of at most size bytes
+func slices(data []byte, size int) func(func([]byte) bool) {
+	return func(yield func([]byte) bool) {
+		for len(data) > 0 {
+			n := min(size, len(data))
+			if !yield(data[:n]) {
+				return
+			}
+			data = data[n:]
+		}
+	}
+}
@@ -0,0 +1,378 @@
+package s3
+
+import (
+	"OpenSPMRegistry/config"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// client handles HTTP operations against an S3-compatible API
+type client struct {
+	httpClient *http.Client
+	endpoint   *url.URL
+	bucket     string
+	pathStyle  bool
+	signer     *signer
+	now        func() time.Time
+}
+
+// HTTPStatusError carries the HTTP status code for failed requests.
+type HTTPStatusError struct {
+	StatusCode int
+	Code       string
+}
+
+// listBucketResult is the XML response of ListObjectsV2
+type listBucketResult struct {
+	XMLName               xml.Name       `xml:"ListBucketResult"`
+	Contents              []objectInfo   `xml:"Contents"`
+	CommonPrefixes        []commonPrefix `xml:"CommonPrefixes"`
+	IsTruncated           bool           `xml:"IsTruncated"`
+	NextContinuationToken string         `xml:"NextContinuationToken"`
+}
+
+type objectInfo struct {
+	Key  string `xml:"Key"`
+	Size int64  `xml:"Size"`
+}
+
+type commonPrefix struct {
+	Prefix string `xml:"Prefix"`
+}
+
+// initiateMultipartUploadResult is the XML response of CreateMultipartUpload
+type initiateMultipartUploadResult struct {
+	XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+	UploadId string   `xml:"UploadId"`
+}
+
+// completeMultipartUpload is the XML request body of CompleteMultipartUpload
+type completeMultipartUpload struct {
+	XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+	Parts   []completedPart `xml:"Part"`
+}
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+// errorResponse is the XML error body returned by S3
+type errorResponse struct {
+	XMLName xml.Name `xml:"Error"`
+	Code    string   `xml:"Code"`
+	Message string   `xml:"Message"`
+}
+
+// ErrHTTPStatus is returned when the server responds with status >= 400.
+// Callers can use errors.As to detect specific status codes (e.g. 404).
+var ErrHTTPStatus = errors.New("s3 request failed with error status")
+
+// newClient creates a new S3 HTTP client
+func newClient(cfg config.S3Config) (*client, error) {
+	if cfg.Endpoint == "" {
+		return nil, errors.New("s3 endpoint is required")
+	}
+	if cfg.Bucket == "" {
+		return nil, errors.New("s3 bucket is required")
+	}
+	endpoint, err := url.Parse(strings.TrimSuffix(cfg.Endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid s3 endpoint: %w", err)
+	}
+	if endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint: %s", cfg.Endpoint)
+	}
+
+	// Default timeout: 30 seconds (configurable via config.yml s3.timeout)
+	timeout := 30 * time.Second
+	if cfg.Timeout > 0 {
+		timeout = time.Duration(cfg.Timeout) * time.Second
+	}
+
+	region := cfg.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	return &client{
+		httpClient: &http.Client{Timeout: timeout},
+		endpoint:   endpoint,
+		bucket:     cfg.Bucket,
+		pathStyle:  cfg.PathStyle,
+		signer: &signer{
+			accessKeyId:     cfg.AccessKeyId,
+			secretAccessKey: cfg.SecretAccessKey,
+			sessionToken:    cfg.SessionToken,
+			region:          region,
+		},
+		now: time.Now,
+	}, nil
+}
+
+func (e *HTTPStatusError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("s3 request failed with status %d (%s)", e.StatusCode, e.Code)
+	}
+	return fmt.Sprintf("s3 request failed with status %d", e.StatusCode)
+}
+
+func (e *HTTPStatusError) Is(target error) bool {
+	return target == ErrHTTPStatus
+}
+
+// isNotFound reports whether err is an S3 404 response
+func isNotFound(err error) bool {
+	var statusErr *HTTPStatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound
+}
+
+// objectURL builds the URL for the given object key (empty key addresses the bucket)
+func (c *client) objectURL(key string, query url.Values) *url.URL {
+	u := *c.endpoint
+	basePath := strings.TrimSuffix(c.endpoint.Path, "/")
+	baseRawPath := strings.TrimSuffix(c.endpoint.EscapedPath(), "/")
+	if c.pathStyle {
+		u.Path = basePath + "/" + c.bucket + "/" + key
+		u.RawPath = baseRawPath + "/" + escapeKey(c.bucket) + "/" + escapeKey(key)
+	} else {
+		u.Host = c.bucket + "." + c.endpoint.Host
+		u.Path = basePath + "/" + key
+		u.RawPath = baseRawPath + "/" + escapeKey(key)
+	}
+	if query != nil {
+		u.RawQuery = query.Encode()
+	}
+	return &u
+}
+
+// escapeKey URI-encodes every segment of an object key, keeping slashes
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = uriEncode(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// do signs and executes a request. Responses with status >= 400 are converted into *HTTPStatusError.
+// Caller must close resp.Body.
+func (c *client) do(ctx context.Context, method, key string, query url.Values, body io.Reader, size int64, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.objectURL(key, query).String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.ContentLength = size
+	}
+	for name, values := range header {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+
+	payloadHash := unsignedPayload
+	if body == nil {
+		payloadHash = emptyPayloadHash
+	}
+	c.signer.sign(req, payloadHash, c.now())
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s request failed: %w", method, err)
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		statusErr := &HTTPStatusError{StatusCode: resp.StatusCode}
+		if method != http.MethodHead {
+			var errResp errorResponse
+			if data, readErr := io.ReadAll(io.LimitReader(resp.Body, 64*1024)); readErr == nil && xml.Unmarshal(data, &errResp) == nil {
+				statusErr.Code = errResp.Code
+			}
+		}
+		_ = resp.Body.Close()
+		return nil, statusErr
+	}
+
+	return resp, nil
+}
+
+// HEAD returns the response headers of the object (body already closed)
+func (c *client) HEAD(ctx context.Context, key string) (*http.Response, error) {
+	resp, err := c.do(ctx, http.MethodHead, key, nil, nil, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	_ = resp.Body.Close()
+	return resp, nil
+}
+
+// GET downloads the object. When start >= 0 only the range [start, end] is requested (end < 0 means until EOF).
+func (c *client) GET(ctx context.Context, key string, start, end int64) (*http.Response, error) {
+	header := http.Header{}
+	if start >= 0 {
+		if end < 0 {
+			header.Set("Range", fmt.Sprintf("bytes=%d-", start))
+		} else {
+			header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+		}
+	}
+	return c.do(ctx, http.MethodGet, key, nil, nil, 0, header)
+}
+
+// PUT uploads the object in a single request
+func (c *client) PUT(ctx context.Context, key string, data []byte, contentType string) error {
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	resp, err := c.do(ctx, http.MethodPut, key, nil, bytes.NewReader(data), int64(len(data)), header)
+	if err != nil {
+		return err
+	}
+	if slog.Default().Enabled(ctx, slog.LevelDebug) {
+		slog.Debug("S3 PUT successful", "key", key, "size", len(data))
+	}
+	return resp.Body.Close()
+}
+
+// DELETE removes the object. Missing objects are not treated as error (S3 semantics).
+func (c *client) DELETE(ctx context.Context, key string) error {
+	resp, err := c.do(ctx, http.MethodDelete, key, nil, nil, 0, nil)
+	if err != nil {
+		if isNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// createMultipartUpload starts a multipart upload and returns its upload id
+func (c *client) createMultipartUpload(ctx context.Context, key string, contentType string) (string, error) {
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	resp, err := c.do(ctx, http.MethodPost, key, url.Values{"uploads": {""}}, nil, 0, header)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var result initiateMultipartUploadResult
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode multipart upload response: %w", err)
+	}
+	if result.UploadId == "" {
+		return "", errors.New("multipart upload response without upload id")
+	}
+	return result.UploadId, nil
+}
+
+// uploadPart uploads a single part and returns its ETag
+func (c *client) uploadPart(ctx context.Context, key, uploadId string, partNumber int, data []byte) (string, error) {
+	query := url.Values{
+		"partNumber": {strconv.Itoa(partNumber)},
+		"uploadId":   {uploadId},
+	}
+	resp, err := c.do(ctx, http.MethodPut, key, query, bytes.NewReader(data), int64(len(data)), nil)
+	if err != nil {
+		return "", err
+	}
+	etag := resp.Header.Get("ETag")
+	if err := resp.Body.Close(); err != nil {
+		return "", err
+	}
+	if etag == "" {
+		return "", fmt.Errorf("part %d uploaded without ETag", partNumber)
+	}
+	return etag, nil
+}
+
+// completeMultipartUpload assembles the uploaded parts into the final object
+func (c *client) completeMultipartUpload(ctx context.Context, key, uploadId string, parts []completedPart) error {
+	body, err := xml.Marshal(completeMultipartUpload{Parts: parts})
+	if err != nil {
+		return fmt.Errorf("failed to encode multipart completion: %w", err)
+	}
+	header := http.Header{}
+	header.Set("Content-Type", "application/xml")
+	resp, err := c.do(ctx, http.MethodPost, key, url.Values{"uploadId": {uploadId}}, bytes.NewReader(body), int64(len(body)), header)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	// S3 may answer 200 with an error document when the completion fails late
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	var errResp errorResponse
+	if xml.Unmarshal(data, &errResp) == nil && errResp.Code != "" {
+		return &HTTPStatusError{StatusCode: http.StatusInternalServerError, Code: errResp.Code}
+	}
+	return nil
+}
+
+// abortMultipartUpload discards all uploaded parts
+func (c *client) abortMultipartUpload(ctx context.Context, key, uploadId string) error {
+	resp, err := c.do(ctx, http.MethodDelete, key, url.Values{"uploadId": {uploadId}}, nil, 0, nil)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// list returns all object keys and common prefixes below prefix (ListObjectsV2, following continuation tokens).
+// With a delimiter only the next hierarchy level is returned as common prefixes.
+func (c *client) list(ctx context.Context, prefix, delimiter string) ([]objectInfo, []string, error) {
+	var objects []objectInfo
+	var prefixes []string
+	continuationToken := ""
+
+	for {
+		query := url.Values{"list-type": {"2"}}
+		if prefix != "" {
+			query.Set("prefix", prefix)
+		}
+		if delimiter != "" {
+			query.Set("delimiter", delimiter)
+		}
+		if continuationToken != "" {
+			query.Set("continuation-token", continuationToken)
+		}
+
+		resp, err := c.do(ctx, http.MethodGet, "", query, nil, 0, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		var result listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to decode list response: %w", err)
+		}
+
+		objects = append(objects, result.Contents...)
+		for _, p := range result.CommonPrefixes {
+			prefixes = append(prefixes, p.Prefix)
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, prefixes, nil
+		}
+		continuationToken = result.NextContinuationToken
+	}
+}
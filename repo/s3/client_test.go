@@ -0,0 +1,136 @@
+package s3
+
+import (
+	"OpenSPMRegistry/config"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func Test_newClient_ValidConfig_ReturnsClient(t *testing.T) {
+	c, err := newClient(config.S3Config{Endpoint: "https://s3.example.com/", Bucket: "bucket", Timeout: 60})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.endpoint.String() != "https://s3.example.com" {
+		t.Errorf("expected endpoint without trailing slash, got %s", c.endpoint)
+	}
+	if c.httpClient.Timeout != 60*time.Second {
+		t.Errorf("expected timeout 60s, got %v", c.httpClient.Timeout)
+	}
+	if c.signer.region != "us-east-1" {
+		t.Errorf("expected default region us-east-1, got %s", c.signer.region)
+	}
+}
+
+func Test_newClient_MissingEndpointOrBucket_ReturnsError(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.S3Config
+	}{
+		{name: "missing endpoint", cfg: config.S3Config{Bucket: "bucket"}},
+		{name: "missing bucket", cfg: config.S3Config{Endpoint: "https://s3.example.com"}},
+		{name: "endpoint without scheme", cfg: config.S3Config{Endpoint: "s3.example.com", Bucket: "bucket"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newClient(tt.cfg); err == nil {
+				t.Errorf("expected error, got nil")
+			}
+		})
+	}
+}
+
+func Test_objectURL_PathStyleAndVirtualHosted(t *testing.T) {
+	c, _ := newClient(config.S3Config{Endpoint: "https://s3.example.com", Bucket: "bucket", PathStyle: true})
+	if got := c.objectURL("scope/Package@swift-5.8.swift", nil).String(); got != "https://s3.example.com/bucket/scope/Package%40swift-5.8.swift" {
+		t.Errorf("unexpected path-style URL %s", got)
+	}
+
+	c.pathStyle = false
+	if got := c.objectURL("scope/a.zip", nil).String(); got != "https://bucket.s3.example.com/scope/a.zip" {
+		t.Errorf("unexpected virtual-hosted URL %s", got)
+	}
+}
+
+func Test_GET_MissingObject_ReturnsNotFoundStatusError(t *testing.T) {
+	_, server := newFakeS3(t)
+	c, _ := newClient(testConfig(server))
+
+	_, err := c.GET(context.Background(), "missing", -1, -1)
+
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound || statusErr.Code != "NoSuchKey" {
+		t.Fatalf("expected 404 NoSuchKey, got %v", err)
+	}
+	if !errors.Is(err, ErrHTTPStatus) || !isNotFound(err) {
+		t.Errorf("expected error to match ErrHTTPStatus and not found")
+	}
+}
+
+func Test_GET_Range_ReturnsPartialContent(t *testing.T) {
+	fake, server := newFakeS3(t)
+	fake.put("a.txt", []byte("0123456789"))
+	c, _ := newClient(testConfig(server))
+
+	resp, err := c.GET(context.Background(), "a.txt", 2, 4)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, _ := io.ReadAll(resp.Body)
+
+	if string(data) != "234" {
+		t.Errorf("expected 234, got %s", data)
+	}
+}
+
+func Test_list_FollowsContinuationTokens(t *testing.T) {
+	fake, server := newFakeS3(t)
+	fake.maxKeys = 2
+	for _, key := range []string{"a/1", "a/2", "a/3", "b/1", "c"} {
+		fake.put(key, []byte("x"))
+	}
+	c, _ := newClient(testConfig(server))
+
+	objects, prefixes, err := c.list(context.Background(), "", "/")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(prefixes) != 2 || prefixes[0] != "a/" || prefixes[1] != "b/" {
+		t.Errorf("expected prefixes [a/ b/], got %v", prefixes)
+	}
+	if len(objects) != 1 || objects[0].Key != "c" {
+		t.Errorf("expected object c, got %v", objects)
+	}
+	if fake.requestCount("list-type=2") != 2 {
+		t.Errorf("expected 2 list requests, got %d", fake.requestCount("list-type=2"))
+	}
+}
+
+func Test_DELETE_MissingObject_ReturnsNil(t *testing.T) {
+	_, server := newFakeS3(t)
+	c, _ := newClient(testConfig(server))
+
+	if err := c.DELETE(context.Background(), "missing"); err != nil {
+		t.Errorf("expected nil, got %v", err)
+	}
+}
+
+func Test_do_WrongCredentials_ReturnsForbidden(t *testing.T) {
+	_, server := newFakeS3(t)
+	cfg := testConfig(server)
+	cfg.AccessKeyId = "other"
+	c, _ := newClient(cfg)
+
+	err := c.PUT(context.Background(), "a", []byte("x"), "")
+
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusForbidden {
+		t.Errorf("expected 403, got %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// objectReader implements io.ReadSeekCloser and io.ReaderAt on top of S3 ranged GET requests.
+// Sequential reads stream a single open-ended range; seeking or ReadAt only fetches the bytes needed,
+// so e.g. zip.NewReader can read the central directory without downloading the whole archive.
+type objectReader struct {
+	client *client
+	key    string
+	ctx    context.Context
+	size   int64
+	pos    int64
+	mu     sync.Mutex
+	body   io.ReadCloser
+	closed bool
+}
+
+// newObjectReader creates a reader for the object; the object size is determined via HEAD
+func newObjectReader(ctx context.Context, client *client, key string) (*objectReader, error) {
+	resp, err := client.HEAD(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if resp.ContentLength < 0 {
+		return nil, fmt.Errorf("content length not available for %s", key)
+	}
+	return &objectReader{
+		client: client,
+		key:    key,
+		ctx:    ctx,
+		size:   resp.ContentLength,
+	}, nil
+}
+
+// Size returns the object size in bytes
+func (r *objectReader) Size() int64 {
+	return r.size
+}
+
+// Read reads from the current position, opening a ranged GET on first use or after a seek
+func (r *objectReader) Read(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return 0, io.ErrClosedPipe
+	}
+	if r.pos >= r.size {
+		return 0, io.EOF
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	if r.body == nil {
+		resp, err := r.client.GET(r.ctx, r.key, r.pos, -1)
+		if err != nil {
+			return 0, err
+		}
+		r.body = resp.Body
+	}
+
+	n, err := r.body.Read(p)
+	r.pos += int64(n)
+	if err == io.EOF && r.pos < r.size {
+		// connection ended early, reopen at the current position on next read
+		_ = r.body.Close()
+		r.body = nil
+		err = nil
+	}
+	return n, err
+}
+
+// Seek moves the read position; the open body (if any) is dropped when the position changes
+func (r *objectReader) Seek(offset int64, whence int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return 0, io.ErrClosedPipe
+	}
+
+	var newPos int64
+	switch whence {
+	case io.SeekStart:
+		newPos = offset
+	case io.SeekCurrent:
+		newPos = r.pos + offset
+	case io.SeekEnd:
+		newPos = r.size + offset
+	default:
+		return 0, fmt.Errorf("invalid whence: %d", whence)
+	}
+	if newPos < 0 {
+		return 0, fmt.Errorf("negative position")
+	}
+
+	if newPos != r.pos && r.body != nil {
+		_ = r.body.Close()
+		r.body = nil
+	}
+	r.pos = newPos
+	return r.pos, nil
+}
+
+// ReadAt reads len(p) bytes starting at off with a dedicated range request.
+// It does not change the current read position.
+func (r *objectReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("negative offset")
+	}
+	if off >= r.size {
+		return 0, io.EOF
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	end := min(off+int64(len(p)), r.size) - 1
+	resp, err := r.client.GET(r.ctx, r.key, off, end)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	want := int(end - off + 1)
+	n, err := io.ReadFull(resp.Body, p[:want])
+	if err != nil {
+		return n, err
+	}
+	if want < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Close closes the open response body, if any
+func (r *objectReader) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	if r.body != nil {
+		return r.body.Close()
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package s3
+
+import (
+	"context"
+	"io"
+	"testing"
+)
+
+func newTestReader(t *testing.T, content string) (*fakeS3, *objectReader) {
+	t.Helper()
+	fake, server := newFakeS3(t)
+	fake.put("obj", []byte(content))
+	c, _ := newClient(testConfig(server))
+	reader, err := newObjectReader(context.Background(), c, "obj")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { _ = reader.Close() })
+	return fake, reader
+}
+
+func Test_objectReader_ReadAll_ReturnsContent(t *testing.T) {
+	_, reader := newTestReader(t, "hello world")
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != "hello world" || reader.Size() != 11 {
+		t.Errorf("unexpected content %q (size %d)", data, reader.Size())
+	}
+}
+
+func Test_objectReader_Seek_ReadsFromNewPosition(t *testing.T) {
+	_, reader := newTestReader(t, "hello world")
+
+	if pos, err := reader.Seek(-5, io.SeekEnd); err != nil || pos != 6 {
+		t.Fatalf("unexpected seek result %d %v", pos, err)
+	}
+	data, _ := io.ReadAll(reader)
+	if string(data) != "world" {
+		t.Errorf("expected world, got %q", data)
+	}
+
+	if _, err := reader.Seek(-1, io.SeekStart); err == nil {
+		t.Errorf("expected error for negative position")
+	}
+	if _, err := reader.Seek(0, 42); err == nil {
+		t.Errorf("expected error for invalid whence")
+	}
+}
+
+func Test_objectReader_ReadAt_RequestsOnlyRange(t *testing.T) {
+	fake, reader := newTestReader(t, "hello world")
+
+	buf := make([]byte, 3)
+	n, err := reader.ReadAt(buf, 4)
+	if err != nil || n != 3 || string(buf) != "o w" {
+		t.Fatalf("unexpected ReadAt result %d %q %v", n, buf, err)
+	}
+
+	buf = make([]byte, 10)
+	n, err = reader.ReadAt(buf, 8)
+	if err != io.EOF || n != 3 || string(buf[:n]) != "rld" {
+		t.Errorf("expected short read with EOF, got %d %q %v", n, buf[:n], err)
+	}
+	if fake.requestCount("GET obj") != 2 {
+		t.Errorf("expected 2 ranged GET requests, got %d", fake.requestCount("GET obj"))
+	}
+}
+
+func Test_objectReader_Closed_ReturnsError(t *testing.T) {
+	_, reader := newTestReader(t, "hello")
+	_ = reader.Close()
+
+	if _, err := reader.Read(make([]byte, 1)); err != io.ErrClosedPipe {
+		t.Errorf("expected ErrClosedPipe, got %v", err)
+	}
+	if err := reader.Close(); err != nil {
+		t.Errorf("expected second close to be nil, got %v", err)
+	}
+}
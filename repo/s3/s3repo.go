@@ -0,0 +1,396 @@
+package s3
+
+import (
+	"OpenSPMRegistry/config"
+	"OpenSPMRegistry/mimetypes"
+	"OpenSPMRegistry/models"
+	"OpenSPMRegistry/repo"
+	"OpenSPMRegistry/repo/files"
+	"OpenSPMRegistry/utils"
+	"archive/zip"
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// S3Repo implements the repo.Repo interface on top of an S3-compatible object storage.
+// Objects use the same layout as the file repository: {prefix}{scope}/{name}/{version}/{filename}
+type S3Repo struct {
+	repo.Access
+	access       *access
+	client       *client
+	timeProvider utils.TimeProvider
+	checksums    *repo.ChecksumCache
+}
+
+// NewS3Repo creates a new S3 repository instance
+func NewS3Repo(cfg config.S3Config) (*S3Repo, error) {
+	client, err := newClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 client: %w", err)
+	}
+
+	access := newAccess(client, cfg.Prefix, cfg.PartSize)
+
+	return &S3Repo{
+		Access:       access,
+		access:       access,
+		client:       client,
+		timeProvider: utils.NewRealTimeProvider(),
+		checksums:    repo.NewChecksumCache(repo.DefaultChecksumCacheSize),
+	}, nil
+}
+
+// ExtractManifestFiles extracts Package.swift and Package.json from the source archive.
+// The zip central directory and the manifest entries are read with ranged requests,
+// so the archive is not downloaded as a whole.
+func (s *S3Repo) ExtractManifestFiles(ctx context.Context, element *models.UploadElement) error {
+	mediaType, _, _ := mime.ParseMediaType(element.MimeType)
+	if mediaType != mimetypes.ApplicationZip {
+		return errors.New("unsupported mime type")
+	}
+
+	reader, err := newObjectReader(ctx, s.client, s.access.keyForElement(element))
+	if err != nil {
+		return fmt.Errorf("failed to get source archive: %w", err)
+	}
+	defer func() { _ = reader.Close() }()
+
+	zipReader, err := zip.NewReader(reader, reader.Size())
+	if err != nil {
+		return fmt.Errorf("failed to open zip: %w", err)
+	}
+
+	fileExtractor := func(name string, r io.ReadCloser) error {
+		ext := path.Ext(name)
+		base := strings.TrimSuffix(name, ext)
+
+		var manifestElement *models.UploadElement
+		if strings.HasPrefix(strings.ToLower(name), "package") && strings.ToLower(ext) == ".swift" {
+			manifestElement = models.NewUploadElement(element.Scope, element.Name, element.Version, mimetypes.TextXSwift, models.Manifest)
+			manifestElement.SetFilenameOverwrite(base)
+		} else if strings.ToLower(name) == "package.json" {
+			manifestElement = models.NewUploadElement(element.Scope, element.Name, element.Version, mimetypes.ApplicationJson, models.PackageManifestJson)
+		} else {
+			return nil
+		}
+
+		writer, err := s.GetWriter(ctx, manifestElement)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(writer, r); err != nil {
+			_ = writer.Close()
+			return err
+		}
+		return writer.Close()
+	}
+
+	return files.ExtractManifestFilesFromZipReader(element, zipReader, fileExtractor)
+}
+
+// List returns all versions of a package (one common prefix per version)
+func (s *S3Repo) List(ctx context.Context, scope string, name string) ([]models.ListElement, error) {
+	versions, err := s.listFolders(ctx, path.Join(scope, name))
+	if err != nil {
+		return nil, err
+	}
+
+	// Spec 4.1: return empty list so controller returns 404
+	elements := []models.ListElement{}
+	for _, version := range versions {
+		elements = append(elements, *models.NewListElement(scope, name, version))
+	}
+	return elements, nil
+}
+
+// listFolders returns the names of the direct "sub folders" below the given folder key
+func (s *S3Repo) listFolders(ctx context.Context, folder string) ([]string, error) {
+	prefix := s.access.prefix
+	if folder != "" {
+		prefix += folder + "/"
+	}
+	_, prefixes, err := s.client.list(ctx, prefix, "/")
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, p := range prefixes {
+		name := strings.TrimSuffix(strings.TrimPrefix(p, prefix), "/")
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// EncodeBase64 returns the base64 representation of the content
+func (s *S3Repo) EncodeBase64(ctx context.Context, element *models.UploadElement) (string, error) {
+	data, err := s.readAll(ctx, element)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// readAll downloads the complete element
+func (s *S3Repo) readAll(ctx context.Context, element *models.UploadElement) ([]byte, error) {
+	resp, err := s.client.GET(ctx, s.access.keyForElement(element), -1, -1)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, fmt.Errorf("file not exists: %s", element.FileName())
+		}
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	return io.ReadAll(resp.Body)
+}
+
+// PublishDate returns the publish date of the release from its publication record,
+// the Last-Modified date of releases published before records were kept
+func (s *S3Repo) PublishDate(ctx context.Context, element *models.UploadElement) (time.Time, error) {
+	return repo.PublishDate(ctx, s, element, s.lastModified)
+}
+
+// lastModified returns the Last-Modified date of the element
+func (s *S3Repo) lastModified(ctx context.Context, element *models.UploadElement) (time.Time, error) {
+	resp, err := s.client.HEAD(ctx, s.access.keyForElement(element))
+	if err != nil {
+		return s.timeProvider.Now(), err
+	}
+
+	lastModified := resp.Header.Get("Last-Modified")
+	if lastModified == "" {
+		return s.timeProvider.Now(), nil
+	}
+	t, err := http.ParseTime(lastModified)
+	if err != nil {
+		return s.timeProvider.Now(), err
+	}
+	return t, nil
+}
+
+// LoadMetadata loads the metadata.json of a release
+func (s *S3Repo) LoadMetadata(ctx context.Context, scope string, name string, version string) (map[string]any, error) {
+	element := models.NewUploadElement(scope, name, version, mimetypes.ApplicationJson, models.Metadata)
+	data, err := s.readAll(ctx, element)
+	if err != nil {
+		return nil, err
+	}
+
+	var metadata map[string]any
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse metadata: %w", err)
+	}
+	return metadata, nil
+}
+
+// Checksum returns the SHA-256 checksum of the element, for source archives the one recorded
+// on publication (see repo.ChecksumCache)
+func (s *S3Repo) Checksum(ctx context.Context, element *models.UploadElement) (string, error) {
+	return s.checksums.Checksum(ctx, s, element, s.computeChecksum)
+}
+
+// computeChecksum returns the SHA-256 checksum of the element.
+// The .sha256 sidecar written on upload is used when present, otherwise the object is hashed.
+func (s *S3Repo) computeChecksum(ctx context.Context, element *models.UploadElement) (string, error) {
+	key := s.access.keyForElement(element)
+	if resp, err := s.client.GET(ctx, key+checksumSuffix, -1, -1); err == nil {
+		data, readErr := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if checksum := strings.TrimSpace(string(data)); readErr == nil && len(checksum) == 64 {
+			return checksum, nil
+		}
+	}
+
+	resp, err := s.client.GET(ctx, key, -1, -1)
+	if err != nil {
+		if isNotFound(err) {
+			return "", fmt.Errorf("file not exists: %s", element.FileName())
+		}
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, resp.Body); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
+// GetAlternativeManifests returns the Package@swift-*.swift variants stored for the release
+func (s *S3Repo) GetAlternativeManifests(ctx context.Context, element *models.UploadElement) ([]models.UploadElement, error) {
+	folder := s.access.prefix + path.Join(element.Scope, element.Name, element.Version) + "/"
+	objects, _, err := s.client.list(ctx, folder, "/")
+	if err != nil {
+		return nil, err
+	}
+
+	var manifests []models.UploadElement
+	for _, object := range objects {
+		filename := strings.TrimPrefix(object.Key, folder)
+		if filename != "Package.swift" && strings.HasPrefix(filename, "Package") && path.Ext(filename) == ".swift" {
+			manifest := models.NewUploadElement(element.Scope, element.Name, element.Version, mimetypes.TextXSwift, models.Manifest)
+			manifest.SetFilenameOverwrite(strings.TrimSuffix(filename, path.Ext(filename)))
+			manifests = append(manifests, *manifest)
+		}
+	}
+	return manifests, nil
+}
+
+// GetSwiftToolVersion returns the swift-tools-version from the first line of the manifest
+func (s *S3Repo) GetSwiftToolVersion(ctx context.Context, manifest *models.UploadElement) (string, error) {
+	resp, err := s.client.GET(ctx, s.access.keyForElement(manifest), -1, -1)
+	if err != nil {
+		if isNotFound(err) {
+			return "", fmt.Errorf("file not exists: %s", manifest.FileName())
+		}
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	const swiftVersionPrefix = "// swift-tools-version:"
+	scanner := bufio.NewScanner(resp.Body)
+	if scanner.Scan() {
+		if after, ok := strings.CutPrefix(scanner.Text(), swiftVersionPrefix); ok {
+			return strings.TrimSpace(after), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", errors.New("swift-tools-version not found")
+}
+
+// Lookup returns the identifiers of packages whose metadata lists the given repository URL.
+// Every metadata.json in the bucket is inspected, first match wins.
+func (s *S3Repo) Lookup(ctx context.Context, url string) []string {
+	objects, _, err := s.client.list(ctx, s.access.prefix, "")
+	if err != nil {
+		slog.Error("Error listing objects:", "error", err)
+		return nil
+	}
+
+	var result []string
+	for _, object := range objects {
+		parts := strings.Split(strings.TrimPrefix(object.Key, s.access.prefix), "/")
+		if len(parts) != 4 || parts[3] != "metadata.json" {
+			continue
+		}
+		scope, packageName, version := parts[0], parts[1], parts[2]
+		metadata, err := s.LoadMetadata(ctx, scope, packageName, version)
+		if err != nil {
+			continue
+		}
+		if repositoryURLs, ok := metadata["repositoryURLs"].([]any); ok {
+			for _, repoURL := range repositoryURLs {
+				if repoURLStr, ok := repoURL.(string); ok && repoURLStr == url {
+					return append(result, fmt.Sprintf("%s.%s", scope, packageName))
+				}
+			}
+		}
+	}
+	return result
+}
+
+// GetWriter returns a writer uploading the element, a cached checksum of it is dropped
+func (s *S3Repo) GetWriter(ctx context.Context, element *models.UploadElement) (io.WriteCloser, error) {
+	s.checksums.Forget(element)
+	return s.Access.GetWriter(ctx, element)
+}
+
+// Remove deletes the element and its checksum sidecar
+func (s *S3Repo) Remove(ctx context.Context, element *models.UploadElement) error {
+	s.checksums.Forget(element)
+	if !s.Exists(ctx, element) {
+		return fmt.Errorf("file not exists: %s", element.FileName())
+	}
+	key := s.access.keyForElement(element)
+	if err := s.client.DELETE(ctx, key); err != nil {
+		return err
+	}
+	return s.client.DELETE(ctx, key+checksumSuffix)
+}
+
+// ListScopes returns all available scopes in the registry
+func (s *S3Repo) ListScopes(ctx context.Context) ([]string, error) {
+	return s.listFolders(ctx, "")
+}
+
+// ListInScope returns all packages in a specific scope
+func (s *S3Repo) ListInScope(ctx context.Context, scope string) ([]models.ListElement, error) {
+	packageNames, err := s.listFolders(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	var packages []models.ListElement
+	for _, packageName := range packageNames {
+		versions, err := s.List(ctx, scope, packageName)
+		if err == nil {
+			packages = append(packages, versions...)
+		}
+	}
+	return packages, nil
+}
+
+// ListAll returns all packages across all scopes
+func (s *S3Repo) ListAll(ctx context.Context) ([]models.ListElement, error) {
+	scopes, err := s.ListScopes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var allPackages []models.ListElement
+	for _, scope := range scopes {
+		packages, err := s.ListInScope(ctx, scope)
+		if err != nil {
+			slog.Warn("Error listing packages in scope", "scope", scope, "error", err)
+			continue
+		}
+		allPackages = append(allPackages, packages...)
+	}
+	return allPackages, nil
+}
+
+// LoadPackageJson loads the Package.json of a release
+func (s *S3Repo) LoadPackageJson(ctx context.Context, scope string, name string, version string) (map[string]any, error) {
+	element := models.NewUploadElement(scope, name, version, mimetypes.ApplicationJson, models.PackageManifestJson)
+	data, err := s.readAll(ctx, element)
+	if err != nil {
+		return nil, fmt.Errorf("package.json not found for %s.%s@%s", scope, name, version)
+	}
+
+	var packageJson map[string]any
+	if err := json.Unmarshal(data, &packageJson); err != nil {
+		return nil, fmt.Errorf("failed to parse Package.json: %w", err)
+	}
+	return packageJson, nil
+}
+
+// CheckHealth verifies the bucket can be listed with the configured credentials
+func (s *S3Repo) CheckHealth(ctx context.Context) error {
+	query := url.Values{"list-type": {"2"}, "max-keys": {"1"}}
+	if s.access.prefix != "" {
+		query.Set("prefix", s.access.prefix)
+	}
+	resp, err := s.client.do(ctx, http.MethodGet, "", query, nil, 0, nil)
+	if err != nil {
+		return fmt.Errorf("bucket not readable: %w", err)
+	}
+	return resp.Body.Close()
+}
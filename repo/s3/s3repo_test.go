@@ -0,0 +1,298 @@
+package s3
+
+import (
+	"OpenSPMRegistry/config"
+	"OpenSPMRegistry/mimetypes"
+	"OpenSPMRegistry/models"
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newTestRepo(t *testing.T) (*fakeS3, *S3Repo) {
+	t.Helper()
+	fake, server := newFakeS3(t)
+	cfg := testConfig(server)
+	cfg.Prefix = "spm"
+	r, err := NewS3Repo(cfg)
+	if err != nil {
+		t.Fatalf("failed to create repo: %v", err)
+	}
+	return fake, r
+}
+
+func zipArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	for name, content := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatalf("failed to create zip entry: %v", err)
+		}
+		_, _ = f.Write([]byte(content))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to close zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func Test_NewS3Repo_InvalidConfig_ReturnsError(t *testing.T) {
+	if _, err := NewS3Repo(config.S3Config{Endpoint: "https://s3.example.com"}); err == nil {
+		t.Errorf("expected error for missing bucket")
+	}
+}
+
+func Test_ExtractManifestFiles_StoresManifests(t *testing.T) {
+	fake, r := newTestRepo(t)
+	fake.put("spm/scope/name/1.0.0/scope.name-1.0.0.zip", zipArchive(t, map[string]string{
+		"scope.name/Package.swift":           "// swift-tools-version:5.9\n",
+		"scope.name/Package@swift-5.8.swift": "// swift-tools-version:5.8\n",
+		"scope.name/Package.json":            "{}",
+		"scope.name/Sources/main.swift":      "print()",
+	}))
+	element := models.NewUploadElement("scope", "name", "1.0.0", mimetypes.ApplicationZip, models.SourceArchive)
+
+	if err := r.ExtractManifestFiles(context.Background(), element); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, key := range []string{"Package.swift", "Package@swift-5.8.swift", "Package.json"} {
+		if _, ok := fake.get("spm/scope/name/1.0.0/" + key); !ok {
+			t.Errorf("expected %s to be stored", key)
+		}
+	}
+	if _, ok := fake.get("spm/scope/name/1.0.0/main.swift"); ok {
+		t.Errorf("expected sources to not be extracted")
+	}
+}
+
+func Test_ExtractManifestFiles_UnsupportedMimeType_ReturnsError(t *testing.T) {
+	_, r := newTestRepo(t)
+	element := models.NewUploadElement("scope", "name", "1.0.0", mimetypes.ApplicationJson, models.Metadata)
+
+	if err := r.ExtractManifestFiles(context.Background(), element); err == nil {
+		t.Errorf("expected error for unsupported mime type")
+	}
+}
+
+func Test_List_ListScopes_ListInScope_ListAll(t *testing.T) {
+	fake, r := newTestRepo(t)
+	for _, key := range []string{
+		"spm/a/one/1.0.0/metadata.json",
+		"spm/a/one/1.1.0/metadata.json",
+		"spm/a/two/2.0.0/metadata.json",
+		"spm/b/three/0.1.0/metadata.json",
+		"other/c/four/1.0.0/metadata.json",
+	} {
+		fake.put(key, []byte("{}"))
+	}
+	ctx := context.Background()
+
+	versions, _ := r.List(ctx, "a", "one")
+	if len(versions) != 2 || versions[0].Version != "1.0.0" || versions[1].Version != "1.1.0" {
+		t.Errorf("unexpected versions %v", versions)
+	}
+	if missing, err := r.List(ctx, "a", "missing"); err != nil || missing == nil || len(missing) != 0 {
+		t.Errorf("expected empty non-nil list, got %v %v", missing, err)
+	}
+
+	scopes, _ := r.ListScopes(ctx)
+	if !reflect.DeepEqual(scopes, []string{"a", "b"}) {
+		t.Errorf("expected scopes [a b], got %v", scopes)
+	}
+
+	inScope, _ := r.ListInScope(ctx, "a")
+	if len(inScope) != 3 {
+		t.Errorf("expected 3 releases in scope a, got %v", inScope)
+	}
+
+	all, _ := r.ListAll(ctx)
+	if len(all) != 4 {
+		t.Errorf("expected 4 releases, got %v", all)
+	}
+}
+
+func Test_Checksum_UsesSidecarOrHashesObject(t *testing.T) {
+	fake, r := newTestRepo(t)
+	element := models.NewUploadElement("scope", "name", "1.0.0", mimetypes.ApplicationZip, models.SourceArchive)
+	key := "spm/scope/name/1.0.0/scope.name-1.0.0.zip"
+	fake.put(key, []byte("archive"))
+	expected := fmt.Sprintf("%x", sha256.Sum256([]byte("archive")))
+
+	if checksum, err := r.Checksum(context.Background(), element); err != nil || checksum != expected {
+		t.Errorf("expected hashed checksum %s, got %s %v", expected, checksum, err)
+	}
+
+	sidecar := fmt.Sprintf("%x", sha256.Sum256([]byte("sidecar")))
+	fake.put(key+checksumSuffix, []byte(sidecar+"\n"))
+	// changed behind the back of the repository, which caches checksums of source archives
+	r.checksums.Forget(element)
+	if checksum, err := r.Checksum(context.Background(), element); err != nil || checksum != sidecar {
+		t.Errorf("expected sidecar checksum %s, got %s %v", sidecar, checksum, err)
+	}
+
+	missing := models.NewUploadElement("scope", "name", "2.0.0", mimetypes.ApplicationZip, models.SourceArchive)
+	if _, err := r.Checksum(context.Background(), missing); err == nil {
+		t.Errorf("expected error for missing element")
+	}
+}
+
+func Test_Checksum_SourceArchive_ServedFromRecordAndCache(t *testing.T) {
+	fake, r := newTestRepo(t)
+	element := models.NewUploadElement("scope", "name", "1.0.0", mimetypes.ApplicationZip, models.SourceArchive)
+	fake.put("spm/scope/name/1.0.0/scope.name-1.0.0.zip", []byte("archive"))
+	fake.put("spm/scope/name/1.0.0/publication.json", []byte(`{"publishedAt":"2024-03-01T10:00:00Z","checksum":"recorded"}`))
+
+	var recordRequests int
+	for i := 0; i < 2; i++ {
+		if checksum, err := r.Checksum(context.Background(), element); err != nil || checksum != "recorded" {
+			t.Errorf("expected recorded checksum, got %s %v", checksum, err)
+		}
+		if i == 0 {
+			recordRequests = fake.requestCount("publication.json")
+		}
+	}
+	if count := fake.requestCount("scope.name-1.0.0.zip"); count != 0 {
+		t.Errorf("expected archive not to be read, got %d requests", count)
+	}
+	if count := fake.requestCount("publication.json"); recordRequests == 0 || count != recordRequests {
+		t.Errorf("expected publication record to be read on the first lookup only, got %d and %d requests", recordRequests, count)
+	}
+}
+
+func Test_Remove_DeletesObjectAndSidecar(t *testing.T) {
+	fake, r := newTestRepo(t)
+	element := models.NewUploadElement("scope", "name", "1.0.0", mimetypes.ApplicationZip, models.SourceArchive)
+	key := "spm/scope/name/1.0.0/scope.name-1.0.0.zip"
+	fake.put(key, []byte("archive"))
+	fake.put(key+checksumSuffix, []byte("checksum"))
+
+	if err := r.Remove(context.Background(), element); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := fake.get(key); ok {
+		t.Errorf("expected object to be deleted")
+	}
+	if _, ok := fake.get(key + checksumSuffix); ok {
+		t.Errorf("expected sidecar to be deleted")
+	}
+	if err := r.Remove(context.Background(), element); err == nil {
+		t.Errorf("expected error removing missing element")
+	}
+}
+
+func Test_GetAlternativeManifests_And_GetSwiftToolVersion(t *testing.T) {
+	fake, r := newTestRepo(t)
+	fake.put("spm/scope/name/1.0.0/Package.swift", []byte("// swift-tools-version:5.9\n"))
+	fake.put("spm/scope/name/1.0.0/Package@swift-5.8.swift", []byte("// swift-tools-version:5.8\nimport PackageDescription\n"))
+	fake.put("spm/scope/name/1.0.0/metadata.json", []byte("{}"))
+	element := models.NewUploadElement("scope", "name", "1.0.0", mimetypes.TextXSwift, models.Manifest)
+
+	manifests, err := r.GetAlternativeManifests(context.Background(), element)
+	if err != nil || len(manifests) != 1 || manifests[0].FileName() != "Package@swift-5.8.swift" {
+		t.Fatalf("unexpected manifests %v %v", manifests, err)
+	}
+
+	version, err := r.GetSwiftToolVersion(context.Background(), &manifests[0])
+	if err != nil || version != "5.8" {
+		t.Errorf("expected 5.8, got %s %v", version, err)
+	}
+}
+
+func Test_Lookup_MatchesRepositoryURL(t *testing.T) {
+	fake, r := newTestRepo(t)
+	fake.put("spm/scope/name/1.0.0/metadata.json", []byte(`{"repositoryURLs":["https://github.com/scope/name"]}`))
+	fake.put("spm/scope/other/1.0.0/metadata.json", []byte(`{"repositoryURLs":["https://github.com/scope/other"]}`))
+	fake.put("spm/scope/broken/1.0.0/metadata.json", []byte(`not json`))
+
+	result := r.Lookup(context.Background(), "https://github.com/scope/name")
+
+	if !reflect.DeepEqual(result, []string{"scope.name"}) {
+		t.Errorf("expected [scope.name], got %v", result)
+	}
+	if result := r.Lookup(context.Background(), "https://example.com"); len(result) != 0 {
+		t.Errorf("expected no match, got %v", result)
+	}
+}
+
+func Test_PublishDate_ReturnsLastModified(t *testing.T) {
+	fake, r := newTestRepo(t)
+	fake.put("spm/scope/name/1.0.0/scope.name-1.0.0.zip", []byte("archive"))
+	element := models.NewUploadElement("scope", "name", "1.0.0", mimetypes.ApplicationZip, models.SourceArchive)
+
+	date, err := r.PublishDate(context.Background(), element)
+
+	if err != nil || !date.Equal(time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected publish date %v %v", date, err)
+	}
+}
+
+func Test_PublishDate_RecordsLastModified_ThenIgnoresIt(t *testing.T) {
+	fake, r := newTestRepo(t)
+	archive := []byte("archive")
+	fake.put("spm/scope/name/1.0.0/scope.name-1.0.0.zip", archive)
+	element := models.NewUploadElement("scope", "name", "1.0.0", mimetypes.ApplicationZip, models.SourceArchive)
+	published := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	if date, err := r.PublishDate(context.Background(), element); err != nil || !date.Equal(published) {
+		t.Fatalf("unexpected publish date %v %v", date, err)
+	}
+	data, ok := fake.get("spm/scope/name/1.0.0/publication.json")
+	expected := fmt.Sprintf(`{"publishedAt":"2024-03-01T10:00:00Z","checksum":"%x"}`, sha256.Sum256(archive))
+	if !ok || string(data) != expected {
+		t.Fatalf("expected publication record %s, got %s", expected, data)
+	}
+
+	// a restored object gets a new Last-Modified date, the record keeps the original one
+	fake.put("spm/scope/name/1.0.0/publication.json", []byte(`{"publishedAt":"2023-01-02T03:04:05Z"}`))
+	if date, err := r.PublishDate(context.Background(), element); err != nil || !date.Equal(time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("expected recorded publish date, got %v %v", date, err)
+	}
+}
+
+func Test_LoadPackageJson_And_LoadMetadata(t *testing.T) {
+	fake, r := newTestRepo(t)
+	fake.put("spm/scope/name/1.0.0/Package.json", []byte(`{"name":"name"}`))
+	fake.put("spm/scope/name/1.0.0/metadata.json", []byte(`{"description":"desc"}`))
+	ctx := context.Background()
+
+	if packageJson, err := r.LoadPackageJson(ctx, "scope", "name", "1.0.0"); err != nil || packageJson["name"] != "name" {
+		t.Errorf("unexpected Package.json %v %v", packageJson, err)
+	}
+	if _, err := r.LoadPackageJson(ctx, "scope", "name", "2.0.0"); err == nil {
+		t.Errorf("expected error for missing Package.json")
+	}
+	if metadata, err := r.LoadMetadata(ctx, "scope", "name", "1.0.0"); err != nil || metadata["description"] != "desc" {
+		t.Errorf("unexpected metadata %v %v", metadata, err)
+	}
+}
+
+func Test_EncodeBase64_ReturnsEncodedContent(t *testing.T) {
+	fake, r := newTestRepo(t)
+	fake.put("spm/scope/name/1.0.0/Package.swift", []byte("swift"))
+	element := models.NewUploadElement("scope", "name", "1.0.0", mimetypes.TextXSwift, models.Manifest)
+
+	if encoded, err := r.EncodeBase64(context.Background(), element); err != nil || encoded != "c3dpZnQ=" {
+		t.Errorf("unexpected encoding %s %v", encoded, err)
+	}
+}
+
+func Test_CheckHealth_ListsBucket(t *testing.T) {
+	fake, r := newTestRepo(t)
+
+	if err := r.CheckHealth(context.Background()); err != nil {
+		t.Errorf("expected bucket to be healthy, got %v", err)
+	}
+
+	fake.failOn = "list-type=2"
+	if err := r.CheckHealth(context.Background()); err == nil {
+		t.Errorf("expected failing bucket listing to be unhealthy")
+	}
+}
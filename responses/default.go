@@ -5,4 +5,6 @@ type Response struct {
 
 type Error struct {
 	Detail string `json:"detail"`
+	// Errors lists every problem found when validating a request
+	Errors []string `json:"errors,omitempty"`
 }
@@ -0,0 +1,71 @@
+package search
+
+import (
+	"OpenSPMRegistry/repo"
+	"context"
+	"strings"
+)
+
+// Document holds the searchable fields of a release
+type Document struct {
+	Scope        string            `json:"scope"`
+	Name         string            `json:"name"`
+	Version      string            `json:"version"`
+	Description  string            `json:"description,omitempty"`
+	Keywords     []string          `json:"keywords,omitempty"`
+	Products     []string          `json:"products,omitempty"`
+	Targets      []string          `json:"targets,omitempty"`
+	ToolsVersion string            `json:"toolsVersion,omitempty"`
+	Platforms    map[string]string `json:"platforms,omitempty"` // platform name (lowercase) -> minimum version
+}
+
+// NewDocument loads the searchable fields of a release from its Package.json, Package.swift and metadata.
+// Releases without Package.json are indexed by scope, name and metadata only.
+func NewDocument(ctx context.Context, r repo.Repo, scope string, name string, version string) *Document {
+	doc := &Document{Scope: scope, Name: name, Version: version}
+
+	if manifest, err := repo.ReleaseManifest(ctx, r, scope, name, version); err == nil {
+		doc.ToolsVersion = manifest.ToolsVersion
+		for _, product := range manifest.Products {
+			doc.Products = append(doc.Products, product.Name)
+		}
+		for _, target := range manifest.Targets {
+			doc.Targets = append(doc.Targets, target.Name)
+		}
+		for _, platform := range manifest.MinimumPlatformVersions {
+			if doc.Platforms == nil {
+				doc.Platforms = make(map[string]string)
+			}
+			doc.Platforms[strings.ToLower(platform.Name)] = platform.Version
+		}
+	}
+
+	if metadata, err := r.LoadMetadata(ctx, scope, name, version); err == nil {
+		if description, ok := metadata["description"].(string); ok {
+			doc.Description = description
+		}
+		if keywords, ok := metadata["keywords"].([]any); ok {
+			for _, keyword := range keywords {
+				if k, ok := keyword.(string); ok {
+					doc.Keywords = append(doc.Keywords, k)
+				}
+			}
+		}
+	}
+
+	return doc
+}
+
+// id identifies the release of the document
+func (d *Document) id() string {
+	return releaseId(d.Scope, d.Name, d.Version)
+}
+
+// packageId identifies the package of the document, case-insensitive like scopes and names
+func (d *Document) packageId() string {
+	return strings.ToLower(d.Scope + "." + d.Name)
+}
+
+func releaseId(scope string, name string, version string) string {
+	return strings.ToLower(scope+"."+name) + "@" + version
+}
@@ -0,0 +1,71 @@
+package search
+
+import (
+	"OpenSPMRegistry/mimetypes"
+	"OpenSPMRegistry/models"
+	"OpenSPMRegistry/repo/files"
+	"context"
+	"reflect"
+	"testing"
+)
+
+func writeElement(t *testing.T, r *files.FileRepo, element *models.UploadElement, content string) {
+	t.Helper()
+	w, err := r.GetWriter(context.Background(), element)
+	if err != nil {
+		t.Fatalf("failed to create %s: %v", element.FileName(), err)
+	}
+	_, _ = w.Write([]byte(content))
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to write %s: %v", element.FileName(), err)
+	}
+}
+
+func newTestRepo(t *testing.T) *files.FileRepo {
+	t.Helper()
+	r := files.NewFileRepo(t.TempDir())
+	writeElement(t, r, models.NewUploadElement("acme", "network", "1.0.0", mimetypes.ApplicationZip, models.SourceArchive), "zip")
+	writeElement(t, r, models.NewUploadElement("acme", "network", "1.0.0", mimetypes.ApplicationJson, models.PackageManifestJson),
+		`{"name":"Network","products":[{"name":"NetworkKit","targets":["Network"]}],
+		"targets":[{"name":"Network","type":"regular"},{"name":"NetworkTests","type":"test"}],
+		"platforms":[{"platformName":"ios","version":"15.0"}]}`)
+	writeElement(t, r, models.NewUploadElement("acme", "network", "1.0.0", mimetypes.TextXSwift, models.Manifest),
+		"// swift-tools-version:5.9\n")
+	writeElement(t, r, models.NewUploadElement("acme", "network", "1.0.0", mimetypes.ApplicationJson, models.Metadata),
+		`{"description":"HTTP networking","keywords":["http","rest"]}`)
+	return r
+}
+
+func Test_NewDocument_ReadsPackageJsonAndMetadata(t *testing.T) {
+	r := newTestRepo(t)
+
+	doc := NewDocument(context.Background(), r, "acme", "network", "1.0.0")
+
+	expected := &Document{
+		Scope:        "acme",
+		Name:         "network",
+		Version:      "1.0.0",
+		Description:  "HTTP networking",
+		Keywords:     []string{"http", "rest"},
+		Products:     []string{"NetworkKit"},
+		Targets:      []string{"Network"},
+		ToolsVersion: "5.9",
+		Platforms:    map[string]string{"ios": "15.0"},
+	}
+	if !reflect.DeepEqual(doc, expected) {
+		t.Errorf("expected %+v, got %+v", expected, doc)
+	}
+}
+
+func Test_Rebuild_IndexesAllReleases(t *testing.T) {
+	r := newTestRepo(t)
+	i := NewIndex()
+
+	if err := i.Rebuild(context.Background(), r); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result := i.Search(Query{Text: "rest"}); result.Total != 1 || result.Hits[0].Name != "network" {
+		t.Errorf("expected network to be found by keyword, got %+v", result.Hits)
+	}
+}
@@ -0,0 +1,281 @@
+package search
+
+import (
+	"OpenSPMRegistry/models"
+	"OpenSPMRegistry/repo"
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Index is an in-memory search index of all releases, built once from the repository
+// and kept up to date on publish and delete
+type Index struct {
+	mu   sync.RWMutex
+	docs map[string]*Document // release id -> document
+	// removed tracks releases removed while a rebuild is running, so the rebuild does not add them again
+	removed    map[string]struct{}
+	rebuilding bool
+}
+
+// Query describes a search, empty fields are not filtered on
+type Query struct {
+	// Text is matched against name, scope, description, keywords, product and target names.
+	// All terms (separated by whitespace) must match.
+	Text  string
+	Scope string
+	// Platform and PlatformVersion only match releases that support the platform at this version,
+	// i.e. do not declare a higher minimum deployment target
+	Platform        string
+	PlatformVersion string
+	// ToolsVersion only matches releases whose Package.swift requires at most this Swift tools version
+	ToolsVersion string
+	// Allowed filters the scopes the searching user may read, nil allows all
+	Allowed func(scope string) bool
+	Page    int // 1-based
+	PerPage int
+}
+
+// Hit is a matching package represented by its newest release satisfying the filters
+type Hit struct {
+	Document
+	Score float64 `json:"score"`
+}
+
+// Result is a page of hits ordered by score
+type Result struct {
+	Total int
+	Hits  []Hit
+}
+
+// weights of a query term matching a field exactly or partially
+const (
+	weightNameExact        = 10
+	weightNamePrefix       = 6
+	weightNamePartial      = 4
+	weightScopeExact       = 5
+	weightScopePartial     = 2
+	weightComponentExact   = 4
+	weightComponentPartial = 2
+	weightKeywordExact     = 3
+	weightKeywordPartial   = 1.5
+	weightDescription      = 1
+)
+
+// NewIndex creates an empty index
+func NewIndex() *Index {
+	return &Index{docs: make(map[string]*Document)}
+}
+
+// Rebuild adds all releases of the repository to the index
+func (i *Index) Rebuild(ctx context.Context, r repo.Repo) error {
+	start := time.Now()
+	i.mu.Lock()
+	i.removed = make(map[string]struct{})
+	i.rebuilding = true
+	i.mu.Unlock()
+	defer func() {
+		i.mu.Lock()
+		i.removed = nil
+		i.rebuilding = false
+		i.mu.Unlock()
+	}()
+
+	elements, err := r.ListAll(ctx)
+	if err != nil {
+		return err
+	}
+	docs := make([]*Document, 0, len(elements))
+	for _, element := range elements {
+		docs = append(docs, NewDocument(ctx, r, element.Scope, element.PackageName, element.Version))
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, doc := range docs {
+		if _, removed := i.removed[doc.id()]; !removed {
+			i.docs[doc.id()] = doc
+		}
+	}
+	slog.Info("Search index built", "releases", len(i.docs), "duration", time.Since(start))
+	return nil
+}
+
+// Add adds or replaces the document of a release
+func (i *Index) Add(doc *Document) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.docs[doc.id()] = doc
+	if i.rebuilding {
+		delete(i.removed, doc.id())
+	}
+}
+
+// Remove removes a release from the index
+func (i *Index) Remove(scope string, name string, version string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	id := releaseId(scope, name, version)
+	delete(i.docs, id)
+	if i.rebuilding {
+		i.removed[id] = struct{}{}
+	}
+}
+
+// Len returns the number of indexed releases
+func (i *Index) Len() int {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return len(i.docs)
+}
+
+// Search returns the matching packages ordered by score (highest first), then by scope and name
+func (i *Index) Search(q Query) Result {
+	terms := strings.Fields(strings.ToLower(q.Text))
+
+	// newest matching release per package
+	best := make(map[string]Hit)
+	i.mu.RLock()
+	for _, doc := range i.docs {
+		if !q.matchesFilters(doc) {
+			continue
+		}
+		score, ok := scoreDocument(doc, terms)
+		if !ok {
+			continue
+		}
+		key := doc.packageId()
+		if current, exists := best[key]; !exists || compareVersions(doc.Version, current.Version) > 0 {
+			best[key] = Hit{Document: *doc, Score: score}
+		}
+	}
+	i.mu.RUnlock()
+
+	hits := make([]Hit, 0, len(best))
+	for _, hit := range best {
+		hits = append(hits, hit)
+	}
+	slices.SortFunc(hits, func(a Hit, b Hit) int {
+		if a.Score != b.Score {
+			if a.Score > b.Score {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.packageId(), b.packageId())
+	})
+
+	result := Result{Total: len(hits)}
+	if q.PerPage <= 0 {
+		result.Hits = hits
+		return result
+	}
+	// compared by page before multiplying, large pages would overflow
+	pages := len(hits) / q.PerPage
+	if len(hits)%q.PerPage != 0 {
+		pages++
+	}
+	if max(q.Page, 1)-1 >= pages {
+		result.Hits = []Hit{}
+		return result
+	}
+	start := (max(q.Page, 1) - 1) * q.PerPage
+	result.Hits = hits[start : start+min(q.PerPage, len(hits)-start)]
+	return result
+}
+
+func (q Query) matchesFilters(doc *Document) bool {
+	if q.Scope != "" && !strings.EqualFold(q.Scope, doc.Scope) {
+		return false
+	}
+	if q.Allowed != nil && !q.Allowed(doc.Scope) {
+		return false
+	}
+	if q.Platform != "" {
+		minimum, declared := doc.Platforms[strings.ToLower(q.Platform)]
+		if declared && q.PlatformVersion != "" && compareVersions(minimum, q.PlatformVersion) > 0 {
+			return false
+		}
+	}
+	if q.ToolsVersion != "" && doc.ToolsVersion != "" && compareVersions(doc.ToolsVersion, q.ToolsVersion) > 0 {
+		return false
+	}
+	return true
+}
+
+// scoreDocument sums the weights of the fields each term matches, false if a term matches nothing
+func scoreDocument(doc *Document, terms []string) (float64, bool) {
+	name := strings.ToLower(doc.Name)
+	scope := strings.ToLower(doc.Scope)
+	description := strings.ToLower(doc.Description)
+
+	total := 0.0
+	for _, term := range terms {
+		score := 0.0
+		switch {
+		case name == term:
+			score += weightNameExact
+		case strings.HasPrefix(name, term):
+			score += weightNamePrefix
+		case strings.Contains(name, term):
+			score += weightNamePartial
+		}
+		switch {
+		case scope == term:
+			score += weightScopeExact
+		case strings.Contains(scope, term):
+			score += weightScopePartial
+		}
+		score += matchAny(append(slices.Clone(doc.Products), doc.Targets...), term, weightComponentExact, weightComponentPartial)
+		score += matchAny(doc.Keywords, term, weightKeywordExact, weightKeywordPartial)
+		if strings.Contains(description, term) {
+			score += weightDescription
+		}
+		if score == 0 {
+			return 0, false
+		}
+		total += score
+	}
+	return total, true
+}
+
+// matchAny returns the weight of the best match of term in values
+func matchAny(values []string, term string, exact float64, partial float64) float64 {
+	best := 0.0
+	for _, value := range values {
+		value = strings.ToLower(value)
+		if value == term {
+			return exact
+		}
+		if strings.Contains(value, term) {
+			best = partial
+		}
+	}
+	return best
+}
+
+// compareVersions compares dotted versions (e.g. 15.0, 5.9, 1.2.3-beta), missing minor and patch versions count as 0,
+// unparsable versions compare as strings
+func compareVersions(a string, b string) int {
+	va, errA := models.ParseVersion(completeVersion(strings.TrimSpace(a)))
+	vb, errB := models.ParseVersion(completeVersion(strings.TrimSpace(b)))
+	if errA != nil || errB != nil {
+		return strings.Compare(a, b)
+	}
+	return va.Compare(vb)
+}
+
+// completeVersion appends missing minor and patch versions of platform and tools versions, e.g. 15 becomes 15.0.0
+func completeVersion(version string) string {
+	core, rest := version, ""
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		core, rest = version[:i], version[i:]
+	}
+	for strings.Count(core, ".") < 2 {
+		core += ".0"
+	}
+	return core + rest
+}
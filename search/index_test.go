@@ -0,0 +1,119 @@
+package search
+
+import (
+	"math"
+	"testing"
+)
+
+func newTestIndex() *Index {
+	i := NewIndex()
+	i.Add(&Document{Scope: "apple", Name: "swift-log", Version: "1.5.0", Description: "A Logging API for Swift",
+		Keywords: []string{"logging"}, Products: []string{"Logging"}, Targets: []string{"Logging"}, ToolsVersion: "5.8",
+		Platforms: map[string]string{"ios": "13.0"}})
+	i.Add(&Document{Scope: "apple", Name: "swift-log", Version: "1.6.0", Description: "A Logging API for Swift",
+		Keywords: []string{"logging"}, Products: []string{"Logging"}, Targets: []string{"Logging"}, ToolsVersion: "5.9",
+		Platforms: map[string]string{"ios": "16.0"}})
+	i.Add(&Document{Scope: "acme", Name: "network", Version: "2.0.0", Description: "Networking with logging support",
+		Products: []string{"Network"}, Targets: []string{"Network", "NetworkLogging"}, ToolsVersion: "5.7"})
+	i.Add(&Document{Scope: "acme", Name: "log", Version: "1.0.0", Description: "Tiny logger"})
+	return i
+}
+
+func Test_Search_RanksNameMatchesFirst(t *testing.T) {
+	result := newTestIndex().Search(Query{Text: "log"})
+
+	if result.Total != 3 {
+		t.Fatalf("expected 3 packages, got %d: %+v", result.Total, result.Hits)
+	}
+	names := []string{result.Hits[0].Name, result.Hits[1].Name, result.Hits[2].Name}
+	if names[0] != "log" || names[1] != "swift-log" || names[2] != "network" {
+		t.Errorf("unexpected ranking %v", names)
+	}
+}
+
+func Test_Search_ReturnsNewestMatchingRelease(t *testing.T) {
+	result := newTestIndex().Search(Query{Text: "swift-log"})
+
+	if result.Total != 1 || result.Hits[0].Version != "1.6.0" {
+		t.Errorf("expected newest release 1.6.0, got %+v", result.Hits)
+	}
+}
+
+func Test_Search_AllTermsMustMatch(t *testing.T) {
+	result := newTestIndex().Search(Query{Text: "logging network"})
+
+	if result.Total != 1 || result.Hits[0].Name != "network" {
+		t.Errorf("expected only network, got %+v", result.Hits)
+	}
+}
+
+func Test_Search_Filters(t *testing.T) {
+	i := newTestIndex()
+
+	tests := []struct {
+		name     string
+		query    Query
+		expected map[string]string // package name -> version
+	}{
+		{"scope", Query{Scope: "ACME"}, map[string]string{"network": "2.0.0", "log": "1.0.0"}},
+		{"platform", Query{Text: "swift-log", Platform: "iOS", PlatformVersion: "15.0"}, map[string]string{"swift-log": "1.5.0"}},
+		{"platform not declared", Query{Text: "network", Platform: "ios", PlatformVersion: "12.0"}, map[string]string{"network": "2.0.0"}},
+		{"tools version", Query{Text: "swift-log", ToolsVersion: "5.8"}, map[string]string{"swift-log": "1.5.0"}},
+		{"tools version too old", Query{Text: "swift-log", ToolsVersion: "5.7"}, map[string]string{}},
+		{"allowed scopes", Query{Allowed: func(scope string) bool { return scope == "apple" }}, map[string]string{"swift-log": "1.6.0"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := i.Search(test.query)
+			if result.Total != len(test.expected) {
+				t.Fatalf("expected %d hits, got %+v", len(test.expected), result.Hits)
+			}
+			for _, hit := range result.Hits {
+				if test.expected[hit.Name] != hit.Version {
+					t.Errorf("unexpected hit %s@%s", hit.Name, hit.Version)
+				}
+			}
+		})
+	}
+}
+
+func Test_Search_Paginates(t *testing.T) {
+	i := newTestIndex()
+
+	first := i.Search(Query{Page: 1, PerPage: 2})
+	second := i.Search(Query{Page: 2, PerPage: 2})
+	beyond := i.Search(Query{Page: 3, PerPage: 2})
+
+	if first.Total != 3 || len(first.Hits) != 2 || len(second.Hits) != 1 || len(beyond.Hits) != 0 {
+		t.Errorf("unexpected pages %+v %+v %+v", first, second, beyond)
+	}
+	if first.Hits[0].Name != "log" || first.Hits[1].Name != "network" || second.Hits[0].Name != "swift-log" {
+		t.Errorf("expected pages ordered by identifier without query, got %+v %+v", first.Hits, second.Hits)
+	}
+}
+
+func Test_Search_HugePage_ReturnsNoHits(t *testing.T) {
+	i := newTestIndex()
+
+	result := i.Search(Query{Page: math.MaxInt/2 + 2, PerPage: 2})
+
+	if result.Total != 3 || len(result.Hits) != 0 {
+		t.Errorf("expected no hits beyond the last page, got %+v", result)
+	}
+	if result := i.Search(Query{Page: 1, PerPage: math.MaxInt}); len(result.Hits) != 3 {
+		t.Errorf("expected all hits on one page, got %+v", result)
+	}
+}
+
+func Test_Remove_DropsRelease(t *testing.T) {
+	i := newTestIndex()
+
+	i.Remove("apple", "swift-log", "1.6.0")
+
+	if i.Len() != 3 {
+		t.Errorf("expected 3 releases, got %d", i.Len())
+	}
+	if result := i.Search(Query{Text: "swift-log"}); result.Hits[0].Version != "1.5.0" {
+		t.Errorf("expected remaining release 1.5.0, got %+v", result.Hits)
+	}
+}
@@ -0,0 +1,284 @@
+package signing
+
+import (
+	"OpenSPMRegistry/config"
+	"OpenSPMRegistry/models"
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"time"
+)
+
+// ArchiveVerifier verifies detached CMS signatures (signature format cms-1.0.0) of source archives
+// against a set of trusted root certificates
+type ArchiveVerifier struct {
+	roots *x509.CertPool
+	now   func() time.Time
+}
+
+// contentInfo is the CMS ContentInfo wrapping the SignedData (RFC 5652 section 3)
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,tag:0"`
+}
+
+// signedData is the CMS SignedData (RFC 5652 section 5.1)
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo encapsulatedContentInfo
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+type encapsulatedContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"optional,explicit,tag:0"`
+}
+
+// signerInfo is the CMS SignerInfo (RFC 5652 section 5.3)
+type signerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	UnsignedAttrs      asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type issuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values []asn1.RawValue `asn1:"set"`
+}
+
+var (
+	oidSignedData    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidData          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidContentType   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+
+	digestAlgorithms = map[string]crypto.Hash{
+		"2.16.840.1.101.3.4.2.1": crypto.SHA256,
+		"2.16.840.1.101.3.4.2.2": crypto.SHA384,
+		"2.16.840.1.101.3.4.2.3": crypto.SHA512,
+	}
+	// signatureAlgorithms maps the accepted signature algorithms to the key type they need
+	signatureAlgorithms = map[string]x509.PublicKeyAlgorithm{
+		"1.2.840.10045.2.1":     x509.ECDSA, // id-ecPublicKey, hash given by the digest algorithm
+		"1.2.840.10045.4.3.2":   x509.ECDSA, // ecdsa-with-SHA256
+		"1.2.840.10045.4.3.3":   x509.ECDSA, // ecdsa-with-SHA384
+		"1.2.840.10045.4.3.4":   x509.ECDSA, // ecdsa-with-SHA512
+		"1.2.840.113549.1.1.1":  x509.RSA,   // rsaEncryption, hash given by the digest algorithm
+		"1.2.840.113549.1.1.11": x509.RSA,   // sha256WithRSAEncryption
+		"1.2.840.113549.1.1.12": x509.RSA,   // sha384WithRSAEncryption
+		"1.2.840.113549.1.1.13": x509.RSA,   // sha512WithRSAEncryption
+	}
+)
+
+// NewArchiveVerifier loads the trusted root certificates configured
+func NewArchiveVerifier(cfg config.SignatureConfig) (*ArchiveVerifier, error) {
+	roots := x509.NewCertPool()
+	for _, file := range cfg.TrustedRoots {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("reading trusted roots: %w", err)
+		}
+		certs, err := parseCertificates(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		for _, cert := range certs {
+			roots.AddCert(cert)
+		}
+	}
+	if len(cfg.TrustedRoots) == 0 {
+		return nil, errors.New("no trusted root certificates configured")
+	}
+	return &ArchiveVerifier{roots: roots, now: time.Now}, nil
+}
+
+// Verify checks that signature is a valid detached CMS signature of the archive
+// by a code signing certificate chaining up to a trusted root.
+// Returns the signing certificate.
+func (v *ArchiveVerifier) Verify(archive io.Reader, signature []byte) (*x509.Certificate, error) {
+	sd, cert, certs, err := parseSignature(signature)
+	if err != nil {
+		return nil, err
+	}
+	signer := &sd.SignerInfos[0]
+	if len(sd.EncapContentInfo.Content.Bytes) > 0 {
+		return nil, errors.New("signature must be detached from the source archive")
+	}
+
+	hash, ok := digestAlgorithms[signer.DigestAlgorithm.Algorithm.String()]
+	if !ok {
+		return nil, fmt.Errorf("unsupported digest algorithm %s", signer.DigestAlgorithm.Algorithm)
+	}
+	hasher := hash.New()
+	if _, err := io.Copy(hasher, archive); err != nil {
+		return nil, fmt.Errorf("reading source archive: %w", err)
+	}
+	digest := hasher.Sum(nil)
+
+	// with signed attributes the signature covers their DER encoding, which must carry the archive digest
+	if len(signer.SignedAttrs.FullBytes) > 0 {
+		signedAttrs, err := checkSignedAttributes(signer.SignedAttrs, digest)
+		if err != nil {
+			return nil, err
+		}
+		hasher = hash.New()
+		hasher.Write(signedAttrs)
+		digest = hasher.Sum(nil)
+	}
+	if err := verifySignature(cert, signer.SignatureAlgorithm, hash, digest, signer.Signature); err != nil {
+		return nil, err
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, c := range certs {
+		if c != cert {
+			intermediates.AddCert(c)
+		}
+	}
+	if _, err := cert.Verify(x509.VerifyOptions{
+		Roots:         v.roots,
+		Intermediates: intermediates,
+		CurrentTime:   v.now(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}); err != nil {
+		return nil, fmt.Errorf("signing certificate %q is not trusted: %w", cert.Subject.CommonName, err)
+	}
+	return cert, nil
+}
+
+// NewSigner describes the signer of a package version (SE-0291) by its verified signing certificate
+func NewSigner(cert *x509.Certificate) *models.Signer {
+	name := certificateName(cert.Subject)
+	return &models.Signer{
+		CommonName:         name.CommonName,
+		OrganizationalUnit: name.OrganizationalUnit,
+		Organization:       name.Organization,
+	}
+}
+
+// parseSignature decodes the SignedData of a signature with exactly one signer
+// returns (signed data, signer certificate, all included certificates, error)
+func parseSignature(signature []byte) (*signedData, *x509.Certificate, []*x509.Certificate, error) {
+	var ci contentInfo
+	if rest, err := asn1.Unmarshal(signature, &ci); err != nil {
+		return nil, nil, nil, fmt.Errorf("signature is not a valid CMS structure: %v", err)
+	} else if len(rest) > 0 {
+		return nil, nil, nil, errors.New("signature has trailing data after the CMS structure")
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		return nil, nil, nil, fmt.Errorf("signature content type %s is not signed data", ci.ContentType)
+	}
+	var sd signedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		return nil, nil, nil, fmt.Errorf("signature is not valid CMS signed data: %v", err)
+	}
+	if len(sd.SignerInfos) != 1 {
+		return nil, nil, nil, fmt.Errorf("signature must have exactly one signer, got %d", len(sd.SignerInfos))
+	}
+	certs, err := x509.ParseCertificates(sd.Certificates.Bytes)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("parsing certificates: %w", err)
+	}
+	cert, err := findSignerCertificate(sd.SignerInfos[0].SID, certs)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return &sd, cert, certs, nil
+}
+
+// findSignerCertificate returns the certificate identified by issuer and serial number or subject key identifier
+func findSignerCertificate(sid asn1.RawValue, certs []*x509.Certificate) (*x509.Certificate, error) {
+	var issuerAndSerial issuerAndSerialNumber
+	isIssuerAndSerial := sid.Class == asn1.ClassUniversal && sid.Tag == asn1.TagSequence
+	if isIssuerAndSerial {
+		if _, err := asn1.Unmarshal(sid.FullBytes, &issuerAndSerial); err != nil {
+			return nil, fmt.Errorf("invalid signer identifier: %v", err)
+		}
+	}
+	for _, cert := range certs {
+		if isIssuerAndSerial {
+			if cert.SerialNumber.Cmp(issuerAndSerial.SerialNumber) == 0 && bytes.Equal(cert.RawIssuer, issuerAndSerial.Issuer.FullBytes) {
+				return cert, nil
+			}
+		} else if sid.Class == asn1.ClassContextSpecific && sid.Tag == 0 && bytes.Equal(cert.SubjectKeyId, sid.Bytes) {
+			return cert, nil
+		}
+	}
+	return nil, errors.New("signing certificate not included in signature")
+}
+
+// checkSignedAttributes verifies content type and message digest attributes
+// and returns the DER encoding of the attributes the signature is computed over
+func checkSignedAttributes(raw asn1.RawValue, digest []byte) ([]byte, error) {
+	// the signature covers the attributes encoded as SET OF instead of the implicit [0] tag
+	signed := append([]byte{0x31}, raw.FullBytes[1:]...)
+	var attributes []attribute
+	if _, err := asn1.UnmarshalWithParams(signed, &attributes, "set"); err != nil {
+		return nil, fmt.Errorf("invalid signed attributes: %v", err)
+	}
+
+	var contentTypeOk, digestOk bool
+	for _, attr := range attributes {
+		if len(attr.Values) != 1 {
+			continue
+		}
+		switch {
+		case attr.Type.Equal(oidContentType):
+			var contentType asn1.ObjectIdentifier
+			_, err := asn1.Unmarshal(attr.Values[0].FullBytes, &contentType)
+			contentTypeOk = err == nil && contentType.Equal(oidData)
+		case attr.Type.Equal(oidMessageDigest):
+			var messageDigest []byte
+			_, err := asn1.Unmarshal(attr.Values[0].FullBytes, &messageDigest)
+			digestOk = err == nil && bytes.Equal(messageDigest, digest)
+		}
+	}
+	if !contentTypeOk {
+		return nil, errors.New("signed attributes lack content type data")
+	}
+	if !digestOk {
+		return nil, errors.New("signature does not match the source archive")
+	}
+	return signed, nil
+}
+
+func verifySignature(cert *x509.Certificate, algorithm pkix.AlgorithmIdentifier, hash crypto.Hash, digest []byte, signature []byte) error {
+	keyType, ok := signatureAlgorithms[algorithm.Algorithm.String()]
+	if !ok {
+		return fmt.Errorf("unsupported signature algorithm %s", algorithm.Algorithm)
+	}
+	switch key := cert.PublicKey.(type) {
+	case *ecdsa.PublicKey:
+		if keyType == x509.ECDSA && ecdsa.VerifyASN1(key, digest, signature) {
+			return nil
+		}
+	case *rsa.PublicKey:
+		if keyType == x509.RSA && rsa.VerifyPKCS1v15(key, hash, digest, signature) == nil {
+			return nil
+		}
+	default:
+		return fmt.Errorf("unsupported signing key type %T", cert.PublicKey)
+	}
+	return errors.New("signature does not match the source archive")
+}
@@ -0,0 +1,194 @@
+package signing
+
+import (
+	"OpenSPMRegistry/config"
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	oidSHA256          = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidECDSAWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+	oidRSAEncryption   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+)
+
+// newTestSignature creates a detached CMS signature of content by the first certificate of chain like SwiftPM does,
+// with or without signed attributes. Only the certificates in included are embedded.
+func newTestSignature(t *testing.T, content []byte, key crypto.Signer, chain []*x509.Certificate, included []*x509.Certificate, withSignedAttrs bool) []byte {
+	t.Helper()
+	mustMarshal := func(v any, params string) []byte {
+		data, err := asn1.MarshalWithParams(v, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	digest := sha256.Sum256(content)
+	toSign := digest[:]
+	var signedAttrs asn1.RawValue
+	if withSignedAttrs {
+		attributes := []attribute{
+			{Type: oidContentType, Values: []asn1.RawValue{{FullBytes: mustMarshal(oidData, "")}}},
+			{Type: oidMessageDigest, Values: []asn1.RawValue{{FullBytes: mustMarshal(digest[:], "")}}},
+		}
+		set := mustMarshal(attributes, "set")
+		signedAttrs = asn1.RawValue{FullBytes: append([]byte{0xA0}, set[1:]...)}
+		attrsDigest := sha256.Sum256(set)
+		toSign = attrsDigest[:]
+	}
+	signature, err := key.Sign(rand.Reader, toSign, crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signatureAlgorithm := oidECDSAWithSHA256
+	if _, ok := key.(*rsa.PrivateKey); ok {
+		signatureAlgorithm = oidRSAEncryption
+	}
+
+	leaf := chain[0]
+	var rawCerts []byte
+	for _, cert := range included {
+		rawCerts = append(rawCerts, cert.Raw...)
+	}
+	sd := signedData{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{{Algorithm: oidSHA256}},
+		EncapContentInfo: encapsulatedContentInfo{ContentType: oidData},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: rawCerts},
+		SignerInfos: []signerInfo{{
+			Version:            1,
+			SID:                asn1.RawValue{FullBytes: mustMarshal(issuerAndSerialNumber{Issuer: asn1.RawValue{FullBytes: leaf.RawIssuer}, SerialNumber: leaf.SerialNumber}, "")},
+			DigestAlgorithm:    pkix.AlgorithmIdentifier{Algorithm: oidSHA256},
+			SignedAttrs:        signedAttrs,
+			SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: signatureAlgorithm},
+			Signature:          signature,
+		}},
+	}
+	return mustMarshal(contentInfo{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: mustMarshal(sd, "")},
+	}, "")
+}
+
+// newTestVerifier creates a certificate chain for key and a verifier trusting its root
+func newTestVerifier(t *testing.T, key crypto.Signer) (*ArchiveVerifier, []*x509.Certificate) {
+	t.Helper()
+	chainPEM, _ := newTestChain(t, key, testNow.Add(time.Hour))
+	chain, err := parseCertificates(chainPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(chain[1])
+	return &ArchiveVerifier{roots: roots, now: func() time.Time { return testNow }}, chain
+}
+
+func Test_Verify_ValidSignature_ReturnsSigner(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive := []byte("source archive")
+
+	for _, tc := range []struct {
+		name            string
+		key             crypto.Signer
+		withSignedAttrs bool
+	}{
+		{"ec with signed attributes", ecKey, true},
+		{"ec without signed attributes", ecKey, false},
+		{"rsa with signed attributes", rsaKey, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			verifier, chain := newTestVerifier(t, tc.key)
+			signature := newTestSignature(t, archive, tc.key, chain, chain, tc.withSignedAttrs)
+
+			cert, err := verifier.Verify(bytes.NewReader(archive), signature)
+
+			if err != nil {
+				t.Fatalf("expected valid signature, got %v", err)
+			}
+			if cert.Subject.CommonName != "Acme Registry" {
+				t.Errorf("expected leaf certificate, got %s", cert.Subject.CommonName)
+			}
+		})
+	}
+}
+
+func Test_Verify_InvalidSignature_ReturnsError(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	verifier, chain := newTestVerifier(t, key)
+	otherVerifier, _ := newTestVerifier(t, key)
+	archive := []byte("source archive")
+
+	for _, tc := range []struct {
+		name      string
+		verifier  *ArchiveVerifier
+		archive   []byte
+		signature []byte
+		expected  string
+	}{
+		{"tampered archive", verifier, []byte("tampered"), newTestSignature(t, archive, key, chain, chain, true), "does not match"},
+		{"tampered archive without signed attributes", verifier, []byte("tampered"), newTestSignature(t, archive, key, chain, chain, false), "does not match"},
+		{"untrusted root", otherVerifier, archive, newTestSignature(t, archive, key, chain, chain, true), "not trusted"},
+		{"missing signer certificate", verifier, archive, newTestSignature(t, archive, key, chain, chain[1:], true), "not included"},
+		{"not cms", verifier, archive, []byte("signature"), "not a valid CMS"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.verifier.Verify(bytes.NewReader(tc.archive), tc.signature)
+
+			if err == nil || !strings.Contains(err.Error(), tc.expected) {
+				t.Errorf("expected error containing %q, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func Test_NewSigner_VerifiedCertificate_ReturnsSignerNames(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	verifier, chain := newTestVerifier(t, key)
+
+	cert, err := verifier.Verify(bytes.NewReader([]byte("archive")), newTestSignature(t, []byte("archive"), key, chain, chain, true))
+	if err != nil {
+		t.Fatalf("failed to verify signature: %v", err)
+	}
+
+	signer := NewSigner(cert)
+	if signer.CommonName != "Acme Registry" || signer.OrganizationalUnit != "Mobile" || signer.Organization != "Acme" {
+		t.Errorf("unexpected signer %+v", signer)
+	}
+}
+
+func Test_NewArchiveVerifier_LoadsTrustedRoots(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	chainPEM, _ := newTestChain(t, key, testNow.Add(time.Hour))
+	file := filepath.Join(t.TempDir(), "roots.pem")
+	if err := os.WriteFile(file, chainPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewArchiveVerifier(config.SignatureConfig{TrustedRoots: []string{file}}); err != nil {
+		t.Errorf("expected trusted roots to load, got %v", err)
+	}
+	if _, err := NewArchiveVerifier(config.SignatureConfig{}); err == nil {
+		t.Errorf("expected error without trusted roots")
+	}
+	if _, err := NewArchiveVerifier(config.SignatureConfig{TrustedRoots: []string{filepath.Join(t.TempDir(), "missing.pem")}}); err == nil {
+		t.Errorf("expected error for missing file")
+	}
+}
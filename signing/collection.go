@@ -0,0 +1,231 @@
+package signing
+
+import (
+	"OpenSPMRegistry/config"
+	"OpenSPMRegistry/models"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+)
+
+// CollectionSigner signs package collections with a certificate and its private key.
+// Signed collections are cached until their contents change.
+type CollectionSigner struct {
+	algorithm jose.SignatureAlgorithm
+	key       crypto.Signer
+	chain     []*x509.Certificate
+
+	mu    sync.Mutex
+	cache map[[sha256.Size]byte][]byte
+	// cacheOrder holds the cached digests, oldest first
+	cacheOrder [][sha256.Size]byte
+}
+
+// maxCachedCollections limits the signed collections kept (e.g. global and per scope, filtered per user)
+const maxCachedCollections = 64
+
+// oidUserID is the userID (UID) attribute of a distinguished name
+var oidUserID = asn1.ObjectIdentifier{0, 9, 2342, 19200300, 100, 1, 1}
+
+// NewCollectionSigner loads the certificate chain and private key configured
+func NewCollectionSigner(cfg config.CollectionSigningConfig) (*CollectionSigner, error) {
+	chainPEM, err := os.ReadFile(cfg.CertificateChain)
+	if err != nil {
+		return nil, fmt.Errorf("reading certificate chain: %w", err)
+	}
+	keyPEM, err := os.ReadFile(cfg.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("reading private key: %w", err)
+	}
+	return newCollectionSigner(chainPEM, keyPEM, time.Now())
+}
+
+func newCollectionSigner(chainPEM []byte, keyPEM []byte, now time.Time) (*CollectionSigner, error) {
+	chain, err := parseCertificates(chainPEM)
+	if err != nil {
+		return nil, err
+	}
+	leaf := chain[0]
+	if now.After(leaf.NotAfter) || now.Before(leaf.NotBefore) {
+		return nil, fmt.Errorf("signing certificate %q is not valid at %s", leaf.Subject.CommonName, now.Format(time.RFC3339))
+	}
+
+	key, err := parsePrivateKey(keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	if public, ok := key.Public().(interface{ Equal(crypto.PublicKey) bool }); !ok || !public.Equal(leaf.PublicKey) {
+		return nil, errors.New("private key does not belong to the signing certificate")
+	}
+
+	var algorithm jose.SignatureAlgorithm
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		if k.N.BitLen() < 2048 {
+			return nil, fmt.Errorf("RSA key must have at least 2048 bits, got %d", k.N.BitLen())
+		}
+		algorithm = jose.RS256
+	case *ecdsa.PrivateKey:
+		if k.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("EC key must use curve P-256, got %s", k.Curve.Params().Name)
+		}
+		algorithm = jose.ES256
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T, expected RSA or EC", key)
+	}
+
+	return &CollectionSigner{
+		algorithm: algorithm,
+		key:       key,
+		chain:     chain,
+		cache:     make(map[[sha256.Size]byte][]byte),
+	}, nil
+}
+
+// Sign returns the JSON encoding of the signed collection.
+// Collections only differing in their generation time are signed once, the first signed one is returned again.
+func (s *CollectionSigner) Sign(collection *models.PackageCollection) ([]byte, error) {
+	contents := *collection
+	contents.GeneratedAt = ""
+	contentsJSON, err := json.Marshal(contents)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(contentsJSON)
+
+	s.mu.Lock()
+	cached, ok := s.cache[digest]
+	s.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	payload, err := json.Marshal(collection)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := s.sign(payload)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(models.SignedPackageCollection{
+		PackageCollection: *collection,
+		Signature: models.CollectionSignature{
+			Signature: signature,
+			Certificate: models.SignatureCertificate{
+				Subject: certificateName(s.chain[0].Subject),
+				Issuer:  certificateName(s.chain[0].Issuer),
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.cache[digest]; !ok {
+		s.cache[digest] = data
+		s.cacheOrder = append(s.cacheOrder, digest)
+		if len(s.cacheOrder) > maxCachedCollections {
+			delete(s.cache, s.cacheOrder[0])
+			s.cacheOrder = s.cacheOrder[1:]
+		}
+	}
+	return data, nil
+}
+
+// sign creates the compact JWS of the payload with the certificate chain in its x5c header
+func (s *CollectionSigner) sign(payload []byte) (string, error) {
+	x5c := make([]string, 0, len(s.chain))
+	for _, cert := range s.chain {
+		x5c = append(x5c, base64.StdEncoding.EncodeToString(cert.Raw))
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: s.algorithm, Key: s.key}, (&jose.SignerOptions{}).WithHeader("x5c", x5c))
+	if err != nil {
+		return "", err
+	}
+	jws, err := signer.Sign(payload)
+	if err != nil {
+		return "", err
+	}
+	return jws.CompactSerialize()
+}
+
+func certificateName(dn pkix.Name) models.CertificateName {
+	name := models.CertificateName{CommonName: dn.CommonName}
+	if len(dn.OrganizationalUnit) > 0 {
+		name.OrganizationalUnit = dn.OrganizationalUnit[0]
+	}
+	if len(dn.Organization) > 0 {
+		name.Organization = dn.Organization[0]
+	}
+	for _, attribute := range dn.Names {
+		if attribute.Type.Equal(oidUserID) {
+			if userID, ok := attribute.Value.(string); ok {
+				name.UserID = userID
+			}
+		}
+	}
+	return name
+}
+
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var chain []*x509.Certificate
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parsing certificate: %w", err)
+		}
+		chain = append(chain, cert)
+	}
+	if len(chain) == 0 {
+		return nil, errors.New("no certificate found in certificate chain")
+	}
+	return chain, nil
+}
+
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found in private key")
+	}
+	var key any
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported private key PEM type %q", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing private key: %w", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
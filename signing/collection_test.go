@@ -0,0 +1,215 @@
+package signing
+
+import (
+	"OpenSPMRegistry/models"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+)
+
+var testNow = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// newTestChain creates a CA and a leaf certificate for key, returning the chain (leaf first) and key as PEM
+func newTestChain(t *testing.T, key crypto.Signer, notAfter time.Time) ([]byte, []byte) {
+	t.Helper()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Root CA", Organization: []string{"Acme"}},
+		NotBefore:             testNow.Add(-time.Hour),
+		NotAfter:              testNow.Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, caKey.Public(), caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject: pkix.Name{
+			CommonName:         "Acme Registry",
+			Organization:       []string{"Acme"},
+			OrganizationalUnit: []string{"Mobile"},
+			ExtraNames:         []pkix.AttributeTypeAndValue{{Type: oidUserID, Value: "ACME123"}},
+		},
+		NotBefore:   testNow.Add(-time.Hour),
+		NotAfter:    notAfter,
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTemplate, caTemplate, key.Public(), caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain := append(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leafDER}),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})...)
+	return chain, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+}
+
+func newTestCollection(generatedAt string) *models.PackageCollection {
+	return &models.PackageCollection{
+		Name:          "All Packages",
+		Packages:      []models.CollectionPackage{{URL: "acme.network", Versions: []models.PackageVersion{{Version: "1.0.0"}}}},
+		FormatVersion: "1.0",
+		Revision:      1,
+		GeneratedAt:   generatedAt,
+	}
+}
+
+func Test_Sign_RSAAndECKeys_ProduceVerifiableJWS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name      string
+		key       crypto.Signer
+		algorithm jose.SignatureAlgorithm
+	}{
+		{"rsa", rsaKey, jose.RS256},
+		{"ec", ecKey, jose.ES256},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			chain, key := newTestChain(t, tc.key, testNow.Add(time.Hour))
+			signer, err := newCollectionSigner(chain, key, testNow)
+			if err != nil {
+				t.Fatalf("failed to create signer: %v", err)
+			}
+			collection := newTestCollection("2025-06-01T12:00:00Z")
+
+			data, err := signer.Sign(collection)
+			if err != nil {
+				t.Fatalf("failed to sign: %v", err)
+			}
+
+			var signed models.SignedPackageCollection
+			if err := json.Unmarshal(data, &signed); err != nil {
+				t.Fatalf("failed to decode signed collection: %v", err)
+			}
+			expectedSubject := models.CertificateName{UserID: "ACME123", CommonName: "Acme Registry", OrganizationalUnit: "Mobile", Organization: "Acme"}
+			if signed.Signature.Certificate.Subject != expectedSubject || signed.Signature.Certificate.Issuer.CommonName != "Test Root CA" {
+				t.Errorf("unexpected certificate %+v", signed.Signature.Certificate)
+			}
+			if !reflect.DeepEqual(signed.PackageCollection, *collection) {
+				t.Errorf("expected collection fields at top level, got %+v", signed.PackageCollection)
+			}
+
+			jws, err := jose.ParseSigned(signed.Signature.Signature, []jose.SignatureAlgorithm{tc.algorithm})
+			if err != nil {
+				t.Fatalf("failed to parse JWS: %v", err)
+			}
+			payload, err := jws.Verify(tc.key.Public())
+			if err != nil {
+				t.Fatalf("failed to verify JWS: %v", err)
+			}
+			var signedCollection models.PackageCollection
+			if err := json.Unmarshal(payload, &signedCollection); err != nil || !reflect.DeepEqual(signedCollection, *collection) {
+				t.Errorf("expected payload to be the collection, got %s (%v)", payload, err)
+			}
+		})
+	}
+}
+
+func Test_Sign_JWSCarriesCertificateChain(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	chain, keyPEM := newTestChain(t, key, testNow.Add(time.Hour))
+	signer, err := newCollectionSigner(chain, keyPEM, testNow)
+	if err != nil {
+		t.Fatalf("failed to create signer: %v", err)
+	}
+
+	data, _ := signer.Sign(newTestCollection("2025-06-01T12:00:00Z"))
+
+	var signed models.SignedPackageCollection
+	_ = json.Unmarshal(data, &signed)
+	jws, err := jose.ParseSigned(signed.Signature.Signature, []jose.SignatureAlgorithm{jose.ES256})
+	if err != nil {
+		t.Fatalf("failed to parse JWS: %v", err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(signer.chain[1])
+	certs, err := jws.Signatures[0].Protected.Certificates(x509.VerifyOptions{
+		Roots:       roots,
+		CurrentTime: testNow,
+		KeyUsages:   []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	})
+	if err != nil || len(certs) != 1 || len(certs[0]) != 2 {
+		t.Errorf("expected x5c chain of leaf and root to verify, got %v (%v)", certs, err)
+	}
+}
+
+func Test_Sign_UnchangedContents_ReturnsCachedSignature(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	chain, keyPEM := newTestChain(t, key, testNow.Add(time.Hour))
+	signer, err := newCollectionSigner(chain, keyPEM, testNow)
+	if err != nil {
+		t.Fatalf("failed to create signer: %v", err)
+	}
+
+	first, _ := signer.Sign(newTestCollection("2025-06-01T12:00:00Z"))
+	second, _ := signer.Sign(newTestCollection("2025-06-01T13:00:00Z"))
+	changed := newTestCollection("2025-06-01T13:00:00Z")
+	changed.Packages[0].Versions = append(changed.Packages[0].Versions, models.PackageVersion{Version: "1.1.0"})
+	third, _ := signer.Sign(changed)
+
+	if string(first) != string(second) {
+		t.Errorf("expected collection differing only in generation time to be served from cache")
+	}
+	if string(first) == string(third) {
+		t.Errorf("expected changed collection to be signed again")
+	}
+}
+
+func Test_NewCollectionSigner_InvalidKeyOrCertificate_ReturnsError(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	p384Key, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	chain, _ := newTestChain(t, key, testNow.Add(time.Hour))
+	_, otherKeyPEM := newTestChain(t, otherKey, testNow.Add(time.Hour))
+	expiredChain, keyPEM := newTestChain(t, key, testNow.Add(-time.Minute))
+	p384Chain, p384KeyPEM := newTestChain(t, p384Key, testNow.Add(time.Hour))
+
+	for _, tc := range []struct {
+		name  string
+		chain []byte
+		key   []byte
+	}{
+		{"key of other certificate", chain, otherKeyPEM},
+		{"expired certificate", expiredChain, keyPEM},
+		{"unsupported curve", p384Chain, p384KeyPEM},
+		{"no certificate", nil, keyPEM},
+		{"no key", chain, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := newCollectionSigner(tc.chain, tc.key, testNow); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
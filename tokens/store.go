@@ -0,0 +1,264 @@
+package tokens
+
+import (
+	"OpenSPMRegistry/config"
+	"OpenSPMRegistry/utils"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Token is a personal access token issued by the registry.
+// Only the SHA-256 hash of its secret is stored.
+type Token struct {
+	Id          string     `json:"id"`
+	Name        string     `json:"name"`
+	Owner       string     `json:"owner"`
+	Groups      []string   `json:"groups,omitempty"`      // Groups of the owner when the token was created
+	Scopes      []string   `json:"scopes,omitempty"`      // Scope patterns the token is restricted to (empty: all)
+	Permissions []string   `json:"permissions,omitempty"` // Permissions the token is restricted to (empty: all)
+	CreatedAt   time.Time  `json:"createdAt"`
+	ExpiresAt   time.Time  `json:"expiresAt"`
+	LastUsedAt  *time.Time `json:"lastUsedAt,omitempty"`
+	Hash        string     `json:"hash"`
+}
+
+// Store keeps personal access tokens in a JSON file
+type Store struct {
+	mu           sync.Mutex
+	path         string
+	maxLifetime  time.Duration
+	tokens       []*Token
+	timeProvider utils.TimeProvider
+}
+
+const (
+	// Prefix starts the secret of every personal access token,
+	// so they can be told apart from other Bearer tokens (e.g. OIDC ID tokens)
+	Prefix = "spmr_"
+
+	defaultPath        = "tokens.json"
+	defaultMaxLifetime = 365 // days
+	secretLength       = 32
+	idLength           = 8
+	// lastUsedPersistInterval limits how often the last usage of a token is written to disk
+	lastUsedPersistInterval = time.Minute
+)
+
+var (
+	ErrInvalidToken  = errors.New("invalid token")
+	ErrTokenExpired  = errors.New("token expired")
+	ErrTokenNotFound = errors.New("token not found")
+	ErrInvalidExpiry = errors.New("invalid expiry")
+)
+
+// NewStore creates a store and loads the tokens already persisted at the configured path
+func NewStore(cfg config.TokensConfig) (*Store, error) {
+	path := cfg.Path
+	if path == "" {
+		path = defaultPath
+	}
+	maxLifetime := cfg.MaxLifetime
+	if maxLifetime <= 0 {
+		maxLifetime = defaultMaxLifetime
+	}
+
+	s := &Store{
+		path:         path,
+		maxLifetime:  time.Duration(maxLifetime) * 24 * time.Hour,
+		timeProvider: utils.NewRealTimeProvider(),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &s.tokens); err != nil {
+			return nil, fmt.Errorf("error reading tokens from %s: %w", path, err)
+		}
+	}
+	return s, nil
+}
+
+// IsToken reports whether the secret looks like a personal access token
+func IsToken(secret string) bool {
+	return strings.HasPrefix(secret, Prefix)
+}
+
+// Create issues a new token for the owner and returns it together with its secret.
+// The secret is not stored and can not be retrieved later.
+// A zero expiresAt uses the maximum lifetime; later expiries are rejected with ErrInvalidExpiry.
+func (s *Store) Create(owner string, groups []string, name string, scopes []string, permissions []string, expiresAt time.Time) (*Token, string, error) {
+	now := s.timeProvider.Now().UTC()
+	latest := now.Add(s.maxLifetime)
+	if expiresAt.IsZero() {
+		expiresAt = latest
+	}
+	if !expiresAt.After(now) || expiresAt.After(latest) {
+		return nil, "", fmt.Errorf("%w: must be in the future and at most %d days ahead", ErrInvalidExpiry, int(s.maxLifetime.Hours()/24))
+	}
+
+	secret, err := randomString(secretLength)
+	if err != nil {
+		return nil, "", err
+	}
+	id, err := randomHex(idLength)
+	if err != nil {
+		return nil, "", err
+	}
+	secret = Prefix + secret
+
+	token := &Token{
+		Id:          id,
+		Name:        name,
+		Owner:       owner,
+		Groups:      groups,
+		Scopes:      scopes,
+		Permissions: permissions,
+		CreatedAt:   now,
+		ExpiresAt:   expiresAt.UTC(),
+		Hash:        hashSecret(secret),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens = append(s.tokens, token)
+	if err := s.save(); err != nil {
+		s.tokens = s.tokens[:len(s.tokens)-1]
+		return nil, "", err
+	}
+	result := *token
+	return &result, secret, nil
+}
+
+// List returns the tokens of the owner, or all tokens if owner is empty
+func (s *Store) List(owner string) []Token {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]Token, 0, len(s.tokens))
+	for _, token := range s.tokens {
+		if owner == "" || token.Owner == owner {
+			result = append(result, *token)
+		}
+	}
+	return result
+}
+
+// Get returns the token with the given id
+func (s *Store) Get(id string) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, token := range s.tokens {
+		if token.Id == id {
+			result := *token
+			return &result, nil
+		}
+	}
+	return nil, ErrTokenNotFound
+}
+
+// Revoke removes the token with the given id
+func (s *Store) Revoke(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index := slices.IndexFunc(s.tokens, func(token *Token) bool { return token.Id == id })
+	if index < 0 {
+		return ErrTokenNotFound
+	}
+	revoked := s.tokens[index]
+	s.tokens = slices.Delete(s.tokens, index, index+1)
+	if err := s.save(); err != nil {
+		s.tokens = slices.Insert(s.tokens, index, revoked)
+		return err
+	}
+	return nil
+}
+
+// Verify returns the token the secret belongs to and records its usage.
+// Returns ErrInvalidToken for unknown secrets and ErrTokenExpired for expired tokens.
+func (s *Store) Verify(secret string) (*Token, error) {
+	if !IsToken(secret) {
+		return nil, ErrInvalidToken
+	}
+	hash := hashSecret(secret)
+	now := s.timeProvider.Now().UTC()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, token := range s.tokens {
+		if subtle.ConstantTimeCompare([]byte(token.Hash), []byte(hash)) != 1 {
+			continue
+		}
+		if !now.Before(token.ExpiresAt) {
+			return nil, ErrTokenExpired
+		}
+
+		persist := token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= lastUsedPersistInterval
+		token.LastUsedAt = &now
+		if persist {
+			// failing to record the usage must not fail the request
+			_ = s.save()
+		}
+		result := *token
+		return &result, nil
+	}
+	return nil, ErrInvalidToken
+}
+
+// save writes all tokens to the store file, callers must hold the lock
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s.tokens, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(s.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return err
+		}
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func hashSecret(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
+
+func randomString(length int) (string, error) {
+	b := make([]byte, length)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func randomHex(length int) (string, error) {
+	b := make([]byte, length)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,154 @@
+package tokens
+
+import (
+	"OpenSPMRegistry/config"
+	"OpenSPMRegistry/utils"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	store, err := NewStore(config.TokensConfig{Path: filepath.Join(t.TempDir(), "tokens.json"), MaxLifetime: 30})
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	store.timeProvider = utils.NewMockTimeProvider(testNow)
+	return store
+}
+
+func Test_NewStore_MissingFile_ReturnsEmptyStore(t *testing.T) {
+	store := newTestStore(t)
+
+	if tokens := store.List(""); len(tokens) != 0 {
+		t.Errorf("expected no tokens, got %v", tokens)
+	}
+}
+
+func Test_NewStore_InvalidFile_ReturnsError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	if err := os.WriteFile(path, []byte("{invalid"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewStore(config.TokensConfig{Path: path}); err == nil {
+		t.Errorf("expected error for invalid token file")
+	}
+}
+
+func Test_Create_StoresHashOnlyAndPersists(t *testing.T) {
+	store := newTestStore(t)
+
+	token, secret, err := store.Create("alice", []string{"ios-team"}, "ci", []string{"acme"}, []string{"publish"}, time.Time{})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if !strings.HasPrefix(secret, Prefix) {
+		t.Errorf("expected secret to start with %s, got %s", Prefix, secret)
+	}
+	if !token.ExpiresAt.Equal(testNow.Add(30 * 24 * time.Hour)) {
+		t.Errorf("expected default expiry of max lifetime, got %v", token.ExpiresAt)
+	}
+
+	data, err := os.ReadFile(store.path)
+	if err != nil {
+		t.Fatalf("expected token file, got %v", err)
+	}
+	if strings.Contains(string(data), secret) {
+		t.Errorf("expected secret not to be stored")
+	}
+
+	reloaded, err := NewStore(config.TokensConfig{Path: store.path})
+	if err != nil {
+		t.Fatalf("failed to reload store: %v", err)
+	}
+	tokens := reloaded.List("alice")
+	if len(tokens) != 1 || tokens[0].Id != token.Id || tokens[0].Name != "ci" {
+		t.Errorf("expected persisted token, got %v", tokens)
+	}
+}
+
+func Test_Create_InvalidExpiry_ReturnsError(t *testing.T) {
+	store := newTestStore(t)
+
+	for _, expiresAt := range []time.Time{testNow.Add(-time.Hour), testNow.Add(31 * 24 * time.Hour)} {
+		if _, _, err := store.Create("alice", nil, "ci", nil, nil, expiresAt); !errors.Is(err, ErrInvalidExpiry) {
+			t.Errorf("expected ErrInvalidExpiry for %v, got %v", expiresAt, err)
+		}
+	}
+}
+
+func Test_List_FiltersByOwner(t *testing.T) {
+	store := newTestStore(t)
+	_, _, _ = store.Create("alice", nil, "a", nil, nil, time.Time{})
+	_, _, _ = store.Create("bob", nil, "b", nil, nil, time.Time{})
+
+	if tokens := store.List("alice"); len(tokens) != 1 || tokens[0].Owner != "alice" {
+		t.Errorf("expected alice's token only, got %v", tokens)
+	}
+	if tokens := store.List(""); len(tokens) != 2 {
+		t.Errorf("expected all tokens, got %v", tokens)
+	}
+}
+
+func Test_Verify_ValidSecret_RecordsLastUsed(t *testing.T) {
+	store := newTestStore(t)
+	created, secret, _ := store.Create("alice", nil, "ci", nil, nil, time.Time{})
+
+	usedAt := testNow.Add(time.Hour)
+	store.timeProvider = utils.NewMockTimeProvider(usedAt)
+	token, err := store.Verify(secret)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if token.Id != created.Id {
+		t.Errorf("expected token %s, got %s", created.Id, token.Id)
+	}
+
+	reloaded, _ := NewStore(config.TokensConfig{Path: store.path})
+	listed := reloaded.List("alice")
+	if len(listed) != 1 || listed[0].LastUsedAt == nil || !listed[0].LastUsedAt.Equal(usedAt) {
+		t.Errorf("expected persisted last usage %v, got %v", usedAt, listed)
+	}
+}
+
+func Test_Verify_UnknownOrExpiredSecret_ReturnsError(t *testing.T) {
+	store := newTestStore(t)
+	_, secret, _ := store.Create("alice", nil, "ci", nil, nil, testNow.Add(time.Hour))
+
+	if _, err := store.Verify(Prefix + "unknown"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected ErrInvalidToken, got %v", err)
+	}
+	if _, err := store.Verify("not-a-token"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected ErrInvalidToken, got %v", err)
+	}
+
+	store.timeProvider = utils.NewMockTimeProvider(testNow.Add(2 * time.Hour))
+	if _, err := store.Verify(secret); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("expected ErrTokenExpired, got %v", err)
+	}
+}
+
+func Test_Revoke_RemovesToken(t *testing.T) {
+	store := newTestStore(t)
+	token, secret, _ := store.Create("alice", nil, "ci", nil, nil, time.Time{})
+
+	if err := store.Revoke(token.Id); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if _, err := store.Verify(secret); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected revoked token to be rejected, got %v", err)
+	}
+	if err := store.Revoke(token.Id); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("expected ErrTokenNotFound, got %v", err)
+	}
+	if _, err := store.Get(token.Id); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("expected ErrTokenNotFound, got %v", err)
+	}
+}
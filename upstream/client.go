@@ -0,0 +1,198 @@
+package upstream
+
+import (
+	"OpenSPMRegistry/config"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Client reads packages from an upstream Swift package registry (SE-0292 API)
+type Client struct {
+	baseURL    string
+	authHeader string
+	httpClient *http.Client
+
+	// after a failed request the upstream is not asked again until unavailableUntil,
+	// so an outage does not delay every request by the client timeout
+	mu               sync.Mutex
+	unavailableUntil time.Time
+}
+
+// Release is the release information (GET /{scope}/{name}/{version}) of the upstream registry
+type Release struct {
+	Id        string         `json:"id"`
+	Version   string         `json:"version"`
+	Resources []Resource     `json:"resources"`
+	Metadata  map[string]any `json:"metadata"`
+	// PublishedAt is the publish date in ISO 8601 format, optional
+	PublishedAt string `json:"publishedAt"`
+}
+
+// Resource is a downloadable resource of a release
+type Resource struct {
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Checksum string   `json:"checksum"`
+	Signing  *Signing `json:"signing"`
+}
+
+// Signing is the signature of a resource
+type Signing struct {
+	SignatureBase64Encoded string `json:"signatureBase64Encoded"`
+	SignatureFormat        string `json:"signatureFormat"`
+}
+
+const (
+	acceptJson = "application/vnd.swift.registry.v1+json"
+	acceptZip  = "application/vnd.swift.registry.v1+zip"
+	// retryAfterFailure is how long the upstream is considered unavailable after a failed request
+	retryAfterFailure = 30 * time.Second
+)
+
+var (
+	// ErrNotFound is returned when the upstream registry does not have the package or release
+	ErrNotFound = errors.New("not found in upstream registry")
+	// ErrUnavailable is returned when the upstream registry cannot be reached or answers with an error
+	ErrUnavailable = errors.New("upstream registry unavailable")
+)
+
+// NewClient creates a client for the configured upstream registry
+func NewClient(cfg config.UpstreamConfig) *Client {
+	timeout := 30 * time.Second
+	if cfg.Timeout > 0 {
+		timeout = time.Duration(cfg.Timeout) * time.Second
+	}
+
+	var authHeader string
+	if cfg.Token != "" {
+		authHeader = "Bearer " + cfg.Token
+	} else if cfg.Username != "" {
+		authHeader = "Basic " + base64.StdEncoding.EncodeToString([]byte(cfg.Username+":"+cfg.Password))
+	}
+
+	return &Client{
+		baseURL:    strings.TrimSuffix(cfg.URL, "/"),
+		authHeader: authHeader,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// ListReleases returns the versions of the package the upstream registry has, sorted
+func (c *Client) ListReleases(ctx context.Context, scope string, name string) ([]string, error) {
+	resp, err := c.get(ctx, acceptJson, scope, name)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var list struct {
+		Releases map[string]json.RawMessage `json:"releases"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, fmt.Errorf("%w: decoding release list: %v", ErrUnavailable, err)
+	}
+	versions := make([]string, 0, len(list.Releases))
+	for version := range list.Releases {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+	return versions, nil
+}
+
+// FetchRelease returns the release information including checksum, signature and metadata
+func (c *Client) FetchRelease(ctx context.Context, scope string, name string, version string) (*Release, error) {
+	resp, err := c.get(ctx, acceptJson, scope, name, version)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var release Release
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, fmt.Errorf("%w: decoding release: %v", ErrUnavailable, err)
+	}
+	return &release, nil
+}
+
+// DownloadSourceArchive returns the source archive of the release, the caller must close it
+func (c *Client) DownloadSourceArchive(ctx context.Context, scope string, name string, version string) (io.ReadCloser, error) {
+	resp, err := c.get(ctx, acceptZip, scope, name, version+".zip")
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// SourceArchive returns the source archive resource of the release
+// returns (resource|nil if the release has none)
+func (r *Release) SourceArchive() *Resource {
+	for i := range r.Resources {
+		if r.Resources[i].Name == "source-archive" {
+			return &r.Resources[i]
+		}
+	}
+	return nil
+}
+
+// get requests the path below the base URL, responses other than 200 are returned as error
+func (c *Client) get(ctx context.Context, accept string, path ...string) (*http.Response, error) {
+	if c.isUnavailable() {
+		return nil, fmt.Errorf("%w: retrying after previous failure", ErrUnavailable)
+	}
+
+	fullURL, err := url.JoinPath(c.baseURL, path...)
+	if err != nil {
+		return nil, fmt.Errorf("building upstream URL: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	if c.authHeader != "" {
+		req.Header.Set("Authorization", c.authHeader)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.markUnavailable()
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return resp, nil
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, fullURL)
+	case resp.StatusCode >= http.StatusInternalServerError:
+		c.markUnavailable()
+	}
+	_ = resp.Body.Close()
+	return nil, fmt.Errorf("%w: %s answered %d", ErrUnavailable, fullURL, resp.StatusCode)
+}
+
+func (c *Client) isUnavailable() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return time.Now().Before(c.unavailableUntil)
+}
+
+func (c *Client) markUnavailable() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if time.Now().After(c.unavailableUntil) {
+		slog.Warn("Upstream registry unavailable, serving local releases only", "url", c.baseURL, "retryAfter", retryAfterFailure)
+	}
+	c.unavailableUntil = time.Now().Add(retryAfterFailure)
+}
@@ -0,0 +1,114 @@
+package upstream
+
+import (
+	"OpenSPMRegistry/config"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func Test_ListReleases_ReturnsSortedVersions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/acme/network" || r.Header.Get("Accept") != acceptJson {
+			t.Errorf("unexpected request %s (Accept %q)", r.URL.Path, r.Header.Get("Accept"))
+		}
+		_, _ = w.Write([]byte(`{"releases":{"1.1.0":{"url":"x"},"1.0.0":{"url":"y"}}}`))
+	}))
+	defer server.Close()
+
+	versions, err := NewClient(config.UpstreamConfig{URL: server.URL + "/"}).ListReleases(context.Background(), "acme", "network")
+
+	if err != nil || !reflect.DeepEqual(versions, []string{"1.0.0", "1.1.0"}) {
+		t.Errorf("expected [1.0.0 1.1.0], got %v (%v)", versions, err)
+	}
+}
+
+func Test_FetchRelease_ReturnsSourceArchiveResource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id":"acme.network","version":"1.0.0","resources":[{"name":"source-archive","type":"application/zip","checksum":"abc","signing":{"signatureBase64Encoded":"c2ln","signatureFormat":"cms-1.0.0"}}],"metadata":{"description":"net"}}`))
+	}))
+	defer server.Close()
+
+	release, err := NewClient(config.UpstreamConfig{URL: server.URL}).FetchRelease(context.Background(), "acme", "network", "1.0.0")
+	if err != nil {
+		t.Fatalf("failed to fetch release: %v", err)
+	}
+
+	resource := release.SourceArchive()
+	if resource == nil || resource.Checksum != "abc" || resource.Signing == nil || resource.Signing.SignatureBase64Encoded != "c2ln" {
+		t.Errorf("unexpected source archive resource %+v", resource)
+	}
+	if release.Metadata["description"] != "net" {
+		t.Errorf("expected metadata, got %v", release.Metadata)
+	}
+}
+
+func Test_DownloadSourceArchive_SendsCredentials(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		cfg      config.UpstreamConfig
+		expected string
+	}{
+		{"token", config.UpstreamConfig{Token: "secret", Username: "user", Password: "pass"}, "Bearer secret"},
+		{"basic", config.UpstreamConfig{Username: "user", Password: "pass"}, "Basic dXNlcjpwYXNz"},
+		{"anonymous", config.UpstreamConfig{}, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if got := r.Header.Get("Authorization"); got != tc.expected {
+					t.Errorf("expected Authorization %q, got %q", tc.expected, got)
+				}
+				if r.URL.Path != "/acme/network/1.0.0.zip" || r.Header.Get("Accept") != acceptZip {
+					t.Errorf("unexpected request %s (Accept %q)", r.URL.Path, r.Header.Get("Accept"))
+				}
+				_, _ = w.Write([]byte("zip"))
+			}))
+			defer server.Close()
+			tc.cfg.URL = server.URL
+
+			archive, err := NewClient(tc.cfg).DownloadSourceArchive(context.Background(), "acme", "network", "1.0.0")
+			if err != nil {
+				t.Fatalf("failed to download: %v", err)
+			}
+			defer func() { _ = archive.Close() }()
+			if data, _ := io.ReadAll(archive); string(data) != "zip" {
+				t.Errorf("expected archive content, got %q", data)
+			}
+		})
+	}
+}
+
+func Test_Get_NotFound_ReturnsErrNotFound(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	_, err := NewClient(config.UpstreamConfig{URL: server.URL}).FetchRelease(context.Background(), "acme", "network", "1.0.0")
+
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func Test_Get_ServerError_SkipsUpstreamUntilRetry(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	client := NewClient(config.UpstreamConfig{URL: server.URL})
+
+	_, first := client.ListReleases(context.Background(), "acme", "network")
+	_, second := client.ListReleases(context.Background(), "acme", "network")
+
+	if !errors.Is(first, ErrUnavailable) || !errors.Is(second, ErrUnavailable) {
+		t.Errorf("expected ErrUnavailable, got %v and %v", first, second)
+	}
+	if calls != 1 {
+		t.Errorf("expected upstream to be asked once while unavailable, got %d calls", calls)
+	}
+}